@@ -22,6 +22,8 @@ if [ -f .env ]; then
 fi
 
 GO_IMAGE=${GO_IMAGE:-golang:1.21}
+BUILD_COMMIT=${BUILD_COMMIT:-$(git rev-parse --short HEAD 2>/dev/null || echo unknown)}
+BUILD_DATE=${BUILD_DATE:-$(date -u +%Y-%m-%dT%H:%M:%SZ)}
 
 if [ "$BUILD_VERSION" == "" ]; then
   echo "missing environment variable BUILD_VERSION"
@@ -58,7 +60,7 @@ for i in "${!BUILD[@]}"; do
     --env CGO_ENABLED=0 \
     -v $(pwd):/code -w /code $GO_IMAGE \
     go build -buildvcs=false -a -tags 'osusergo netgo static_build' \
-    -ldflags '-X kool-dev/kool/commands.version='$BUILD_VERSION' -extldflags "-static"' \
+    -ldflags '-X kool-dev/kool/commands.version='$BUILD_VERSION' -X kool-dev/kool/commands.commit='$BUILD_COMMIT' -X kool-dev/kool/commands.buildDate='$BUILD_DATE' -extldflags "-static"' \
     -o $dist
 done
 
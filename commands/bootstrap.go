@@ -0,0 +1,192 @@
+package commands
+
+import (
+	"fmt"
+	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/clock"
+	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/parser"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// bootstrapHealthInterval is how often we check the
+// containers health while waiting for them to be ready
+var bootstrapHealthInterval = 2 * time.Second
+
+// bootstrapHealthTimeout bounds how long we wait for the containers health
+var bootstrapHealthTimeout = 5 * time.Minute
+
+// defaultBootstrapScript is the kool.yml script run when
+// there is no bootstrap entry defined in kool.yml
+const defaultBootstrapScript = "setup"
+
+// KoolBootstrap holds handlers and functions to wait for the
+// environment to be ready and then run the setup scripts
+type KoolBootstrap struct {
+	DefaultKoolService
+
+	parser parser.Parser
+	env    environment.EnvStorage
+	run    *KoolRun
+	health builder.Command
+	clock  clock.Clock
+}
+
+// NewKoolBootstrap creates a new handler for bootstrap logic with default dependencies
+func NewKoolBootstrap() *KoolBootstrap {
+	return &KoolBootstrap{
+		*newDefaultKoolService(),
+		parser.NewParser(),
+		environment.NewEnvStorage(),
+		NewKoolRun(),
+		builder.NewComposeCommand("ps", "--format", "{{.Service}}|{{.Health}}"),
+		clock.NewClock(),
+	}
+}
+
+// Execute waits for the services to be healthy and then
+// runs the bootstrap scripts, stopping on the first failure
+func (b *KoolBootstrap) Execute(args []string) (err error) {
+	var scripts []string
+
+	// fail early out of a project, before waiting on services
+	if err = addKoolYmlLookupPaths(b.parser, b.env); err != nil {
+		return
+	}
+
+	if err = selectEnvironment(b.env, b.parser, b.run.promptSelect, b.Shell().IsTerminal()); err != nil {
+		return
+	}
+
+	if err = b.waitHealthy(); err != nil {
+		return
+	}
+
+	if scripts, err = b.getScripts(); err != nil {
+		return
+	}
+
+	if len(scripts) == 0 {
+		b.Shell().Warning(fmt.Sprintf("No setup scripts to run; define them under 'bootstrap' in kool.yml or add a '%s' script.", defaultBootstrapScript))
+		return
+	}
+
+	for _, script := range scripts {
+		if err = b.runScript(script); err != nil {
+			err = fmt.Errorf("bootstrap failed running script '%s': %v", script, err)
+			return
+		}
+	}
+
+	b.Shell().Success("Environment is ready.")
+	return
+}
+
+// getScripts parses the scripts list from the kool.yml bootstrap entry,
+// falling back to the setup script when it is available
+func (b *KoolBootstrap) getScripts() (scripts []string, err error) {
+	var available []string
+
+	if scripts, err = b.parser.ParseBootstrapScripts(); err != nil || len(scripts) > 0 {
+		return
+	}
+
+	if available, err = b.parser.ParseAvailableScripts(defaultBootstrapScript); err != nil {
+		return
+	}
+
+	for _, script := range available {
+		if script == defaultBootstrapScript {
+			scripts = append(scripts, script)
+		}
+	}
+
+	return
+}
+
+// runScript runs the script the same way kool run does
+func (b *KoolBootstrap) runScript(script string) (err error) {
+	b.Shell().Info("→ Running ", script)
+
+	err = b.run.Execute([]string{script})
+	return
+}
+
+// waitHealthy polls the services health until none of them is still
+// starting; services without a healthcheck are considered ready
+func (b *KoolBootstrap) waitHealthy() (err error) {
+	var (
+		output   string
+		deadline = b.clock.Now().Add(bootstrapHealthTimeout)
+	)
+
+	b.Shell().Info("→ Waiting for services to be healthy")
+
+	for {
+		if output, err = b.Shell().Exec(b.health); err != nil {
+			return
+		}
+
+		starting := []string{}
+
+		for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+			// Service|Health
+			parts := strings.SplitN(strings.TrimSpace(line), "|", 2)
+
+			if len(parts) != 2 {
+				continue
+			}
+
+			switch parts[1] {
+			case "unhealthy":
+				err = fmt.Errorf("service %s is unhealthy; check its logs with 'kool logs %s'", parts[0], parts[0])
+				return
+			case "starting":
+				starting = append(starting, parts[0])
+			}
+		}
+
+		if len(starting) == 0 {
+			return
+		}
+
+		if b.clock.Now().After(deadline) {
+			err = fmt.Errorf("timeout waiting for services to be healthy: %s", strings.Join(starting, ", "))
+			return
+		}
+
+		b.clock.Sleep(bootstrapHealthInterval)
+	}
+}
+
+// NewBootstrapCommand initializes new kool bootstrap command
+func NewBootstrapCommand(start KoolService, bootstrap *KoolBootstrap) *cobra.Command {
+	return &cobra.Command{
+		Use:   "bootstrap",
+		Short: "Start the environment and run the setup scripts",
+		Long: `Get the project environment up and running in a single step: start the
+service containers, wait for them to be healthy and then run the setup scripts,
+stopping on the first failure.
+
+The scripts to be run are listed under the 'bootstrap' entry of kool.yml;
+when there is no such entry, the 'setup' script is run if it exists:
+
+  bootstrap:
+    - setup
+    - migrate
+
+When kool.yml declares environments and none was picked, you are asked which
+one to use; pick it upfront with the global --environment flag or KOOL_ENV.`,
+		Args: cobra.NoArgs,
+		RunE: DefaultCommandRunFunction(start, bootstrap),
+
+		DisableFlagsInUseLine: true,
+	}
+}
+
+func AddKoolBootstrap(root *cobra.Command) {
+	root.AddCommand(NewBootstrapCommand(NewKoolStart(), NewKoolBootstrap()))
+}
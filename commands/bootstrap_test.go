@@ -0,0 +1,196 @@
+package commands
+
+import (
+	"errors"
+	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/clock"
+	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/parser"
+	"kool-dev/kool/core/shell"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newFakeKoolBootstrap() *KoolBootstrap {
+	bootstrap := &KoolBootstrap{
+		*(newDefaultKoolService().Fake()),
+		&parser.FakeParser{},
+		environment.NewFakeEnvStorage(),
+		newFakeKoolRun(nil, nil),
+		&builder.FakeCommand{MockCmd: "health", MockExecOut: "app|healthy\ncache|"},
+		clock.NewFakeClock(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)),
+	}
+
+	// kool run shares the bootstrap shell, parser and environment
+	bootstrap.run.DefaultKoolService = bootstrap.DefaultKoolService
+	bootstrap.run.parser = bootstrap.parser
+	bootstrap.run.env = bootstrap.env
+
+	return bootstrap
+}
+
+func TestNewKoolBootstrap(t *testing.T) {
+	b := NewKoolBootstrap()
+
+	if _, ok := b.parser.(*parser.DefaultParser); !ok {
+		t.Error("unexpected parser.Parser on default KoolBootstrap instance")
+	}
+
+	if b.health.String() != "docker compose ps --format {{.Service}}|{{.Health}}" {
+		t.Errorf("unexpected health command on default KoolBootstrap instance: %s", b.health.String())
+	}
+}
+
+func TestBootstrapCommand(t *testing.T) {
+	fakeStart := newFakeKoolService()
+	bootstrap := newFakeKoolBootstrap()
+	bootstrap.parser.(*parser.FakeParser).MockBootstrapScripts = []string{"setup", "migrate"}
+	bootstrap.parser.(*parser.FakeParser).MockParsedCommands = map[string][]builder.Command{
+		"setup":   {&builder.FakeCommand{MockCmd: "setup-cmd"}},
+		"migrate": {&builder.FakeCommand{MockCmd: "migrate-cmd"}},
+	}
+
+	cmd := NewBootstrapCommand(fakeStart, bootstrap)
+	cmd.SetArgs([]string{})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing bootstrap command; error: %v", err)
+	}
+
+	if !fakeStart.CalledExecute {
+		t.Error("did not start the environment")
+	}
+
+	fakeShell := bootstrap.shell.(*shell.FakeShell)
+
+	if !fakeShell.CalledExec["health"] {
+		t.Error("did not check the services health")
+	}
+
+	if !fakeShell.CalledInteractive["setup-cmd"] || !fakeShell.CalledInteractive["migrate-cmd"] {
+		t.Errorf("did not run all bootstrap scripts; got %v", fakeShell.CalledInteractive)
+	}
+
+	if !fakeShell.CalledSuccess {
+		t.Error("did not print out success message")
+	}
+}
+
+func TestBootstrapCommandStopsOnFailure(t *testing.T) {
+	fakeStart := newFakeKoolService()
+	fakeStart.MockExecuteErr = errors.New("start error")
+	bootstrap := newFakeKoolBootstrap()
+
+	cmd := NewBootstrapCommand(fakeStart, bootstrap)
+
+	assertExecGotError(t, cmd, "start error")
+
+	if bootstrap.shell.(*shell.FakeShell).CalledExec["health"] {
+		t.Error("should not wait for health when start fails")
+	}
+
+	bootstrap = newFakeKoolBootstrap()
+	bootstrap.parser.(*parser.FakeParser).MockBootstrapScripts = []string{"setup", "migrate"}
+	bootstrap.parser.(*parser.FakeParser).MockParsedCommands = map[string][]builder.Command{
+		"setup":   {&builder.FakeCommand{MockCmd: "setup-cmd", MockInteractiveError: errors.New("setup error")}},
+		"migrate": {&builder.FakeCommand{MockCmd: "migrate-cmd"}},
+	}
+
+	cmd = NewBootstrapCommand(newFakeKoolService(), bootstrap)
+
+	assertExecGotError(t, cmd, "bootstrap failed running script 'setup': setup error")
+
+	if bootstrap.shell.(*shell.FakeShell).CalledInteractive["migrate-cmd"] {
+		t.Error("should not run further scripts after a failure")
+	}
+}
+
+func TestBootstrapRunsScriptsLikeKoolRun(t *testing.T) {
+	bootstrap := newFakeKoolBootstrap()
+	bootstrap.parser.(*parser.FakeParser).MockBootstrapScripts = []string{"setup"}
+	bootstrap.parser.(*parser.FakeParser).MockParsedCommands = map[string][]builder.Command{
+		"setup":   {&builder.FakeCommand{MockCmd: "setup-cmd"}},
+		"install": {&builder.FakeCommand{MockCmd: "install-cmd"}},
+	}
+	bootstrap.parser.(*parser.FakeParser).MockDependencies = map[string][]string{"setup": {"install"}}
+
+	if err := bootstrap.Execute(nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if called := bootstrap.shell.(*shell.FakeShell).CalledInteractive; !called["install-cmd"] || !called["setup-cmd"] {
+		t.Errorf("expected the scripts setup needs to run along with it; got %v", called)
+	}
+
+	bootstrap = newFakeKoolBootstrap()
+	bootstrap.parser.(*parser.FakeParser).MockBootstrapScripts = []string{"missing"}
+
+	if err := bootstrap.Execute(nil); err == nil || !strings.Contains(err.Error(), ErrKoolScriptNotFound.Error()) {
+		t.Errorf("expected script not found error; got %v", err)
+	}
+}
+
+func TestBootstrapDefaultSetupScript(t *testing.T) {
+	bootstrap := newFakeKoolBootstrap()
+	bootstrap.parser.(*parser.FakeParser).MockScripts = []string{"setup", "setup-db"}
+	bootstrap.parser.(*parser.FakeParser).MockParsedCommands = map[string][]builder.Command{
+		"setup": {&builder.FakeCommand{MockCmd: "setup-cmd"}},
+	}
+
+	if err := bootstrap.Execute(nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if !bootstrap.shell.(*shell.FakeShell).CalledInteractive["setup-cmd"] {
+		t.Error("did not run the default setup script")
+	}
+
+	bootstrap = newFakeKoolBootstrap()
+
+	if err := bootstrap.Execute(nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if fakeShell := bootstrap.shell.(*shell.FakeShell); !fakeShell.CalledWarning || !strings.Contains(fakeShell.WarningOutput[0].(string), "No setup scripts to run") {
+		t.Errorf("should warn about missing setup scripts; got %v", fakeShell.WarningOutput)
+	}
+}
+
+func TestBootstrapWaitHealthy(t *testing.T) {
+	bootstrap := newFakeKoolBootstrap()
+	bootstrap.health.(*builder.FakeCommand).MockExecOut = "app|unhealthy"
+
+	if err := bootstrap.waitHealthy(); err == nil || !strings.Contains(err.Error(), "service app is unhealthy") {
+		t.Errorf("expected unhealthy error; got %v", err)
+	}
+
+	bootstrap.health.(*builder.FakeCommand).MockExecOut = "app|starting\ndb|healthy"
+
+	if err := bootstrap.waitHealthy(); err == nil || !strings.Contains(err.Error(), "timeout waiting for services to be healthy: app") {
+		t.Errorf("expected timeout error; got %v", err)
+	}
+
+	if slept := bootstrap.clock.(*clock.FakeClock).Slept; slept <= bootstrapHealthTimeout {
+		t.Errorf("expected to wait over the health timeout; waited %v", slept)
+	}
+
+	bootstrap.health.(*builder.FakeCommand).MockExecError = errors.New("ps error")
+
+	if err := bootstrap.waitHealthy(); err == nil || err.Error() != "ps error" {
+		t.Errorf("expected ps error; got %v", err)
+	}
+}
+
+func TestBootstrapWithoutKoolYml(t *testing.T) {
+	b := newFakeKoolBootstrap()
+	b.parser.(*parser.FakeParser).MockAddLookupPathError = parser.ErrKoolYmlNotFound
+
+	if err := b.Execute(nil); !errors.Is(err, parser.ErrKoolYmlNotFound) {
+		t.Errorf("expected kool.yml not found error; got %v", err)
+	}
+
+	if b.shell.(*shell.FakeShell).CalledExec["health"] {
+		t.Error("should not wait on services without a kool.yml")
+	}
+}
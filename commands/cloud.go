@@ -1,6 +1,10 @@
 package commands
 
-import "github.com/spf13/cobra"
+import (
+	"kool-dev/kool/services/cloud/api"
+
+	"github.com/spf13/cobra"
+)
 
 func AddKoolCloud(root *cobra.Command) {
 	var (
@@ -12,6 +16,7 @@ func AddKoolCloud(root *cobra.Command) {
 	cloudCmd.AddCommand(NewDeployDestroyCommand(NewKoolDeployDestroy()))
 	cloudCmd.AddCommand(NewDeployLogsCommand(NewKoolDeployLogs()))
 	cloudCmd.AddCommand(NewSetupCommand(NewKoolCloudSetup()))
+	cloudCmd.AddCommand(NewCloudStatusCommand(NewKoolCloudStatus()))
 
 	root.AddCommand(cloudCmd)
 }
@@ -27,5 +32,7 @@ func NewCloudCommand() (cloudCmd *cobra.Command) {
 		DisableFlagsInUseLine: true,
 	}
 
+	cloudCmd.PersistentFlags().Duration("api-timeout", api.DefaultRequestTimeout, "Timeout for each request to the Kool Cloud API (overrides KOOL_API_REQUEST_TIMEOUT)")
+
 	return
 }
@@ -1,7 +1,12 @@
 package commands
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"kool-dev/kool/core/builder"
 	"kool-dev/kool/core/environment"
 	"kool-dev/kool/services/cloud"
@@ -10,8 +15,8 @@ import (
 	"kool-dev/kool/services/tgz"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -28,11 +33,41 @@ type KoolCloudDeployFlags struct {
 	WwwRedirect        bool     // env: KOOL_DEPLOY_WWW_REDIRECT
 	DeployDomain       string   // env: KOOL_DEPLOY_DOMAIN
 	DeployDomainExtras []string // env: KOOL_DEPLOY_DOMAIN_EXTRAS
+	Resume             bool
 
 	// Cluster            string // env: KOOL_DEPLOY_CLUSTER
 	// env: KOOL_API_URL
 }
 
+// deployRetries bounds how many times in a row a deploy
+// step is retried after failing on an error it may be retried on
+const deployRetries = 3
+
+// deployRetryDelay is how long to wait before retrying a deploy step
+var deployRetryDelay = 3 * time.Second
+
+// deployState is what is kept of a deploy in progress,
+// so an interrupted one can be followed up with --resume
+type deployState struct {
+	ID        string    `json:"id"`
+	Domain    string    `json:"domain"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// deployStatePath tells where the state of the deploy
+// in progress for the given project directory is kept
+var deployStatePath = func(projectDir string) (path string, err error) {
+	var cacheDir string
+
+	if cacheDir, err = os.UserCacheDir(); err != nil {
+		return
+	}
+
+	sum := sha256.Sum256([]byte(projectDir))
+	path = filepath.Join(cacheDir, "kool", "deploys", hex.EncodeToString(sum[:8])+".json")
+	return
+}
+
 // KoolDeploy holds handlers and functions for using Deploy API
 type KoolDeploy struct {
 	DefaultKoolService
@@ -48,8 +83,16 @@ func NewDeployCommand(deploy *KoolDeploy) (cmd *cobra.Command) {
 	cmd = &cobra.Command{
 		Use:   "deploy",
 		Short: "Deploy a local application to a Kool Cloud environment",
-		RunE:  DefaultCommandRunFunction(deploy),
-		Args:  cobra.NoArgs,
+		Long: `Deploy a local application to a Kool Cloud environment.
+
+Checking on the deploy progress is retried a few times on transient failures,
+like network errors. Uploading the release starts a new deploy, so it is only
+retried when it surely did not reach Kool Cloud (the connection could not be
+made) or on a server error, never after a timeout. When the deploy gets
+interrupted after the release was uploaded (i.e. on a timeout or a flaky
+connection), use --resume to keep following it instead of starting over.`,
+		RunE: DefaultCommandRunFunction(deploy),
+		Args: cobra.NoArgs,
 
 		DisableFlagsInUseLine: true,
 	}
@@ -59,6 +102,7 @@ func NewDeployCommand(deploy *KoolDeploy) (cmd *cobra.Command) {
 	cmd.Flags().UintVarP(&deploy.flags.Timeout, "timeout", "", 0, "Timeout in minutes for waiting the deployment to finish")
 	cmd.Flags().StringArrayVarP(&deploy.flags.DeployDomainExtras, "domain-extra", "", []string{}, "List of extra domain aliases")
 	cmd.Flags().BoolVarP(&deploy.flags.WwwRedirect, "www-redirect", "", false, "Redirect www to non-www domain")
+	cmd.Flags().BoolVarP(&deploy.flags.Resume, "resume", "", false, "Keep following the last interrupted deploy instead of starting a new one")
 
 	return
 }
@@ -91,58 +135,70 @@ func (d *KoolDeploy) Execute(args []string) (err error) {
 		api.SetBaseURL(url)
 	}
 
-	d.Shell().Info("Create release file...")
-	if filename, err = d.createReleaseFile(); err != nil {
-		return
-	}
+	if d.flags.Resume {
+		if deploy, err = d.resumeDeploy(); err != nil {
+			return
+		}
+	} else {
+		d.Shell().Info("Create release file...")
+		if filename, err = d.createReleaseFile(); err != nil {
+			return
+		}
 
-	defer func(file string) {
-		var err error
-		if err = os.Remove(file); err != nil {
-			d.Shell().Error(fmt.Errorf("error trying to remove temporary tarball: %v", err))
+		defer func(file string) {
+			var err error
+			if err = os.Remove(file); err != nil {
+				d.Shell().Error(fmt.Errorf("error trying to remove temporary tarball: %v", err))
+			}
+		}(filename)
+
+		deploy = api.NewDeploy(filename)
+
+		d.Shell().Info("Upload release file...")
+		// creating the deploy is not idempotent, so it is only sent again
+		// when it surely did not go through, not on a late answer
+		if err = d.retry("Uploading the release file", api.IsSafeToResend, deploy.SendFile); err != nil {
+			return
 		}
-	}(filename)
 
-	deploy = api.NewDeploy(filename)
+		if stateErr := d.saveDeployState(deploy.GetID()); stateErr != nil {
+			d.Shell().Warning(fmt.Sprintf("failed keeping the deploy state; it cannot be resumed if interrupted: %v", stateErr))
+		}
 
-	d.Shell().Info("Upload release file...")
-	if err = deploy.SendFile(); err != nil {
-		return
+		d.Shell().Println("Going to deploy...")
 	}
 
-	d.Shell().Println("Going to deploy...")
-
 	timeout := 15 * time.Minute
 
 	if d.flags.Timeout > 0 {
 		timeout = time.Duration(d.flags.Timeout) * time.Minute
-	} else if min, err := strconv.Atoi(d.env.Get("KOOL_API_TIMEOUT")); err == nil {
+	} else if min, found := d.env.GetInt("KOOL_API_TIMEOUT"); found {
 		timeout = time.Duration(min) * time.Minute
 	}
 
-	var finishes chan bool = make(chan bool)
+	var (
+		finishes chan bool = make(chan bool)
+		progress           = newDeployProgress(d.Shell().OutStream(), d.Shell().IsTerminal())
+		failure  error
+	)
 
 	go func(deploy *api.Deploy, finishes chan bool) {
-		var (
-			lastStatus string
-			err        error
-		)
+		var err error
 
 		for {
-			err = deploy.FetchLatestStatus()
-
-			if lastStatus != deploy.Status.Status {
-				lastStatus = deploy.Status.Status
-				d.Shell().Println("  > deploy:", lastStatus)
-			}
+			err = d.retry("Checking the deploy status", api.IsTransient, deploy.FetchLatestStatus)
 
 			if err != nil {
+				progress.Done()
+				failure = err
 				finishes <- false
-				d.Shell().Error(err)
 				break
 			}
 
+			progress.Update(deploy.Status.Status)
+
 			if deploy.IsSuccessful() {
+				progress.Done()
 				finishes <- true
 				break
 			}
@@ -156,9 +212,15 @@ func (d *KoolDeploy) Execute(args []string) (err error) {
 	case success = <-finishes:
 		{
 			if success {
+				d.clearDeployState()
 				d.Shell().Success("Deploy finished: ", deploy.GetURL())
+			} else if errors.Is(failure, api.ErrDeployFailed) {
+				// there is nothing to resume on a failed deploy
+				d.clearDeployState()
+				err = deployFailure(failure, progress.Stage())
+				return
 			} else {
-				err = fmt.Errorf("deploy failed")
+				err = resumableFailure(deployFailure(failure, progress.Stage()))
 				return
 			}
 			break
@@ -166,7 +228,8 @@ func (d *KoolDeploy) Execute(args []string) (err error) {
 
 	case <-time.After(timeout):
 		{
-			err = fmt.Errorf("timeout waiting deploy to finish")
+			progress.Done()
+			err = resumableFailure(fmt.Errorf("timeout waiting deploy to finish (last stage: %s)", progress.Stage()))
 			break
 		}
 	}
@@ -174,6 +237,193 @@ func (d *KoolDeploy) Execute(args []string) (err error) {
 	return
 }
 
+// resumableFailure points out how to keep following the deploy
+func resumableFailure(err error) error {
+	return fmt.Errorf("%v; run 'kool cloud deploy --resume' to keep following it", err)
+}
+
+// retry runs the deploy step, retrying it a few times when it fails on
+// an error the step may be retried on; the retries are reported along the way
+func (d *KoolDeploy) retry(step string, retriable func(error) bool, fn func() error) (err error) {
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || !retriable(err) || attempt > deployRetries {
+			return
+		}
+
+		d.Shell().Warning(fmt.Sprintf("%s failed (%s); retrying (%d/%d)", step, strings.TrimSpace(err.Error()), attempt, deployRetries))
+		time.Sleep(deployRetryDelay)
+	}
+}
+
+// resumeDeploy picks up the deploy interrupted within this project
+func (d *KoolDeploy) resumeDeploy() (deploy *api.Deploy, err error) {
+	var state deployState
+
+	if state, err = readDeployState(d.env.Get("PWD")); err != nil {
+		if os.IsNotExist(err) {
+			err = fmt.Errorf("there is no interrupted deploy to resume")
+		}
+		return
+	}
+
+	if domain := d.env.Get("KOOL_DEPLOY_DOMAIN"); state.Domain != domain {
+		err = fmt.Errorf("the interrupted deploy was to %s, not %s", state.Domain, domain)
+		return
+	}
+
+	d.Shell().Info(fmt.Sprintf("Resuming deploy %s to %s, started at %s...", state.ID, state.Domain, state.StartedAt.Local().Format(time.RFC822)))
+	deploy = api.ResumeDeploy(state.ID)
+	return
+}
+
+// readDeployState reads the state of the deploy in progress
+// for the given project directory (see saveDeployState)
+func readDeployState(projectDir string) (state deployState, err error) {
+	var (
+		path    string
+		content []byte
+	)
+
+	if path, err = deployStatePath(projectDir); err != nil {
+		return
+	}
+
+	if content, err = os.ReadFile(path); err != nil {
+		return
+	}
+
+	if err = json.Unmarshal(content, &state); err != nil {
+		err = fmt.Errorf("failed reading the interrupted deploy state: %v", err)
+	}
+	return
+}
+
+// saveDeployState keeps the state of the deploy just requested
+func (d *KoolDeploy) saveDeployState(id string) (err error) {
+	var (
+		path    string
+		content []byte
+	)
+
+	if path, err = deployStatePath(d.env.Get("PWD")); err != nil {
+		return
+	}
+
+	if content, err = json.Marshal(deployState{id, d.env.Get("KOOL_DEPLOY_DOMAIN"), time.Now()}); err != nil {
+		return
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return
+	}
+
+	err = os.WriteFile(path, content, 0644)
+	return
+}
+
+// clearDeployState drops the state once the deploy is over
+func (d *KoolDeploy) clearDeployState() {
+	if path, err := deployStatePath(d.env.Get("PWD")); err == nil {
+		_ = os.Remove(path)
+	}
+}
+
+// deployFailure tells which stage the deploy failed at, if any got reported
+func deployFailure(err error, stage string) error {
+	if stage == "" {
+		return fmt.Errorf("deploy failed: %v", err)
+	}
+
+	if errors.Is(err, api.ErrDeployFailed) {
+		return fmt.Errorf("deploy failed at stage '%s'", stage)
+	}
+
+	return fmt.Errorf("deploy failed at stage '%s': %v", stage, err)
+}
+
+// deployProgress renders the stages the deploy goes through, as reported
+// by the status API. On a terminal the line of the current stage keeps
+// being rewritten with the time spent on it; otherwise each stage is
+// printed once, as it starts, so logs of CI pipelines stay readable.
+type deployProgress struct {
+	sync.Mutex
+
+	out      io.Writer
+	terminal bool
+	now      func() time.Time
+
+	stage   string
+	started time.Time
+	width   int
+	done    bool
+}
+
+func newDeployProgress(out io.Writer, terminal bool) *deployProgress {
+	return &deployProgress{out: out, terminal: terminal, now: time.Now}
+}
+
+// Update reports the current stage of the deploy
+func (p *deployProgress) Update(stage string) {
+	p.Lock()
+	defer p.Unlock()
+
+	if stage == p.stage {
+		if p.terminal {
+			p.rewrite()
+		}
+		return
+	}
+
+	if p.terminal && p.stage != "" {
+		p.rewrite()
+		fmt.Fprintln(p.out)
+	}
+
+	p.stage = stage
+	p.started = p.now()
+	p.width = 0
+
+	if p.terminal {
+		p.rewrite()
+	} else {
+		fmt.Fprintln(p.out, "  > deploy:", stage)
+	}
+}
+
+// Done finishes the line of the current stage
+func (p *deployProgress) Done() {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.terminal && p.stage != "" && !p.done {
+		p.rewrite()
+		fmt.Fprintln(p.out)
+	}
+
+	p.done = true
+}
+
+// Stage returns the last stage reported
+func (p *deployProgress) Stage() string {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.stage
+}
+
+// rewrite prints the current stage line over the previous one, padding
+// it with spaces instead of using escape sequences to clear the line
+func (p *deployProgress) rewrite() {
+	line := fmt.Sprintf("  > deploy: %s (%s)", p.stage, p.now().Sub(p.started).Round(time.Second))
+
+	if pad := p.width - len(line); pad > 0 {
+		line += strings.Repeat(" ", pad)
+	}
+
+	p.width = len(line)
+	fmt.Fprint(p.out, "\r"+line)
+}
+
 func (d *KoolDeploy) createReleaseFile() (filename string, err error) {
 	var (
 		tarball *tgz.TarGz
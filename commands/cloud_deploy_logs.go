@@ -48,7 +48,7 @@ Must use a KOOL_API_TOKEN environment variable for authentication.`,
 func NewKoolDeployLogs() *KoolDeployLogs {
 	return &KoolDeployLogs{
 		*newDefaultKoolService(),
-		&KoolDeployLogsFlags{KoolLogsFlags{25, false}, "default"},
+		&KoolDeployLogsFlags{KoolLogsFlags{25, false, false, "", false, false, defaultLogsSortWindow, false}, "default"},
 		environment.NewEnvStorage(),
 		k8s.NewDefaultK8S(),
 	}
@@ -1,14 +1,19 @@
 package commands
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"kool-dev/kool/core/builder"
 	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/shell"
+	"kool-dev/kool/services/cloud/api"
 	"kool-dev/kool/services/cloud/setup"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewKoolDeploy(t *testing.T) {
@@ -109,3 +114,189 @@ func TestParseFilesListFromGIT(t *testing.T) {
 		t.Errorf("unexpected error from parseFileListFromGIT: %v", err)
 	}
 }
+
+func TestDeployProgress(t *testing.T) {
+	var (
+		out     = new(bytes.Buffer)
+		now     = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		advance = func(d time.Duration) { now = now.Add(d) }
+	)
+
+	progress := newDeployProgress(out, false)
+	progress.now = func() time.Time { return now }
+
+	progress.Update("building")
+	advance(5 * time.Second)
+	progress.Update("building")
+	progress.Update("pushing")
+	progress.Done()
+
+	if expected := "  > deploy: building\n  > deploy: pushing\n"; out.String() != expected {
+		t.Errorf("expected plain stage lines %q; got %q", expected, out.String())
+	}
+
+	if progress.Stage() != "pushing" {
+		t.Errorf("expected last stage pushing; got %s", progress.Stage())
+	}
+
+	out.Reset()
+	progress = newDeployProgress(out, true)
+	progress.now = func() time.Time { return now }
+
+	progress.Update("building")
+	advance(65 * time.Second)
+	progress.Update("building")
+	progress.Update("deploying")
+	advance(2 * time.Second)
+	progress.Done()
+	progress.Done()
+
+	expected := "\r  > deploy: building (0s)" +
+		"\r  > deploy: building (1m5s)" +
+		"\r  > deploy: building (1m5s)\n" +
+		"\r  > deploy: deploying (0s)" +
+		"\r  > deploy: deploying (2s)\n"
+
+	if out.String() != expected {
+		t.Errorf("expected rewritten stage lines %q; got %q", expected, out.String())
+	}
+}
+
+func TestDeployFailure(t *testing.T) {
+	if err := deployFailure(api.ErrDeployFailed, "deploying"); err.Error() != "deploy failed at stage 'deploying'" {
+		t.Errorf("unexpected failure message: %v", err)
+	}
+
+	if err := deployFailure(errors.New("bad gateway"), "pushing"); err.Error() != "deploy failed at stage 'pushing': bad gateway" {
+		t.Errorf("unexpected failure message: %v", err)
+	}
+
+	if err := deployFailure(errors.New("unauthorized"), ""); err.Error() != "deploy failed: unauthorized" {
+		t.Errorf("unexpected failure message: %v", err)
+	}
+}
+
+func TestDeployRetry(t *testing.T) {
+	originalDelay := deployRetryDelay
+	deployRetryDelay = 0
+	defer func() {
+		deployRetryDelay = originalDelay
+	}()
+
+	var (
+		fake  = fakeKoolDeploy()
+		calls int
+	)
+
+	err := fake.retry("Uploading", api.IsTransient, func() error {
+		if calls++; calls < 3 {
+			return &api.ErrAPI{Status: 502, Message: "bad gateway"}
+		}
+		return nil
+	})
+
+	if err != nil || calls != 3 {
+		t.Errorf("expected step to succeed on the third call; got %d calls (err: %v)", calls, err)
+	}
+
+	if warning := fmt.Sprint(fake.shell.(*shell.FakeShell).WarningOutput...); warning != "Uploading failed (502 - bad gateway); retrying (2/3)" {
+		t.Errorf("unexpected retry warning: %s", warning)
+	}
+
+	calls = 0
+	err = fake.retry("Uploading", api.IsTransient, func() error {
+		calls++
+		return fmt.Errorf("%w after 5m0s", api.ErrRequestTimeout)
+	})
+
+	if !errors.Is(err, api.ErrRequestTimeout) || calls != deployRetries+1 {
+		t.Errorf("expected retries to be bounded; got %d calls (err: %v)", calls, err)
+	}
+
+	calls = 0
+	err = fake.retry("Uploading", api.IsTransient, func() error {
+		calls++
+		return api.ErrUnauthorized
+	})
+
+	if !errors.Is(err, api.ErrUnauthorized) || calls != 1 {
+		t.Errorf("expected no retries on permanent errors; got %d calls (err: %v)", calls, err)
+	}
+
+	calls = 0
+	err = fake.retry("Uploading", api.IsSafeToResend, func() error {
+		calls++
+		return fmt.Errorf("%w after 5m0s", api.ErrRequestTimeout)
+	})
+
+	if !errors.Is(err, api.ErrRequestTimeout) || calls != 1 {
+		t.Errorf("expected no resending after a timeout; got %d calls (err: %v)", calls, err)
+	}
+}
+
+func TestDeployState(t *testing.T) {
+	var (
+		fake         = fakeKoolDeploy()
+		statePath    = filepath.Join(t.TempDir(), "deploys", "state.json")
+		originalPath = deployStatePath
+	)
+
+	deployStatePath = func(projectDir string) (string, error) {
+		return statePath, nil
+	}
+	defer func() {
+		deployStatePath = originalPath
+	}()
+
+	fake.env.Set("KOOL_DEPLOY_DOMAIN", "foo.kool.dev")
+
+	if _, err := fake.resumeDeploy(); err == nil || err.Error() != "there is no interrupted deploy to resume" {
+		t.Errorf("expected error for missing deploy state; got %v", err)
+	}
+
+	if err := fake.saveDeployState("100"); err != nil {
+		t.Fatalf("unexpected error saving deploy state: %v", err)
+	}
+
+	deploy, err := fake.resumeDeploy()
+
+	if err != nil || deploy.GetID() != "100" {
+		t.Errorf("expected to resume deploy 100; got %v (err: %v)", deploy, err)
+	}
+
+	fake.env.Set("KOOL_DEPLOY_DOMAIN", "bar.kool.dev")
+
+	if _, err = fake.resumeDeploy(); err == nil || err.Error() != "the interrupted deploy was to foo.kool.dev, not bar.kool.dev" {
+		t.Errorf("expected error for a different domain; got %v", err)
+	}
+
+	fake.clearDeployState()
+
+	if _, err = os.Stat(statePath); !os.IsNotExist(err) {
+		t.Errorf("expected deploy state to be cleared; got %v", err)
+	}
+
+	_ = os.WriteFile(statePath, []byte("{"), 0644)
+
+	if _, err = fake.resumeDeploy(); err == nil || !strings.Contains(err.Error(), "failed reading the interrupted deploy state") {
+		t.Errorf("expected error for a broken deploy state; got %v", err)
+	}
+}
+
+func TestDeployStatePath(t *testing.T) {
+	first, err := deployStatePath("/project/one")
+
+	if err != nil {
+		t.Skipf("no user cache dir available: %v", err)
+	}
+
+	if second, _ := deployStatePath("/project/two"); first == second {
+		t.Error("expected different projects to keep their deploy states apart")
+	}
+}
+
+func TestResumableFailure(t *testing.T) {
+	if err := resumableFailure(errors.New("timeout")); err.Error() != "timeout; run 'kool cloud deploy --resume' to keep following it" {
+		t.Errorf("unexpected failure message: %v", err)
+	}
+}
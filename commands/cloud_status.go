@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/shell"
+	"kool-dev/kool/services/cloud/api"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// KoolCloudStatus holds handlers and functions for checking the status of a deploy
+type KoolCloudStatus struct {
+	DefaultKoolService
+
+	env       environment.EnvStorage
+	apiStatus func(string) api.StatusCall
+	table     shell.TableWriter
+}
+
+// NewCloudStatusCommand initializes new kool cloud status command
+func NewCloudStatusCommand(status *KoolCloudStatus) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status [DEPLOY_ID]",
+		Short: "Show the status of a deploy to Kool Cloud",
+		Long: `Show the status of a deploy to Kool Cloud and the URL it is available at.
+DEPLOY_ID is the one kool cloud deploy prints out; when not given, the deploy
+in progress for the current project is checked.
+
+The Kool Cloud API only reports the overall status and URL of a deploy, so the
+state and image tag of each deployed service and the active revision are not
+shown; check them on the Kool Cloud dashboard.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: DefaultCommandRunFunction(status),
+
+		DisableFlagsInUseLine: true,
+	}
+}
+
+// NewKoolCloudStatus creates a new pointer with default KoolCloudStatus service dependencies
+func NewKoolCloudStatus() *KoolCloudStatus {
+	return &KoolCloudStatus{
+		*newDefaultKoolService(),
+		environment.NewEnvStorage(),
+		func(deployID string) api.StatusCall {
+			return api.NewDefaultStatusCall(deployID)
+		},
+		shell.NewTableWriter(),
+	}
+}
+
+// Execute runs the cloud status logic - integrating with Deploy API
+func (s *KoolCloudStatus) Execute(args []string) (err error) {
+	var (
+		deployID string
+		resp     *api.StatusResponse
+	)
+
+	if url := s.env.Get("KOOL_API_URL"); url != "" {
+		api.SetBaseURL(url)
+	}
+
+	if len(args) > 0 {
+		deployID = args[0]
+	} else {
+		var state deployState
+
+		if state, err = readDeployState(s.env.Get("PWD")); err != nil {
+			if os.IsNotExist(err) {
+				err = fmt.Errorf("there is no deploy in progress; give the ID of the deploy to check")
+			}
+			return
+		}
+
+		deployID = state.ID
+	}
+
+	if resp, err = s.apiStatus(deployID).Call(); err != nil {
+		if errors.Is(err, api.ErrMissingToken) {
+			err = fmt.Errorf("you are not logged in to Kool Cloud; %v (get your access token at kool.dev)", err)
+		} else if errAPI, is := err.(*api.ErrAPI); is && errAPI.Status == http.StatusUnauthorized {
+			err = api.ErrUnauthorized
+		}
+		return
+	}
+
+	s.table.SetWriter(s.Shell().OutStream())
+	s.table.AppendHeader("Deploy", "Status", "URL")
+	s.table.AppendRow(deployID, resp.Status, resp.URL)
+	s.table.Render()
+
+	return
+}
@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"errors"
+	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/shell"
+	"kool-dev/kool/services/cloud/api"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type fakeStatusCall struct {
+	api.DefaultEndpoint
+
+	deployID string
+	err      error
+	resp     *api.StatusResponse
+}
+
+func (s *fakeStatusCall) Call() (*api.StatusResponse, error) {
+	return s.resp, s.err
+}
+
+func newFakeKoolCloudStatus(call *fakeStatusCall) *KoolCloudStatus {
+	return &KoolCloudStatus{
+		*(newDefaultKoolService().Fake()),
+		environment.NewFakeEnvStorage(),
+		func(deployID string) api.StatusCall {
+			call.deployID = deployID
+			return call
+		},
+		&shell.FakeTableWriter{},
+	}
+}
+
+func TestNewCloudStatusCommand(t *testing.T) {
+	status := NewKoolCloudStatus()
+	cmd := NewCloudStatusCommand(status)
+	if cmd.Use != "status [DEPLOY_ID]" {
+		t.Errorf("bad command use: %s", cmd.Use)
+	}
+
+	if _, ok := status.env.(*environment.DefaultEnvStorage); !ok {
+		t.Error("unexpected default env on cloud status")
+	}
+
+	if _, ok := status.apiStatus("100").(*api.DefaultStatusCall); !ok {
+		t.Error("unexpected default status call on cloud status")
+	}
+
+	if _, ok := status.table.(*shell.DefaultTableWriter); !ok {
+		t.Error("unexpected default table writer on cloud status")
+	}
+}
+
+func TestCloudStatusExec(t *testing.T) {
+	call := &fakeStatusCall{DefaultEndpoint: *api.NewDefaultEndpoint("")}
+	status := newFakeKoolCloudStatus(call)
+
+	call.err = api.ErrMissingToken
+
+	if err := status.Execute([]string{"100"}); err == nil || !strings.Contains(err.Error(), "not logged in") {
+		t.Errorf("unexpected error - expected not logged in, got: %v", err)
+	}
+
+	call.err = &api.ErrAPI{Status: 401}
+
+	if err := status.Execute([]string{"100"}); !errors.Is(err, api.ErrUnauthorized) {
+		t.Errorf("unexpected error - expected unauthorized, got: %v", err)
+	}
+
+	call.err = nil
+	call.resp = &api.StatusResponse{Status: "success", URL: "https://app.domain.com"}
+
+	if err := status.Execute([]string{"100"}); err != nil {
+		t.Errorf("unexpected error, got: %v", err)
+	}
+
+	if call.deployID != "100" {
+		t.Errorf("expected the status of deploy 100; got %s", call.deployID)
+	}
+
+	expected := `Deploy | Status | URL
+100 | success | https://app.domain.com`
+
+	if output := strings.TrimSpace(status.table.(*shell.FakeTableWriter).TableOut); output != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, output)
+	}
+}
+
+func TestCloudStatusDeployInProgress(t *testing.T) {
+	originalStatePath := deployStatePath
+	defer func() { deployStatePath = originalStatePath }()
+
+	statePath := filepath.Join(t.TempDir(), "deploy.json")
+	deployStatePath = func(string) (string, error) { return statePath, nil }
+
+	call := &fakeStatusCall{
+		DefaultEndpoint: *api.NewDefaultEndpoint(""),
+		resp:            &api.StatusResponse{Status: "building"},
+	}
+	status := newFakeKoolCloudStatus(call)
+
+	if err := status.Execute(nil); err == nil || !strings.Contains(err.Error(), "there is no deploy in progress") {
+		t.Errorf("unexpected error - expected no deploy in progress, got: %v", err)
+	}
+
+	_ = os.WriteFile(statePath, []byte(`{"id":"200","domain":"domain.com"}`), 0644)
+
+	if err := status.Execute(nil); err != nil || call.deployID != "200" {
+		t.Errorf("expected the status of the deploy in progress; got %s (err: %v)", call.deployID, err)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"kool-dev/kool/core/shell"
 	"strings"
 	"testing"
+
+	"github.com/spf13/cobra"
 )
 
 func newFakeKoolCompletion() *KoolCompletion {
@@ -155,3 +157,36 @@ func TestPowershellNewCompletionCommand(t *testing.T) {
 		t.Error("unexpected powershell output for completion command")
 	}
 }
+
+func TestFlagValuesCompletion(t *testing.T) {
+	var testCases = []struct {
+		cmd      *cobra.Command
+		flag     string
+		expected []string
+	}{
+		{NewServicesCommand(newFakeKoolServices("", nil)), "--format", []string{"plain", "json"}},
+		{NewShellCommand(newFakeKoolShell()), "--shell", []string{"bash", "zsh", "sh"}},
+	}
+
+	for _, tc := range testCases {
+		root := &cobra.Command{Use: "kool"}
+		root.AddCommand(tc.cmd)
+
+		out := new(bytes.Buffer)
+		root.SetOut(out)
+		root.SetArgs([]string{cobra.ShellCompRequestCmd, tc.cmd.Name(), tc.flag, ""})
+
+		if err := root.Execute(); err != nil {
+			t.Fatalf("unexpected error completing %s %s; error: %v", tc.cmd.Name(), tc.flag, err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if got := lines[:len(lines)-1]; strings.Join(got, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("expected completions %v for %s %s; got %v", tc.expected, tc.cmd.Name(), tc.flag, got)
+		}
+
+		if directive := lines[len(lines)-1]; directive != ":4" {
+			t.Errorf("expected no file completion for %s %s; got directive %s", tc.cmd.Name(), tc.flag, directive)
+		}
+	}
+}
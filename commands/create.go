@@ -1,24 +1,45 @@
 package commands
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io/fs"
+	"kool-dev/kool/core/builder"
 	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/network"
 	"kool-dev/kool/core/presets"
 	"kool-dev/kool/core/shell"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
 // TODO: create flag for --no-preset so the command runs only the create portion of the preset config
 
+// KoolCreateFlags holds the flags for the kool create command
+type KoolCreateFlags struct {
+	Force bool
+	Diff  bool
+	From  string
+	Ref   string
+
+	KeepOnFailure bool
+}
+
 // KoolCreate holds handlers and functions to implement the create command logic
 type KoolCreate struct {
 	DefaultKoolService
+	Flags  *KoolCreateFlags
 	parser presets.Parser
 	env    environment.EnvStorage
+	clone  builder.Command
 }
 
 func AddKoolCreate(root *cobra.Command) {
@@ -33,8 +54,10 @@ func AddKoolCreate(root *cobra.Command) {
 func NewKoolCreate() *KoolCreate {
 	return &KoolCreate{
 		*newDefaultKoolService(),
+		&KoolCreateFlags{false, false, "", "", false},
 		presets.NewParser(),
 		environment.NewEnvStorage(),
+		builder.NewCommand("git", "clone", "--depth", "1"),
 	}
 }
 
@@ -44,6 +67,11 @@ func (c *KoolCreate) Execute(args []string) (err error) {
 		createDirectory, preset string
 	)
 
+	if c.Flags.From != "" {
+		err = c.createFromTemplate(args)
+		return
+	}
+
 	if len(args) == 2 {
 		preset = args[0]
 		createDirectory = args[1]
@@ -91,8 +119,20 @@ func (c *KoolCreate) Execute(args []string) (err error) {
 		return
 	}
 
+	if !c.Flags.Diff && !c.Flags.KeepOnFailure {
+		rollback := newCreateRollback(createDirectory)
+
+		defer func() {
+			if err != nil {
+				c.rollback(rollback)
+			}
+		}()
+	}
+
 	c.Shell().Println("Creating new", preset, "project...")
 
+	c.parser.SetForce(c.Flags.Force)
+	c.parser.SetDiffMode(c.Flags.Diff)
 	c.parser.PrepareExecutor(c.Shell())
 
 	if err = c.parser.Create(preset); err != nil {
@@ -107,6 +147,15 @@ func (c *KoolCreate) Execute(args []string) (err error) {
 		}
 	}
 
+	if c.Flags.Diff {
+		if _, statErr := os.Stat(createDirectory); os.IsNotExist(statErr) {
+			// nothing to compare against, so we stop here
+			c.Shell().Info(fmt.Sprintf("Folder %s does not exist; all the preset files would be created", createDirectory))
+			c.Shell().Info("No changes were made (--diff mode)")
+			return
+		}
+	}
+
 	if err = os.Chdir(createDirectory); err != nil {
 		return
 	}
@@ -117,22 +166,326 @@ func (c *KoolCreate) Execute(args []string) (err error) {
 		return
 	}
 
+	if c.Flags.Diff {
+		c.Shell().Info("No changes were made (--diff mode)")
+		return
+	}
+
+	printPresetWriteSummary(c.Shell(), c.parser)
+
 	c.Shell().Success("Preset ", preset, " created successfully!")
 
 	return
 }
 
+// createFromTemplate clones the template repository into the new
+// project folder and runs the template's own preset config, if any
+func (c *KoolCreate) createFromTemplate(args []string) (err error) {
+	var createDirectory string
+
+	if len(args) != 1 {
+		err = fmt.Errorf("bad number of arguments - when using --from specify only the folder to create")
+		return
+	}
+
+	if c.Flags.Diff {
+		err = fmt.Errorf("--diff cannot be used along with --from")
+		return
+	}
+
+	if err = network.CheckOnline(c.env, "cloning the template repository"); err != nil {
+		return
+	}
+
+	createDirectory = args[0]
+
+	if _, statErr := os.Stat(createDirectory); !os.IsNotExist(statErr) {
+		err = fmt.Errorf("folder %s already exists", createDirectory)
+		return
+	}
+
+	if !c.Flags.KeepOnFailure {
+		rollback := newCreateRollback(createDirectory)
+
+		defer func() {
+			if err != nil {
+				c.rollback(rollback)
+			}
+		}()
+	}
+
+	cloneArgs := []string{}
+	if c.Flags.Ref != "" {
+		cloneArgs = append(cloneArgs, "--branch", c.Flags.Ref)
+	}
+	repoURL := templateRepoURL(c.Flags.From)
+	cloneArgs = append(cloneArgs, repoURL, createDirectory)
+
+	c.Shell().Println("Cloning template", c.Flags.From, "...")
+
+	// the credentials are only for the clone, not for
+	// the template actions run afterwards
+	restoreEnv := c.setTemplateCredentials(repoURL)
+	err = c.Shell().Interactive(c.clone, cloneArgs...)
+	restoreEnv()
+
+	if err != nil {
+		err = fmt.Errorf("failed cloning template %s: %v", c.Flags.From, err)
+		return
+	}
+
+	// the new project should not carry the template's history
+	if err = os.RemoveAll(filepath.Join(createDirectory, ".git")); err != nil {
+		return
+	}
+
+	if createDirectory, err = filepath.Abs(createDirectory); err != nil {
+		return
+	}
+
+	if err = os.Chdir(createDirectory); err != nil {
+		return
+	}
+
+	c.env.Set("CREATE_DIRECTORY", createDirectory)
+	c.env.Set("PWD", createDirectory)
+
+	c.parser.SetForce(c.Flags.Force)
+	c.parser.PrepareExecutor(c.Shell())
+
+	if err = c.parser.InstallTemplate(createDirectory); err != nil {
+		return
+	}
+
+	printPresetWriteSummary(c.Shell(), c.parser)
+
+	c.Shell().Success("Project created successfully from template ", c.Flags.From, "!")
+	return
+}
+
+// rollback restores the files overwritten by a failed create and
+// removes what it left behind, reporting it
+func (c *KoolCreate) rollback(rollback *createRollback) {
+	restored, removed, err := rollback.Undo(c.parser.Backups())
+
+	if len(restored) > 0 || len(removed) > 0 {
+		c.Shell().Warning("Rolling back the failed create (use --keep-on-failure to keep its files)")
+
+		for _, path := range restored {
+			c.Shell().Println("  restored:", path)
+		}
+
+		for _, path := range removed {
+			c.Shell().Println("  removed:", path)
+		}
+	}
+
+	if err != nil {
+		c.Shell().Warning("Failed rolling back the create: ", err.Error())
+	}
+}
+
+// createRollback keeps track of the paths existing in the create
+// directory before kool create runs, so whatever it creates can be
+// removed again if it fails partway through
+type createRollback struct {
+	root    string
+	existed map[string]bool
+}
+
+// newCreateRollback takes a snapshot of the paths within root
+func newCreateRollback(root string) (rollback *createRollback) {
+	rollback = &createRollback{root, make(map[string]bool)}
+
+	if abs, err := filepath.Abs(root); err == nil {
+		rollback.root = abs
+	}
+
+	_ = filepath.WalkDir(rollback.root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil {
+			rollback.existed[path] = true
+		}
+		return nil
+	})
+
+	return
+}
+
+// Undo first restores the given backups (overwritten files mapped to
+// the backups of their original content) and then removes the paths
+// created after the snapshot; directories that already existed are
+// kept, along with the files within them that were already there.
+// Backups are never removed, even when restoring them fails.
+func (r *createRollback) Undo(backups map[string]string) (restored, removed []string, err error) {
+	var (
+		keep       = make(map[string]bool)
+		restoreErr error
+	)
+
+	for dst, backup := range backups {
+		if renameErr := os.Rename(backup, dst); renameErr != nil {
+			keep[backup] = true
+			restoreErr = fmt.Errorf("failed restoring %s from %s: %v", dst, backup, renameErr)
+			continue
+		}
+
+		restored = append(restored, dst)
+	}
+
+	sort.Strings(restored)
+
+	defer func() {
+		if restoreErr != nil {
+			err = errors.Join(restoreErr, err)
+		}
+	}()
+
+	if !r.existed[r.root] && len(keep) == 0 {
+		if _, statErr := os.Stat(r.root); statErr != nil {
+			return
+		}
+
+		if err = os.RemoveAll(r.root); err == nil {
+			removed = append(removed, r.root)
+		}
+		return
+	}
+
+	err = filepath.WalkDir(r.root, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil || r.existed[path] || keep[path] {
+			return nil
+		}
+
+		if d.IsDir() && holdsAny(path, keep) {
+			// look inside instead, for the backups to be kept
+			return nil
+		}
+
+		if removeErr := os.RemoveAll(path); removeErr != nil {
+			return removeErr
+		}
+
+		removed = append(removed, path)
+
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+
+	return
+}
+
+// holdsAny tells whether any of the given paths is within dir
+func holdsAny(dir string, paths map[string]bool) bool {
+	for path := range paths {
+		if strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// templateRepoURL turns a template reference like github.com/org/template
+// into a clonable URL; full URLs and SSH addresses are kept as they are
+func templateRepoURL(from string) string {
+	if strings.Contains(from, "://") || strings.HasPrefix(from, "git@") {
+		return from
+	}
+
+	if _, err := os.Stat(from); err == nil {
+		// a local repository
+		return from
+	}
+
+	return "https://" + from
+}
+
+// setTemplateCredentials passes KOOL_TEMPLATE_TOKEN to git for the template
+// host only, through git config environment variables (so it does not show
+// up on the command line) added after the ones already set; the returned
+// function sets those variables back to how they were
+func (c *KoolCreate) setTemplateCredentials(repoURL string) (restore func()) {
+	var (
+		token    = c.env.Get("KOOL_TEMPLATE_TOKEN")
+		original = make(map[string]*string)
+		parsed   *url.URL
+		err      error
+	)
+
+	restore = func() {
+		for key, value := range original {
+			if value == nil {
+				c.env.Unset(key)
+			} else {
+				c.env.Set(key, *value)
+			}
+		}
+	}
+
+	if token == "" {
+		return
+	}
+
+	if parsed, err = url.Parse(repoURL); err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		// the token is for HTTP hosts only
+		return
+	}
+
+	count, _ := c.env.GetInt("GIT_CONFIG_COUNT")
+	if count < 0 {
+		count = 0
+	}
+
+	for key, value := range map[string]string{
+		"GIT_CONFIG_COUNT":                        strconv.Itoa(count + 1),
+		fmt.Sprintf("GIT_CONFIG_KEY_%d", count):   fmt.Sprintf("http.%s://%s/.extraHeader", parsed.Scheme, parsed.Host),
+		fmt.Sprintf("GIT_CONFIG_VALUE_%d", count): "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte("x-access-token:"+token)),
+	} {
+		if current, set := c.env.Lookup(key); set {
+			original[key] = &current
+		} else {
+			original[key] = nil
+		}
+
+		c.env.Set(key, value)
+	}
+
+	return
+}
+
 // NewCreateCommand initializes new kool create command
 func NewCreateCommand(create *KoolCreate) (createCmd *cobra.Command) {
 	createCmd = &cobra.Command{
 		Use:   "create PRESET FOLDER",
 		Short: "Create a new project using a preset",
-		Long:  "Create a new project using the specified PRESET in a directory named FOLDER.",
-		Args:  cobra.MaximumNArgs(2),
-		RunE:  DefaultCommandRunFunction(create),
+		Long: `Create a new project using the specified PRESET in a directory named FOLDER.
+Existing files that would be changed are prompted for being overwritten or skipped;
+in non-interactive environments they are skipped unless --force is used.
+
+With --from the project is created out of a template repository instead, as in
+'kool create myapp --from github.com/org/template'. The repository is cloned into
+FOLDER (pin a branch or tag with --ref), its git history is removed and the actions
+of its ` + presets.TemplateConfigFile + ` config, if any, are run. For private repositories
+set KOOL_TEMPLATE_TOKEN to an access token; it is only sent to the template's
+host, for the clone.
+
+If create fails partway through, the files and folders it created are removed
+again (folders that already existed are kept), so it is safe to retry; use
+--keep-on-failure to leave them in place for inspection.`,
+		Example: `kool create laravel my-app
+kool create my-app --from github.com/org/template --ref v1.0`,
+		Args: cobra.MaximumNArgs(2),
+		RunE: DefaultCommandRunFunction(create),
 
 		DisableFlagsInUseLine: true,
 	}
 
+	createCmd.Flags().BoolVarP(&create.Flags.Force, "force", "", false, "Overwrite existing files without asking")
+	createCmd.Flags().BoolVarP(&create.Flags.Diff, "diff", "", false, "Only show a diff of the changes to existing files, without applying them")
+	createCmd.Flags().StringVarP(&create.Flags.From, "from", "", "", "Create the project out of a template repository instead of a preset")
+	createCmd.Flags().StringVarP(&create.Flags.Ref, "ref", "", "", "Branch or tag of the template repository to use (with --from)")
+	createCmd.Flags().BoolVarP(&create.Flags.KeepOnFailure, "keep-on-failure", "", false, "Keep the files created so far when create fails, instead of removing them")
+
 	return
 }
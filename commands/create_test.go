@@ -6,10 +6,14 @@ package commands
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"kool-dev/kool/core/automate"
+	"kool-dev/kool/core/builder"
 	"kool-dev/kool/core/environment"
 	"kool-dev/kool/core/presets"
 	"kool-dev/kool/core/shell"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -17,8 +21,10 @@ import (
 func newFakeKoolCreate() *KoolCreate {
 	return &KoolCreate{
 		*(newDefaultKoolService().Fake()),
+		&KoolCreateFlags{false, false, "", "", false},
 		&presets.FakeParser{},
 		environment.NewFakeEnvStorage(),
+		&builder.FakeCommand{MockCmd: "git"},
 	}
 }
 
@@ -120,3 +126,318 @@ func TestErrInstallCreateCommand(t *testing.T) {
 	// return to original folder
 	_ = os.Chdir(cwd)
 }
+
+func TestForceCreateCommand(t *testing.T) {
+	f := newFakeKoolCreate()
+
+	f.parser.(*presets.FakeParser).MockExists = true
+	f.parser.(*presets.FakeParser).MockWritten = []string{"kool.yml"}
+	f.parser.(*presets.FakeParser).MockSkipped = []string{"docker-compose.yml"}
+
+	cmd := NewCreateCommand(f)
+
+	cwd, _ := os.Getwd()
+
+	cmd.SetArgs([]string{"--force", "laravel", t.TempDir()})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing create command; error: %v", err)
+	}
+
+	// return to original folder
+	_ = os.Chdir(cwd)
+
+	if !f.parser.(*presets.FakeParser).CalledSetForce || !f.parser.(*presets.FakeParser).MockForce {
+		t.Error("did not set force on parser")
+	}
+
+	if summary := fmt.Sprint(f.shell.(*shell.FakeShell).InfoOutput...); summary != "1 file(s) written, 1 file(s) skipped" {
+		t.Errorf("unexpected write summary: %s", summary)
+	}
+}
+
+func TestDiffCreateCommand(t *testing.T) {
+	f := newFakeKoolCreate()
+
+	f.parser.(*presets.FakeParser).MockExists = true
+
+	cmd := NewCreateCommand(f)
+
+	cmd.SetArgs([]string{"--diff", "laravel", filepath.Join(t.TempDir(), "missing")})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing create command; error: %v", err)
+	}
+
+	if !f.parser.(*presets.FakeParser).MockDiff {
+		t.Error("did not set diff mode on parser")
+	}
+
+	if f.parser.(*presets.FakeParser).CalledInstall {
+		t.Error("should not install preset into missing folder on diff mode")
+	}
+
+	cwd, _ := os.Getwd()
+
+	cmd.SetArgs([]string{"--diff", "laravel", t.TempDir()})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing create command; error: %v", err)
+	}
+
+	// return to original folder
+	_ = os.Chdir(cwd)
+
+	if !f.parser.(*presets.FakeParser).CalledInstall {
+		t.Error("did not call parser.Install")
+	}
+
+	if f.shell.(*shell.FakeShell).CalledSuccess {
+		t.Error("should not report success on diff mode")
+	}
+}
+
+func TestFromTemplateCreateCommand(t *testing.T) {
+	wd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(wd) }()
+
+	f := newFakeKoolCreate()
+	f.env.Set("KOOL_TEMPLATE_TOKEN", "secret")
+	f.env.Set("GIT_CONFIG_COUNT", "1")
+	f.env.Set("GIT_CONFIG_KEY_0", "core.autocrlf")
+	f.env.Set("GIT_CONFIG_VALUE_0", "false")
+	dir := filepath.Join(t.TempDir(), "my-app")
+
+	cmd := NewCreateCommand(f)
+	cmd.SetArgs([]string{dir, "--from", "github.com/org/template", "--ref", "v1.0"})
+
+	if err := cmd.Execute(); err == nil {
+		// the fake clone does not create the folder to step into
+		t.Fatal("expected an error since the cloned folder does not exist")
+	}
+
+	args := strings.Join(f.shell.(*shell.FakeShell).ArgsInteractive["git"], " ")
+	if expected := "--branch v1.0 https://github.com/org/template " + dir; args != expected {
+		t.Errorf("expected clone args '%s'; got '%s'", expected, args)
+	}
+
+	if strings.Contains(args, "secret") {
+		t.Error("the template token should not be on the command line")
+	}
+
+	history := f.env.(*environment.FakeEnvStorage).EnvsHistory
+
+	if keys := history["GIT_CONFIG_KEY_1"]; len(keys) != 1 || keys[0] != "http.https://github.com/.extraHeader" {
+		t.Errorf("expected the template token to be scoped to the template host, after the existing git config; got %v", keys)
+	}
+
+	if values := history["GIT_CONFIG_VALUE_1"]; len(values) != 1 || !strings.HasPrefix(values[0], "Authorization: Basic ") {
+		t.Errorf("expected the template token to be passed through git config environment; got %v", values)
+	}
+
+	if f.env.Get("GIT_CONFIG_COUNT") != "1" || f.env.Get("GIT_CONFIG_KEY_0") != "core.autocrlf" {
+		t.Error("should set the existing git config back after cloning")
+	}
+
+	for _, key := range []string{"GIT_CONFIG_KEY_1", "GIT_CONFIG_VALUE_1"} {
+		if _, set := f.env.Lookup(key); set {
+			t.Errorf("should clear %s after cloning", key)
+		}
+	}
+}
+
+func TestFromTemplateBadUsageCreateCommand(t *testing.T) {
+	f := newFakeKoolCreate()
+	cmd := NewCreateCommand(f)
+	cmd.SetArgs([]string{"laravel", "my-app", "--from", "github.com/org/template"})
+
+	assertExecGotError(t, cmd, "when using --from specify only the folder to create")
+
+	f = newFakeKoolCreate()
+	cmd = NewCreateCommand(f)
+	cmd.SetArgs([]string{t.TempDir(), "--from", "github.com/org/template"})
+
+	assertExecGotError(t, cmd, "already exists")
+
+	f = newFakeKoolCreate()
+	f.clone.(*builder.FakeCommand).MockInteractiveError = errors.New("clone error")
+	cmd = NewCreateCommand(f)
+	cmd.SetArgs([]string{filepath.Join(t.TempDir(), "app"), "--from", "github.com/org/template"})
+
+	assertExecGotError(t, cmd, "failed cloning template github.com/org/template: clone error")
+}
+
+func TestTemplateRepoURL(t *testing.T) {
+	for from, expected := range map[string]string{
+		"github.com/org/template":             "https://github.com/org/template",
+		"https://gitlab.com/org/template.git": "https://gitlab.com/org/template.git",
+		"git@github.com:org/template.git":     "git@github.com:org/template.git",
+	} {
+		if url := templateRepoURL(from); url != expected {
+			t.Errorf("expected %s for %s; got %s", expected, from, url)
+		}
+	}
+}
+
+func TestCreateRollback(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "existing.txt"), []byte("keep"), 0644)
+	_ = os.Mkdir(filepath.Join(dir, "existing"), 0755)
+
+	rollback := newCreateRollback(dir)
+
+	_ = os.WriteFile(filepath.Join(dir, "new.txt"), []byte("remove"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "existing", "new.txt"), []byte("remove"), 0644)
+	_ = os.MkdirAll(filepath.Join(dir, "vendor", "pkg"), 0755)
+
+	restored, removed, err := rollback.Undo(nil)
+
+	if err != nil || len(restored) != 0 {
+		t.Fatalf("unexpected rolling back; restored: %v error: %v", restored, err)
+	}
+
+	expected := []string{
+		filepath.Join(dir, "existing", "new.txt"),
+		filepath.Join(dir, "new.txt"),
+		filepath.Join(dir, "vendor"),
+	}
+
+	if fmt.Sprint(removed) != fmt.Sprint(expected) {
+		t.Errorf("expected removed paths %v; got %v", expected, removed)
+	}
+
+	for _, path := range []string{"existing.txt", "existing"} {
+		if _, err := os.Stat(filepath.Join(dir, path)); err != nil {
+			t.Errorf("pre-existing %s should have been kept", path)
+		}
+	}
+
+	newDir := filepath.Join(t.TempDir(), "my-app")
+	rollback = newCreateRollback(newDir)
+	_ = os.MkdirAll(filepath.Join(newDir, "app"), 0755)
+
+	if _, removed, err = rollback.Undo(nil); err != nil || fmt.Sprint(removed) != fmt.Sprint([]string{newDir}) {
+		t.Errorf("expected the new folder to be removed; got %v (error: %v)", removed, err)
+	}
+
+	if _, err := os.Stat(newDir); !os.IsNotExist(err) {
+		t.Error("the new folder should have been removed")
+	}
+
+	if _, removed, err = rollback.Undo(nil); err != nil || len(removed) != 0 {
+		t.Errorf("expected nothing to roll back for a missing folder; got %v (error: %v)", removed, err)
+	}
+}
+
+func TestCreateRollbackRestoresBackups(t *testing.T) {
+	var (
+		dir      = t.TempDir()
+		composer = filepath.Join(dir, "composer.json")
+		executor = automate.NewExecutor(&shell.FakeShell{}, func(string) ([]byte, error) {
+			return []byte("preset content"), nil
+		})
+	)
+
+	_ = os.WriteFile(composer, []byte("original content"), 0644)
+
+	rollback := newCreateRollback(dir)
+
+	executor.SetForce(true)
+
+	if err := executor.Do([]*automate.ActionSet{{Actions: []*automate.Action{{Src: "composer.json", Dst: composer}}}}); err != nil {
+		t.Fatalf("unexpected error overwriting the file: %v", err)
+	}
+
+	if data, _ := os.ReadFile(composer); string(data) != "preset content" {
+		t.Fatalf("expected the preset to overwrite the file; got '%s'", string(data))
+	}
+
+	restored, removed, err := rollback.Undo(executor.Backups())
+
+	if err != nil || fmt.Sprint(restored) != fmt.Sprint([]string{composer}) || len(removed) != 0 {
+		t.Errorf("expected composer.json to be restored; restored: %v removed: %v error: %v", restored, removed, err)
+	}
+
+	if data, _ := os.ReadFile(composer); string(data) != "original content" {
+		t.Errorf("expected the original content back; got '%s'", string(data))
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected just composer.json to be left; got %v", entries)
+	}
+
+	// a backup that fails to be restored is kept, along with its folder
+	backup := filepath.Join(dir, "sub", "missing.txt.bak")
+	rollback = newCreateRollback(dir)
+	_ = os.MkdirAll(filepath.Join(dir, "sub", "missing.txt"), 0755)
+	_ = os.WriteFile(filepath.Join(dir, "sub", "missing.txt", "file"), []byte("x"), 0644)
+	_ = os.WriteFile(backup, []byte("original"), 0644)
+
+	if _, _, err = rollback.Undo(map[string]string{filepath.Join(dir, "sub", "missing.txt"): backup}); err == nil || !strings.Contains(err.Error(), "failed restoring") {
+		t.Errorf("expected error restoring over a folder; got %v", err)
+	}
+
+	if data, _ := os.ReadFile(backup); string(data) != "original" {
+		t.Error("a backup should never be removed")
+	}
+}
+
+func TestRollbackCreateCommand(t *testing.T) {
+	cwd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(cwd) }()
+
+	dir := t.TempDir()
+
+	f := newFakeKoolCreate()
+	f.parser.(*presets.FakeParser).MockExists = true
+	f.parser.(*presets.FakeParser).MockCreate = nil
+	f.parser.(*presets.FakeParser).MockInstall = errors.New("install error")
+
+	_ = os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("original"), 0644)
+	rollback := newCreateRollback(dir)
+	_ = os.WriteFile(filepath.Join(dir, "kool.yml"), []byte("scripts: {}"), 0644)
+	_ = os.Rename(filepath.Join(dir, "Dockerfile"), filepath.Join(dir, "Dockerfile.bak"))
+	_ = os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("preset"), 0644)
+	f.parser.(*presets.FakeParser).MockBackups = map[string]string{
+		filepath.Join(dir, "Dockerfile"): filepath.Join(dir, "Dockerfile.bak"),
+	}
+	f.rollback(rollback)
+
+	if data, _ := os.ReadFile(filepath.Join(dir, "Dockerfile")); string(data) != "original" {
+		t.Errorf("expected Dockerfile to be restored; got '%s'", string(data))
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "kool.yml")); !os.IsNotExist(err) {
+		t.Error("expected kool.yml to be rolled back")
+	}
+
+	if output := fmt.Sprint(f.shell.(*shell.FakeShell).OutLines); !strings.Contains(output, "removed: "+filepath.Join(dir, "kool.yml")) {
+		t.Errorf("expected the rolled back file to be reported; got %s", output)
+	}
+
+	if output := fmt.Sprint(f.shell.(*shell.FakeShell).OutLines); !strings.Contains(output, "restored: "+filepath.Join(dir, "Dockerfile")) {
+		t.Errorf("expected the restored file to be reported; got %s", output)
+	}
+
+	cmd := NewCreateCommand(f)
+	cmd.SetArgs([]string{"laravel", dir, "--keep-on-failure"})
+
+	assertExecGotError(t, cmd, "install error")
+
+	if !f.Flags.KeepOnFailure {
+		t.Error("expected --keep-on-failure to be set")
+	}
+}
+
+func TestFromTemplateOfflineCreateCommand(t *testing.T) {
+	f := newFakeKoolCreate()
+	f.env.Set("KOOL_OFFLINE", "true")
+	cmd := NewCreateCommand(f)
+	cmd.SetArgs([]string{filepath.Join(t.TempDir(), "app"), "--from", "github.com/org/template"})
+
+	assertExecGotError(t, cmd, "offline mode")
+
+	if f.shell.(*shell.FakeShell).CalledInteractive["git"] {
+		t.Error("should not clone the template when offline")
+	}
+}
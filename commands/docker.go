@@ -1,10 +1,15 @@
 package commands
 
 import (
+	"fmt"
 	"kool-dev/kool/core/builder"
 	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/network"
 	"os"
+	"regexp"
 	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -15,8 +20,20 @@ type KoolDockerFlags struct {
 	Volumes      []string
 	Publish      []string
 	Network      []string
+	NoMount      bool
+	Memory       string
+	CPUs         string
 }
 
+// memoryLimitRegex matches the memory limits accepted by docker, as in 512m or 1.5g
+var memoryLimitRegex = regexp.MustCompile(`^(\d+(\.\d+)?)([kmgt])?i?b?$`)
+
+// memoryUnits maps the memory limit units to their size in bytes
+var memoryUnits = map[string]float64{"": 1, "k": 1 << 10, "m": 1 << 20, "g": 1 << 30, "t": 1 << 40}
+
+// minMemoryLimit is the lowest memory limit docker allows for a container
+const minMemoryLimit = 6 * 1024 * 1024
+
 // KoolDocker holds handlers and functions to implement the docker command logic
 type KoolDocker struct {
 	DefaultKoolService
@@ -39,7 +56,7 @@ func AddKoolDocker(root *cobra.Command) {
 func NewKoolDocker() *KoolDocker {
 	return &KoolDocker{
 		*newDefaultKoolService(),
-		&KoolDockerFlags{[]string{}, []string{}, []string{}, []string{}},
+		&KoolDockerFlags{[]string{}, []string{}, []string{}, []string{}, false, "", ""},
 		environment.NewEnvStorage(),
 		builder.NewCommand("docker", "run", "--init", "--rm", "-w", "/app", "-i"),
 	}
@@ -49,6 +66,10 @@ func NewKoolDocker() *KoolDocker {
 func (d *KoolDocker) Execute(args []string) (err error) {
 	workDir, _ := os.Getwd()
 
+	if err = d.validateLimits(); err != nil {
+		return
+	}
+
 	if d.Shell().IsTerminal() {
 		d.dockerRun.AppendArgs("-t")
 	}
@@ -64,7 +85,9 @@ func (d *KoolDocker) Execute(args []string) (err error) {
 		}
 	}
 
-	d.dockerRun.AppendArgs("--volume", workDir+":/app:delegated")
+	if !d.Flags.NoMount {
+		d.dockerRun.AppendArgs("--volume", workDir+":/app:delegated")
+	}
 
 	if len(d.Flags.Volumes) > 0 {
 		for _, volume := range d.Flags.Volumes {
@@ -84,10 +107,52 @@ func (d *KoolDocker) Execute(args []string) (err error) {
 		}
 	}
 
+	if d.Flags.Memory != "" {
+		d.dockerRun.AppendArgs("--memory", d.Flags.Memory)
+	}
+
+	if d.Flags.CPUs != "" {
+		d.dockerRun.AppendArgs("--cpus", d.Flags.CPUs)
+	}
+
+	if network.Offline(d.envStorage) {
+		// only images already available locally can be used
+		d.dockerRun.AppendArgs("--pull", "never")
+	}
+
 	err = d.Shell().Interactive(d.dockerRun, args...)
 	return
 }
 
+// validateLimits checks the --memory and --cpus values upfront, so
+// a typo does not surface as an obscure error from docker itself
+func (d *KoolDocker) validateLimits() (err error) {
+	if d.Flags.Memory != "" {
+		matches := memoryLimitRegex.FindStringSubmatch(strings.ToLower(d.Flags.Memory))
+
+		if matches == nil {
+			err = fmt.Errorf("invalid --memory value %s (use a number of bytes with an optional unit, as in 512m or 2g)", d.Flags.Memory)
+			return
+		}
+
+		size, _ := strconv.ParseFloat(matches[1], 64)
+
+		if size*memoryUnits[matches[3]] < minMemoryLimit {
+			err = fmt.Errorf("invalid --memory value %s (the minimum allowed is 6m)", d.Flags.Memory)
+			return
+		}
+	}
+
+	if d.Flags.CPUs != "" {
+		if cpus, parseErr := strconv.ParseFloat(d.Flags.CPUs, 64); parseErr != nil || cpus <= 0 {
+			err = fmt.Errorf("invalid --cpus value %s (use a positive number of CPUs, as in 1.5)", d.Flags.CPUs)
+			return
+		}
+	}
+
+	return
+}
+
 // NewDockerCommand initializes new kool docker command
 func NewDockerCommand(docker *KoolDocker) (cmd *cobra.Command) {
 	cmd = &cobra.Command{
@@ -97,7 +162,10 @@ func NewDockerCommand(docker *KoolDocker) (cmd *cobra.Command) {
 		Long: `A helper for 'docker run'. Any [OPTIONS] added before the
 IMAGE name will be used by 'docker run' itself (i.e. --env='VAR=VALUE').
 Add an optional [COMMAND] to execute on the IMAGE, and use [--] after
-the [COMMAND] to provide optional arguments required by the COMMAND.`,
+the [COMMAND] to provide optional arguments required by the COMMAND.
+
+Use --memory and --cpus to limit the resources of heavy one-off commands,
+so they do not starve the other running containers.`,
 		RunE: DefaultCommandRunFunction(docker),
 
 		DisableFlagsInUseLine: true,
@@ -107,6 +175,9 @@ the [COMMAND] to provide optional arguments required by the COMMAND.`,
 	cmd.Flags().StringArrayVarP(&docker.Flags.Volumes, "volume", "v", []string{}, "Bind mount a volume.")
 	cmd.Flags().StringArrayVarP(&docker.Flags.Publish, "publish", "p", []string{}, "Publish a container's port(s) to the host.")
 	cmd.Flags().StringArrayVarP(&docker.Flags.Network, "network", "n", []string{}, "Connect a container to a network.")
+	cmd.Flags().BoolVarP(&docker.Flags.NoMount, "no-mount", "", false, "Do not mount the current directory into the container.")
+	cmd.Flags().StringVarP(&docker.Flags.Memory, "memory", "", "", "Memory limit for the container (i.e. 512m or 2g).")
+	cmd.Flags().StringVarP(&docker.Flags.CPUs, "cpus", "", "", "Number of CPUs the container may use (i.e. 1.5).")
 
 	//After a non-flag arg, stop parsing flags
 	cmd.Flags().SetInterspersed(false)
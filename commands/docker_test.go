@@ -8,13 +8,14 @@ import (
 	"kool-dev/kool/core/shell"
 	"os"
 	"runtime"
+	"strings"
 	"testing"
 )
 
 func newFakeKoolDocker() *KoolDocker {
 	return &KoolDocker{
 		*(newDefaultKoolService().Fake()),
-		&KoolDockerFlags{[]string{}, []string{}, []string{}, []string{}},
+		&KoolDockerFlags{[]string{}, []string{}, []string{}, []string{}, false, "", ""},
 		environment.NewFakeEnvStorage(),
 		&builder.FakeCommand{MockCmd: "docker"},
 	}
@@ -23,7 +24,7 @@ func newFakeKoolDocker() *KoolDocker {
 func newFailedFakeKoolDocker() *KoolDocker {
 	return &KoolDocker{
 		*(newDefaultKoolService().Fake()),
-		&KoolDockerFlags{[]string{}, []string{}, []string{}, []string{}},
+		&KoolDockerFlags{[]string{}, []string{}, []string{}, []string{}, false, "", ""},
 		environment.NewFakeEnvStorage(),
 		&builder.FakeCommand{MockCmd: "docker", MockInteractiveError: errors.New("error docker")},
 	}
@@ -269,3 +270,68 @@ func TestNonTerminalNewDockerCommand(t *testing.T) {
 		t.Errorf("bad arguments to KoolDocker.dockerRun Command on non terminal environment")
 	}
 }
+
+func TestResourceLimitsFlagsNewDockerCommand(t *testing.T) {
+	f := newFakeKoolDocker()
+	f.shell.(*shell.FakeShell).MockIsTerminal = false
+	cmd := NewDockerCommand(f)
+
+	cmd.SetArgs([]string{"--memory=1.5g", "--cpus=2", "--no-mount", "image"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing docker command; error: %v", err)
+	}
+
+	argsAppend := f.dockerRun.(*builder.FakeCommand).ArgsAppend
+
+	if len(argsAppend) != 4 || argsAppend[0] != "--memory" || argsAppend[1] != "1.5g" || argsAppend[2] != "--cpus" || argsAppend[3] != "2" {
+		t.Errorf("bad arguments to KoolDocker.dockerRun Command with resource limits flags: %v", argsAppend)
+	}
+
+	invalid := map[string]string{
+		"--memory=lots": "invalid --memory value lots",
+		"--memory=1m":   "the minimum allowed is 6m",
+		"--memory=-2g":  "invalid --memory value -2g",
+		"--cpus=0":      "invalid --cpus value 0",
+		"--cpus=two":    "invalid --cpus value two",
+	}
+
+	for flag, expected := range invalid {
+		f = newFakeKoolDocker()
+		cmd = NewDockerCommand(f)
+		cmd.SetArgs([]string{flag, "image"})
+
+		assertExecGotError(t, cmd, expected)
+
+		if f.shell.(*shell.FakeShell).CalledInteractive["docker"] {
+			t.Errorf("should not run docker with %s", flag)
+		}
+	}
+
+	for _, memory := range []string{"512m", "512MB", "1GiB", "6291456"} {
+		f = newFakeKoolDocker()
+		f.Flags.Memory = memory
+
+		if err := f.validateLimits(); err != nil {
+			t.Errorf("unexpected error validating --memory %s: %v", memory, err)
+		}
+	}
+}
+
+func TestOfflineNewDockerCommand(t *testing.T) {
+	f := newFakeKoolDocker()
+	f.envStorage.Set("KOOL_OFFLINE", "true")
+
+	cmd := NewDockerCommand(f)
+	cmd.SetArgs([]string{"image"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing docker command; error: %v", err)
+	}
+
+	argsAppend := strings.Join(f.dockerRun.(*builder.FakeCommand).ArgsAppend, " ")
+
+	if !strings.HasSuffix(argsAppend, "--pull never") {
+		t.Errorf("expected --pull never when offline; got %s", argsAppend)
+	}
+}
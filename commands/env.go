@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"fmt"
+	"kool-dev/kool/core/environment"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// KoolEnvEncrypt holds handlers and functions to implement the env encrypt command logic
+type KoolEnvEncrypt struct {
+	DefaultKoolService
+
+	env environment.EnvStorage
+}
+
+// KoolEnvDecrypt holds handlers and functions to implement the env decrypt command logic
+type KoolEnvDecrypt struct {
+	DefaultKoolService
+
+	env environment.EnvStorage
+}
+
+func AddKoolEnv(root *cobra.Command) {
+	var (
+		envCmd = NewEnvCommand()
+	)
+
+	envCmd.AddCommand(NewEnvEncryptCommand(NewKoolEnvEncrypt()))
+	envCmd.AddCommand(NewEnvDecryptCommand(NewKoolEnvDecrypt()))
+
+	root.AddCommand(envCmd)
+}
+
+// NewEnvCommand initializes new kool env command
+func NewEnvCommand() (envCmd *cobra.Command) {
+	envCmd = &cobra.Command{
+		Use:   "env COMMAND",
+		Short: "Manage encrypted environment variables",
+		Long: fmt.Sprintf(`Encrypt environment variables into a %s file that can be safely
+shared in the project repository. The file is decrypted in memory when kool loads up,
+using the key provided by the %[2]s environment variable (a base64 encoded 32 bytes
+key that can be generated with 'openssl rand -base64 32').
+
+When %[2]s is not set, the key is read from the OS keychain, stored under the
+service '%[3]s' and account '%[2]s':
+
+  macOS: security add-generic-password -s %[3]s -a %[2]s -w KEY
+  Linux: secret-tool store --label=kool service %[3]s account %[2]s`, environment.EncryptedEnvFile, environment.EnvKeyVariable, environment.KeychainService),
+
+		DisableFlagsInUseLine: true,
+	}
+
+	return
+}
+
+// NewKoolEnvEncrypt creates a new handler for env encrypt logic
+func NewKoolEnvEncrypt() *KoolEnvEncrypt {
+	return &KoolEnvEncrypt{
+		*newDefaultKoolService(),
+		environment.NewEnvStorage(),
+	}
+}
+
+// Execute runs the env encrypt logic with incoming arguments.
+func (e *KoolEnvEncrypt) Execute(args []string) (err error) {
+	var key, plain, encrypted []byte
+
+	if key, err = environment.EnvKey(e.env); err != nil {
+		return
+	}
+
+	if plain, err = os.ReadFile(args[0]); err != nil {
+		return
+	}
+
+	if encrypted, err = environment.EncryptEnv(plain, key); err != nil {
+		return
+	}
+
+	if err = os.WriteFile(environment.EncryptedEnvFile, encrypted, 0644); err != nil {
+		return
+	}
+
+	e.Shell().Success(fmt.Sprintf("Encrypted %s into %s", args[0], environment.EncryptedEnvFile))
+	e.Shell().Warning(fmt.Sprintf("Make sure to not commit %s to your repository.", args[0]))
+	return
+}
+
+// NewEnvEncryptCommand initializes new kool env encrypt command
+func NewEnvEncryptCommand(encrypt *KoolEnvEncrypt) *cobra.Command {
+	return &cobra.Command{
+		Use:   "encrypt FILE",
+		Short: fmt.Sprintf("Encrypt the environment variables from FILE into %s", environment.EncryptedEnvFile),
+		Args:  cobra.ExactArgs(1),
+		RunE:  DefaultCommandRunFunction(encrypt),
+
+		DisableFlagsInUseLine: true,
+	}
+}
+
+// NewKoolEnvDecrypt creates a new handler for env decrypt logic
+func NewKoolEnvDecrypt() *KoolEnvDecrypt {
+	return &KoolEnvDecrypt{
+		*newDefaultKoolService(),
+		environment.NewEnvStorage(),
+	}
+}
+
+// Execute runs the env decrypt logic with incoming arguments.
+func (d *KoolEnvDecrypt) Execute(args []string) (err error) {
+	var key, encrypted, plain []byte
+
+	if key, err = environment.EnvKey(d.env); err != nil {
+		return
+	}
+
+	if encrypted, err = os.ReadFile(environment.EncryptedEnvFile); err != nil {
+		return
+	}
+
+	if plain, err = environment.DecryptEnv(encrypted, key); err != nil {
+		return
+	}
+
+	// decrypted values only go to the output so they never touch the disk
+	d.Shell().Printf("%s", plain)
+	return
+}
+
+// NewEnvDecryptCommand initializes new kool env decrypt command
+func NewEnvDecryptCommand(decrypt *KoolEnvDecrypt) *cobra.Command {
+	return &cobra.Command{
+		Use:   "decrypt",
+		Short: fmt.Sprintf("Print out the decrypted environment variables from %s", environment.EncryptedEnvFile),
+		Args:  cobra.NoArgs,
+		RunE:  DefaultCommandRunFunction(decrypt),
+
+		DisableFlagsInUseLine: true,
+	}
+}
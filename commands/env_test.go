@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"encoding/base64"
+	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/shell"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var fakeEnvKey = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+
+func newFakeKoolEnvEncrypt() *KoolEnvEncrypt {
+	return &KoolEnvEncrypt{
+		*(newDefaultKoolService().Fake()),
+		environment.NewFakeEnvStorage(),
+	}
+}
+
+func newFakeKoolEnvDecrypt() *KoolEnvDecrypt {
+	return &KoolEnvDecrypt{
+		*(newDefaultKoolService().Fake()),
+		environment.NewFakeEnvStorage(),
+	}
+}
+
+func TestNewEnvCommand(t *testing.T) {
+	root := NewRootCmd(environment.NewFakeEnvStorage())
+	AddKoolEnv(root)
+
+	for _, sub := range []string{"encrypt", "decrypt"} {
+		if cmd, _, err := root.Find([]string{"env", sub}); err != nil || cmd.Name() != sub {
+			t.Errorf("expected env to have the '%s' subcommand; err: %v", sub, err)
+		}
+	}
+}
+
+func TestEnvEncryptDecryptCommand(t *testing.T) {
+	wd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(wd) }()
+	_ = os.Chdir(t.TempDir())
+
+	if err := os.WriteFile(".env.secrets", []byte("SECRET=value\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	encrypt := newFakeKoolEnvEncrypt()
+	encrypt.env.Set(environment.EnvKeyVariable, fakeEnvKey)
+	cmd := NewEnvEncryptCommand(encrypt)
+	cmd.SetArgs([]string{".env.secrets"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error executing env encrypt command; error: %v", err)
+	}
+
+	if !encrypt.shell.(*shell.FakeShell).CalledSuccess {
+		t.Error("did not call Success after encrypting")
+	}
+
+	if encrypted, err := os.ReadFile(filepath.Join(".", environment.EncryptedEnvFile)); err != nil {
+		t.Errorf("failed reading %s: %v", environment.EncryptedEnvFile, err)
+	} else if strings.Contains(string(encrypted), "SECRET") {
+		t.Error("encrypted file should not hold plain text values")
+	}
+
+	decrypt := newFakeKoolEnvDecrypt()
+	decrypt.env.Set(environment.EnvKeyVariable, fakeEnvKey)
+	cmd = NewEnvDecryptCommand(decrypt)
+	cmd.SetArgs([]string{})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error executing env decrypt command; error: %v", err)
+	}
+
+	if out := decrypt.shell.(*shell.FakeShell).FOutput; out != "SECRET=value\n" {
+		t.Errorf("unexpected decrypted output: %q", out)
+	}
+
+	decrypt.env.Set(environment.EnvKeyVariable, base64.StdEncoding.EncodeToString([]byte(strings.Repeat("x", 32))))
+	assertExecGotError(t, cmd, "failed decrypting")
+}
+
+func TestEnvEncryptMissingKeyCommand(t *testing.T) {
+	encrypt := newFakeKoolEnvEncrypt()
+	cmd := NewEnvEncryptCommand(encrypt)
+	cmd.SetArgs([]string{".env"})
+
+	assertExecGotError(t, cmd, "missing KOOL_ENV_KEY")
+}
@@ -6,7 +6,10 @@ import (
 	"io"
 	"kool-dev/kool/core/builder"
 	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/network"
+	"kool-dev/kool/core/shell"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -16,6 +19,8 @@ import (
 type KoolExecFlags struct {
 	EnvVariables []string
 	Detach       bool
+	EnvFiles     []string
+	Start        bool
 }
 
 // KoolExec holds handlers and functions to implement the exec command logic
@@ -25,8 +30,18 @@ type KoolExec struct {
 
 	env         environment.EnvStorage
 	composeExec builder.Command
+	running     builder.Command
+	start       builder.Command
+
+	promptSelect shell.PromptSelect
+
+	// shells caches the shell detected for each service
+	shells map[string]string
 }
 
+// shellCandidates are the shells probed for, in order of preference
+var shellCandidates = []string{"bash", "zsh", "sh"}
+
 func AddKoolExec(root *cobra.Command) {
 	var (
 		exec    = NewKoolExec()
@@ -40,9 +55,13 @@ func AddKoolExec(root *cobra.Command) {
 func NewKoolExec() *KoolExec {
 	return &KoolExec{
 		*newDefaultKoolService(),
-		&KoolExecFlags{[]string{}, false},
+		&KoolExecFlags{[]string{}, false, []string{}, false},
 		environment.NewEnvStorage(),
-		builder.NewCommand("docker", "compose", "exec"),
+		builder.NewComposeCommand("exec"),
+		builder.NewComposeCommand("ps", "--services", "--filter", "status=running"),
+		builder.NewComposeCommand("up", "-d"),
+		shell.NewPromptSelect(),
+		make(map[string]string),
 	}
 }
 
@@ -92,12 +111,88 @@ func (e *KoolExec) checkUser(service string) {
 	}
 }
 
+// ensureRunning makes sure the service is running before executing
+// in it, starting it (along with its dependencies) with --start or upon
+// confirmation on a terminal; failing to tell the running services is
+// not critical, leaving it for the exec itself to fail
+func (e *KoolExec) ensureRunning(service string) (err error) {
+	var (
+		output string
+		start  = e.Flags.Start
+	)
+
+	if output, err = e.Shell().Exec(e.running); err != nil {
+		err = nil
+		return
+	}
+
+	for _, running := range strings.Fields(output) {
+		if running == service {
+			return
+		}
+	}
+
+	if !start && e.Shell().IsTerminal() {
+		if start, err = e.promptSelect.Confirm("Service %s is not running. Do you want to start it?", service); err != nil {
+			return
+		}
+	}
+
+	if !start {
+		err = fmt.Errorf("service %s is not running; start it with 'kool start %s' or use --start", service, service)
+		return
+	}
+
+	if network.Offline(e.env) {
+		// only images already available locally can be used
+		e.start.AppendArgs("--pull", "never")
+	}
+
+	err = e.Shell().Interactive(e.start, service)
+	return
+}
+
+// DetectShell probes the service container for the first available shell
+// among bash, zsh and sh; the result is cached per service
+func (e *KoolExec) DetectShell(service string) (shell string, err error) {
+	if shell = e.shells[service]; shell != "" {
+		return
+	}
+
+	for _, candidate := range shellCandidates {
+		if _, probeErr := e.Shell().Exec(e.composeExec, "-T", service, candidate, "-c", "exit 0"); probeErr == nil {
+			shell = candidate
+			e.shells[service] = shell
+			return
+		}
+	}
+
+	err = fmt.Errorf("could not find any of %s in service %s; use --shell to pick one", strings.Join(shellCandidates, ", "), service)
+	return
+}
+
 // Execute runs the exec logic with incoming arguments.
 func (e *KoolExec) Execute(args []string) (err error) {
+	if err = e.ensureRunning(args[0]); err != nil {
+		return
+	}
+
 	e.detectTTY()
 
 	e.checkUser(args[0])
 
+	for _, envFile := range e.Flags.EnvFiles {
+		var envVars []string
+
+		if envVars, err = readEnvFile(envFile); err != nil {
+			return
+		}
+
+		for _, envVar := range envVars {
+			e.composeExec.AppendArgs("--env", envVar)
+		}
+	}
+
 	if len(e.Flags.EnvVariables) > 0 {
 		for _, envVar := range e.Flags.EnvVariables {
 			e.composeExec.AppendArgs("--env", envVar)
@@ -112,20 +207,51 @@ func (e *KoolExec) Execute(args []string) (err error) {
 	return
 }
 
+// readEnvFile parses the given .env file into a sorted list of KEY=VALUE pairs
+func readEnvFile(envFile string) (envVars []string, err error) {
+	var envs map[string]string
+
+	if envs, err = environment.ReadDotenv(envFile); err != nil {
+		if os.IsNotExist(err) {
+			err = fmt.Errorf("env file %s not found", envFile)
+		}
+		return
+	}
+
+	for key, value := range envs {
+		envVars = append(envVars, fmt.Sprintf("%s=%s", key, value))
+	}
+
+	sort.Strings(envVars)
+	return
+}
+
 // NewExecCommand initializes new kool exec command
 func NewExecCommand(exec *KoolExec) (execCmd *cobra.Command) {
 	execCmd = &cobra.Command{
 		Use:   "exec [OPTIONS] SERVICE COMMAND [--] [ARG...]",
 		Short: "Execute a command inside a running service container",
-		Long:  `Execute a COMMAND inside the specified SERVICE container (similar to an SSH session).`,
-		Args:  cobra.MinimumNArgs(2),
-		RunE:  DefaultCommandRunFunction(exec),
+		Long: `Execute a COMMAND inside the specified SERVICE container (similar to an SSH session).
+
+When the SERVICE is not running, it is started first (along with the services it
+depends on) with --start, or upon confirmation on a terminal.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: DefaultCommandRunFunction(exec),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveDefault
+			}
+
+			return compListServices(toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
 
 		DisableFlagsInUseLine: true,
 	}
 
 	execCmd.Flags().StringArrayVarP(&exec.Flags.EnvVariables, "env", "e", []string{}, "Environment variables.")
+	execCmd.Flags().StringArrayVarP(&exec.Flags.EnvFiles, "env-file", "", []string{}, "Read environment variables from a file (variables given with --env take precedence).")
 	execCmd.Flags().BoolVarP(&exec.Flags.Detach, "detach", "d", false, "Detached mode: Run command in the background.")
+	execCmd.Flags().BoolVarP(&exec.Flags.Start, "start", "", false, "Start the service first if it is not running.")
 
 	//After a non-flag arg, stop parsing flags
 	execCmd.Flags().SetInterspersed(false)
@@ -6,24 +6,35 @@ import (
 	"kool-dev/kool/core/builder"
 	"kool-dev/kool/core/environment"
 	"kool-dev/kool/core/shell"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
 func newFakeKoolExec() *KoolExec {
 	return &KoolExec{
 		*(newDefaultKoolService().Fake()),
-		&KoolExecFlags{[]string{}, false},
+		&KoolExecFlags{[]string{}, false, []string{}, false},
 		environment.NewFakeEnvStorage(),
 		&builder.FakeCommand{MockCmd: "exec"},
+		&builder.FakeCommand{MockCmd: "running", MockExecOut: "service\napp\ndatabase"},
+		&builder.FakeCommand{MockCmd: "start"},
+		&shell.FakePromptSelect{},
+		make(map[string]string),
 	}
 }
 
 func newFailedFakeKoolExec() *KoolExec {
 	return &KoolExec{
 		*(newDefaultKoolService().Fake()),
-		&KoolExecFlags{[]string{}, false},
+		&KoolExecFlags{[]string{}, false, []string{}, false},
 		environment.NewFakeEnvStorage(),
 		&builder.FakeCommand{MockCmd: "exec", MockInteractiveError: errors.New("error exec")},
+		&builder.FakeCommand{MockCmd: "running", MockExecOut: "service\napp\ndatabase"},
+		&builder.FakeCommand{MockCmd: "start"},
+		&shell.FakePromptSelect{},
+		make(map[string]string),
 	}
 }
 
@@ -149,6 +160,36 @@ func TestEnvFlagNewExecCommand(t *testing.T) {
 	}
 }
 
+func TestEnvFileFlagNewExecCommand(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), "test.env")
+	_ = os.WriteFile(envFile, []byte("DB_NAME=testing\nAPP_ENV=\"test\"\n"), os.ModePerm)
+
+	f := newFakeKoolExec()
+	cmd := NewExecCommand(f)
+
+	cmd.SetArgs([]string{"--env-file", envFile, "--env=APP_ENV=override", "service", "command"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing exec command; error: %v", err)
+	}
+
+	argsAppend := strings.Join(f.composeExec.(*builder.FakeCommand).ArgsAppend, " ")
+
+	if argsAppend != "--env APP_ENV=test --env DB_NAME=testing --env APP_ENV=override" {
+		t.Errorf("bad arguments to KoolExec.composeExec Command with EnvFiles flag: %s", argsAppend)
+	}
+
+	f = newFakeKoolExec()
+	cmd = NewExecCommand(f)
+	cmd.SetArgs([]string{"--env-file", filepath.Join(t.TempDir(), "missing.env"), "service", "command"})
+
+	assertExecGotError(t, cmd, "missing.env not found")
+
+	if f.shell.(*shell.FakeShell).CalledInteractive["exec"] {
+		t.Error("should not run the command when the env file is missing")
+	}
+}
+
 func TestDetachFlagNewExecCommand(t *testing.T) {
 	f := newFakeKoolExec()
 	cmd := NewExecCommand(f)
@@ -217,3 +258,113 @@ func TestNonTerminalNewExecCommand(t *testing.T) {
 		t.Errorf("bad arguments to KoolExec.composeExec Command on non terminal environment")
 	}
 }
+
+func TestDetectShellKoolExec(t *testing.T) {
+	f := newFakeKoolExec()
+
+	shellBin, err := f.DetectShell("service")
+
+	if err != nil {
+		t.Fatalf("unexpected error detecting shell: %v", err)
+	}
+
+	if shellBin != "bash" {
+		t.Errorf("expected to detect 'bash' as the first available shell; got '%s'", shellBin)
+	}
+
+	// once detected, the shell is taken from the cache without probing again
+	f.composeExec.(*builder.FakeCommand).MockExecError = errors.New("probe error")
+
+	if shellBin, err = f.DetectShell("service"); err != nil || shellBin != "bash" {
+		t.Errorf("expected cached 'bash' shell for service; got '%s' (error: %v)", shellBin, err)
+	}
+
+	if _, err = f.DetectShell("other"); err == nil {
+		t.Error("expected an error when no shell can be found on the service")
+	} else if !strings.Contains(err.Error(), "could not find any of bash, zsh, sh in service other") {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
+
+func TestNotRunningServiceNewExecCommand(t *testing.T) {
+	f := newFakeKoolExec()
+	f.running.(*builder.FakeCommand).MockExecOut = "app"
+	f.shell.(*shell.FakeShell).MockIsTerminal = false
+	cmd := NewExecCommand(f)
+	cmd.SetArgs([]string{"service", "command"})
+
+	assertExecGotError(t, cmd, "service service is not running; start it with 'kool start service' or use --start")
+
+	if f.shell.(*shell.FakeShell).CalledInteractive["exec"] {
+		t.Error("should not exec on a service not running")
+	}
+
+	f = newFakeKoolExec()
+	f.running.(*builder.FakeCommand).MockExecOut = "app"
+	f.shell.(*shell.FakeShell).MockIsTerminal = false
+	cmd = NewExecCommand(f)
+	cmd.SetArgs([]string{"--start", "service", "command"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing exec command; error: %v", err)
+	}
+
+	if args := f.shell.(*shell.FakeShell).ArgsInteractive["start"]; len(args) != 1 || args[0] != "service" {
+		t.Errorf("expected to start service before exec; got %v", args)
+	}
+
+	if !f.shell.(*shell.FakeShell).CalledInteractive["exec"] {
+		t.Error("did not exec after starting the service")
+	}
+
+	if f.start.(*builder.FakeCommand).CalledAppendArgs {
+		t.Error("should only add --pull never when offline")
+	}
+
+	f = newFakeKoolExec()
+	f.env.Set("KOOL_OFFLINE", "true")
+	f.running.(*builder.FakeCommand).MockExecOut = "app"
+	cmd = NewExecCommand(f)
+	cmd.SetArgs([]string{"--start", "service", "command"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing exec command; error: %v", err)
+	}
+
+	if args := strings.Join(f.start.(*builder.FakeCommand).ArgsAppend, " "); args != "--pull never" {
+		t.Errorf("expected --pull never when offline; got '%s'", args)
+	}
+}
+
+func TestNotRunningServicePromptNewExecCommand(t *testing.T) {
+	f := newFakeKoolExec()
+	f.running.(*builder.FakeCommand).MockExecOut = "app"
+	f.promptSelect.(*shell.FakePromptSelect).MockConfirm = map[string]bool{
+		"Service %s is not running. Do you want to start it?": true,
+	}
+	cmd := NewExecCommand(f)
+	cmd.SetArgs([]string{"service", "command"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing exec command; error: %v", err)
+	}
+
+	if len(f.promptSelect.(*shell.FakePromptSelect).CalledConfirm) != 1 {
+		t.Error("did not ask to start the service")
+	}
+
+	if !f.shell.(*shell.FakeShell).CalledInteractive["start"] || !f.shell.(*shell.FakeShell).CalledInteractive["exec"] {
+		t.Error("expected to start the service and then exec")
+	}
+
+	f = newFakeKoolExec()
+	f.running.(*builder.FakeCommand).MockExecOut = "app"
+	cmd = NewExecCommand(f)
+	cmd.SetArgs([]string{"service", "command"})
+
+	assertExecGotError(t, cmd, "service service is not running")
+
+	if f.shell.(*shell.FakeShell).CalledInteractive["start"] {
+		t.Error("should not start the service when not confirmed")
+	}
+}
@@ -1,44 +1,79 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
 	"kool-dev/kool/core/builder"
 	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/network"
+	"kool-dev/kool/core/shell"
 	"os"
 	"os/exec"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
+// composeInUse tells which Docker Compose kool resolved to run
+var composeInUse = shell.ComposeInUse
+
+// infoProbeTimeout bounds how long reaching a published port may take
+var infoProbeTimeout = 2 * time.Second
+
+// KoolInfoFlags holds the flags for the kool info command
+type KoolInfoFlags struct {
+	Network bool
+}
+
 // KoolInfo holds handlers and functions for info logic
 type KoolInfo struct {
 	DefaultKoolService
+	Flags *KoolInfoFlags
 
 	envStorage                  environment.EnvStorage
 	cmdDocker, cmdDockerCompose builder.Command
+	cmdDiskUsage, cmdResources  builder.Command
+
+	cmdNetwork, cmdServices, cmdResolve builder.Command
 }
 
 // NewInfoCmd initializes new kool info command
-func NewInfoCmd(info *KoolInfo) *cobra.Command {
-	return &cobra.Command{
+func NewInfoCmd(info *KoolInfo) (infoCmd *cobra.Command) {
+	infoCmd = &cobra.Command{
 		Use:   "info",
 		Short: "Print out information about the local environment",
-		Long:  "Print out information about the local environment, such as environment variables.",
-		RunE:  DefaultCommandRunFunction(info),
-		Args:  cobra.MaximumNArgs(1),
+		Long: `Print out information about the local environment, such as Docker disk usage,
+available resources and environment variables.
+
+With --network, the networking of the running services is probed as well: whether
+the kool global network exists, whether the services can resolve each other's
+names and whether their published ports can be reached from the host.`,
+		RunE: DefaultCommandRunFunction(info),
+		Args: cobra.MaximumNArgs(1),
 
 		DisableFlagsInUseLine: true,
 	}
+
+	infoCmd.Flags().BoolVarP(&info.Flags.Network, "network", "", false, "Probe the network connectivity of the running services")
+	return
 }
 
 // NewKoolInfo creates a new pointer with default KoolInfo service
 func NewKoolInfo() *KoolInfo {
 	return &KoolInfo{
 		*newDefaultKoolService(),
+		&KoolInfoFlags{false},
 		environment.NewEnvStorage(),
 		builder.NewCommand("docker", "-v"),
-		builder.NewCommand("docker", "compose", "version"),
+		builder.NewComposeCommand("version"),
+		builder.NewCommand("docker", "system", "df", "--format", "{{.Type}}|{{.TotalCount}}|{{.Size}}|{{.Reclaimable}}"),
+		builder.NewCommand("docker", "info", "--format", "{{.NCPU}}|{{.MemTotal}}"),
+		builder.NewCommand("docker", "network", "inspect", "--format", "{{.Name}}"),
+		builder.NewComposeCommand("ps", "--format", "json"),
+		builder.NewComposeCommand("exec", "-T"),
 	}
 }
 
@@ -78,9 +113,20 @@ func (i *KoolInfo) Execute(args []string) (err error) {
 
 	i.Shell().Println("Docker Bin Path:", output)
 
+	if host := i.envStorage.Get("DOCKER_HOST"); host != "" {
+		i.Shell().Println("Docker Host:", host)
+	} else {
+		i.Shell().Println("Docker Host: (local default)")
+	}
+
 	i.Shell().Println("")
 
-	// docker compose v2 info
+	if output, err = composeInUse(); err != nil {
+		output = "(not found)"
+	}
+	i.Shell().Println("Compose Command:", output)
+
+	// docker compose version info
 	if output, err = i.Shell().Exec(i.cmdDockerCompose); err != nil {
 		// just alert missing docker compose, but don't elevate error
 		i.Shell().Warning("Docker Compose:", err.Error())
@@ -90,6 +136,20 @@ func (i *KoolInfo) Execute(args []string) (err error) {
 		i.Shell().Println(output)
 	}
 
+	if project := i.envStorage.Get("COMPOSE_PROJECT_NAME"); project != "" {
+		i.Shell().Println("Compose Project Name:", project)
+	} else {
+		i.Shell().Println("Compose Project Name: (not set; defaults to the directory name)")
+	}
+
+	i.Shell().Println("")
+	i.printDockerResources()
+
+	if i.Flags.Network {
+		i.Shell().Println("")
+		i.printNetworkDiagnostics()
+	}
+
 	i.Shell().Println("")
 	i.Shell().Println("Environment Variables of Interest:")
 	i.Shell().Println("")
@@ -110,3 +170,140 @@ func (i *KoolInfo) Execute(args []string) (err error) {
 
 	return
 }
+
+// printDockerResources prints out the Docker disk usage and the resources
+// available to it; failures are just warned since they are not critical
+func (i *KoolInfo) printDockerResources() {
+	var (
+		output string
+		err    error
+	)
+
+	i.Shell().Println("Docker Disk Usage:")
+
+	if output, err = i.Shell().Exec(i.cmdDiskUsage); err != nil {
+		i.Shell().Warning(fmt.Sprintf("Could not fetch Docker disk usage: %v", err))
+	} else {
+		for _, line := range strings.Split(output, "\n") {
+			// Type|TotalCount|Size|Reclaimable
+			if parts := strings.Split(strings.TrimSpace(line), "|"); len(parts) == 4 {
+				i.Shell().Println(fmt.Sprintf("  %s: %s (%s, reclaimable %s)", parts[0], parts[1], parts[2], parts[3]))
+			}
+		}
+	}
+
+	if output, err = i.Shell().Exec(i.cmdResources); err != nil {
+		i.Shell().Warning(fmt.Sprintf("Could not fetch Docker resources: %v", err))
+		return
+	}
+
+	// NCPU|MemTotal
+	if parts := strings.Split(output, "|"); len(parts) == 2 {
+		memory := parts[1]
+		if bytes, parseErr := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64); parseErr == nil {
+			memory = fmt.Sprintf("%.2f GiB", float64(bytes)/1024/1024/1024)
+		}
+
+		i.Shell().Println("Docker CPUs:", strings.TrimSpace(parts[0]))
+		i.Shell().Println("Docker Memory:", memory)
+	}
+}
+
+// infoService is a running service as reported by docker compose ps
+type infoService struct {
+	Service    string `json:"Service"`
+	Publishers []struct {
+		URL           string `json:"URL"`
+		PublishedPort int    `json:"PublishedPort"`
+		Protocol      string `json:"Protocol"`
+	} `json:"Publishers"`
+}
+
+// printNetworkDiagnostics probes the kool global network, the name
+// resolution between the running services and the reachability of their
+// published ports; failures are just warned since they are the findings
+func (i *KoolInfo) printNetworkDiagnostics() {
+	var (
+		services  []infoService
+		err       error
+		globalNet = i.envStorage.Get("KOOL_GLOBAL_NETWORK")
+	)
+
+	i.Shell().Println("Network Diagnostics:")
+
+	if _, err = i.Shell().Exec(i.cmdNetwork, globalNet); err != nil {
+		i.Shell().Warning(fmt.Sprintf("Network %s: not found; it is created by kool start (%v)", globalNet, err))
+	} else {
+		i.Shell().Println(fmt.Sprintf("  Network %s: found", globalNet))
+	}
+
+	if services, err = i.runningServices(); err != nil {
+		i.Shell().Warning(fmt.Sprintf("Could not list the running services: %v", err))
+		return
+	}
+
+	if len(services) == 0 {
+		i.Shell().Println("  No running services to probe; start them with kool start")
+		return
+	}
+
+	// name resolution is probed from the first service to the others
+	source := services[0].Service
+
+	for _, target := range services[1:] {
+		if _, err = i.Shell().Exec(i.cmdResolve, source, "sh", "-c", fmt.Sprintf("getent hosts %[1]s || nslookup %[1]s", target.Service)); err != nil {
+			i.Shell().Warning(fmt.Sprintf("DNS %s -> %s: failed (%v)", source, target.Service, err))
+		} else {
+			i.Shell().Println(fmt.Sprintf("  DNS %s -> %s: ok", source, target.Service))
+		}
+	}
+
+	for _, service := range services {
+		for _, publisher := range service.Publishers {
+			if publisher.PublishedPort == 0 || (publisher.Protocol != "" && publisher.Protocol != "tcp") {
+				continue
+			}
+
+			port := strconv.Itoa(publisher.PublishedPort)
+
+			if network.Reachable(publisher.URL, port, infoProbeTimeout) {
+				i.Shell().Println(fmt.Sprintf("  Port %s (service %s): reachable", port, service.Service))
+			} else {
+				i.Shell().Warning(fmt.Sprintf("Port %s (service %s): not reachable from the host", port, service.Service))
+			}
+		}
+	}
+}
+
+// runningServices lists the running services, sorted by name; docker
+// compose prints either a JSON array or one JSON object per line
+func (i *KoolInfo) runningServices() (services []infoService, err error) {
+	var output string
+
+	if output, err = i.Shell().Exec(i.cmdServices); err != nil {
+		return
+	}
+
+	if output = strings.TrimSpace(output); strings.HasPrefix(output, "[") {
+		err = json.Unmarshal([]byte(output), &services)
+	} else {
+		for _, line := range strings.Split(output, "\n") {
+			var service infoService
+
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+
+			if err = json.Unmarshal([]byte(line), &service); err != nil {
+				return
+			}
+
+			services = append(services, service)
+		}
+	}
+
+	sort.SliceStable(services, func(a, b int) bool {
+		return services[a].Service < services[b].Service
+	})
+	return
+}
@@ -1,9 +1,12 @@
 package commands
 
 import (
+	"errors"
+	"fmt"
 	"kool-dev/kool/core/builder"
 	"kool-dev/kool/core/environment"
 	"kool-dev/kool/core/shell"
+	"net"
 	"strings"
 	"testing"
 
@@ -18,9 +21,15 @@ func setupInfoTest(f *KoolInfo) {
 func fakeKoolInfo() *KoolInfo {
 	return &KoolInfo{
 		*(newDefaultKoolService().Fake()),
+		&KoolInfoFlags{false},
 		environment.NewFakeEnvStorage(),
 		&builder.FakeCommand{},
 		&builder.FakeCommand{},
+		&builder.FakeCommand{},
+		&builder.FakeCommand{},
+		&builder.FakeCommand{MockCmd: "network"},
+		&builder.FakeCommand{MockCmd: "services"},
+		&builder.FakeCommand{MockCmd: "resolve"},
 	}
 }
 
@@ -35,7 +44,7 @@ func TestInfo(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	for _, expected := range []string{"KOOL_FILTER_TESTING=1", "KOOL_TESTING=1"} {
+	for _, expected := range []string{"KOOL_FILTER_TESTING=1", "KOOL_TESTING=1", "Compose Project Name: (not set"} {
 		if !strings.Contains(output, expected) {
 			t.Errorf("Expected '%s', got '%s'", expected, output)
 		}
@@ -63,6 +72,115 @@ func TestFilteredInfo(t *testing.T) {
 	}
 }
 
+func TestInfoComposeProjectName(t *testing.T) {
+	f := fakeKoolInfo()
+	f.envStorage.Set("COMPOSE_PROJECT_NAME", "my-project")
+
+	output, err := execInfoCommand(NewInfoCmd(f), f)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(output, "Compose Project Name: my-project") {
+		t.Errorf("expected compose project name on output, got '%s'", output)
+	}
+}
+
+func TestInfoDockerHost(t *testing.T) {
+	f := fakeKoolInfo()
+
+	output, err := execInfoCommand(NewInfoCmd(f), f)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(output, "Docker Host: (local default)") {
+		t.Errorf("expected local default docker host on output, got '%s'", output)
+	}
+
+	f = fakeKoolInfo()
+	f.envStorage.Set("DOCKER_HOST", "ssh://user@remote")
+
+	if output, err = execInfoCommand(NewInfoCmd(f), f); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(output, "Docker Host: ssh://user@remote") {
+		t.Errorf("expected remote docker host on output, got '%s'", output)
+	}
+}
+
+func TestInfoComposeInUse(t *testing.T) {
+	originalComposeInUse := composeInUse
+	defer func() {
+		composeInUse = originalComposeInUse
+	}()
+
+	composeInUse = func() (string, error) { return "docker-compose", nil }
+
+	f := fakeKoolInfo()
+	f.cmdDockerCompose.(*builder.FakeCommand).MockExecOut = "docker-compose version 1.29.2"
+
+	output, err := execInfoCommand(NewInfoCmd(f), f)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(output, "Compose Command: docker-compose\ndocker-compose version 1.29.2") {
+		t.Errorf("expected the compose in use along with its version on output, got '%s'", output)
+	}
+
+	composeInUse = func() (string, error) { return "", errors.New("not found") }
+
+	f = fakeKoolInfo()
+
+	if output, err = execInfoCommand(NewInfoCmd(f), f); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(output, "Compose Command: (not found)") {
+		t.Errorf("expected missing compose on output, got '%s'", output)
+	}
+}
+
+func TestInfoDockerResources(t *testing.T) {
+	f := fakeKoolInfo()
+
+	f.cmdDiskUsage.(*builder.FakeCommand).MockExecOut = "Images|12|3.2GB|1.1GB (34%)\nLocal Volumes|4|500MB|0B (0%)"
+	f.cmdResources.(*builder.FakeCommand).MockExecOut = "8|8589934592"
+
+	output, err := execInfoCommand(NewInfoCmd(f), f)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"Images: 12 (3.2GB, reclaimable 1.1GB (34%))",
+		"Local Volumes: 4 (500MB, reclaimable 0B (0%))",
+		"Docker CPUs: 8",
+		"Docker Memory: 8.00 GiB",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected '%s', got '%s'", expected, output)
+		}
+	}
+
+	f = fakeKoolInfo()
+	f.cmdDiskUsage.(*builder.FakeCommand).MockExecError = errors.New("df error")
+
+	if _, err = execInfoCommand(NewInfoCmd(f), f); err != nil {
+		t.Errorf("disk usage failure should not fail info; got %v", err)
+	}
+
+	if warning := fmt.Sprint(f.shell.(*shell.FakeShell).WarningOutput...); !strings.Contains(warning, "df error") {
+		t.Errorf("expected disk usage warning; got '%s'", warning)
+	}
+}
+
 func execInfoCommand(cmd *cobra.Command, f *KoolInfo) (output string, err error) {
 	if err = cmd.Execute(); err != nil {
 		return
@@ -71,3 +189,80 @@ func execInfoCommand(cmd *cobra.Command, f *KoolInfo) (output string, err error)
 	output = strings.Join(f.shell.(*shell.FakeShell).OutLines, "\n")
 	return
 }
+
+func TestInfoNetwork(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer listener.Close()
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	f := fakeKoolInfo()
+	f.envStorage.Set("KOOL_GLOBAL_NETWORK", "kool_global")
+	f.cmdServices.(*builder.FakeCommand).MockExecOut = fmt.Sprintf(
+		`{"Service":"database","Publishers":[]}`+"\n"+
+			`{"Service":"app","Publishers":[{"URL":"0.0.0.0","PublishedPort":%s,"Protocol":"tcp"},{"URL":"","PublishedPort":0,"Protocol":"tcp"}]}`,
+		port,
+	)
+
+	cmd := NewInfoCmd(f)
+	cmd.SetArgs([]string{"--network"})
+
+	output, err := execInfoCommand(cmd, f)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"Network Diagnostics:",
+		"Network kool_global: found",
+		"DNS app -> database: ok",
+		fmt.Sprintf("Port %s (service app): reachable", port),
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected '%s', got '%s'", expected, output)
+		}
+	}
+}
+
+func TestInfoNetworkFailures(t *testing.T) {
+	f := fakeKoolInfo()
+	f.envStorage.Set("KOOL_GLOBAL_NETWORK", "kool_global")
+	f.cmdNetwork.(*builder.FakeCommand).MockExecError = errors.New("no such network")
+	f.cmdResolve.(*builder.FakeCommand).MockExecError = errors.New("resolve error")
+	f.cmdServices.(*builder.FakeCommand).MockExecOut = `[{"Service":"app","Publishers":[]},{"Service":"database","Publishers":[]}]`
+	f.Flags.Network = true
+
+	if err := f.Execute(nil); err != nil {
+		t.Errorf("network failures should not fail info; got %v", err)
+	}
+
+	if warning := fmt.Sprint(f.shell.(*shell.FakeShell).WarningOutput...); !strings.Contains(warning, "DNS app -> database: failed (resolve error)") {
+		t.Errorf("expected DNS failure warning; got '%s'", warning)
+	}
+
+	f = fakeKoolInfo()
+	cmd := NewInfoCmd(f)
+	cmd.SetArgs([]string{"--network"})
+
+	output, err := execInfoCommand(cmd, f)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(output, "No running services to probe") {
+		t.Errorf("expected no running services message; got '%s'", output)
+	}
+
+	f = fakeKoolInfo()
+
+	if output, _ = execInfoCommand(NewInfoCmd(f), f); strings.Contains(output, "Network Diagnostics") {
+		t.Error("should only probe the network with --network")
+	}
+}
@@ -73,6 +73,7 @@ func (t *DefaultKoolTask) Run(args []string) (err error) {
 		statusMessage = color.New(color.Green).Sprint("done")
 	}
 
+	t.refreshTermWidth()
 	t.actualOut.Printf("\r" + strings.Repeat(" ", t.termWidth-1) + "\r")
 	t.actualOut.Println(fmt.Sprintf("[%s] %s", statusMessage, t.message))
 
@@ -84,6 +85,14 @@ func (t *DefaultKoolTask) SetFrameOutput(frame bool) {
 	t.frameOutput = frame
 }
 
+// refreshTermWidth updates the known terminal width
+// so output keeps up with window resizes
+func (t *DefaultKoolTask) refreshTermWidth() {
+	if width, err := shell.GetTerminalWidth(t.originalOut); err == nil {
+		t.termWidth = width
+	}
+}
+
 func (t *DefaultKoolTask) execService(args []string) <-chan error {
 	var err = make(chan error)
 
@@ -131,6 +140,7 @@ func (t *DefaultKoolTask) printServiceOutput(lines chan string) <-chan bool {
 
 		for line := range lines {
 			loading.Lock()
+			t.refreshTermWidth()
 			t.actualOut.Printf("\r" + strings.Repeat(" ", t.termWidth-1) + "\r")
 			if t.frameOutput {
 				t.actualOut.Println(">", line)
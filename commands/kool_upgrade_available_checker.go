@@ -1,17 +1,26 @@
 package commands
 
 import (
+	"kool-dev/kool/core/clock"
+	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/network"
 	"kool-dev/kool/services/updater"
 
 	"time"
 )
 
+// updateCheckTimeout bounds how long we wait for the new version check
+// after the command is done, so it does not hold the user back
+var updateCheckTimeout = time.Second
+
 // UpdateAwareService holds functions to implement the checker to see if theres a new version available
 type UpdateAwareService struct {
 	KoolService
 
 	updater updater.Updater
 	skip    bool
+	clock   clock.Clock
+	env     environment.EnvStorage
 }
 
 // CheckNewVersion wraps the service with checker logic
@@ -20,12 +29,14 @@ func CheckNewVersion(service KoolService, updater updater.Updater, skip bool) *U
 		service,
 		updater,
 		skip,
+		clock.NewClock(),
+		environment.NewEnvStorage(),
 	}
 }
 
 // Execute runs the check logic and proxies to original service
 func (u *UpdateAwareService) Execute(args []string) (err error) {
-	if u.skip || !u.KoolService.Shell().IsTerminal() {
+	if u.skip || !u.KoolService.Shell().IsTerminal() || network.Offline(u.env) {
 		err = u.KoolService.Execute(args)
 		return
 	}
@@ -43,7 +54,7 @@ func (u *UpdateAwareService) Execute(args []string) (err error) {
 		if update {
 			defer u.KoolService.Shell().Warning("There's a new version available! Run kool self-update to upgrade!")
 		}
-	case <-time.After(time.Second):
+	case <-u.clock.After(updateCheckTimeout):
 		break
 	}
 
@@ -1,12 +1,15 @@
 package commands
 
 import (
+	"kool-dev/kool/core/clock"
+	"kool-dev/kool/core/environment"
 	"kool-dev/kool/core/shell"
 	"kool-dev/kool/services/updater"
 
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 )
 
 func newFakeUpdateAwareService(start *KoolStart, koolFakeUpdater *updater.FakeUpdater) *UpdateAwareService {
@@ -14,6 +17,8 @@ func newFakeUpdateAwareService(start *KoolStart, koolFakeUpdater *updater.FakeUp
 		start,
 		koolFakeUpdater,
 		false,
+		clock.NewClock(),
+		environment.NewFakeEnvStorage(),
 	}
 }
 
@@ -63,6 +68,7 @@ func TestStartWithUpdaterWrapperTimeout(t *testing.T) {
 
 	cmd := NewStartCommand(koolStart)
 	fakeUpdateAwareService := newFakeUpdateAwareService(koolStart, koolUpdater)
+	fakeUpdateAwareService.clock = clock.NewFakeClock(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC))
 
 	cmd.RunE = DefaultCommandRunFunction(fakeUpdateAwareService)
 
@@ -164,3 +170,27 @@ func TestDontCheckForUpdatesWhenNonTerminal(t *testing.T) {
 		t.Errorf("called CheckForUpdates")
 	}
 }
+
+func TestDontCheckForUpdatesWhenOffline(t *testing.T) {
+	koolStart := newFakeKoolStart()
+	koolStart.Fake()
+
+	koolUpdater := &updater.FakeUpdater{
+		MockCurrentVersion: "0.0.0",
+		MockLatestVersion:  "1.0.0",
+	}
+
+	cmd := NewStartCommand(koolStart)
+	fakeUpdateAwareService := newFakeUpdateAwareService(koolStart, koolUpdater)
+	fakeUpdateAwareService.env.Set("KOOL_OFFLINE", "true")
+
+	cmd.RunE = DefaultCommandRunFunction(fakeUpdateAwareService)
+
+	if _, err := execStartCommand(cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	if koolUpdater.CalledCheckForUpdates {
+		t.Error("should not check for updates when offline")
+	}
+}
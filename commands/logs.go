@@ -1,26 +1,53 @@
 package commands
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/shell"
+	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 // KoolLogsFlags holds the flags for the logs command
 type KoolLogsFlags struct {
-	Tail   int
-	Follow bool
+	Tail       int
+	Follow     bool
+	WatchEnv   bool
+	Output     string
+	Previous   bool
+	Sort       bool
+	SortWindow time.Duration
+	Merge      bool
 }
 
+// defaultLogsSortWindow is how long log lines are held for sorting by default
+const defaultLogsSortWindow = 500 * time.Millisecond
+
 // KoolLogs holds handlers and functions to implement the logs command logic
 type KoolLogs struct {
 	DefaultKoolService
 	Flags *KoolLogsFlags
 
+	env  environment.EnvStorage
 	list builder.Command
 	logs builder.Command
+
+	project       builder.Command
+	history       builder.Command
+	containerLogs builder.Command
+
+	services     *KoolServices
+	promptSelect shell.PromptSelect
 }
 
 func AddKoolLogs(root *cobra.Command) {
@@ -36,9 +63,15 @@ func AddKoolLogs(root *cobra.Command) {
 func NewKoolLogs() *KoolLogs {
 	return &KoolLogs{
 		*newDefaultKoolService(),
-		&KoolLogsFlags{25, false},
-		builder.NewCommand("docker", "compose", "ps", "-aq"),
-		builder.NewCommand("docker", "compose", "logs"),
+		&KoolLogsFlags{25, false, false, "", false, false, defaultLogsSortWindow, false},
+		environment.NewEnvStorage(),
+		builder.NewComposeCommand("ps", "-aq"),
+		builder.NewComposeCommand("logs"),
+		builder.NewCommand("docker", "inspect", "--format", `{{index .Config.Labels "com.docker.compose.project"}}`),
+		builder.NewCommand("docker", "ps", "-aq", "--no-trunc"),
+		builder.NewCommand("docker", "logs"),
+		NewKoolServices(),
+		shell.NewPromptSelect(),
 	}
 }
 
@@ -46,6 +79,34 @@ func NewKoolLogs() *KoolLogs {
 func (l *KoolLogs) Execute(args []string) (err error) {
 	var services string
 
+	if l.Flags.Merge {
+		if l.Flags.Follow || l.Flags.Previous || l.Flags.Output != "" {
+			err = fmt.Errorf("--merge cannot be used along with --follow, --previous or --output")
+			return
+		}
+
+		// the whole logs of all services, ordered and
+		// timestamped, into a single file for bug reports
+		l.Flags.Tail = 0
+		l.Flags.Sort = true
+		l.Flags.Output = l.mergedLogsFile()
+	}
+
+	if l.Flags.Previous && len(args) == 0 && l.Shell().IsTerminal() {
+		var service string
+
+		if service, err = l.services.Pick(l.promptSelect); err != nil {
+			return
+		}
+
+		args = []string{service}
+	}
+
+	if l.Flags.Previous && len(args) != 1 {
+		err = fmt.Errorf("--previous requires exactly one SERVICE")
+		return
+	}
+
 	if services, err = l.Shell().Exec(l.list, args...); err != nil {
 		return
 	}
@@ -55,17 +116,181 @@ func (l *KoolLogs) Execute(args []string) (err error) {
 		return
 	}
 
+	logs := l.logs
+
+	if l.Flags.Previous {
+		var previous string
+
+		if previous, err = l.previousContainer(args[0], strings.Fields(services)); err != nil {
+			return
+		}
+
+		logs = l.containerLogs
+		args = []string{previous}
+	}
+
+	if l.Flags.Sort {
+		if l.Flags.SortWindow < 0 {
+			err = fmt.Errorf("--sort-window cannot be negative")
+			return
+		}
+
+		logs.AppendArgs("--timestamps")
+	}
+
 	if l.Flags.Tail == 0 {
-		l.logs.AppendArgs("--tail", "all")
+		logs.AppendArgs("--tail", "all")
 	} else {
-		l.logs.AppendArgs("--tail", strconv.Itoa(l.Flags.Tail))
+		logs.AppendArgs("--tail", strconv.Itoa(l.Flags.Tail))
 	}
 
 	if l.Flags.Follow {
-		l.logs.AppendArgs("--follow")
+		logs.AppendArgs("--follow")
+
+		if l.Flags.WatchEnv {
+			watcher := environment.NewEnvWatcher(l.env)
+
+			if err = watcher.Watch(l.envReloaded); err != nil {
+				return
+			}
+
+			defer watcher.Close()
+		}
+	}
+
+	if l.Flags.Output != "" {
+		err = l.writeToFile(logs, args)
+		return
+	}
+
+	err = l.interactive(logs, args)
+	return
+}
+
+// interactive runs the logs command, sorting its output
+// lines by their timestamps when --sort is given
+// envReloaded tells which variables got reloaded after the environment
+// file changed; the logs being followed started off with the environment
+// as it was, so they are not affected
+func (l *KoolLogs) envReloaded(file string, reloaded []string) {
+	if len(reloaded) == 0 {
+		l.Shell().Info(fmt.Sprintf("Environment file %s changed; no variables to reload (the ones set on the environment take precedence)", file))
+		return
+	}
+
+	l.Shell().Info(fmt.Sprintf("Environment file %s changed; reloaded %s (the logs being followed keep their original environment)", file, strings.Join(reloaded, ", ")))
+}
+
+func (l *KoolLogs) interactive(logs builder.Command, args []string) (err error) {
+	if !l.Flags.Sort {
+		err = l.Shell().Interactive(logs, args...)
+		return
+	}
+
+	var (
+		original = l.Shell().OutStream()
+		sorter   = newLogSorter(original, l.Flags.SortWindow)
+	)
+
+	l.Shell().SetOutStream(sorter)
+	err = l.Shell().Interactive(logs, args...)
+	sorter.Close()
+	l.Shell().SetOutStream(original)
+	return
+}
+
+// previousContainer finds the most recent container of the service
+// which is no longer the current one, like one replaced on recreation
+func (l *KoolLogs) previousContainer(service string, current []string) (previous string, err error) {
+	var project, output string
+
+	if project, err = l.Shell().Exec(l.project, current[0]); err != nil {
+		return
+	}
+
+	if output, err = l.Shell().Exec(l.history,
+		"--filter", "label=com.docker.compose.project="+strings.TrimSpace(project),
+		"--filter", "label=com.docker.compose.service="+service,
+	); err != nil {
+		return
 	}
 
-	err = l.Shell().Interactive(l.logs, args...)
+	// docker lists the newest containers first
+	for _, id := range strings.Fields(output) {
+		if !isCurrentContainer(id, current) {
+			previous = id
+			return
+		}
+	}
+
+	err = fmt.Errorf("no previous container found for service %s; docker does not keep the logs of removed containers", service)
+	return
+}
+
+// isCurrentContainer tells whether the full container id matches
+// one of the (possibly truncated) current container ids
+func isCurrentContainer(id string, current []string) bool {
+	for _, c := range current {
+		if strings.HasPrefix(id, c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergedLogsFile names the file for the merged logs
+// after the project and the current time
+func (l *KoolLogs) mergedLogsFile() string {
+	project := l.env.Get("COMPOSE_PROJECT_NAME")
+
+	if project == "" {
+		project = l.env.Get("KOOL_NAME")
+	}
+
+	if project == "" {
+		if wd, err := os.Getwd(); err == nil {
+			project = filepath.Base(wd)
+		}
+	}
+
+	return fmt.Sprintf("%s-logs-%s.log", project, time.Now().Format("20060102-150405"))
+}
+
+// writeToFile runs the logs command writing its output into the
+// file given by --output; when following, output gets appended
+func (l *KoolLogs) writeToFile(logs builder.Command, args []string) (err error) {
+	var (
+		file     *os.File
+		path     string
+		flags    = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		original = l.Shell().OutStream()
+	)
+
+	if path, err = filepath.Abs(l.Flags.Output); err != nil {
+		return
+	}
+
+	if l.Flags.Follow {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		l.Shell().Info(fmt.Sprintf("Appending logs to %s (press Ctrl+C to stop)", path))
+	}
+
+	if file, err = os.OpenFile(path, flags, 0644); err != nil {
+		return
+	}
+
+	defer file.Close()
+
+	l.Shell().SetOutStream(file)
+	err = l.interactive(logs, args)
+	l.Shell().SetOutStream(original)
+
+	if err != nil {
+		return
+	}
+
+	l.Shell().Success("Logs written to ", path)
 	return
 }
 
@@ -76,13 +301,173 @@ func NewLogsCommand(logs *KoolLogs) (logsCmd *cobra.Command) {
 		Short: "Display log output from running service containers",
 		Long: `Display log output from all running service containers,
 or one or more specified [SERVICE...] containers. Add a '-f' option to the
-the command to follow the log output (i.e. 'kool logs -f [SERVICE...]').`,
+the command to follow the log output (i.e. 'kool logs -f [SERVICE...]').
+
+Use '--previous' to see the logs of a service's previous container (i.e. one
+replaced when the service was recreated), as long as docker still keeps it;
+when SERVICE is omitted on a terminal, it can be picked from the services list.
+
+Use '--sort' to have the lines of different services ordered by their timestamps.
+Lines are held for the '--sort-window' duration before being shown, so that
+later arriving lines can still be put in order; a larger window orders more
+reliably at the cost of more latency. Lines without timestamps are shown
+as they arrive.
+
+Use '--merge' to capture the whole logs of all services (or the given ones)
+into a single file, like for bug reports. Lines are timestamped, prefixed with
+their service and ordered; the file is named after the project and the current
+time (i.e. 'myproject-logs-20240131-154500.log'), and its path is printed when done.`,
 		RunE: DefaultCommandRunFunction(logs),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return compListServices(toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
 
 		DisableFlagsInUseLine: true,
 	}
 
 	logsCmd.Flags().IntVarP(&logs.Flags.Tail, "tail", "t", 25, "Number of lines to show from the end of the logs for each container. A value equal to 0 will show all lines.")
 	logsCmd.Flags().BoolVarP(&logs.Flags.Follow, "follow", "f", false, "Follow log output.")
+	logsCmd.Flags().BoolVarP(&logs.Flags.WatchEnv, "watch-env", "", false, "Reload environment files when they change while following log output, telling which variables changed.")
+	logsCmd.Flags().StringVarP(&logs.Flags.Output, "output", "o", "", "Write the log output to the given file instead of the terminal.")
+	logsCmd.Flags().BoolVarP(&logs.Flags.Previous, "previous", "p", false, "Show the logs of the service's previous container, like one replaced on recreation.")
+	logsCmd.Flags().BoolVarP(&logs.Flags.Sort, "sort", "", false, "Order the log lines of all services by their timestamps (implies timestamps are shown).")
+	logsCmd.Flags().DurationVarP(&logs.Flags.SortWindow, "sort-window", "", defaultLogsSortWindow, "How long log lines are held for ordering with --sort.")
+	logsCmd.Flags().BoolVarP(&logs.Flags.Merge, "merge", "", false, "Write the whole logs of all services, timestamped and ordered, into a single file named after the project.")
+	return
+}
+
+// logLine is a log line held for sorting
+type logLine struct {
+	at       time.Time
+	received time.Time
+	text     []byte
+}
+
+// logSorter writes log lines in the order of their timestamps, holding
+// each line for the window duration so later arriving ones can still be
+// put in order; lines without timestamps are written right away
+type logSorter struct {
+	out    io.Writer
+	window time.Duration
+
+	mutex   sync.Mutex
+	partial []byte
+	lines   []logLine
+	timer   *time.Timer
+}
+
+func newLogSorter(out io.Writer, window time.Duration) *logSorter {
+	return &logSorter{out: out, window: window}
+}
+
+// Write buffers the complete lines of the given output for sorting
+func (l *logSorter) Write(p []byte) (n int, err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.partial = append(l.partial, p...)
+	now := time.Now()
+
+	for {
+		i := bytes.IndexByte(l.partial, '\n')
+		if i < 0 {
+			break
+		}
+
+		text := append([]byte{}, l.partial[:i+1]...)
+		l.partial = l.partial[i+1:]
+
+		at, ok := logTimestamp(string(text))
+
+		if !ok {
+			// no ordering can be told, so just keep up the output
+			l.flush(time.Time{})
+			_, _ = l.out.Write(text)
+			continue
+		}
+
+		i = sort.Search(len(l.lines), func(i int) bool {
+			return l.lines[i].at.After(at)
+		})
+
+		l.lines = append(l.lines, logLine{})
+		copy(l.lines[i+1:], l.lines[i:])
+		l.lines[i] = logLine{at, now, text}
+	}
+
+	l.flush(now.Add(-l.window))
+	l.schedule()
+
+	return len(p), nil
+}
+
+// Close writes out all the lines still held
+func (l *logSorter) Close() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+
+	l.flush(time.Time{})
+
+	if len(l.partial) > 0 {
+		_, _ = l.out.Write(l.partial)
+		l.partial = nil
+	}
+}
+
+// flush writes out the lines, in order, until the first one received
+// after the given deadline; a zero deadline writes out all of them
+func (l *logSorter) flush(deadline time.Time) {
+	var i int
+
+	for ; i < len(l.lines); i++ {
+		if !deadline.IsZero() && l.lines[i].received.After(deadline) {
+			break
+		}
+
+		_, _ = l.out.Write(l.lines[i].text)
+	}
+
+	l.lines = l.lines[i:]
+}
+
+// schedule has the held lines written out once their window is over
+// even if no more output comes in
+func (l *logSorter) schedule() {
+	if len(l.lines) == 0 || l.timer != nil {
+		return
+	}
+
+	// lines are written out in order, so the first one held is due first
+	l.timer = time.AfterFunc(time.Until(l.lines[0].received.Add(l.window)), func() {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+
+		l.timer = nil
+		l.flush(time.Now().Add(-l.window))
+		l.schedule()
+	})
+}
+
+// logTimestamp parses the timestamp docker adds to log lines
+// (with --timestamps), after the service prefix if any
+func logTimestamp(line string) (at time.Time, ok bool) {
+	if i := strings.Index(line, "| "); i >= 0 {
+		line = line[i+2:]
+	}
+
+	fields := strings.Fields(line)
+
+	if len(fields) == 0 {
+		return
+	}
+
+	var err error
+
+	at, err = time.Parse(time.RFC3339Nano, fields[0])
+	ok = err == nil
 	return
 }
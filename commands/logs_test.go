@@ -1,28 +1,46 @@
 package commands
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/environment"
 	"kool-dev/kool/core/shell"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func newFakeKoolLogs() *KoolLogs {
 	return &KoolLogs{
 		*(newDefaultKoolService().Fake()),
-		&KoolLogsFlags{25, false},
+		&KoolLogsFlags{25, false, false, "", false, false, defaultLogsSortWindow, false},
+		environment.NewFakeEnvStorage(),
 		&builder.FakeCommand{MockCmd: "list", MockExecOut: "app"},
 		&builder.FakeCommand{MockCmd: "logs"},
+		&builder.FakeCommand{MockCmd: "project", MockExecOut: "myproject\n"},
+		&builder.FakeCommand{MockCmd: "history", MockExecOut: "abc123\ndef456\n"},
+		&builder.FakeCommand{MockCmd: "container-logs"},
+		newFakeKoolServices("app\ndatabase", nil),
+		&shell.FakePromptSelect{},
 	}
 }
 
 func newFakeFailedKoolLogs() *KoolLogs {
 	return &KoolLogs{
 		*(newDefaultKoolService().Fake()),
-		&KoolLogsFlags{25, false},
+		&KoolLogsFlags{25, false, false, "", false, false, defaultLogsSortWindow, false},
+		environment.NewFakeEnvStorage(),
 		&builder.FakeCommand{MockCmd: "list", MockExecOut: "app"},
 		&builder.FakeCommand{MockCmd: "logs", MockInteractiveError: errors.New("error logs")},
+		&builder.FakeCommand{MockCmd: "project", MockExecOut: "myproject\n"},
+		&builder.FakeCommand{MockCmd: "history", MockExecOut: "abc123\ndef456\n"},
+		&builder.FakeCommand{MockCmd: "container-logs"},
+		newFakeKoolServices("app\ndatabase", nil),
+		&shell.FakePromptSelect{},
 	}
 }
 
@@ -132,6 +150,38 @@ func TestNewLogsFollowCommand(t *testing.T) {
 	}
 }
 
+func TestNewLogsFollowWatchEnvCommand(t *testing.T) {
+	f := newFakeKoolLogs()
+	f.env.Set("PWD", t.TempDir())
+	cmd := NewLogsCommand(f)
+
+	cmd.SetArgs([]string{"--follow", "--watch-env"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing logs command; error: %v", err)
+	}
+
+	f.env.Set("PWD", filepath.Join(t.TempDir(), "missing"))
+
+	assertExecGotError(t, cmd, "no such file or directory")
+}
+
+func TestLogsEnvReloaded(t *testing.T) {
+	f := newFakeKoolLogs()
+
+	f.envReloaded(".env", []string{"APP_ENV", "DB_HOST"})
+
+	if output := fmt.Sprint(f.shell.(*shell.FakeShell).InfoOutput...); output != "Environment file .env changed; reloaded APP_ENV, DB_HOST (the logs being followed keep their original environment)" {
+		t.Errorf("expected the reloaded variables to be told; got %s", output)
+	}
+
+	f.envReloaded(".env.local", nil)
+
+	if output := fmt.Sprint(f.shell.(*shell.FakeShell).InfoOutput...); output != "Environment file .env.local changed; no variables to reload (the ones set on the environment take precedence)" {
+		t.Errorf("expected no reloaded variables to be told; got %s", output)
+	}
+}
+
 func TestNewLogsServiceCommand(t *testing.T) {
 	f := newFakeKoolLogs()
 	cmd := NewLogsCommand(f)
@@ -188,3 +238,304 @@ func TestFailingNoContainersNewLogsCommand(t *testing.T) {
 
 	assertExecGotError(t, cmd, "error list")
 }
+
+func TestNewLogsOutputCommand(t *testing.T) {
+	f := newFakeKoolLogs()
+	path := filepath.Join(t.TempDir(), "logs.txt")
+
+	if err := os.WriteFile(path, []byte("previous"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := NewLogsCommand(f)
+	cmd.SetArgs([]string{"--output", path})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing logs command; error: %v", err)
+	}
+
+	fakeShell := f.shell.(*shell.FakeShell)
+
+	if !fakeShell.CalledSetOutStream || !fakeShell.CalledInteractive["logs"] {
+		t.Error("did not run logs redirecting its output")
+	}
+
+	if data, _ := os.ReadFile(path); len(data) != 0 {
+		t.Errorf("expected output file to be truncated; got %q", string(data))
+	}
+
+	if output := fmt.Sprint(fakeShell.SuccessOutput...); output != "Logs written to "+path {
+		t.Errorf("unexpected success message: %s", output)
+	}
+}
+
+func TestNewLogsOutputFollowCommand(t *testing.T) {
+	f := newFakeKoolLogs()
+	path := filepath.Join(t.TempDir(), "logs.txt")
+
+	if err := os.WriteFile(path, []byte("previous"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := NewLogsCommand(f)
+	cmd.SetArgs([]string{"--follow", "--output", path})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing logs command; error: %v", err)
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != "previous" {
+		t.Errorf("expected output file to be appended to; got %q", string(data))
+	}
+
+	if !f.shell.(*shell.FakeShell).CalledInfo {
+		t.Error("did not inform the output file being followed")
+	}
+
+	f = newFakeFailedKoolLogs()
+	cmd = NewLogsCommand(f)
+	cmd.SetArgs([]string{"--output", path})
+
+	assertExecGotError(t, cmd, "error logs")
+
+	if f.shell.(*shell.FakeShell).CalledSuccess {
+		t.Error("should not report success when logs fail")
+	}
+}
+
+func TestNewLogsPreviousCommand(t *testing.T) {
+	f := newFakeKoolLogs()
+	f.list.(*builder.FakeCommand).MockExecOut = "abc"
+
+	cmd := NewLogsCommand(f)
+	cmd.SetArgs([]string{"--previous", "app"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing logs command; error: %v", err)
+	}
+
+	fakeShell := f.shell.(*shell.FakeShell)
+
+	if !fakeShell.CalledExec["project"] || !fakeShell.CalledExec["history"] {
+		t.Error("did not look up the service previous containers")
+	}
+
+	if fakeShell.CalledInteractive["logs"] {
+		t.Error("should not call docker compose logs for the previous container")
+	}
+
+	args, ok := fakeShell.ArgsInteractive["container-logs"]
+	if !ok || len(args) != 1 || args[0] != "def456" {
+		t.Errorf("expected logs of previous container def456; got %v", args)
+	}
+
+	argsAppend := f.containerLogs.(*builder.FakeCommand).ArgsAppend
+	if len(argsAppend) != 2 || argsAppend[0] != "--tail" || argsAppend[1] != "25" {
+		t.Errorf("bad arguments to KoolLogs.containerLogs Command with default flags")
+	}
+}
+
+func TestNewLogsPreviousNotFoundCommand(t *testing.T) {
+	f := newFakeKoolLogs()
+	f.list.(*builder.FakeCommand).MockExecOut = "abc"
+	f.history.(*builder.FakeCommand).MockExecOut = "abc123\n"
+
+	cmd := NewLogsCommand(f)
+	cmd.SetArgs([]string{"--previous", "app"})
+
+	assertExecGotError(t, cmd, "no previous container found for service app")
+
+	if f.shell.(*shell.FakeShell).CalledInteractive["container-logs"] {
+		t.Error("should not call docker logs when there is no previous container")
+	}
+}
+
+func TestNewLogsPreviousRequiresServiceCommand(t *testing.T) {
+	f := newFakeKoolLogs()
+	f.shell.(*shell.FakeShell).MockIsTerminal = false
+
+	cmd := NewLogsCommand(f)
+	cmd.SetArgs([]string{"--previous"})
+
+	assertExecGotError(t, cmd, "--previous requires exactly one SERVICE")
+}
+
+func TestNewLogsPreviousFailingHistoryCommand(t *testing.T) {
+	f := newFakeKoolLogs()
+	f.history.(*builder.FakeCommand).MockExecError = errors.New("error history")
+
+	cmd := NewLogsCommand(f)
+	cmd.SetArgs([]string{"--previous", "app"})
+
+	assertExecGotError(t, cmd, "error history")
+}
+
+func TestNewLogsPreviousPickServiceCommand(t *testing.T) {
+	f := newFakeKoolLogs()
+	f.list.(*builder.FakeCommand).MockExecOut = "abc"
+	f.promptSelect.(*shell.FakePromptSelect).MockAnswer = map[string]string{
+		"Which service do you want to use?": "database",
+	}
+
+	cmd := NewLogsCommand(f)
+	cmd.SetArgs([]string{"--previous"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing logs command; error: %v", err)
+	}
+
+	if !f.promptSelect.(*shell.FakePromptSelect).CalledAsk {
+		t.Error("did not prompt for the service")
+	}
+
+	args, ok := f.shell.(*shell.FakeShell).ArgsInteractive["container-logs"]
+	if !ok || len(args) != 1 || args[0] != "def456" {
+		t.Errorf("expected logs of previous container def456; got %v", args)
+	}
+}
+
+func TestNewLogsSortCommand(t *testing.T) {
+	f := newFakeKoolLogs()
+	out := f.shell.OutStream()
+	cmd := NewLogsCommand(f)
+
+	cmd.SetArgs([]string{"--sort", "--sort-window", "1s"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing logs command; error: %v", err)
+	}
+
+	argsAppend := strings.Join(f.logs.(*builder.FakeCommand).ArgsAppend, " ")
+	if argsAppend != "--timestamps --tail 25" {
+		t.Errorf("bad arguments to KoolLogs.logs Command when passing --sort flag: %s", argsAppend)
+	}
+
+	if f.Flags.SortWindow != time.Second {
+		t.Errorf("expected sort window of 1s; got %v", f.Flags.SortWindow)
+	}
+
+	if f.shell.OutStream() != out {
+		t.Error("expected the original output stream to be restored")
+	}
+}
+
+func TestNewLogsMergeCommand(t *testing.T) {
+	var (
+		dir   = t.TempDir()
+		wd, _ = os.Getwd()
+	)
+
+	defer func() { _ = os.Chdir(wd) }()
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newFakeKoolLogs()
+	f.env.Set("COMPOSE_PROJECT_NAME", "myproject")
+
+	cmd := NewLogsCommand(f)
+	cmd.SetArgs([]string{"--merge"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error executing logs command; error: %v", err)
+	}
+
+	argsAppend := strings.Join(f.logs.(*builder.FakeCommand).ArgsAppend, " ")
+	if argsAppend != "--timestamps --tail all" {
+		t.Errorf("bad arguments to KoolLogs.logs Command when passing --merge flag: %s", argsAppend)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "myproject-logs-*.log"))
+
+	if len(files) != 1 {
+		t.Fatalf("expected the merged logs file to be created; got %v", files)
+	}
+
+	if output := fmt.Sprint(f.shell.(*shell.FakeShell).SuccessOutput...); !strings.HasPrefix(output, "Logs written to ") || !strings.HasSuffix(output, filepath.Base(files[0])) {
+		t.Errorf("unexpected success message: %s", output)
+	}
+
+	for _, args := range [][]string{
+		{"--merge", "--follow"},
+		{"--merge", "--previous", "app"},
+		{"--merge", "--output", "logs.txt"},
+	} {
+		cmd = NewLogsCommand(newFakeKoolLogs())
+		cmd.SetArgs(args)
+
+		assertExecGotError(t, cmd, "--merge cannot be used along with --follow, --previous or --output")
+	}
+}
+
+func TestNewLogsSortNegativeWindowCommand(t *testing.T) {
+	f := newFakeKoolLogs()
+	cmd := NewLogsCommand(f)
+
+	cmd.SetArgs([]string{"--sort", "--sort-window", "-1s"})
+
+	assertExecGotError(t, cmd, "--sort-window cannot be negative")
+}
+
+func TestLogSorter(t *testing.T) {
+	out := new(bytes.Buffer)
+	sorter := newLogSorter(out, time.Hour)
+
+	_, _ = sorter.Write([]byte("app-1  | 2024-01-01T10:00:02.000000000Z second\ndb-1   | 2024-01-01T10:00:03.0"))
+	_, _ = sorter.Write([]byte("00000000Z third\ndb-1   | 2024-01-01T10:00:01.000000000Z first\n"))
+
+	if out.Len() != 0 {
+		t.Errorf("expected lines to be held within the window; got %q", out.String())
+	}
+
+	sorter.Close()
+
+	expected := "db-1   | 2024-01-01T10:00:01.000000000Z first\n" +
+		"app-1  | 2024-01-01T10:00:02.000000000Z second\n" +
+		"db-1   | 2024-01-01T10:00:03.000000000Z third\n"
+
+	if out.String() != expected {
+		t.Errorf("expected lines ordered by timestamp; got %q", out.String())
+	}
+}
+
+func TestLogSorterWithoutTimestamps(t *testing.T) {
+	out := new(bytes.Buffer)
+	sorter := newLogSorter(out, time.Hour)
+
+	_, _ = sorter.Write([]byte("app-1  | 2024-01-01T10:00:02Z second\napp-1  | 2024-01-01T10:00:01Z first\nno timestamp here\n"))
+
+	expected := "app-1  | 2024-01-01T10:00:01Z first\napp-1  | 2024-01-01T10:00:02Z second\nno timestamp here\n"
+
+	if out.String() != expected {
+		t.Errorf("expected held lines and then the raw line; got %q", out.String())
+	}
+
+	_, _ = sorter.Write([]byte("partial"))
+	sorter.Close()
+
+	if !strings.HasSuffix(out.String(), "here\npartial") {
+		t.Errorf("expected partial line written out on close; got %q", out.String())
+	}
+}
+
+func TestLogSorterWindow(t *testing.T) {
+	var (
+		out    = new(bytes.Buffer)
+		sorter = newLogSorter(out, 10*time.Millisecond)
+	)
+
+	_, _ = sorter.Write([]byte("2024-01-01T10:00:01Z first\n"))
+
+	time.Sleep(100 * time.Millisecond)
+
+	sorter.mutex.Lock()
+	written := out.String()
+	sorter.mutex.Unlock()
+
+	if written != "2024-01-01T10:00:01Z first\n" {
+		t.Errorf("expected held lines written out after the window; got %q", written)
+	}
+
+	sorter.Close()
+}
@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/shell"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// pluginPrefix is the naming convention for external commands: when there
+// is no built-in command named foo, `kool foo` runs a `kool-foo` executable
+// found on PATH, forwarding all the remaining arguments to it
+const pluginPrefix = "kool-"
+
+// runPlugin looks for an external command matching the given arguments
+// and runs it; found tells whether such executable exists on PATH
+func runPlugin(cmd *cobra.Command, args []string) (found bool, err error) {
+	var (
+		sh     = shell.NewShell()
+		plugin builder.Command
+	)
+
+	if len(args) == 0 || args[0] == "" || strings.HasPrefix(args[0], "-") || strings.ContainsAny(args[0], `/\`) {
+		return
+	}
+
+	plugin = builder.NewCommand(pluginPrefix + args[0])
+
+	if sh.LookPath(plugin) != nil {
+		return
+	}
+
+	found = true
+
+	sh.SetInStream(cmd.InOrStdin())
+	sh.SetOutStream(cmd.OutOrStdout())
+	sh.SetErrStream(cmd.ErrOrStderr())
+
+	err = sh.Interactive(plugin, args[1:]...)
+	return
+}
@@ -5,13 +5,22 @@ import (
 	"kool-dev/kool/core/presets"
 	"kool-dev/kool/core/shell"
 	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
+// KoolPresetFlags holds the flags for the kool preset command
+type KoolPresetFlags struct {
+	Force bool
+	Diff  bool
+	Tags  bool
+}
+
 // KoolPreset holds handlers and functions to implement the preset command logic
 type KoolPreset struct {
 	DefaultKoolService
+	Flags         *KoolPresetFlags
 	presetsParser presets.Parser
 	promptSelect  shell.PromptSelect
 }
@@ -29,6 +38,7 @@ func AddKoolPreset(root *cobra.Command) {
 func NewKoolPreset() *KoolPreset {
 	return &KoolPreset{
 		*newDefaultKoolService(),
+		&KoolPresetFlags{false, false, false},
 		presets.NewParser(),
 		shell.NewPromptSelect(),
 	}
@@ -38,6 +48,11 @@ func NewKoolPreset() *KoolPreset {
 func (p *KoolPreset) Execute(args []string) (err error) {
 	var preset string
 
+	if p.Flags.Tags {
+		p.listTags()
+		return
+	}
+
 	if preset, err = p.getPreset(args); err != nil {
 		return
 	}
@@ -49,16 +64,60 @@ func (p *KoolPreset) Execute(args []string) (err error) {
 
 	p.Shell().Println("Preset", preset, "is initializing!")
 
+	p.presetsParser.SetForce(p.Flags.Force)
+	p.presetsParser.SetDiffMode(p.Flags.Diff)
 	p.presetsParser.PrepareExecutor(p.Shell())
 
 	if err = p.presetsParser.Install(preset); err != nil {
 		return
 	}
 
+	if p.Flags.Diff {
+		p.Shell().Info("No changes were made (--diff mode)")
+		return
+	}
+
+	printPresetWriteSummary(p.Shell(), p.presetsParser)
+
 	p.Shell().Success("Preset ", preset, " initialized!")
 	return
 }
 
+// listTags prints every tag used by the presets, along with
+// how many presets use it, for discovering what to search by
+func (p *KoolPreset) listTags() {
+	var (
+		counts = make(map[string]int)
+		tags   []string
+		width  int
+	)
+
+	for _, config := range p.presetsParser.GetConfigs() {
+		for _, tag := range config.Tags {
+			if counts[tag] == 0 {
+				tags = append(tags, tag)
+
+				if len(tag) > width {
+					width = len(tag)
+				}
+			}
+
+			counts[tag]++
+		}
+	}
+
+	if len(tags) == 0 {
+		p.Shell().Println("No preset tags found")
+		return
+	}
+
+	sort.Strings(tags)
+
+	for _, tag := range tags {
+		p.Shell().Println(fmt.Sprintf("%-*s  %d preset(s)", width, tag, counts[tag]))
+	}
+}
+
 // NewPresetCommand initializes new kool preset command
 func NewPresetCommand(preset *KoolPreset) (presetCmd *cobra.Command) {
 	presetCmd = &cobra.Command{
@@ -66,15 +125,38 @@ func NewPresetCommand(preset *KoolPreset) (presetCmd *cobra.Command) {
 		Short: "Install configuration files customized for Kool in the current directory",
 		Long: `Initialize a project using the specified [PRESET] by installing configuration
 files customized for Kool in the current working directory. If no [PRESET] is provided,
-an interactive wizard will present the available options.`,
+a list of the available presets is presented, which can be searched by typing part
+of the preset name or tag. Presets matching the project files found in the current
+directory (like composer.json or package.json) are suggested first.
+
+Use --tags to list the tags of the available presets.`,
 		Args:                  cobra.MaximumNArgs(1),
 		RunE:                  DefaultCommandRunFunction(preset),
 		DisableFlagsInUseLine: true,
 	}
 
+	presetCmd.Flags().BoolVarP(&preset.Flags.Force, "force", "", false, "Overwrite existing files without asking")
+	presetCmd.Flags().BoolVarP(&preset.Flags.Diff, "diff", "", false, "Only show a diff of the changes to existing files, without applying them")
+	presetCmd.Flags().BoolVarP(&preset.Flags.Tags, "tags", "", false, "List the tags of the available presets, with how many presets use each")
+
 	return
 }
 
+// printPresetWriteSummary reports the files written and skipped by the preset
+func printPresetWriteSummary(sh shell.Shell, parser presets.Parser) {
+	written, skipped := parser.WriteSummary()
+
+	if len(written) == 0 && len(skipped) == 0 {
+		return
+	}
+
+	sh.Info(fmt.Sprintf("%d file(s) written, %d file(s) skipped", len(written), len(skipped)))
+
+	for _, file := range skipped {
+		sh.Println("  skipped:", file)
+	}
+}
+
 func (p *KoolPreset) getPreset(args []string) (pickedPreset string, err error) {
 	if len(args) == 1 {
 		pickedPreset = args[0]
@@ -86,28 +168,68 @@ func (p *KoolPreset) getPreset(args []string) (pickedPreset string, err error) {
 		return
 	}
 
-	var tag string
-	if tag, err = p.promptSelect.Ask("Pick the preset category you are looking for", p.presetsParser.GetTags()); err != nil {
-		return
+	var (
+		detectedIDs, detectedTags = presets.DetectProject(".")
+		configs, suggested        = suggestPresets(p.presetsParser.GetConfigs(), detectedIDs, detectedTags)
+		options                   = make([]string, len(configs))
+		answer                    string
+	)
+
+	for i, config := range configs {
+		options[i] = fmt.Sprintf("%s [%s]", config.Title(), strings.Join(config.Tags, ", "))
+
+		if i < suggested {
+			options[i] += " (suggested)"
+		}
 	}
 
-	var availablePresets = p.presetsParser.GetPresets(tag)
-	var presets []string
+	if answer, err = p.promptSelect.Search("What preset do you want to use (type to search by name or tag)", options, func(input string, index int) bool {
+		return configs[index].Matches(input)
+	}); err != nil {
+		return
+	}
 
-	for _, name := range availablePresets {
-		presets = append(presets, name)
+	for i, option := range options {
+		if option == answer {
+			pickedPreset = configs[i].ID()
+		}
 	}
 
-	sort.Strings(presets)
+	return
+}
 
-	if pickedPreset, err = p.promptSelect.Ask("What preset do you want to use", presets); err != nil {
-		return
+// suggestPresets moves the presets matching the detected project to the top,
+// the ones detected by ID first and then the ones sharing a detected tag;
+// it returns how many presets were moved up as suggestions
+func suggestPresets(configs []*presets.PresetConfig, ids []string, tags []string) (sorted []*presets.PresetConfig, suggested int) {
+	rank := func(config *presets.PresetConfig) int {
+		for i, id := range ids {
+			if config.ID() == id {
+				return i
+			}
+		}
+
+		for _, tag := range tags {
+			if config.HasTag(tag) {
+				return len(ids)
+			}
+		}
+
+		return len(ids) + 1
 	}
 
-	for preset, name := range availablePresets {
-		if name == pickedPreset {
-			pickedPreset = preset
+	sorted = make([]*presets.PresetConfig, len(configs))
+	copy(sorted, configs)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return rank(sorted[i]) < rank(sorted[j])
+	})
+
+	for _, config := range sorted {
+		if rank(config) > len(ids) {
+			break
 		}
+		suggested++
 	}
 
 	return
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"fmt"
 	"kool-dev/kool/core/presets"
 	"kool-dev/kool/core/shell"
 	"testing"
@@ -21,3 +22,101 @@ func TestNewKoolPreset(t *testing.T) {
 		t.Errorf("unexpected shell.PromptSelect on default KoolPreset instance")
 	}
 }
+
+func TestGetPresetSearch(t *testing.T) {
+	k := &KoolPreset{
+		*(newDefaultKoolService().Fake()),
+		&KoolPresetFlags{false, false, false},
+		&presets.FakeParser{MockGetConfigs: []*presets.PresetConfig{
+			{Name: "Laravel", Tags: []string{"php"}},
+			{Name: "NestJS", Tags: []string{"javascript"}},
+		}},
+		&shell.FakePromptSelect{},
+	}
+
+	question := "What preset do you want to use (type to search by name or tag)"
+
+	k.shell.(*shell.FakeShell).MockIsTerminal = false
+
+	if _, err := k.getPreset([]string{}); err == nil {
+		t.Error("should require preset as argument on non-TTY")
+	}
+
+	k.shell.(*shell.FakeShell).MockIsTerminal = true
+	prompt := k.promptSelect.(*shell.FakePromptSelect)
+	prompt.MockAnswer = map[string]string{question: "NestJS [javascript]"}
+
+	if _, err := k.getPreset([]string{}); err != nil {
+		t.Errorf("unexpected error getting preset: %v", err)
+	}
+
+	if !prompt.CalledSearch || len(prompt.SearchOptions) != 2 || prompt.SearchOptions[0] != "Laravel [php]" {
+		t.Errorf("bad search options: %v", prompt.SearchOptions)
+	}
+
+	if !prompt.SearchFilter("js", 1) || prompt.SearchFilter("js", 0) || !prompt.SearchFilter("php", 0) {
+		t.Error("bad search filter matching")
+	}
+
+	if preset, _ := k.getPreset([]string{"laravel"}); preset != "laravel" {
+		t.Errorf("expected preset from argument; got %s", preset)
+	}
+}
+
+func TestSuggestPresets(t *testing.T) {
+	configs := []*presets.PresetConfig{
+		{Name: "AdonisJS", Tags: []string{"JS"}},
+		{Name: "Laravel", Tags: []string{"PHP"}},
+		{Name: "NestJS", Tags: []string{"Typescript"}},
+		{Name: "Symfony", Tags: []string{"PHP"}},
+	}
+
+	sorted, suggested := suggestPresets(configs, nil, []string{"PHP"})
+
+	if suggested != 2 || sorted[0].Name != "Laravel" || sorted[1].Name != "Symfony" || sorted[2].Name != "AdonisJS" || sorted[3].Name != "NestJS" {
+		t.Errorf("expected PHP presets to be suggested first; got %d suggested", suggested)
+	}
+
+	if configs[0].Name != "AdonisJS" {
+		t.Error("should not reorder the original configs")
+	}
+
+	if sorted, suggested = suggestPresets(configs, nil, nil); suggested != 0 || sorted[0].Name != "AdonisJS" {
+		t.Errorf("expected no suggestions without a detected project; got %d", suggested)
+	}
+}
+
+func TestPresetTagsCommand(t *testing.T) {
+	k := &KoolPreset{
+		*(newDefaultKoolService().Fake()),
+		&KoolPresetFlags{false, false, false},
+		&presets.FakeParser{MockGetConfigs: []*presets.PresetConfig{
+			{Name: "Laravel", Tags: []string{"php", "framework"}},
+			{Name: "NestJS", Tags: []string{"javascript", "framework"}},
+			{Name: "PHP", Tags: []string{"php"}},
+			{Name: "Untagged"},
+		}},
+		&shell.FakePromptSelect{},
+	}
+
+	cmd := NewPresetCommand(k)
+	cmd.SetArgs([]string{"--tags"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error listing preset tags: %v", err)
+	}
+
+	expected := []string{
+		"framework   2 preset(s)",
+		"javascript  1 preset(s)",
+		"php         2 preset(s)",
+	}
+
+	if output := k.shell.(*shell.FakeShell).OutLines; fmt.Sprint(output) != fmt.Sprint(expected) {
+		t.Errorf("expected tags %v; got %v", expected, output)
+	}
+
+	if k.presetsParser.(*presets.FakeParser).CalledInstall {
+		t.Error("should not install any preset when listing tags")
+	}
+}
@@ -11,9 +11,15 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// KoolRecipeFlags holds the flags for the kool recipe command
+type KoolRecipeFlags struct {
+	Diff bool
+}
+
 // KoolRecipe holds handlers and functions to implement the preset command logic
 type KoolRecipe struct {
 	DefaultKoolService
+	Flags *KoolRecipeFlags
 
 	promptSelet shell.PromptSelect
 }
@@ -31,6 +37,7 @@ func AddKoolRecipe(root *cobra.Command) {
 func NewKoolRecipe() *KoolRecipe {
 	return &KoolRecipe{
 		*newDefaultKoolService(),
+		&KoolRecipeFlags{false},
 		shell.NewPromptSelect(),
 	}
 }
@@ -70,7 +77,10 @@ func (p *KoolRecipe) Execute(args []string) (err error) {
 		}
 	}
 
-	err = presets.NewParser().Add(recipe, p.Shell())
+	parser := presets.NewParser()
+	parser.SetDiffMode(p.Flags.Diff)
+
+	err = parser.Add(recipe, p.Shell())
 
 	return
 }
@@ -105,5 +115,7 @@ func NewRecipeCommand(recipe *KoolRecipe) (recipeCmd *cobra.Command) {
 		DisableFlagsInUseLine: true,
 	}
 
+	recipeCmd.Flags().BoolVarP(&recipe.Flags.Diff, "diff", "", false, "Only show a diff of the changes to existing files, without applying them")
+
 	return
 }
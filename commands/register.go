@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"fmt"
+	"kool-dev/kool/core/environment"
+
+	"github.com/spf13/cobra"
+)
+
+// CommandFactory builds a new instance of an extension command
+type CommandFactory func() *cobra.Command
+
+// registeredCommands holds the factories of the extension commands added through RegisterCommand
+var registeredCommands []CommandFactory
+
+// RegisterCommand adds an extension command to kool along with the built-in
+// ones. The factory is called for every root command kool builds (including
+// the ones for nested kool calls), as a command can only belong to one root.
+// It fails when the command name or any of its aliases collides with a
+// command that already exists.
+func RegisterCommand(factory CommandFactory) (err error) {
+	var root = NewRootCmd(environment.NewEnvStorage())
+
+	AddCommands(root)
+
+	if err = checkCommandNames(root, factory()); err != nil {
+		return
+	}
+
+	registeredCommands = append(registeredCommands, factory)
+
+	// the default root command has already been wired on init
+	rootCmd.AddCommand(factory())
+	return
+}
+
+// addRegisteredCommands builds the extension commands for the given root,
+// leaving out the ones colliding with the commands it already has
+func addRegisteredCommands(root *cobra.Command) {
+	for _, factory := range registeredCommands {
+		cmd := factory()
+
+		if checkCommandNames(root, cmd) == nil {
+			root.AddCommand(cmd)
+		}
+	}
+}
+
+// checkCommandNames checks the command name and aliases
+// are not in use by any of the root subcommands
+func checkCommandNames(root, cmd *cobra.Command) (err error) {
+	taken := map[string]bool{"help": true}
+
+	for _, existing := range root.Commands() {
+		for _, name := range commandNames(existing) {
+			taken[name] = true
+		}
+	}
+
+	for _, name := range commandNames(cmd) {
+		if taken[name] {
+			err = fmt.Errorf("cannot register command %s: name %s is already in use", cmd.Name(), name)
+			return
+		}
+	}
+
+	return
+}
+
+func commandNames(cmd *cobra.Command) []string {
+	return append([]string{cmd.Name()}, cmd.Aliases...)
+}
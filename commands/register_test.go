@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"kool-dev/kool/core/environment"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestRegisterCommand(t *testing.T) {
+	factory := func() *cobra.Command {
+		return &cobra.Command{Use: "company-deploy", Aliases: []string{"cd"}}
+	}
+
+	defer func() {
+		registeredCommands = nil
+
+		if found, _, err := rootCmd.Find([]string{"company-deploy"}); err == nil && found != rootCmd {
+			rootCmd.RemoveCommand(found)
+		}
+	}()
+
+	if err := RegisterCommand(factory); err != nil {
+		t.Fatalf("unexpected error registering command: %v", err)
+	}
+
+	if found, _, err := rootCmd.Find([]string{"company-deploy"}); err != nil || found.Name() != "company-deploy" {
+		t.Error("registered command was not added to the root command")
+	}
+
+	root := NewRootCmd(environment.NewFakeEnvStorage())
+	AddCommands(root)
+
+	child := NewRootCmd(environment.NewFakeEnvStorage())
+	AddCommands(child)
+
+	found, _, err := root.Find([]string{"cd"})
+
+	if err != nil || found.Name() != "company-deploy" {
+		t.Fatal("registered command was not wired by AddCommands")
+	}
+
+	if found.Root() != root || found.CommandPath() != "kool company-deploy" {
+		t.Error("each root should get its own instance of the registered command")
+	}
+
+	if childFound, _, _ := child.Find([]string{"cd"}); childFound == found {
+		t.Error("roots should not share the registered command instances")
+	}
+
+	for _, collision := range []*cobra.Command{
+		{Use: "start"},
+		{Use: "ps"},
+		{Use: "help"},
+		{Use: "other", Aliases: []string{"company-deploy"}},
+		{Use: "cd"},
+	} {
+		collision := collision
+
+		if err := RegisterCommand(func() *cobra.Command { return collision }); err == nil || !strings.Contains(err.Error(), "is already in use") {
+			t.Errorf("expected collision error registering %s; got %v", collision.Name(), err)
+		}
+	}
+
+	if len(registeredCommands) != 1 {
+		t.Errorf("expected only 1 registered command; got %d", len(registeredCommands))
+	}
+}
+
+func TestAddRegisteredCommandsSkipsCollisions(t *testing.T) {
+	defer func() { registeredCommands = nil }()
+
+	registeredCommands = []CommandFactory{
+		func() *cobra.Command { return &cobra.Command{Use: "start"} },
+		func() *cobra.Command { return &cobra.Command{Use: "company-deploy"} },
+	}
+
+	root := NewRootCmd(environment.NewFakeEnvStorage())
+	root.AddCommand(&cobra.Command{Use: "start", Short: "built-in"})
+
+	addRegisteredCommands(root)
+
+	if len(root.Commands()) != 2 {
+		t.Errorf("expected the colliding command to be left out; got %d commands", len(root.Commands()))
+	}
+}
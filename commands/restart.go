@@ -1,23 +1,325 @@
 package commands
 
 import (
+	"fmt"
+	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/clock"
+	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/network"
+	"kool-dev/kool/services/checker"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/spf13/cobra"
 )
 
+// restartHealthInterval is how often we check a restarted
+// instance health while rolling restart a service
+var restartHealthInterval = 2 * time.Second
+
+// restartHealthTimeout bounds how long we wait for a restarted instance health
+var restartHealthTimeout = 2 * time.Minute
+
+// configHashLabel is the label docker compose sets on the containers
+// with the hash of the service definition they were created from
+const configHashLabel = "com.docker.compose.config-hash"
+
 // KoolRestartFlags holds the flags for the kool restart command
 type KoolRestartFlags struct {
-	Purge   bool
-	Rebuild bool
+	Purge    bool
+	Rebuild  bool
+	Hard     bool
+	Soft     bool
+	Rolling  bool
+	Recreate bool
+	All      bool
+}
+
+// KoolRestart holds handlers and functions to implement the soft restart logic
+type KoolRestart struct {
+	DefaultKoolService
+	Flags *KoolRestartFlags
+
+	check      checker.Checker
+	restart    builder.Command
+	services   builder.Command
+	containers builder.Command
+	restartOne builder.Command
+	health     builder.Command
+	clock      clock.Clock
+
+	configHashes    builder.Command
+	containerHashes builder.Command
+	recreate        builder.Command
+
+	env environment.EnvStorage
+}
+
+// NewKoolRestart creates a new handler for the soft restart logic
+func NewKoolRestart() *KoolRestart {
+	defaultKoolService := newDefaultKoolService()
+	return &KoolRestart{
+		*defaultKoolService,
+		&KoolRestartFlags{false, false, false, false, false, false, false},
+		checker.NewChecker(defaultKoolService.shell),
+		builder.NewComposeCommand("restart"),
+		builder.NewComposeCommand("config", "--services"),
+		builder.NewComposeCommand("ps", "-q"),
+		builder.NewCommand("docker", "restart"),
+		builder.NewCommand("docker", "inspect", "--format", "{{if .State.Health}}{{.State.Health.Status}}{{else}}{{.State.Status}}{{end}}"),
+		clock.NewClock(),
+		builder.NewComposeCommand("config", "--hash", "*"),
+		builder.NewComposeCommand("ps", "--format", "{{.Service}}|{{.Labels}}"),
+		builder.NewComposeCommand("up", "-d", "--no-deps", "--force-recreate"),
+		environment.NewEnvStorage(),
+	}
+}
+
+// Execute runs the soft restart logic with incoming arguments.
+func (r *KoolRestart) Execute(args []string) (err error) {
+	if err = r.check.Check(); err != nil {
+		return
+	}
+
+	if r.Flags.Rolling {
+		err = r.rollingRestart(args)
+		return
+	}
+
+	if r.Flags.Recreate {
+		err = r.recreateChanged(args)
+		return
+	}
+
+	err = r.Shell().Interactive(r.restart, args...)
+	return
+}
+
+// rollingRestart restarts the instances of each service one at a time,
+// waiting for each one to be healthy before moving on to the next;
+// single instance services are just restarted
+func (r *KoolRestart) rollingRestart(services []string) (err error) {
+	var output string
+
+	if len(services) == 0 {
+		if output, err = r.Shell().Exec(r.services); err != nil {
+			return
+		}
+
+		services = strings.Fields(output)
+	}
+
+	for _, service := range services {
+		if output, err = r.Shell().Exec(r.containers, service); err != nil {
+			return
+		}
+
+		instances := strings.Fields(output)
+
+		if len(instances) <= 1 {
+			if err = r.Shell().Interactive(r.restart, service); err != nil {
+				return
+			}
+			continue
+		}
+
+		for i, instance := range instances {
+			r.Shell().Info(fmt.Sprintf("→ Restarting %s instance %d/%d", service, i+1, len(instances)))
+
+			if _, err = r.Shell().Exec(r.restartOne, instance); err != nil {
+				return
+			}
+
+			if err = r.waitHealthy(service, instance); err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// recreateChanged recreates the running services whose definition changed
+// since their containers were created, telling by the config hash compose
+// labels them with, leaving the others alone; with --all every running
+// service is recreated
+func (r *KoolRestart) recreateChanged(services []string) (err error) {
+	var (
+		output    string
+		hashes    = make(map[string]string)
+		running   map[string]string
+		recreated []string
+		unchanged []string
+	)
+
+	if output, err = r.Shell().Exec(r.configHashes); err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			hashes[fields[0]] = fields[1]
+		}
+	}
+
+	if output, err = r.Shell().Exec(r.containerHashes); err != nil {
+		return
+	}
+
+	running = parseContainerHashes(output)
+
+	if len(services) == 0 {
+		for service := range running {
+			services = append(services, service)
+		}
+	}
+
+	sort.Strings(services)
+
+	for _, service := range services {
+		hash, isRunning := running[service]
+
+		if !isRunning {
+			continue
+		}
+
+		if r.Flags.All || hash != hashes[service] {
+			recreated = append(recreated, service)
+		} else {
+			unchanged = append(unchanged, service)
+		}
+	}
+
+	if len(recreated) == 0 {
+		r.Shell().Println("No running service definition changed; nothing to recreate")
+		return
+	}
+
+	if network.Offline(r.env) {
+		// only images already available locally can be used
+		r.recreate.AppendArgs("--pull", "never")
+	}
+
+	if err = r.Shell().Interactive(r.recreate, recreated...); err != nil {
+		return
+	}
+
+	r.Shell().Success(fmt.Sprintf("Recreated: %s", strings.Join(recreated, ", ")))
+
+	if len(unchanged) > 0 {
+		r.Shell().Println(fmt.Sprintf("Unchanged: %s", strings.Join(unchanged, ", ")))
+	}
+
+	return
+}
+
+// parseContainerHashes maps the services of the running containers
+// to the config hash they were created from, out of their labels
+func parseContainerHashes(output string) (hashes map[string]string) {
+	hashes = make(map[string]string)
+
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		service, labels, found := strings.Cut(strings.TrimSpace(line), "|")
+
+		if !found || service == "" {
+			continue
+		}
+
+		hashes[service] = ""
+
+		for _, label := range strings.Split(labels, ",") {
+			if key, value, _ := strings.Cut(label, "="); key == configHashLabel {
+				hashes[service] = value
+			}
+		}
+	}
+
+	return
 }
 
-// NewRestartCommand initializes new kool start command
-func NewRestartCommand(stop KoolService, start KoolService) (restartCmd *cobra.Command) {
-	var flags *KoolRestartFlags = &KoolRestartFlags{false, false}
+// waitHealthy polls the instance state until it is healthy, or just
+// running in case the service does not define a healthcheck
+func (r *KoolRestart) waitHealthy(service, instance string) (err error) {
+	var (
+		output   string
+		deadline = r.clock.Now().Add(restartHealthTimeout)
+	)
+
+	for {
+		if output, err = r.Shell().Exec(r.health, instance); err != nil {
+			return
+		}
+
+		switch strings.TrimSpace(output) {
+		case "healthy", "running":
+			return
+		case "unhealthy", "exited", "dead":
+			err = fmt.Errorf("service %s instance is %s after restart; check its logs with 'kool logs %s'", service, strings.TrimSpace(output), service)
+			return
+		}
+
+		if r.clock.Now().After(deadline) {
+			err = fmt.Errorf("timeout waiting for service %s instance to be healthy after restart", service)
+			return
+		}
+
+		r.clock.Sleep(restartHealthInterval)
+	}
+}
+
+// NewRestartCommand initializes new kool restart command
+func NewRestartCommand(restart KoolService, stop KoolService, start KoolService) (restartCmd *cobra.Command) {
+	var flags *KoolRestartFlags = &KoolRestartFlags{false, false, false, false, false, false, false}
 
 	restartCmd = &cobra.Command{
 		Use:   "restart",
-		Short: "Restart running service containers (the same as 'kool stop' followed by 'kool start')",
+		Short: "Restart running service containers",
+		Long: `Restart running service containers. By default (or explicitly with --hard) the
+containers are removed and recreated, the same as 'kool stop' followed by 'kool start',
+picking up changes to the docker-compose.yml, environment variables and volumes.
+Use --soft to just restart the existing containers instead (docker compose restart),
+which is quicker but keeps running them with the configuration they were created with.
+
+With --rolling, services scaled to multiple instances are restarted one instance at
+a time, waiting for each to be healthy before moving on, so the service is never
+fully down; single instance services are just restarted.
+
+With --recreate, only the running services whose definition changed since their
+containers were created get recreated, leaving the others alone; add --all to
+recreate all the running services regardless.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.All && !flags.Recreate {
+				return fmt.Errorf("--all can only be used along with --recreate")
+			}
+
+			if flags.Rolling && flags.Recreate {
+				return fmt.Errorf("--rolling cannot be used along with --recreate")
+			}
+
+			if flags.Hard && (flags.Soft || flags.Rolling || flags.Recreate) {
+				return fmt.Errorf("--hard cannot be used along with --soft, --rolling or --recreate")
+			}
+
+			if flags.Soft && (flags.Rolling || flags.Recreate) {
+				return fmt.Errorf("--soft cannot be used along with --rolling or --recreate")
+			}
+
+			if mode := restartMode(flags); mode != "" {
+				if flags.Purge || flags.Rebuild {
+					return fmt.Errorf("--%s cannot be used along with --purge or --rebuild", mode)
+				}
+
+				if kr, ok := restart.(*KoolRestart); ok {
+					kr.Flags.Soft = kr.Flags.Soft || flags.Soft
+					kr.Flags.Rolling = kr.Flags.Rolling || flags.Rolling
+					kr.Flags.Recreate = kr.Flags.Recreate || flags.Recreate
+					kr.Flags.All = kr.Flags.All || flags.All
+				}
+
+				return DefaultCommandRunFunction(restart)(cmd, args)
+			}
+
 			if _, ok := stop.(*KoolStop); ok && flags.Purge {
 				stop.(*KoolStop).Flags.Purge = true
 			}
@@ -31,12 +333,32 @@ func NewRestartCommand(stop KoolService, start KoolService) (restartCmd *cobra.C
 		DisableFlagsInUseLine: true,
 	}
 
+	restartCmd.Flags().BoolVarP(&flags.Hard, "hard", "", false, "Remove and recreate the containers, picking up configuration changes (the default)")
+	restartCmd.Flags().BoolVarP(&flags.Soft, "soft", "", false, "Just restart the existing containers, not picking up configuration changes")
 	restartCmd.Flags().BoolVarP(&flags.Purge, "purge", "", false, "Remove all persistent data from volume mounts on containers")
 	restartCmd.Flags().BoolVarP(&flags.Rebuild, "rebuild", "", false, "Updates and builds service's images")
+	restartCmd.Flags().BoolVarP(&flags.Rolling, "rolling", "", false, "Restart instances of scaled services one at a time, waiting for each to be healthy")
+	restartCmd.Flags().BoolVarP(&flags.Recreate, "recreate", "", false, "Recreate only the running services whose definition changed")
+	restartCmd.Flags().BoolVarP(&flags.All, "all", "", false, "Recreate all the running services (with --recreate)")
 
 	return
 }
 
+// restartMode returns the flag of the restart mode handled by KoolRestart
+// (--soft, --rolling or --recreate), or empty for the default stop and start
+func restartMode(flags *KoolRestartFlags) string {
+	switch {
+	case flags.Soft:
+		return "soft"
+	case flags.Rolling:
+		return "rolling"
+	case flags.Recreate:
+		return "recreate"
+	}
+
+	return ""
+}
+
 func AddKoolRestart(root *cobra.Command) {
-	root.AddCommand(NewRestartCommand(NewKoolStop(), NewKoolStart()))
+	root.AddCommand(NewRestartCommand(NewKoolRestart(), NewKoolStop(), NewKoolStart()))
 }
@@ -2,17 +2,55 @@ package commands
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/clock"
+	"kool-dev/kool/core/environment"
 	"kool-dev/kool/core/shell"
+	"kool-dev/kool/services/checker"
+	"strings"
 	"testing"
+	"time"
 )
 
-// NewRestartCommand initializes new kool start command
+func newFakeKoolRestart() *KoolRestart {
+	return &KoolRestart{
+		*(newDefaultKoolService().Fake()),
+		&KoolRestartFlags{false, false, false, false, false, false, false},
+		&checker.FakeChecker{},
+		&builder.FakeCommand{MockCmd: "restart"},
+		&builder.FakeCommand{MockCmd: "services", MockExecOut: "app\nworker"},
+		&builder.FakeCommand{MockCmd: "containers", MockExecOut: "c1\nc2\nc3"},
+		&builder.FakeCommand{MockCmd: "restart-one"},
+		&builder.FakeCommand{MockCmd: "health", MockExecOut: "healthy"},
+		clock.NewFakeClock(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)),
+		&builder.FakeCommand{MockCmd: "config-hashes", MockExecOut: "app aaa\nworker bbb\ncache ccc"},
+		&builder.FakeCommand{MockCmd: "container-hashes", MockExecOut: "app|com.docker.compose.project=x,com.docker.compose.config-hash=aaa\nworker|com.docker.compose.config-hash=old,com.docker.compose.service=worker"},
+		&builder.FakeCommand{MockCmd: "recreate"},
+		environment.NewFakeEnvStorage(),
+	}
+}
+
+func TestNewKoolRestart(t *testing.T) {
+	k := NewKoolRestart()
+
+	if _, ok := k.check.(*checker.DefaultChecker); !ok {
+		t.Errorf("unexpected checker.Checker on default KoolRestart instance")
+	}
+
+	if k.restart.String() != "docker compose restart" {
+		t.Errorf("unexpected restart command on default KoolRestart instance: %s", k.restart.String())
+	}
+}
+
 func TestRestartCommand(t *testing.T) {
+	fakeRestart := newFakeKoolRestart()
 	fakeStop := newFakeKoolService()
 	fakeStart := newFakeKoolService()
 
-	cmd := NewRestartCommand(fakeStop, fakeStart)
+	cmd := NewRestartCommand(fakeRestart, fakeStop, fakeStart)
+	cmd.SetArgs([]string{})
 
 	if err := cmd.Execute(); err != nil {
 		t.Errorf("unexpected error executing restart command; error: %v", err)
@@ -25,6 +63,71 @@ func TestRestartCommand(t *testing.T) {
 	if !fakeStart.CalledExecute {
 		t.Errorf("restart command did not call Execute on start service")
 	}
+
+	if fakeRestart.shell.(*shell.FakeShell).CalledInteractive["restart"] {
+		t.Errorf("default restart should not just restart the containers")
+	}
+}
+
+func TestHardRestartCommand(t *testing.T) {
+	fakeRestart := newFakeKoolRestart()
+	fakeStop := newFakeKoolService()
+	fakeStart := newFakeKoolService()
+
+	cmd := NewRestartCommand(fakeRestart, fakeStop, fakeStart)
+	cmd.SetArgs([]string{"--hard"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing restart command; error: %v", err)
+	}
+
+	if !fakeStop.CalledExecute || !fakeStart.CalledExecute {
+		t.Errorf("hard restart should stop and start the services")
+	}
+
+	if fakeRestart.shell.(*shell.FakeShell).CalledInteractive["restart"] {
+		t.Errorf("hard restart should not just restart the containers")
+	}
+
+	cmd = NewRestartCommand(newFakeKoolRestart(), newFakeKoolService(), newFakeKoolService())
+	cmd.SetArgs([]string{"--hard", "--soft"})
+
+	assertExecGotError(t, cmd, "--hard cannot be used along with --soft, --rolling or --recreate")
+}
+
+func TestSoftRestartCommand(t *testing.T) {
+	fakeRestart := newFakeKoolRestart()
+	fakeStop := newFakeKoolService()
+	fakeStart := newFakeKoolService()
+
+	cmd := NewRestartCommand(fakeRestart, fakeStop, fakeStart)
+	cmd.SetArgs([]string{"--soft", "app"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing restart command; error: %v", err)
+	}
+
+	if args, ok := fakeRestart.shell.(*shell.FakeShell).ArgsInteractive["restart"]; !ok || len(args) != 1 || args[0] != "app" {
+		t.Errorf("did not restart the given services; got %v", args)
+	}
+
+	if fakeStop.CalledExecute || fakeStart.CalledExecute {
+		t.Errorf("soft restart should not call stop/start services")
+	}
+
+	fakeRestart.check.(*checker.FakeChecker).MockError = errors.New("check error")
+
+	assertExecGotError(t, cmd, "check error")
+
+	cmd = NewRestartCommand(newFakeKoolRestart(), newFakeKoolService(), newFakeKoolService())
+	cmd.SetArgs([]string{"--soft", "--purge"})
+
+	assertExecGotError(t, cmd, "--soft cannot be used along with --purge or --rebuild")
+
+	cmd = NewRestartCommand(newFakeKoolRestart(), newFakeKoolService(), newFakeKoolService())
+	cmd.SetArgs([]string{"--soft", "--rolling"})
+
+	assertExecGotError(t, cmd, "--soft cannot be used along with --rolling or --recreate")
 }
 
 func TestFailingStartRestartCommand(t *testing.T) {
@@ -33,7 +136,8 @@ func TestFailingStartRestartCommand(t *testing.T) {
 
 	fakeStart.MockExecuteErr = errors.New("start error")
 
-	cmd := NewRestartCommand(fakeStop, fakeStart)
+	cmd := NewRestartCommand(newFakeKoolRestart(), fakeStop, fakeStart)
+	cmd.SetArgs([]string{})
 
 	assertExecGotError(t, cmd, "start error")
 }
@@ -44,7 +148,8 @@ func TestFailingStopRestartCommand(t *testing.T) {
 
 	fakeStop.MockExecuteErr = errors.New("stop error")
 
-	cmd := NewRestartCommand(fakeStop, fakeStart)
+	cmd := NewRestartCommand(newFakeKoolRestart(), fakeStop, fakeStart)
+	cmd.SetArgs([]string{})
 
 	assertExecGotError(t, cmd, "stop error")
 }
@@ -53,7 +158,7 @@ func TestPurgeRestartCommand(t *testing.T) {
 	fakeStop := newFakeKoolStop()
 	fakeStart := newFakeKoolService()
 
-	cmd := NewRestartCommand(fakeStop, fakeStart)
+	cmd := NewRestartCommand(newFakeKoolRestart(), fakeStop, fakeStart)
 	cmd.SetArgs([]string{"--purge"})
 
 	if err := cmd.Execute(); err != nil {
@@ -69,7 +174,7 @@ func TestRebuildRestartCommand(t *testing.T) {
 	fakeStop := newFakeKoolService()
 	fakeStart := newFakeKoolStart()
 
-	cmd := NewRestartCommand(fakeStop, fakeStart)
+	cmd := NewRestartCommand(newFakeKoolRestart(), fakeStop, fakeStart)
 	cmd.SetArgs([]string{"--rebuild"})
 
 	fakeStart.rebuilder.(*KoolRebuild).shell.(*shell.FakeShell).MockOutStream = io.Discard
@@ -83,3 +188,162 @@ func TestRebuildRestartCommand(t *testing.T) {
 		t.Error("did not set the rebuild flag to true in the start service")
 	}
 }
+
+func TestRollingRestartCommand(t *testing.T) {
+	fakeRestart := newFakeKoolRestart()
+
+	cmd := NewRestartCommand(fakeRestart, newFakeKoolService(), newFakeKoolService())
+	cmd.SetArgs([]string{"--rolling", "worker"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing restart command; error: %v", err)
+	}
+
+	fakeShell := fakeRestart.shell.(*shell.FakeShell)
+
+	if !fakeShell.CalledExec["restart-one"] || !fakeShell.CalledExec["health"] {
+		t.Error("did not restart the instances one at a time waiting for their health")
+	}
+
+	if fakeShell.CalledExec["services"] {
+		t.Error("should not list all services when given services to restart")
+	}
+
+	if fakeShell.CalledInteractive["restart"] {
+		t.Error("should not restart all instances of a scaled service at once")
+	}
+
+	if len(fakeShell.InfoOutput) != 1 || fakeShell.InfoOutput[0] != "→ Restarting worker instance 3/3" {
+		t.Errorf("did not restart all the instances; last output: %v", fakeShell.InfoOutput)
+	}
+}
+
+func TestRollingRestartSingleInstance(t *testing.T) {
+	fakeRestart := newFakeKoolRestart()
+	fakeRestart.Flags.Rolling = true
+	fakeRestart.containers.(*builder.FakeCommand).MockExecOut = "c1"
+
+	if err := fakeRestart.Execute(nil); err != nil {
+		t.Errorf("unexpected error on rolling restart; error: %v", err)
+	}
+
+	fakeShell := fakeRestart.shell.(*shell.FakeShell)
+
+	if !fakeShell.CalledExec["services"] {
+		t.Error("did not list all services for restarting")
+	}
+
+	if !fakeShell.CalledInteractive["restart"] || fakeShell.CalledExec["restart-one"] {
+		t.Error("single instance services should just be restarted")
+	}
+}
+
+func TestRollingRestartUnhealthy(t *testing.T) {
+	fakeRestart := newFakeKoolRestart()
+	fakeRestart.health.(*builder.FakeCommand).MockExecOut = "unhealthy"
+
+	cmd := NewRestartCommand(fakeRestart, newFakeKoolService(), newFakeKoolService())
+	cmd.SetArgs([]string{"--rolling", "worker"})
+
+	assertExecGotError(t, cmd, "service worker instance is unhealthy after restart")
+
+	if output := fakeRestart.shell.(*shell.FakeShell).InfoOutput; len(output) != 1 || output[0] != "→ Restarting worker instance 1/3" {
+		t.Errorf("should stop rolling restart on the first unhealthy instance; last output: %v", output)
+	}
+
+	fakeRestart = newFakeKoolRestart()
+	fakeRestart.health.(*builder.FakeCommand).MockExecOut = "starting"
+	fakeRestart.Flags.Rolling = true
+
+	if err := fakeRestart.Execute([]string{"worker"}); err == nil || err.Error() != "timeout waiting for service worker instance to be healthy after restart" {
+		t.Errorf("expected timeout error; got %v", err)
+	}
+}
+
+func TestRollingRebuildRestartCommand(t *testing.T) {
+	cmd := NewRestartCommand(newFakeKoolRestart(), newFakeKoolService(), newFakeKoolService())
+	cmd.SetArgs([]string{"--rolling", "--rebuild"})
+
+	assertExecGotError(t, cmd, "--rolling cannot be used along with --purge or --rebuild")
+}
+
+func TestRecreateRestartCommand(t *testing.T) {
+	fakeRestart := newFakeKoolRestart()
+
+	cmd := NewRestartCommand(fakeRestart, newFakeKoolService(), newFakeKoolService())
+	cmd.SetArgs([]string{"--recreate"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing restart command; error: %v", err)
+	}
+
+	fakeShell := fakeRestart.shell.(*shell.FakeShell)
+
+	if args := fakeShell.ArgsInteractive["recreate"]; len(args) != 1 || args[0] != "worker" {
+		t.Errorf("expected to recreate only the changed worker service; got %v", args)
+	}
+
+	if fakeShell.CalledInteractive["restart"] {
+		t.Error("should not restart the unchanged services")
+	}
+
+	if output := fmt.Sprint(fakeShell.SuccessOutput...); output != "Recreated: worker" {
+		t.Errorf("unexpected recreated report: %s", output)
+	}
+
+	if len(fakeShell.OutLines) != 1 || fakeShell.OutLines[0] != "Unchanged: app" {
+		t.Errorf("unexpected unchanged report: %v", fakeShell.OutLines)
+	}
+}
+
+func TestRecreateRestartCommandOffline(t *testing.T) {
+	fakeRestart := newFakeKoolRestart()
+	fakeRestart.env.Set("KOOL_OFFLINE", "true")
+
+	cmd := NewRestartCommand(fakeRestart, newFakeKoolService(), newFakeKoolService())
+	cmd.SetArgs([]string{"--recreate"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error executing restart command; error: %v", err)
+	}
+
+	if args := strings.Join(fakeRestart.recreate.(*builder.FakeCommand).ArgsAppend, " "); args != "--pull never" {
+		t.Errorf("expected --pull never when offline; got '%s'", args)
+	}
+}
+
+func TestRecreateAllRestartCommand(t *testing.T) {
+	fakeRestart := newFakeKoolRestart()
+
+	cmd := NewRestartCommand(fakeRestart, newFakeKoolService(), newFakeKoolService())
+	cmd.SetArgs([]string{"--recreate", "--all"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing restart command; error: %v", err)
+	}
+
+	if args := fakeRestart.shell.(*shell.FakeShell).ArgsInteractive["recreate"]; len(args) != 2 || args[0] != "app" || args[1] != "worker" {
+		t.Errorf("expected to recreate all the running services; got %v", args)
+	}
+
+	fakeRestart = newFakeKoolRestart()
+	fakeRestart.Flags.Recreate = true
+
+	if err := fakeRestart.Execute([]string{"app", "cache"}); err != nil {
+		t.Errorf("unexpected error on recreate restart; error: %v", err)
+	}
+
+	if fakeRestart.shell.(*shell.FakeShell).CalledInteractive["recreate"] {
+		t.Error("should not recreate unchanged or not running services")
+	}
+
+	cmd = NewRestartCommand(newFakeKoolRestart(), newFakeKoolService(), newFakeKoolService())
+	cmd.SetArgs([]string{"--all"})
+
+	assertExecGotError(t, cmd, "--all can only be used along with --recreate")
+
+	cmd = NewRestartCommand(newFakeKoolRestart(), newFakeKoolService(), newFakeKoolService())
+	cmd.SetArgs([]string{"--recreate", "--purge"})
+
+	assertExecGotError(t, cmd, "--recreate cannot be used along with --purge or --rebuild")
+}
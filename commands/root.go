@@ -5,11 +5,15 @@ import (
 	"io"
 	"kool-dev/kool/core/environment"
 	"kool-dev/kool/core/parser"
+	"kool-dev/kool/core/presets"
 	"kool-dev/kool/core/shell"
+	"kool-dev/kool/services/compose"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -22,11 +26,17 @@ type AddCommandsFN func(*cobra.Command)
 
 var hasWarnedDevelopmentVersion = false
 
+// defaultMaxDepth is how deep kool invocations can be nested (like
+// scripts calling kool) before aborting, unless KOOL_MAX_DEPTH says otherwise
+const defaultMaxDepth = 10
+
 var AddCommands AddCommandsFN = func(root *cobra.Command) {
+	AddKoolBootstrap(root)
 	AddKoolCompletion(root)
 	AddKoolCreate(root)
 	AddKoolCloud(root)
 	AddKoolDocker(root)
+	AddKoolEnv(root)
 	AddKoolExec(root)
 	AddKoolInfo(root)
 	AddKoolLogs(root)
@@ -34,11 +44,17 @@ var AddCommands AddCommandsFN = func(root *cobra.Command) {
 	AddKoolRestart(root)
 	AddKoolRun(root)
 	AddKoolSelfUpdate(root)
+	AddKoolServices(root)
+	AddKoolShell(root)
 	AddKoolShare(root)
 	AddKoolStart(root)
 	AddKoolStatus(root)
 	AddKoolStop(root)
+	AddKoolValidate(root)
+	AddKoolVersion(root)
 	AddKoolRecipe(root)
+
+	addRegisteredCommands(root)
 }
 
 // DEV_VERSION holds the static version shown for development time builds
@@ -51,6 +67,7 @@ var rootCmd = NewRootCmd(environment.NewEnvStorage())
 var originalWorkingDir = ""
 
 func init() {
+	presets.SetKoolVersion(version)
 	AddCommands(rootCmd)
 }
 
@@ -65,6 +82,9 @@ func NewRootCmd(env environment.EnvStorage) (cmd *cobra.Command) {
 that makes Docker container adoption quick and easy for building and deploying cloud native
 applications.
 
+Commands that are not built into kool are looked up on your PATH as 'kool-COMMAND'
+executables, so 'kool foo' runs 'kool-foo' forwarding all the remaining arguments.
+
 Complete documentation is available at https://kool.dev/docs`,
 		Version:               version,
 		DisableAutoGenTag:     true,
@@ -76,45 +96,100 @@ Complete documentation is available at https://kool.dev/docs`,
 				env.Set("KOOL_VERBOSE", verbose.Value.String())
 			}
 
-			if !hasWarnedDevelopmentVersion && version == DEV_VERSION && shell.NewTerminalChecker().IsTerminal(cmd.OutOrStdout()) {
+			if metrics := cmd.Flags().Lookup("metrics"); metrics != nil && metrics.Value.String() == "true" {
+				shell.EnableCommandMetrics(true)
+			}
+
+			if offline := cmd.Flags().Lookup("offline"); offline != nil && offline.Value.String() == "true" {
+				env.Set("KOOL_OFFLINE", offline.Value.String())
+			}
+
+			if apiTimeout := cmd.Flags().Lookup("api-timeout"); apiTimeout != nil && apiTimeout.Changed {
+				env.Set("KOOL_API_REQUEST_TIMEOUT", apiTimeout.Value.String())
+			}
+
+			if logLevel := cmd.Flags().Lookup("log-level"); logLevel != nil && logLevel.Value.String() != "" {
+				if err = shell.ValidateLogLevel(logLevel.Value.String()); err != nil {
+					return
+				}
+
+				env.Set("KOOL_LOG_LEVEL", logLevel.Value.String())
+			}
+
+			if eventsSocket := cmd.Flags().Lookup("events-socket"); eventsSocket != nil && eventsSocket.Value.String() != "" {
+				env.Set("KOOL_EVENTS_SOCKET", eventsSocket.Value.String())
+			}
+
+			if eventsSocket := env.Get("KOOL_EVENTS_SOCKET"); eventsSocket != "" {
+				if err = shell.OpenEventsSocket(eventsSocket); err != nil {
+					err = fmt.Errorf("could not connect to the events socket %s: %v", eventsSocket, err)
+					return
+				}
+
+				shell.EmitEvent(shell.Event{Type: shell.EventCommandStarted, Command: cmd.CommandPath()})
+			}
+
+			// builds from source may silence the warning with KOOL_NO_DEV_WARNING
+			if !hasWarnedDevelopmentVersion && version == DEV_VERSION && !env.IsTrue("KOOL_NO_DEV_WARNING") && shell.NewTerminalChecker().IsTerminal(cmd.OutOrStdout()) {
 				shell.NewShell().Warning("Warning: you are executing a development version of kool.")
 				hasWarnedDevelopmentVersion = true
 			}
 
-			workDirFlag := cmd.Flags().Lookup("working_dir")
-			if workDirFlag != nil && workDirFlag.Value.String() != "" {
-				workDir := workDirFlag.Value.String()
+			if host := cmd.Flags().Lookup("host"); host != nil && host.Value.String() != "" {
+				if err = validateDockerHost(host.Value.String()); err != nil {
+					return
+				}
 
-				if originalWorkingDir != "" {
-					// having an original working dir set means we have
-					// already changed the working dir before and we are in
-					//  a recursive kool call. We need to restore the original
-					// working dir before changing it again.
-					if err = os.Chdir(originalWorkingDir); err != nil {
-						return
-					}
+				env.Set("DOCKER_HOST", host.Value.String())
+			}
+
+			if composeFiles, _ := cmd.Flags().GetStringArray("compose-file"); len(composeFiles) > 0 {
+				var composeFile string
+
+				// resolved before changing the working dir, as
+				// they are relative to where kool was called from
+				if composeFile, err = resolveComposeFiles(composeFiles); err != nil {
+					return
 				}
 
-				if !path.IsAbs(workDir) {
-					if workDir, err = filepath.Abs(workDir); err != nil {
-						return
-					}
+				env.Set("COMPOSE_FILE", composeFile)
+			}
+
+			var workDir string
+
+			if workDirFlag := cmd.Flags().Lookup("working_dir"); workDirFlag != nil {
+				workDir = workDirFlag.Value.String()
+			}
+
+			if projectDirFlag := cmd.Flags().Lookup("project-dir"); projectDirFlag != nil && projectDirFlag.Value.String() != "" {
+				if workDir != "" {
+					err = fmt.Errorf("--project-dir cannot be used along with --working_dir")
+					return
+				}
+
+				if workDir, err = resolveProjectDir(projectDirFlag.Value.String()); err != nil {
+					return
 				}
+			}
 
-				if err = os.Chdir(workDir); err != nil {
+			if workDir != "" {
+				if err = changeWorkingDir(workDir); err != nil {
 					return
 				}
+			}
 
-				if originalWorkingDir == "" {
-					// we only set the original working dir if it is not set
-					// yet. This is to avoid overriding the original working
-					// dir in recursive calls.
-					if originalWorkingDir, err = os.Getwd(); err != nil {
-						return
+			if environmentFlag := cmd.Flags().Lookup("environment"); environmentFlag != nil && environmentFlag.Value.String() != "" {
+				env.Set("KOOL_ENV", environmentFlag.Value.String())
+			}
+
+			if env.Get("COMPOSE_PROJECT_NAME") == "" {
+				// the project name can also be set on kool.yml
+				projectParser := parser.NewParser()
+				if projectParser.AddLookupPath(env.Get("PWD")) == nil {
+					if project, _ := projectParser.ParseProjectName(); project != "" {
+						env.Set("COMPOSE_PROJECT_NAME", project)
 					}
 				}
-
-				environment.NewEnvStorage().Set("PWD", workDir)
 			}
 
 			return
@@ -125,6 +200,11 @@ Complete documentation is available at https://kool.dev/docs`,
 				return
 			}
 
+			var isPlugin bool
+			if isPlugin, err = runPlugin(cmd, args); isPlugin {
+				return
+			}
+
 			scriptParser := parser.NewParser()
 			env := environment.NewEnvStorage()
 
@@ -148,20 +228,219 @@ Complete documentation is available at https://kool.dev/docs`,
 		},
 	}
 
-	cmd.PersistentFlags().Bool("verbose", false, "Increases output verbosity")
+	cmd.PersistentFlags().Bool("verbose", false, "Increases output verbosity (also enables debug output of docker compose)")
+	cmd.PersistentFlags().Bool("metrics", false, "Prints out how long each executed command took")
 	cmd.PersistentFlags().StringP("working_dir", "w", "", "Changes the working directory for the command")
+	cmd.PersistentFlags().String("log-level", "", "Only prints out messages of the given level or above (info, warn or error)")
+	cmd.PersistentFlags().String("events-socket", "", "Emits lifecycle events as JSON lines to the given Unix socket")
+	cmd.PersistentFlags().Bool("offline", false, "Disables network operations like update checks and image pulls (same as KOOL_OFFLINE=1)")
+	cmd.PersistentFlags().String("host", "", "Docker host to run against, like ssh://user@host for a remote one (same as DOCKER_HOST)")
+	cmd.PersistentFlags().StringArray("compose-file", []string{}, "Uses the given compose file instead of docker-compose.yml; can be repeated, later files overriding earlier ones")
+	cmd.PersistentFlags().String("environment", "", "Uses the given kool.yml environment instead of asking for one on run and bootstrap (same as KOOL_ENV)")
+	cmd.PersistentFlags().String("project-dir", "", "Runs the command within the given project directory, which must have a kool.yml file")
+
+	// arguments after an unknown command belong to external plugins
+	cmd.Flags().SetInterspersed(false)
+	return
+}
+
+// changeWorkingDir changes the working directory kool operates in
+func changeWorkingDir(workDir string) (err error) {
+	if originalWorkingDir != "" {
+		// having an original working dir set means we have
+		// already changed the working dir before and we are in
+		//  a recursive kool call. We need to restore the original
+		// working dir before changing it again.
+		if err = os.Chdir(originalWorkingDir); err != nil {
+			return
+		}
+	}
+
+	if !path.IsAbs(workDir) {
+		if workDir, err = filepath.Abs(workDir); err != nil {
+			return
+		}
+	}
+
+	if err = os.Chdir(workDir); err != nil {
+		return
+	}
+
+	if originalWorkingDir == "" {
+		// we only set the original working dir if it is not set
+		// yet. This is to avoid overriding the original working
+		// dir in recursive calls.
+		if originalWorkingDir, err = os.Getwd(); err != nil {
+			return
+		}
+	}
+
+	environment.NewEnvStorage().Set("PWD", workDir)
+	return
+}
+
+// dockerHostSchemes are the docker host address schemes the docker CLI supports
+var dockerHostSchemes = []string{"ssh://", "tcp://", "unix://", "npipe://"}
+
+// validateDockerHost makes sure the docker host address has a scheme
+// the docker CLI understands, so a typo does not fail obscurely later on
+func validateDockerHost(host string) (err error) {
+	for _, scheme := range dockerHostSchemes {
+		if strings.HasPrefix(host, scheme) && len(host) > len(scheme) {
+			return
+		}
+	}
+
+	err = fmt.Errorf("invalid docker host %s; it must start with one of %s", host, strings.Join(dockerHostSchemes, ", "))
+	return
+}
+
+// resolveComposeFiles turns the given compose files into absolute paths
+// (relative to where kool was originally called from), making sure they
+// exist, joined the way docker compose expects them on COMPOSE_FILE
+func resolveComposeFiles(files []string) (composeFile string, err error) {
+	var (
+		base     = originalWorkingDir
+		resolved = make([]string, len(files))
+	)
+
+	if base == "" {
+		if base, err = os.Getwd(); err != nil {
+			return
+		}
+	}
+
+	for i, file := range files {
+		if resolved[i] = file; !filepath.IsAbs(file) {
+			resolved[i] = filepath.Join(base, file)
+		}
+
+		if info, statErr := os.Stat(resolved[i]); statErr != nil || info.IsDir() {
+			err = fmt.Errorf("compose file %s does not exist", file)
+			return
+		}
+	}
+
+	composeFile = strings.Join(resolved, compose.FileSeparator())
+	return
+}
+
+// resolveProjectDir turns the given project directory into an absolute
+// path (relative to where kool was originally called from), making sure
+// it exists and has a kool.yml file
+func resolveProjectDir(projectDir string) (dir string, err error) {
+	var base = originalWorkingDir
+
+	if base == "" {
+		if base, err = os.Getwd(); err != nil {
+			return
+		}
+	}
+
+	if dir = projectDir; !filepath.IsAbs(dir) {
+		dir = filepath.Join(base, dir)
+	}
+
+	if info, statErr := os.Stat(dir); statErr != nil || !info.IsDir() {
+		err = fmt.Errorf("project directory %s does not exist", projectDir)
+		return
+	}
+
+	if parser.NewParser().AddLookupPath(dir) != nil {
+		err = fmt.Errorf("project directory %s does not have a kool.yml file", projectDir)
+	}
+
 	return
 }
 
 // Execute proxies the call to cobra root command
-func Execute() error {
-	setRecursiveCall(rootCmd)
-	return rootCmd.Execute()
+func Execute() (err error) {
+	return execute(rootCmd)
+}
+
+// Run builds a new root command bound to the given streams, executes
+// it with the given arguments and returns the process exit code
+func Run(args []string, in io.Reader, out, errOut io.Writer) (code int) {
+	var (
+		root = NewRootCmd(environment.NewEnvStorage())
+		err  error
+	)
+
+	AddCommands(root)
+
+	root.SetArgs(args)
+	root.SetIn(in)
+	root.SetOut(out)
+	root.SetErr(errOut)
+
+	if err = execute(root); err != nil {
+		code = 1
+		if ex, ok := err.(shell.ErrExitable); ok {
+			code = ex.Code
+		}
+		if code != shell.UserCancelledExitCode {
+			// cancellations have already been reported
+			sh := shell.NewShell()
+			sh.SetOutStream(out)
+			sh.Println(err)
+		}
+	}
+
+	return
+}
+
+func execute(root *cobra.Command) (err error) {
+	var start = time.Now()
+
+	var (
+		cmd   *cobra.Command
+		leave func()
+	)
+
+	if leave, err = enterInvocation(environment.NewEnvStorage()); err != nil {
+		return
+	}
+
+	defer leave()
+
+	setRecursiveCall(root)
+	cmd, err = root.ExecuteC()
+	emitCommandFinished(cmd, err)
+	shell.CloseEventsSocket()
+
+	if shell.CommandMetricsEnabled() {
+		printCommandMetrics(root.ErrOrStderr(), time.Since(start))
+	}
+	return
+}
+
+// printCommandMetrics renders the time taken by each executed
+// command followed by the total wall time
+func printCommandMetrics(w io.Writer, total time.Duration) {
+	table := shell.NewTableWriter()
+	table.SetWriter(w)
+	table.AppendHeader("Command", "Duration")
+
+	for _, metric := range shell.CommandMetrics() {
+		table.AppendRow(metric.Command, metric.Duration.Round(time.Millisecond).String())
+	}
+
+	table.AppendRow("Total (wall time)", total.Round(time.Millisecond).String())
+	table.Render()
 }
 
 func setRecursiveCall(root *cobra.Command) {
 	shell.RecursiveCall = func(args []string, in io.Reader, out, err io.Writer) error {
-		childRoot := NewRootCmd(environment.NewEnvStorage())
+		env := environment.NewEnvStorage()
+
+		leave, depthErr := enterInvocation(env)
+		if depthErr != nil {
+			return depthErr
+		}
+
+		defer leave()
+
+		childRoot := NewRootCmd(env)
 
 		childRoot.SetArgs(args)
 
@@ -171,8 +450,56 @@ func setRecursiveCall(root *cobra.Command) {
 
 		AddCommands(childRoot)
 
-		return childRoot.Execute()
+		cmd, execErr := childRoot.ExecuteC()
+		emitCommandFinished(cmd, execErr)
+		return execErr
+	}
+}
+
+// enterInvocation keeps track of how deep the current kool invocation is
+// nested on KOOL_DEPTH, so nested ones (either recursive calls or actual
+// kool processes started by scripts) can tell; it fails once KOOL_MAX_DEPTH
+// is reached, to stop a script calling kool in a loop before it exhausts the
+// machine. The returned function restores the previous depth.
+func enterInvocation(env environment.EnvStorage) (leave func(), err error) {
+	var (
+		previous    = env.Get("KOOL_DEPTH")
+		depth, _    = env.GetInt("KOOL_DEPTH")
+		maxDepth, _ = env.GetInt("KOOL_MAX_DEPTH")
+	)
+
+	if maxDepth <= 0 {
+		maxDepth = defaultMaxDepth
+	}
+
+	if depth >= maxDepth {
+		err = fmt.Errorf("kool calls nested %d levels deep; is a script calling kool in a loop? (set KOOL_MAX_DEPTH to raise the limit)", depth)
+		return
+	}
+
+	env.Set("KOOL_DEPTH", strconv.Itoa(depth+1))
+
+	leave = func() {
+		env.Set("KOOL_DEPTH", previous)
 	}
+	return
+}
+
+// emitCommandFinished emits the events for the end of the given
+// command, along with its error if it failed
+func emitCommandFinished(cmd *cobra.Command, err error) {
+	var event = shell.Event{Type: shell.EventCommandFinished}
+
+	if cmd != nil {
+		event.Command = cmd.CommandPath()
+	}
+
+	if err != nil {
+		shell.EmitEvent(shell.Event{Type: shell.EventError, Command: event.Command, Error: err.Error()})
+		event.Error = err.Error()
+	}
+
+	shell.EmitEvent(event)
 }
 
 // RootCmd exposes the root command
@@ -191,7 +518,7 @@ func DefaultCommandRunFunction(services ...KoolService) CobraRunE {
 			if err = service.Execute(args); err != nil {
 				if shell.IsUserCancelledError(err) {
 					service.Shell().Warning("Operation Cancelled")
-					err = nil
+					err = shell.ErrExitable{Err: err, Code: shell.UserCancelledExitCode}
 				}
 				return
 			}
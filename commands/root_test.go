@@ -4,14 +4,20 @@
 package commands
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"kool-dev/kool/core/environment"
 	"kool-dev/kool/core/shell"
+	"kool-dev/kool/services/compose"
+	"net"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/creack/pty"
 	"github.com/spf13/cobra"
@@ -77,6 +83,116 @@ func TestRootCmdExecute(t *testing.T) {
 	}
 }
 
+func TestRun(t *testing.T) {
+	var out, errOut bytes.Buffer
+
+	if code := Run([]string{"--version"}, strings.NewReader(""), &out, &errOut); code != 0 {
+		t.Errorf("expected exit code 0; got %d", code)
+	}
+
+	if !strings.Contains(out.String(), fmt.Sprintf("kool version %s", version)) {
+		t.Errorf("expected version on the given output stream; got %q", out.String())
+	}
+
+	out.Reset()
+
+	if code := Run([]string{"not-a-command"}, strings.NewReader(""), &out, &errOut); code != 1 {
+		t.Errorf("expected exit code 1; got %d", code)
+	}
+
+	if !strings.Contains(out.String(), "command not-a-command not found") {
+		t.Errorf("expected error on the given output stream; got %q", out.String())
+	}
+}
+
+func TestRunEventsSocket(t *testing.T) {
+	var (
+		out, errOut bytes.Buffer
+		socket      = filepath.Join(t.TempDir(), "events.sock")
+		events      = make(chan []shell.Event)
+	)
+
+	t.Setenv("KOOL_EVENTS_SOCKET", "")
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets not available: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		var received []shell.Event
+
+		if conn, err := listener.Accept(); err == nil {
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				var event shell.Event
+				_ = json.Unmarshal(scanner.Bytes(), &event)
+				received = append(received, event)
+			}
+			conn.Close()
+		}
+
+		events <- received
+	}()
+
+	if code := Run([]string{"--events-socket", socket, "not-a-command"}, strings.NewReader(""), &out, &errOut); code != 1 {
+		t.Errorf("expected exit code 1; got %d", code)
+	}
+
+	received := <-events
+
+	if len(received) != 3 {
+		t.Fatalf("expected 3 events; got %+v", received)
+	}
+
+	if received[0].Type != shell.EventCommandStarted || received[0].Command != "kool" {
+		t.Errorf("unexpected first event: %+v", received[0])
+	}
+
+	if received[1].Type != shell.EventError || received[1].Error != "command not-a-command not found" {
+		t.Errorf("unexpected second event: %+v", received[1])
+	}
+
+	if received[2].Type != shell.EventCommandFinished || received[2].Error == "" {
+		t.Errorf("unexpected third event: %+v", received[2])
+	}
+
+	if code := Run([]string{"--events-socket", filepath.Join(t.TempDir(), "missing.sock"), "not-a-command"}, strings.NewReader(""), &out, &errOut); code != 1 || !strings.Contains(out.String(), "could not connect to the events socket") {
+		t.Errorf("expected error connecting to a missing socket; got %d (%s)", code, out.String())
+	}
+}
+
+func TestRunPlugin(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "kool-testing-plugin"), []byte("#!/bin/sh\necho \"plugin: $@\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	root := NewRootCmd(environment.NewFakeEnvStorage())
+	root.SetArgs([]string{"testing-plugin", "arg", "--flag"})
+
+	out := bytes.NewBufferString("")
+	root.SetOut(out)
+
+	if err := root.Execute(); err != nil {
+		t.Errorf("unexpected error running plugin: %v", err)
+	}
+
+	if strings.TrimSpace(out.String()) != "plugin: arg --flag" {
+		t.Errorf("unexpected plugin output: %q", out.String())
+	}
+
+	root.SetArgs([]string{"missing-testing-plugin"})
+
+	if err := root.Execute(); err == nil || err.Error() != "command missing-testing-plugin not found" {
+		t.Errorf("expected command not found error; got %v", err)
+	}
+}
+
 func TestVersionFlagCommand(t *testing.T) {
 	cmd := RootCmd()
 
@@ -194,6 +310,312 @@ func TestVerboseFlagRootCommand(t *testing.T) {
 	}
 }
 
+func TestMetricsFlagRootCommand(t *testing.T) {
+	fakeEnv := environment.NewFakeEnvStorage()
+
+	root := NewRootCmd(fakeEnv)
+	root.AddCommand(NewInfoCmd(fakeKoolInfo()))
+
+	root.SetArgs([]string{"--metrics", "info"})
+
+	defer shell.EnableCommandMetrics(false)
+
+	if err := root.Execute(); err != nil {
+		t.Errorf("unexpected error executing command; error: %v", err)
+	}
+
+	if !shell.CommandMetricsEnabled() {
+		t.Error("expecting command metrics to be enabled")
+	}
+
+	if fakeEnv.Get("KOOL_METRICS") != "" {
+		t.Error("should not set KOOL_METRICS on the environment, which spawned kool processes inherit")
+	}
+}
+
+func TestOfflineFlagRootCommand(t *testing.T) {
+	fakeEnv := environment.NewFakeEnvStorage()
+
+	root := NewRootCmd(fakeEnv)
+	root.AddCommand(NewInfoCmd(fakeKoolInfo()))
+
+	root.SetArgs([]string{"--offline", "info"})
+
+	if err := root.Execute(); err != nil {
+		t.Errorf("unexpected error executing command; error: %v", err)
+	}
+
+	if !fakeEnv.IsTrue("KOOL_OFFLINE") {
+		t.Error("expecting 'KOOL_OFFLINE' to be true, got false")
+	}
+}
+
+func TestLogLevelFlagRootCommand(t *testing.T) {
+	fakeEnv := environment.NewFakeEnvStorage()
+
+	root := NewRootCmd(fakeEnv)
+	root.AddCommand(NewInfoCmd(fakeKoolInfo()))
+
+	root.SetArgs([]string{"--log-level", "warn", "info"})
+
+	if err := root.Execute(); err != nil {
+		t.Errorf("unexpected error executing command; error: %v", err)
+	}
+
+	if level := fakeEnv.Get("KOOL_LOG_LEVEL"); level != "warn" {
+		t.Errorf("expecting 'KOOL_LOG_LEVEL' to be 'warn', got '%s'", level)
+	}
+
+	root = NewRootCmd(environment.NewFakeEnvStorage())
+	root.AddCommand(NewInfoCmd(fakeKoolInfo()))
+
+	root.SetArgs([]string{"--log-level", "debug", "info"})
+
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "invalid log level 'debug'") {
+		t.Errorf("expecting invalid log level error, got %v", err)
+	}
+}
+
+func TestApiTimeoutFlagCloudCommand(t *testing.T) {
+	fakeEnv := environment.NewFakeEnvStorage()
+
+	root := NewRootCmd(fakeEnv)
+	cloud := NewCloudCommand()
+	cloud.AddCommand(&cobra.Command{
+		Use:  "noop",
+		RunE: func(cmd *cobra.Command, args []string) error { return nil },
+	})
+	root.AddCommand(cloud)
+
+	root.SetArgs([]string{"cloud", "--api-timeout", "30s", "noop"})
+
+	if err := root.Execute(); err != nil {
+		t.Errorf("unexpected error executing command; error: %v", err)
+	}
+
+	if timeout := fakeEnv.Get("KOOL_API_REQUEST_TIMEOUT"); timeout != "30s" {
+		t.Errorf("expecting 'KOOL_API_REQUEST_TIMEOUT' to be 30s, got '%s'", timeout)
+	}
+}
+
+func TestComposeProjectNameFromKoolYml(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "kool.yml"), []byte("project: my-project\n"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	noop := func() *cobra.Command {
+		return &cobra.Command{
+			Use:  "noop",
+			RunE: func(cmd *cobra.Command, args []string) error { return nil },
+		}
+	}
+
+	fakeEnv := environment.NewFakeEnvStorage()
+	fakeEnv.Set("PWD", dir)
+
+	root := NewRootCmd(fakeEnv)
+	root.AddCommand(noop())
+	root.SetArgs([]string{"noop"})
+
+	if err := root.Execute(); err != nil {
+		t.Errorf("unexpected error executing command; error: %v", err)
+	}
+
+	if project := fakeEnv.Get("COMPOSE_PROJECT_NAME"); project != "my-project" {
+		t.Errorf("expecting 'COMPOSE_PROJECT_NAME' to be my-project, got '%s'", project)
+	}
+
+	fakeEnv = environment.NewFakeEnvStorage()
+	fakeEnv.Set("PWD", dir)
+	fakeEnv.Set("COMPOSE_PROJECT_NAME", "from-env")
+
+	root = NewRootCmd(fakeEnv)
+	root.AddCommand(noop())
+	root.SetArgs([]string{"noop"})
+
+	if err := root.Execute(); err != nil {
+		t.Errorf("unexpected error executing command; error: %v", err)
+	}
+
+	if project := fakeEnv.Get("COMPOSE_PROJECT_NAME"); project != "from-env" {
+		t.Errorf("expecting 'COMPOSE_PROJECT_NAME' to be kept as from-env, got '%s'", project)
+	}
+}
+
+func TestProjectDirFlagRootCommand(t *testing.T) {
+	var (
+		dir        = t.TempDir()
+		emptyDir   = t.TempDir()
+		wd, _      = os.Getwd()
+		originalWd = originalWorkingDir
+		pwd        = os.Getenv("PWD")
+	)
+
+	defer func() {
+		_ = os.Chdir(wd)
+		originalWorkingDir = originalWd
+		os.Setenv("PWD", pwd)
+	}()
+
+	if err := os.WriteFile(filepath.Join(dir, "kool.yml"), []byte("scripts: {}\n"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	newRoot := func(args ...string) *cobra.Command {
+		root := NewRootCmd(environment.NewFakeEnvStorage())
+		root.AddCommand(&cobra.Command{
+			Use:  "noop",
+			RunE: func(cmd *cobra.Command, args []string) error { return nil },
+		})
+		root.SetArgs(args)
+		return root
+	}
+
+	if err := newRoot("--project-dir", dir, "noop").Execute(); err != nil {
+		t.Fatalf("unexpected error executing command; error: %v", err)
+	}
+
+	if current, _ := os.Getwd(); current != dir {
+		expected, _ := filepath.EvalSymlinks(dir)
+		if actual, _ := filepath.EvalSymlinks(current); actual != expected {
+			t.Errorf("expected working directory %s; got %s", dir, current)
+		}
+	}
+
+	if err := newRoot("--project-dir", filepath.Join(dir, "missing"), "noop").Execute(); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected error for missing project directory; got %v", err)
+	}
+
+	if err := newRoot("--project-dir", emptyDir, "noop").Execute(); err == nil || !strings.Contains(err.Error(), "does not have a kool.yml file") {
+		t.Errorf("expected error for project directory without kool.yml; got %v", err)
+	}
+
+	if err := newRoot("--project-dir", dir, "-w", dir, "noop").Execute(); err == nil || err.Error() != "--project-dir cannot be used along with --working_dir" {
+		t.Errorf("expected error for using both --project-dir and --working_dir; got %v", err)
+	}
+}
+
+func TestComposeFileFlagRootCommand(t *testing.T) {
+	var (
+		dir     = t.TempDir()
+		wd, _   = os.Getwd()
+		fakeEnv = environment.NewFakeEnvStorage()
+	)
+
+	defer func() { _ = os.Chdir(wd) }()
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{"compose.yaml", "compose.dev.yaml"} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte("services: {}\n"), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newRoot := func(env environment.EnvStorage, args ...string) *cobra.Command {
+		root := NewRootCmd(env)
+		root.AddCommand(&cobra.Command{
+			Use:  "noop",
+			RunE: func(cmd *cobra.Command, args []string) error { return nil },
+		})
+		root.SetArgs(args)
+		return root
+	}
+
+	if err := newRoot(fakeEnv, "--compose-file", "compose.yaml", "--compose-file", "compose.dev.yaml", "noop").Execute(); err != nil {
+		t.Fatalf("unexpected error executing command; error: %v", err)
+	}
+
+	current, _ := os.Getwd()
+	expected := strings.Join([]string{
+		filepath.Join(current, "compose.yaml"),
+		filepath.Join(current, "compose.dev.yaml"),
+	}, compose.FileSeparator())
+
+	if composeFile := fakeEnv.Get("COMPOSE_FILE"); composeFile != expected {
+		t.Errorf("expected COMPOSE_FILE '%s'; got '%s'", expected, composeFile)
+	}
+
+	if err := newRoot(environment.NewFakeEnvStorage(), "--compose-file", "missing.yaml", "noop").Execute(); err == nil || err.Error() != "compose file missing.yaml does not exist" {
+		t.Errorf("expected error for missing compose file; got %v", err)
+	}
+}
+
+func TestHostFlagRootCommand(t *testing.T) {
+	newRoot := func(env environment.EnvStorage, args ...string) *cobra.Command {
+		root := NewRootCmd(env)
+		root.AddCommand(&cobra.Command{
+			Use:  "noop",
+			RunE: func(cmd *cobra.Command, args []string) error { return nil },
+		})
+		root.SetArgs(args)
+		return root
+	}
+
+	fakeEnv := environment.NewFakeEnvStorage()
+
+	if err := newRoot(fakeEnv, "--host", "ssh://user@remote", "noop").Execute(); err != nil {
+		t.Fatalf("unexpected error executing command; error: %v", err)
+	}
+
+	if host := fakeEnv.Get("DOCKER_HOST"); host != "ssh://user@remote" {
+		t.Errorf("expected DOCKER_HOST 'ssh://user@remote'; got '%s'", host)
+	}
+
+	fakeEnv = environment.NewFakeEnvStorage()
+
+	if err := newRoot(fakeEnv, "--host", "user@remote", "noop").Execute(); err == nil || !strings.Contains(err.Error(), "invalid docker host user@remote") {
+		t.Errorf("expected error for invalid docker host; got %v", err)
+	}
+
+	if host := fakeEnv.Get("DOCKER_HOST"); host != "" {
+		t.Errorf("expected DOCKER_HOST not to be set; got '%s'", host)
+	}
+}
+
+func TestEnvironmentFlagRootCommand(t *testing.T) {
+	fakeEnv := environment.NewFakeEnvStorage()
+
+	root := NewRootCmd(fakeEnv)
+	root.AddCommand(&cobra.Command{
+		Use:  "noop",
+		RunE: func(cmd *cobra.Command, args []string) error { return nil },
+	})
+	root.SetArgs([]string{"--environment", "staging", "noop"})
+
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error executing command; error: %v", err)
+	}
+
+	if koolEnv := fakeEnv.Get("KOOL_ENV"); koolEnv != "staging" {
+		t.Errorf("expected KOOL_ENV 'staging'; got '%s'", koolEnv)
+	}
+}
+
+func TestPrintCommandMetrics(t *testing.T) {
+	shell.ResetCommandMetrics()
+	defer shell.ResetCommandMetrics()
+
+	shell.RecordCommandMetric("docker compose ps", 1500*time.Millisecond)
+
+	b := bytes.NewBufferString("")
+	printCommandMetrics(b, 2*time.Second)
+
+	output := b.String()
+
+	if !strings.Contains(output, "docker compose ps") || !strings.Contains(output, "1.5s") {
+		t.Errorf("missing command metric on output: %s", output)
+	}
+
+	if !strings.Contains(output, "Total (wall time)") || !strings.Contains(output, "2s") {
+		t.Errorf("missing total wall time on output: %s", output)
+	}
+}
+
 func TestRecursiveCall(t *testing.T) {
 	recursive := &cobra.Command{
 		Use: "recursive",
@@ -242,10 +664,12 @@ func TestAddCommands(t *testing.T) {
 	AddCommands(root)
 
 	var subcommands map[string]bool = map[string]bool{
+		"bootstrap":   false,
 		"completion":  false,
 		"create":      false,
 		"cloud":       false,
 		"docker":      false,
+		"env":         false,
 		"exec":        false,
 		"info":        false,
 		"logs":        false,
@@ -253,10 +677,14 @@ func TestAddCommands(t *testing.T) {
 		"restart":     false,
 		"run":         false,
 		"self-update": false,
+		"services":    false,
+		"shell":       false,
 		"share":       false,
 		"start":       false,
 		"status":      false,
 		"stop":        false,
+		"validate":    false,
+		"version":     false,
 		"recipe":      false,
 	}
 
@@ -343,6 +771,18 @@ func TestDevelopmentVersionWarning(t *testing.T) {
 	if hasWarnedDevelopmentVersion {
 		t.Error("should not have warned on non-dev version")
 	}
+
+	fakeEnv.Set("KOOL_NO_DEV_WARNING", "1")
+	version = DEV_VERSION
+	if err := root.Execute(); err != nil {
+		t.Errorf("unexpected error executing command; error: %v", err)
+	}
+
+	if hasWarnedDevelopmentVersion {
+		t.Error("should not have warned with KOOL_NO_DEV_WARNING set")
+	}
+
+	version = "100.100.100"
 }
 
 func TestPromptSelectInterruptedError(t *testing.T) {
@@ -358,8 +798,10 @@ func TestPromptSelectInterruptedError(t *testing.T) {
 	b := bytes.NewBufferString("")
 	cmd.SetOut(b)
 
-	if err := cmd.Execute(); err != nil {
-		t.Errorf("unexpected error executing root command; error: %v", err)
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected an exitable error on user cancellation")
+	} else if exitable, ok := err.(shell.ErrExitable); !ok || exitable.Code != shell.UserCancelledExitCode {
+		t.Errorf("expected exit code %d on user cancellation; got: %v", shell.UserCancelledExitCode, err)
 	}
 
 	var (
@@ -388,3 +830,84 @@ func assertExecGotError(t *testing.T, cmd *cobra.Command, partialErr string) {
 		t.Errorf("unexpected error executing command; '%s' but got error: %v", partialErr, err)
 	}
 }
+
+func TestEnterInvocation(t *testing.T) {
+	env := environment.NewFakeEnvStorage()
+
+	leave, err := enterInvocation(env)
+
+	if err != nil {
+		t.Fatalf("unexpected error entering the first invocation: %v", err)
+	}
+
+	if depth := env.Get("KOOL_DEPTH"); depth != "1" {
+		t.Errorf("expected KOOL_DEPTH 1; got %s", depth)
+	}
+
+	leave()
+
+	if depth := env.Get("KOOL_DEPTH"); depth != "" {
+		t.Errorf("expected KOOL_DEPTH to be restored; got %s", depth)
+	}
+
+	env.Set("KOOL_DEPTH", "10")
+
+	if _, err = enterInvocation(env); err == nil || !strings.Contains(err.Error(), "kool calls nested 10 levels deep") {
+		t.Errorf("expected error for too deep nesting; got %v", err)
+	}
+
+	env.Set("KOOL_MAX_DEPTH", "20")
+
+	if _, err = enterInvocation(env); err != nil {
+		t.Errorf("unexpected error with raised KOOL_MAX_DEPTH: %v", err)
+	}
+
+	if depth := env.Get("KOOL_DEPTH"); depth != "11" {
+		t.Errorf("expected KOOL_DEPTH 11; got %s", depth)
+	}
+}
+
+func TestRecursiveCallDepth(t *testing.T) {
+	t.Setenv("KOOL_DEPTH", "0")
+	t.Setenv("KOOL_MAX_DEPTH", "3")
+
+	root := NewRootCmd(environment.NewFakeEnvStorage())
+	setRecursiveCall(root)
+
+	defer func() {
+		shell.RecursiveCall = nil
+	}()
+
+	var calls int
+
+	recursive := &cobra.Command{
+		Use: "recursive",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			calls++
+			return shell.RecursiveCall([]string{"recursive"}, cmd.InOrStdin(), io.Discard, io.Discard)
+		},
+	}
+
+	originalAddCommands := AddCommands
+	AddCommands = func(root *cobra.Command) {
+		root.AddCommand(recursive)
+	}
+
+	defer func() {
+		AddCommands = originalAddCommands
+	}()
+
+	err := shell.RecursiveCall([]string{"recursive"}, strings.NewReader(""), io.Discard, io.Discard)
+
+	if err == nil || !strings.Contains(err.Error(), "nested 3 levels deep") {
+		t.Errorf("expected runaway recursion to be stopped; got %v", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("expected 3 nested calls before aborting; got %d", calls)
+	}
+
+	if depth := os.Getenv("KOOL_DEPTH"); depth != "0" {
+		t.Errorf("expected KOOL_DEPTH restored after the calls; got %s", depth)
+	}
+}
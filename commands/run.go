@@ -1,13 +1,20 @@
 package commands
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"kool-dev/kool/core/builder"
 	"kool-dev/kool/core/environment"
 	"kool-dev/kool/core/parser"
 	"kool-dev/kool/core/shell"
+	"os"
 	"path"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -15,6 +22,21 @@ import (
 // KoolRunFlags holds the flags for the run command
 type KoolRunFlags struct {
 	EnvVariables []string
+	EnvFiles     []string
+	Cwd          string
+	Fresh        bool
+	NoMount      bool
+	List         bool
+	JSON         bool
+	Timeout      time.Duration
+	SkipDeps     bool
+}
+
+// runScriptInfo describes a kool.yml script as listed by kool run --list --json
+type runScriptInfo struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Aliases     []string `json:"aliases"`
 }
 
 // KoolRun holds handlers and functions to implement the run command logic
@@ -23,16 +45,39 @@ type KoolRun struct {
 	Flags        *KoolRunFlags
 	parser       parser.Parser
 	env          environment.EnvStorage
+	envVars      []string
 	promptSelect shell.PromptSelect
 	commands     []builder.Command
+	parallel     bool
+	dependencies []runDependency
+	docker       *KoolDocker
+}
+
+// runDependency is a script needed by the one being run, parsed
+type runDependency struct {
+	script   string
+	commands []builder.Command
+	parallel bool
 }
 
 // ErrExtraArguments Extra arguments error
 var ErrExtraArguments = errors.New("error: you cannot pass in extra arguments to multiple commands scripts")
 
+// ErrScriptTimeout means that the script did not finish within its timeout
+var ErrScriptTimeout = errors.New("script timed out")
+
 // ErrKoolScriptNotFound means that the given script was not found
 var ErrKoolScriptNotFound = errors.New("script was not found in any kool.yml file")
 
+// runFreshExample is the help example for --fresh, which does not depend on kool.yml
+const runFreshExample = "kool run --fresh node:20 npm -v"
+
+// runStaticExamples are the help examples shown when there is no kool.yml to take them from
+const runStaticExamples = "kool run setup\nkool run composer install\n" + runFreshExample
+
+// runMaxScriptExamples caps how many kool.yml scripts are shown as help examples
+const runMaxScriptExamples = 3
+
 func AddKoolRun(root *cobra.Command) {
 	var (
 		run    = NewKoolRun()
@@ -48,17 +93,40 @@ func AddKoolRun(root *cobra.Command) {
 func NewKoolRun() *KoolRun {
 	return &KoolRun{
 		*newDefaultKoolService(),
-		&KoolRunFlags{[]string{}},
+		&KoolRunFlags{[]string{}, []string{}, "", false, false, false, false, 0, false},
 		parser.NewParser(),
 		environment.NewEnvStorage(),
+		[]string{},
 		shell.NewPromptSelect(),
 		[]builder.Command{},
+		false,
+		[]runDependency{},
+		NewKoolDocker(),
 	}
 }
 
 // Execute runs the run logic with incoming arguments.
 func (r *KoolRun) Execute(originalArgs []string) (err error) {
+	if r.Flags.JSON && !r.Flags.List {
+		err = errors.New("--json can only be used along with --list")
+		return
+	}
+
+	if r.Flags.List {
+		err = r.listScripts()
+		return
+	}
+
+	if r.Flags.Fresh {
+		err = r.runFresh(originalArgs)
+		return
+	}
+
 	if len(originalArgs) == 0 {
+		if err = addKoolYmlLookupPaths(r.parser, r.env); err != nil {
+			return
+		}
+
 		r.shell.Info("\nAvailable scripts:\n")
 		scripts := compListScripts("", r)
 		for _, cmd := range scripts {
@@ -74,29 +142,149 @@ func (r *KoolRun) Execute(originalArgs []string) (err error) {
 		args   []string = originalArgs[1:]
 	)
 
-	// look for kool.yml on current working directory
-	_ = r.parser.AddLookupPath(r.env.Get("PWD"))
-	// look for kool.yml on kool folder within user home directory
-	_ = r.parser.AddLookupPath(path.Join(r.env.Get("HOME"), "kool"))
+	if err = addKoolYmlLookupPaths(r.parser, r.env); err != nil {
+		return
+	}
 
-	if err = r.parseScript(script); err != nil {
+	if err = selectEnvironment(r.env, r.parser, r.promptSelect, r.Shell().IsTerminal()); err != nil {
 		return
 	}
 
-	if len(r.commands) == 0 {
+	if err = r.readEnvVars(); err != nil {
+		return
+	}
+
+	// the variables are kept set for the commands run as well
+	defer r.overrideEnv(r.envVars)()
+
+	if script, err = r.parseScript(script); err != nil {
+		return
+	}
+
+	// project local bin directories take precedence over the PATH
+	if binPaths, pathsErr := r.parser.ParseBinPaths(); pathsErr == nil && len(binPaths) > 0 {
+		r.Shell().SetBinPaths(binPaths)
+	}
+
+	if len(r.commands) == 0 && len(r.dependencies) == 0 {
 		err = ErrKoolScriptNotFound
 		return
 	}
 
+	var hasPlaceholders bool
+
+	if r.commands, hasPlaceholders, err = parser.ApplyScriptArguments(script, r.commands, args); err != nil {
+		return
+	}
+
+	if hasPlaceholders {
+		// the arguments already took their places on the commands
+		args = nil
+	}
+
 	if len(args) > 0 && len(r.commands) > 1 {
 		err = ErrExtraArguments
 		return
 	}
 
+	if len(args) > 0 && len(r.commands) == 0 {
+		err = fmt.Errorf("script '%s' just runs the scripts it needs, which get no arguments", script)
+		return
+	}
+
+	if r.Flags.Cwd != "" {
+		var restore func()
+
+		if restore, err = r.changeDir(r.Flags.Cwd); err != nil {
+			return
+		}
+
+		defer restore()
+	}
+
+	var timeout time.Duration
+
+	if timeout, err = r.scriptTimeout(script); err != nil {
+		return
+	}
+
+	if timeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+
+		defer cancel()
+		defer r.Shell().SetContext(context.Background())
+
+		r.Shell().SetContext(ctx)
+	}
+
+	defer func() {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("%w: %s did not finish within %s", ErrScriptTimeout, script, timeout)
+		}
+	}()
+
+	for _, dependency := range r.dependencies {
+		r.Shell().Info(fmt.Sprintf("Running %s (needed by %s)", dependency.script, script))
+
+		if err = r.runCommands(dependency.script, dependency.commands, dependency.parallel); err != nil {
+			return
+		}
+	}
+
 	for _, command := range r.commands {
 		if len(args) > 0 {
 			command.AppendArgs(args...)
 		}
+	}
+
+	err = r.runCommands(script, r.commands, r.parallel)
+	return
+}
+
+// stepHolds tells whether the step of the script is to be run: steps with
+// an if condition only are when it holds, evaluated right then (within the
+// --cwd directory and with the --env variables); skipped steps are told
+// on verbose mode
+func (r *KoolRun) stepHolds(script string, command builder.Command) (holds bool) {
+	step, isConditional := command.(*parser.ConditionalCommand)
+
+	if !isConditional {
+		return true
+	}
+
+	holds = step.Holds(r.env)
+
+	if !holds && r.env.IsTrue("KOOL_VERBOSE") {
+		r.Shell().Println(fmt.Sprintf("$ skipping '%s' on %s: the condition '%s' does not hold", step.String(), script, step.Condition))
+	}
+	return
+}
+
+// runCommands runs the commands of a script one after the other,
+// or all at once for scripts with their commands under parallel
+func (r *KoolRun) runCommands(script string, commands []builder.Command, parallel bool) (err error) {
+	if parallel && len(commands) > 1 {
+		var holding []builder.Command
+
+		for _, command := range commands {
+			if r.stepHolds(script, command) {
+				holding = append(holding, command)
+			}
+		}
+
+		err = r.runParallel(holding)
+		return
+	}
+
+	for _, command := range commands {
+		if !r.stepHolds(script, command) {
+			continue
+		}
+
+		// echo the steps of multiple commands scripts as they go
+		if len(commands) > 1 {
+			r.Shell().Info("$ ", command.String())
+		}
 
 		if err = r.Shell().Interactive(command); err != nil {
 			return
@@ -105,15 +293,253 @@ func (r *KoolRun) Execute(originalArgs []string) (err error) {
 	return
 }
 
+// runParallel runs the commands concurrently, each one on its own fork
+// of the shell with its output lines prefixed by the command number; it
+// waits for all of them to finish and then reports every failure.
+func (r *KoolRun) runParallel(commands []builder.Command) (err error) {
+	var (
+		wg       sync.WaitGroup
+		out      = shell.NewPrefixedOutput(r.Shell().OutStream())
+		errOut   = shell.NewPrefixedOutput(r.Shell().ErrStream())
+		failures = make([]error, len(commands))
+		failed   []error
+	)
+
+	for i, command := range commands {
+		var (
+			prefix = fmt.Sprintf("[%d] ", i+1)
+			stdout = out.Writer(prefix)
+			stderr = errOut.Writer(prefix)
+			forked = r.Shell().Fork(stdout, stderr)
+		)
+
+		r.Shell().Info(prefix, "$ ", command.String())
+
+		wg.Add(1)
+		go func(i int, command builder.Command) {
+			defer wg.Done()
+
+			failures[i] = forked.Interactive(command)
+
+			_ = stdout.Flush()
+			_ = stderr.Flush()
+		}(i, command)
+	}
+
+	wg.Wait()
+
+	for i, failure := range failures {
+		if failure != nil {
+			failed = append(failed, fmt.Errorf("[%d] %s: %w", i+1, commands[i].String(), failure))
+		}
+	}
+
+	if len(failed) > 0 {
+		err = fmt.Errorf("%d of %d parallel commands failed:\n%w", len(failed), len(commands), errors.Join(failed...))
+	}
+	return
+}
+
+// parseDependencies parses the scripts needed by the given one,
+// in the order they are to be run (see Parser.ParseDependencies)
+func (r *KoolRun) parseDependencies(script string) (err error) {
+	var scripts []string
+
+	if scripts, err = r.parser.ParseDependencies(script); err != nil {
+		return
+	}
+
+	r.dependencies = []runDependency{}
+
+	for _, dependency := range scripts {
+		var commands []builder.Command
+
+		if commands, err = r.parser.Parse(dependency); err != nil && !parser.IsMultipleDefinedScriptError(err) {
+			return
+		}
+
+		// needed scripts get no arguments of their own
+		if commands, _, err = parser.ApplyScriptArguments(dependency, commands, nil); err != nil {
+			return
+		}
+
+		var parallel bool
+
+		if parallel, err = r.parser.ParseScriptParallel(dependency); err != nil {
+			return
+		}
+
+		r.dependencies = append(r.dependencies, runDependency{dependency, commands, parallel})
+	}
+
+	err = nil
+	return
+}
+
+// scriptTimeout returns for how long the script may run: the --timeout
+// flag when given, or else the timeout the script declares in kool.yml
+func (r *KoolRun) scriptTimeout(script string) (timeout time.Duration, err error) {
+	if r.Flags.Timeout > 0 {
+		timeout = r.Flags.Timeout
+		return
+	}
+
+	timeout, err = r.parser.ParseScriptTimeout(script)
+	return
+}
+
+// listScripts prints out the available scripts, one per line
+// or as a JSON array of objects for other tools to consume
+func (r *KoolRun) listScripts() (err error) {
+	var (
+		scripts []string
+		encoded []byte
+	)
+
+	if err = addKoolYmlLookupPaths(r.parser, r.env); err != nil {
+		return
+	}
+
+	if scripts, err = r.parser.ParseAvailableScripts(""); err != nil {
+		return
+	}
+
+	if !r.Flags.JSON {
+		for _, script := range scripts {
+			r.Shell().Println(script)
+		}
+		return
+	}
+
+	infos := make([]runScriptInfo, len(scripts))
+
+	for i, script := range scripts {
+		infos[i] = runScriptInfo{Name: script, Aliases: []string{}}
+	}
+
+	if encoded, err = json.Marshal(infos); err != nil {
+		return
+	}
+
+	r.Shell().Println(string(encoded))
+	return
+}
+
+// runFresh runs the command in a throwaway container of the given
+// image (the same way kool docker does), instead of running a script
+func (r *KoolRun) runFresh(args []string) (err error) {
+	if len(args) == 0 {
+		err = errors.New("--fresh requires an IMAGE to run")
+		return
+	}
+
+	for _, envFile := range r.Flags.EnvFiles {
+		var envVars []string
+
+		if envVars, err = readEnvFile(envFile); err != nil {
+			return
+		}
+
+		r.docker.Flags.EnvVariables = append(r.docker.Flags.EnvVariables, envVars...)
+	}
+
+	// variables given with --env take precedence, so they go last
+	r.docker.Flags.EnvVariables = append(r.docker.Flags.EnvVariables, r.Flags.EnvVariables...)
+	r.docker.Flags.NoMount = r.Flags.NoMount
+
+	if r.Flags.Cwd != "" {
+		var restore func()
+
+		if restore, err = r.changeDir(r.Flags.Cwd); err != nil {
+			return
+		}
+
+		defer restore()
+	}
+
+	r.docker.Shell().SetInStream(r.Shell().InStream())
+	r.docker.Shell().SetOutStream(r.Shell().OutStream())
+	r.docker.Shell().SetErrStream(r.Shell().ErrStream())
+
+	err = r.docker.Execute(args)
+	return
+}
+
+// changeDir moves into the directory given by --cwd for running the
+// script commands; the returned function moves back to where we were
+func (r *KoolRun) changeDir(dir string) (restore func(), err error) {
+	var current string
+
+	if current, err = os.Getwd(); err != nil {
+		return
+	}
+
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(current, dir)
+	}
+
+	if info, statErr := os.Stat(dir); statErr != nil || !info.IsDir() {
+		err = fmt.Errorf("directory %s does not exist", r.Flags.Cwd)
+		return
+	}
+
+	if err = os.Chdir(dir); err != nil {
+		return
+	}
+
+	restore = func() {
+		_ = os.Chdir(current)
+	}
+	return
+}
+
 // NewRunCommand initializes new kool stop command
 func NewRunCommand(run *KoolRun) (runCmd *cobra.Command) {
 	runCmd = &cobra.Command{
 		Use:   "run SCRIPT [--] [ARG...]",
 		Short: "Execute a script defined in kool.yml",
 		Long: `Execute the specified SCRIPT, as defined in the kool.yml file.
-A single-line SCRIPT can be run with optional arguments.`,
-		Args: cobra.ArbitraryArgs,
-		RunE: DefaultCommandRunFunction(run),
+A single-line SCRIPT can be run with optional arguments, which are appended
+to it. Scripts referencing their arguments take them in place instead: $1 to
+$9 by position, $@ for all of them, and named ones like {{arg:branch}} in
+the order they first show up (i.e. 'deploy: git push {{arg:remote}}
+{{arg:branch}}' is run as 'kool run deploy origin main').
+
+Use --cwd to run the script commands from within another directory; the
+kool.yml file is still looked up in the current one.
+
+Use --fresh to run a command in a throwaway container of the given image
+instead (i.e. 'kool run --fresh node:20 npm -v'), even when the image is
+not used by any service; the container is removed afterwards, and the current
+directory is mounted into it (at /app) unless --no-mount is given.
+
+Use --list to list the available scripts, one per line, or along with --json
+as an array of {name, description, aliases} objects for other tools to consume.
+
+A script may declare a timeout in kool.yml by writing it as a mapping, like
+'test: {commands: [...], timeout: 10m}'; it gets killed once the timeout is
+reached. Use --timeout to bound a script run, overriding its own timeout.
+
+A script written as a mapping may also list the scripts it needs, like
+'test: {needs: [setup], commands: [...]}'; they are run before it, along with
+the ones they need in turn, each just once. Use --skip-deps to run the script
+alone.
+
+Commands listed under parallel instead of commands, like
+'build: {parallel: [npm run build, composer install]}', all run at once; their
+output lines are prefixed with the command number, and the script fails when
+any of them does, once they have all finished.
+
+Lines of multi-line scripts may be written as a mapping of their command and
+an if condition, like '- {command: composer install, if: file.exists("composer.json")}';
+they are skipped when the condition does not hold (use --verbose to see why).
+
+When kool.yml declares environments and none was picked, you are asked which
+one to use; pick it upfront with the global --environment flag or KOOL_ENV
+(--env is taken by the script environment variables).`,
+		Example: runStaticExamples,
+		Args:    cobra.ArbitraryArgs,
+		RunE:    DefaultCommandRunFunction(run),
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			if len(args) > 0 {
 				return nil, cobra.ShellCompDirectiveDefault
@@ -125,6 +551,14 @@ A single-line SCRIPT can be run with optional arguments.`,
 	}
 
 	runCmd.Flags().StringArrayVarP(&run.Flags.EnvVariables, "env", "e", []string{}, "Environment variables.")
+	runCmd.Flags().StringArrayVarP(&run.Flags.EnvFiles, "env-file", "", []string{}, "Read environment variables from a file (variables given with --env take precedence).")
+	runCmd.Flags().StringVarP(&run.Flags.Cwd, "cwd", "", "", "Directory to run the script commands from.")
+	runCmd.Flags().BoolVarP(&run.Flags.Fresh, "fresh", "", false, "Run the command in a throwaway container of the given image instead of a script.")
+	runCmd.Flags().BoolVarP(&run.Flags.NoMount, "no-mount", "", false, "Do not mount the current directory into the --fresh container.")
+	runCmd.Flags().BoolVarP(&run.Flags.List, "list", "", false, "List the available scripts instead of running one.")
+	runCmd.Flags().BoolVarP(&run.Flags.JSON, "json", "", false, "Print the --list output as JSON.")
+	runCmd.Flags().BoolVarP(&run.Flags.SkipDeps, "skip-deps", "", false, "Do not run the scripts listed under the script needs.")
+	runCmd.Flags().DurationVarP(&run.Flags.Timeout, "timeout", "", 0, "Kill the script if it runs for longer than this (i.e. 90s, 10m), overriding its kool.yml timeout.")
 
 	// after a non-flag arg, stop parsing flags
 	runCmd.Flags().SetInterspersed(false)
@@ -138,24 +572,13 @@ func SetRunUsageFunc(run *KoolRun, runCmd *cobra.Command) {
 	runCmd.SetUsageFunc(getRunUsageFunc(run, originalUsageText))
 }
 
-func (r *KoolRun) parseScript(script string) (err error) {
+func (r *KoolRun) parseScript(script string) (resolved string, err error) {
 	var (
-		originalEnvs     map[string]string = make(map[string]string)
 		similarIsCorrect string
 		chosenSimilar    string
 	)
 
-	for _, envVar := range r.Flags.EnvVariables {
-		pair := strings.SplitN(envVar, "=", 2)
-		originalEnvs[pair[0]] = r.env.Get(pair[0])
-		r.env.Set(pair[0], pair[1])
-	}
-
-	defer func() {
-		for k, v := range originalEnvs {
-			r.env.Set(k, v)
-		}
-	}()
+	resolved = script
 
 	if r.commands, err = r.parser.Parse(script); err != nil {
 		if parser.IsPossibleTypoError(err) && r.Shell().IsTerminal() {
@@ -184,17 +607,78 @@ func (r *KoolRun) parseScript(script string) (err error) {
 				}
 			}
 
-			r.commands, err = r.parser.Parse(chosenSimilar)
-			return
-		}
-
-		if parser.IsMultipleDefinedScriptError(err) {
+			resolved = chosenSimilar
+			if r.commands, err = r.parser.Parse(chosenSimilar); err != nil {
+				return
+			}
+		} else if parser.IsMultipleDefinedScriptError(err) {
 			// we should just warn the user about multiple finds for the script
 			r.Shell().Warning("Attention: the script was found in more than one kool.yml file")
 			err = nil
+		} else {
+			return
 		}
 	}
 
+	if r.parallel, err = r.parser.ParseScriptParallel(resolved); err != nil {
+		return
+	}
+
+	if !r.Flags.SkipDeps {
+		err = r.parseDependencies(resolved)
+	}
+
+	return
+}
+
+// readEnvVars gathers the variables from the --env-file files
+// and the --env flags, which take precedence
+func (r *KoolRun) readEnvVars() (err error) {
+	r.envVars = []string{}
+
+	for _, envFile := range r.Flags.EnvFiles {
+		var fileEnvVars []string
+
+		if fileEnvVars, err = readEnvFile(envFile); err != nil {
+			return
+		}
+
+		r.envVars = append(r.envVars, fileEnvVars...)
+	}
+
+	r.envVars = append(r.envVars, r.Flags.EnvVariables...)
+	return
+}
+
+// overrideEnv sets the given NAME=value variables on the environment;
+// the returned function sets their original values back, unsetting
+// the ones that were not set before
+func (r *KoolRun) overrideEnv(envVars []string) (restore func()) {
+	type originalEnv struct {
+		value string
+		set   bool
+	}
+
+	originalEnvs := make(map[string]originalEnv)
+
+	for _, envVar := range envVars {
+		pair := strings.SplitN(envVar, "=", 2)
+		if _, saved := originalEnvs[pair[0]]; !saved {
+			value, set := r.env.Lookup(pair[0])
+			originalEnvs[pair[0]] = originalEnv{value, set}
+		}
+		r.env.Set(pair[0], pair[1])
+	}
+
+	restore = func() {
+		for k, original := range originalEnvs {
+			if original.set {
+				r.env.Set(k, original.value)
+			} else {
+				r.env.Unset(k)
+			}
+		}
+	}
 	return
 }
 
@@ -206,19 +690,19 @@ func getRunUsageFunc(run *KoolRun, originalUsageText string) func(*cobra.Command
 			parseErr error
 		)
 
-		// look for kool.yml on current working directory
-		_ = run.parser.AddLookupPath(run.env.Get("PWD"))
-		// look for kool.yml on kool folder within user home directory
-		_ = run.parser.AddLookupPath(path.Join(run.env.Get("HOME"), "kool"))
+		_ = addKoolYmlLookupPaths(run.parser, run.env)
 
-		if scripts, parseErr = run.parser.ParseAvailableScripts(""); parseErr != nil {
-			if run.env.IsTrue("KOOL_VERBOSE") {
+		if scripts, parseErr = run.parser.ParseAvailableScripts(""); parseErr != nil || len(scripts) == 0 {
+			if parseErr != nil && run.env.IsTrue("KOOL_VERBOSE") {
 				run.Shell().Println("$ got an error trying to add available scripts to command usage template; error:", parseErr.Error())
 			}
+
+			// without a kool.yml we stick to the static examples
+			run.Shell().Println(originalUsageText)
 			return
 		}
 
-		sb.WriteString(originalUsageText)
+		sb.WriteString(strings.Replace(originalUsageText, cmd.Example, scriptExamples(scripts), 1))
 		sb.WriteString("\n")
 		sb.WriteString("Available Scripts:\n")
 
@@ -233,12 +717,82 @@ func getRunUsageFunc(run *KoolRun, originalUsageText string) func(*cobra.Command
 	}
 }
 
+// scriptExamples builds the run help examples out of the project's
+// own scripts, keeping the --fresh one which does not depend on them
+func scriptExamples(scripts []string) string {
+	var examples []string
+
+	for i, script := range scripts {
+		if i == runMaxScriptExamples {
+			break
+		}
+
+		examples = append(examples, "kool run "+script)
+	}
+
+	examples = append(examples, runFreshExample)
+
+	return strings.Join(examples, "\n")
+}
+
+// selectEnvironment asks which environment to use when the kool.yml
+// declares some and none was picked (by KOOL_ENV or --environment); the
+// choice is kept on KOOL_ENV, so nested kool calls stick to it. Off a
+// terminal, it stays local.
+func selectEnvironment(env environment.EnvStorage, p parser.Parser, promptSelect shell.PromptSelect, isTerminal bool) (err error) {
+	var (
+		environments []string
+		options      = []string{parser.DefaultEnvironment}
+		chosen       string
+	)
+
+	if !isTerminal || env.Get("KOOL_ENV") != "" {
+		return
+	}
+
+	if environments, err = p.ParseEnvironments(); err != nil || len(environments) == 0 {
+		// a broken kool.yml is for the command using it to report
+		err = nil
+		return
+	}
+
+	for _, name := range environments {
+		if name != parser.DefaultEnvironment {
+			options = append(options, name)
+		}
+	}
+
+	if len(options) == 1 {
+		return
+	}
+
+	if chosen, err = promptSelect.Ask("Which kool.yml environment do you want to use?", options); err != nil {
+		return
+	}
+
+	env.Set("KOOL_ENV", chosen)
+	return
+}
+
+// addKoolYmlLookupPaths sets the parser to look for kool.yml files in the
+// current working directory and in the kool folder within the user home
+// directory; it fails with a parser.ErrNoKoolYml when there is none at all
+func addKoolYmlLookupPaths(p parser.Parser, env environment.EnvStorage) (err error) {
+	var (
+		errPWD  = p.AddLookupPath(env.Get("PWD"))
+		errHome = p.AddLookupPath(path.Join(env.Get("HOME"), "kool"))
+	)
+
+	if errPWD != nil && errHome != nil {
+		err = &parser.ErrNoKoolYml{Dir: env.Get("PWD")}
+	}
+
+	return
+}
+
 func compListScripts(toComplete string, run *KoolRun) (scripts []string) {
 	var err error
-	// look for kool.yml on current working directory
-	_ = run.parser.AddLookupPath(run.env.Get("PWD"))
-	// look for kool.yml on kool folder within user home directory
-	_ = run.parser.AddLookupPath(path.Join(run.env.Get("HOME"), "kool"))
+	_ = addKoolYmlLookupPaths(run.parser, run.env)
 
 	if scripts, err = run.parser.ParseAvailableScripts(toComplete); err != nil {
 		return nil
@@ -4,6 +4,7 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -12,8 +13,10 @@ import (
 	"kool-dev/kool/core/parser"
 	"kool-dev/kool/core/shell"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -21,11 +24,15 @@ import (
 func newFakeKoolRun(mockParsedCommands map[string][]builder.Command, mockParseError map[string]error) *KoolRun {
 	return &KoolRun{
 		*(newDefaultKoolService().Fake()),
-		&KoolRunFlags{[]string{}},
+		&KoolRunFlags{[]string{}, []string{}, "", false, false, false, false, 0, false},
 		&parser.FakeParser{MockParsedCommands: mockParsedCommands, MockParseError: mockParseError},
 		environment.NewFakeEnvStorage(),
+		[]string{},
 		&shell.FakePromptSelect{},
 		[]builder.Command{},
+		false,
+		[]runDependency{},
+		newFakeKoolDocker(),
 	}
 }
 
@@ -86,6 +93,32 @@ func TestNewRunCommand(t *testing.T) {
 	}
 }
 
+func TestNewRunCommandBinPaths(t *testing.T) {
+	fakeParsedCommands := map[string][]builder.Command{
+		"script": {
+			&builder.FakeCommand{MockCmd: "cmd1"},
+		},
+	}
+
+	f := newFakeKoolRun(fakeParsedCommands, nil)
+	f.parser.(*parser.FakeParser).MockBinPaths = []string{"/app/bin", "/app/vendor/bin"}
+	cmd := NewRunCommand(f)
+
+	cmd.SetArgs([]string{"script"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing run command; error: %v", err)
+	}
+
+	if !f.parser.(*parser.FakeParser).CalledParseBinPaths {
+		t.Error("did not call ParseBinPaths")
+	}
+
+	if paths := f.shell.(*shell.FakeShell).BinPaths; len(paths) != 2 || paths[0] != "/app/bin" {
+		t.Errorf("expected the kool.yml bin paths to be set on the shell; got %v", paths)
+	}
+}
+
 func TestNewRunCommandMultipleScriptsWarning(t *testing.T) {
 	f := newFakeKoolRun(nil, map[string]error{"script": parser.ErrMultipleDefinedScript})
 	cmd := NewRunCommand(f)
@@ -146,6 +179,142 @@ func TestNewRunCommandErrorInteractive(t *testing.T) {
 	assertExecGotError(t, cmd, expected)
 }
 
+func TestNewRunCommandScriptTimeout(t *testing.T) {
+	fakeParsedCommands := map[string][]builder.Command{
+		"script": {
+			&builder.FakeCommand{MockCmd: "cmd1"},
+		},
+	}
+
+	for _, tc := range []struct {
+		args     []string
+		expected time.Duration
+	}{
+		{[]string{"script"}, time.Hour},
+		{[]string{"--timeout", "1m", "script"}, time.Minute},
+	} {
+		f := newFakeKoolRun(fakeParsedCommands, nil)
+		f.parser.(*parser.FakeParser).MockScriptTimeout = map[string]time.Duration{"script": time.Hour}
+
+		cmd := NewRunCommand(f)
+		cmd.SetArgs(tc.args)
+
+		start := time.Now()
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected error executing run command; error: %v", err)
+		}
+
+		contexts := f.shell.(*shell.FakeShell).Contexts
+
+		if len(contexts) != 2 {
+			t.Fatalf("expected the script context to be set and then reset; got %v", contexts)
+		}
+
+		if deadline, ok := contexts[0].Deadline(); !ok || deadline.Sub(start) < tc.expected || deadline.Sub(start) > tc.expected+time.Second {
+			t.Errorf("expected script timeout of %s for %v; got deadline in %s", tc.expected, tc.args, deadline.Sub(start))
+		}
+
+		if _, ok := contexts[1].Deadline(); ok {
+			t.Error("expected the shell context to be reset after running the script")
+		}
+	}
+}
+
+func TestNewRunCommandWithoutScriptTimeout(t *testing.T) {
+	fakeParsedCommands := map[string][]builder.Command{
+		"script": {
+			&builder.FakeCommand{MockCmd: "cmd1"},
+		},
+	}
+
+	f := newFakeKoolRun(fakeParsedCommands, nil)
+
+	cmd := NewRunCommand(f)
+	cmd.SetArgs([]string{"script"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error executing run command; error: %v", err)
+	}
+
+	if !f.parser.(*parser.FakeParser).CalledParseScriptTimeout {
+		t.Error("did not look up the script timeout on kool.yml")
+	}
+
+	if f.shell.(*shell.FakeShell).CalledSetContext {
+		t.Error("unexpected context set for a script without timeout")
+	}
+}
+
+func TestNewRunCommandScriptTimedOut(t *testing.T) {
+	fakeParsedCommands := map[string][]builder.Command{
+		"script": {
+			&builder.FakeCommand{MockCmd: "cmd1", MockInteractiveError: context.DeadlineExceeded},
+		},
+	}
+
+	f := newFakeKoolRun(fakeParsedCommands, nil)
+	cmd := NewRunCommand(f)
+	cmd.SetArgs([]string{"--timeout", "90s", "script"})
+
+	err := cmd.Execute()
+
+	if !errors.Is(err, ErrScriptTimeout) || err.Error() != "script timed out: script did not finish within 1m30s" {
+		t.Errorf("expected script timeout error; got %v", err)
+	}
+
+	f = newFakeKoolRun(nil, nil)
+	f.parser.(*parser.FakeParser).MockParsedCommands = fakeParsedCommands
+	f.parser.(*parser.FakeParser).MockParseScriptTimeoutError = errors.New("invalid timeout")
+
+	cmd = NewRunCommand(f)
+	cmd.SetArgs([]string{"script"})
+
+	assertExecGotError(t, cmd, "invalid timeout")
+}
+
+func TestNewRunCommandMultipleCommands(t *testing.T) {
+	fakeParsedCommands := map[string][]builder.Command{
+		"script": {
+			&builder.FakeCommand{MockCmd: "cmd1"},
+			&builder.FakeCommand{MockCmd: "cmd2", MockInteractiveError: errors.New("cmd2 error")},
+			&builder.FakeCommand{MockCmd: "cmd3"},
+		},
+	}
+	f := newFakeKoolRun(fakeParsedCommands, nil)
+	cmd := NewRunCommand(f)
+
+	cmd.SetArgs([]string{"script"})
+
+	assertExecGotError(t, cmd, "cmd2 error")
+
+	fakeShell := f.shell.(*shell.FakeShell)
+
+	if !fakeShell.CalledInteractive["cmd1"] || !fakeShell.CalledInteractive["cmd2"] {
+		t.Error("expected to run the commands up to the failing one")
+	}
+
+	if fakeShell.CalledInteractive["cmd3"] {
+		t.Error("should stop running the script commands on the first failure")
+	}
+
+	if !fakeShell.CalledInfo || !fakeParsedCommands["script"][1].(*builder.FakeCommand).CalledString {
+		t.Error("expected to echo the commands being run")
+	}
+
+	f = newFakeKoolRun(map[string][]builder.Command{"script": {&builder.FakeCommand{MockCmd: "cmd1"}}}, nil)
+	cmd = NewRunCommand(f)
+	cmd.SetArgs([]string{"script"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing run command; error: %v", err)
+	}
+
+	if f.shell.(*shell.FakeShell).CalledInfo {
+		t.Error("should not echo single command scripts")
+	}
+}
+
 func TestNewRunCommandScriptNotFound(t *testing.T) {
 	f := newFakeKoolRun(nil, nil)
 	cmd := NewRunCommand(f)
@@ -156,6 +325,192 @@ func TestNewRunCommandScriptNotFound(t *testing.T) {
 	assertExecGotError(t, cmd, expected)
 }
 
+func TestNewRunCommandDependencies(t *testing.T) {
+	fakeParsedCommands := map[string][]builder.Command{
+		"setup":  {&builder.FakeCommand{MockCmd: "setup-cmd"}},
+		"script": {&builder.FakeCommand{MockCmd: "script-cmd"}},
+	}
+	f := newFakeKoolRun(fakeParsedCommands, nil)
+	f.parser.(*parser.FakeParser).MockDependencies = map[string][]string{"script": {"setup"}}
+	cmd := NewRunCommand(f)
+
+	cmd.SetArgs([]string{"script", "arg"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing run command; error: %v", err)
+	}
+
+	fakeShell := f.shell.(*shell.FakeShell)
+
+	if !fakeShell.CalledInteractive["setup-cmd"] || !fakeShell.CalledInteractive["script-cmd"] {
+		t.Error("expected to run the needed script and then the script itself")
+	}
+
+	if fakeParsedCommands["setup"][0].(*builder.FakeCommand).CalledAppendArgs {
+		t.Error("should not append the arguments to the needed scripts")
+	}
+
+	if !fakeParsedCommands["script"][0].(*builder.FakeCommand).CalledAppendArgs {
+		t.Error("expected to append the arguments to the script")
+	}
+
+	if !fakeShell.CalledInfo || fmt.Sprint(fakeShell.InfoOutput...) != "Running setup (needed by script)" {
+		t.Errorf("unexpected info output: %v", fakeShell.InfoOutput)
+	}
+}
+
+func TestNewRunCommandSkipDependencies(t *testing.T) {
+	fakeParsedCommands := map[string][]builder.Command{
+		"setup":  {&builder.FakeCommand{MockCmd: "setup-cmd"}},
+		"script": {&builder.FakeCommand{MockCmd: "script-cmd"}},
+	}
+	f := newFakeKoolRun(fakeParsedCommands, nil)
+	f.parser.(*parser.FakeParser).MockDependencies = map[string][]string{"script": {"setup"}}
+	cmd := NewRunCommand(f)
+
+	cmd.SetArgs([]string{"--skip-deps", "script"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing run command; error: %v", err)
+	}
+
+	if f.parser.(*parser.FakeParser).CalledParseDependencies {
+		t.Error("should not parse the script needs with --skip-deps")
+	}
+
+	fakeShell := f.shell.(*shell.FakeShell)
+
+	if fakeShell.CalledInteractive["setup-cmd"] || !fakeShell.CalledInteractive["script-cmd"] {
+		t.Error("expected to run only the script itself with --skip-deps")
+	}
+}
+
+func TestNewRunCommandDependenciesOnly(t *testing.T) {
+	fakeParsedCommands := map[string][]builder.Command{
+		"setup": {&builder.FakeCommand{MockCmd: "setup-cmd"}},
+	}
+	f := newFakeKoolRun(fakeParsedCommands, nil)
+	f.parser.(*parser.FakeParser).MockDependencies = map[string][]string{"all": {"setup"}}
+	cmd := NewRunCommand(f)
+
+	cmd.SetArgs([]string{"all"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing run command; error: %v", err)
+	}
+
+	if !f.shell.(*shell.FakeShell).CalledInteractive["setup-cmd"] {
+		t.Error("expected to run the needed script")
+	}
+
+	f = newFakeKoolRun(fakeParsedCommands, nil)
+	f.parser.(*parser.FakeParser).MockDependencies = map[string][]string{"all": {"setup"}}
+	cmd = NewRunCommand(f)
+
+	cmd.SetArgs([]string{"all", "extra"})
+
+	assertExecGotError(t, cmd, "script 'all' just runs the scripts it needs, which get no arguments")
+
+	if f.shell.(*shell.FakeShell).CalledInteractive["setup-cmd"] {
+		t.Error("should not run the needed scripts when given extra arguments")
+	}
+}
+
+func TestNewRunCommandDependenciesError(t *testing.T) {
+	fakeParsedCommands := map[string][]builder.Command{
+		"script": {&builder.FakeCommand{MockCmd: "script-cmd"}},
+	}
+	f := newFakeKoolRun(fakeParsedCommands, nil)
+	f.parser.(*parser.FakeParser).MockParseDependenciesError = &parser.ErrDependencyCycle{Chain: []string{"script", "script"}}
+	cmd := NewRunCommand(f)
+
+	cmd.SetArgs([]string{"script"})
+
+	assertExecGotError(t, cmd, "scripts needs form a cycle: script -> script")
+
+	if f.shell.(*shell.FakeShell).CalledInteractive["script-cmd"] {
+		t.Error("should not run the script when its needs fail to parse")
+	}
+
+	fakeParsedCommands = map[string][]builder.Command{
+		"setup":  {&builder.FakeCommand{MockCmd: "setup-cmd", MockInteractiveError: errors.New("setup error")}},
+		"script": {&builder.FakeCommand{MockCmd: "script-cmd"}},
+	}
+	f = newFakeKoolRun(fakeParsedCommands, nil)
+	f.parser.(*parser.FakeParser).MockDependencies = map[string][]string{"script": {"setup"}}
+	cmd = NewRunCommand(f)
+
+	cmd.SetArgs([]string{"script"})
+
+	assertExecGotError(t, cmd, "setup error")
+
+	if f.shell.(*shell.FakeShell).CalledInteractive["script-cmd"] {
+		t.Error("should not run the script when a needed script fails")
+	}
+}
+
+func TestNewRunCommandParallel(t *testing.T) {
+	fakeParsedCommands := map[string][]builder.Command{
+		"build": {
+			&builder.FakeCommand{MockCmd: "cmd1"},
+			&builder.FakeCommand{MockCmd: "cmd2", MockInteractiveError: errors.New("cmd2 error")},
+			&builder.FakeCommand{MockCmd: "cmd3", MockInteractiveError: errors.New("cmd3 error")},
+		},
+	}
+	f := newFakeKoolRun(fakeParsedCommands, nil)
+	f.parser.(*parser.FakeParser).MockScriptParallel = map[string]bool{"build": true}
+	cmd := NewRunCommand(f)
+
+	cmd.SetArgs([]string{"build"})
+
+	err := cmd.Execute()
+
+	if err == nil {
+		t.Fatal("expected error running parallel commands with failures")
+	}
+
+	for _, expected := range []string{"2 of 3 parallel commands failed", "[2] : cmd2 error", "[3] : cmd3 error"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain '%s'; got '%v'", expected, err)
+		}
+	}
+
+	fakeShell := f.shell.(*shell.FakeShell)
+
+	if !fakeShell.CalledFork || len(fakeShell.Forks) != 3 {
+		t.Fatal("expected to fork the shell for each parallel command")
+	}
+
+	for i, forked := range fakeShell.Forks {
+		if command := fmt.Sprintf("cmd%d", i+1); !forked.CalledInteractive[command] || len(forked.CalledInteractive) != 1 {
+			t.Errorf("expected to run %s on its own forked shell", command)
+		}
+	}
+
+	if len(fakeShell.CalledInteractive) != 0 {
+		t.Error("should not run parallel commands on the main shell")
+	}
+
+	if !fakeShell.CalledInfo || fmt.Sprint(fakeShell.InfoOutput...) != "[3] $ " || !fakeParsedCommands["build"][2].(*builder.FakeCommand).CalledString {
+		t.Errorf("unexpected info output: %v", fakeShell.InfoOutput)
+	}
+
+	f = newFakeKoolRun(map[string][]builder.Command{"build": {&builder.FakeCommand{MockCmd: "cmd1"}, &builder.FakeCommand{MockCmd: "cmd2"}}}, nil)
+	f.parser.(*parser.FakeParser).MockScriptParallel = map[string]bool{"build": true}
+	cmd = NewRunCommand(f)
+	cmd.SetArgs([]string{"build"})
+
+	if err = cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing parallel commands; error: %v", err)
+	}
+
+	f.parser.(*parser.FakeParser).MockParseScriptParallelError = errors.New("parallel error")
+	cmd = NewRunCommand(f)
+	cmd.SetArgs([]string{"build"})
+
+	assertExecGotError(t, cmd, "parallel error")
+}
+
 func TestNewRunCommandWithArguments(t *testing.T) {
 	fakeParsedCommands := map[string][]builder.Command{
 		"script": {
@@ -182,6 +537,134 @@ func TestNewRunCommandWithArguments(t *testing.T) {
 	}
 }
 
+func TestNewRunCommandWithArgumentPlaceholders(t *testing.T) {
+	fakeParsedCommands := map[string][]builder.Command{
+		"deploy": {
+			builder.NewCommand("git", "push", "{{arg:remote}}", "{{arg:branch}}"),
+			builder.NewCommand("notify", "--branch={{arg:branch}}"),
+		},
+	}
+	f := newFakeKoolRun(fakeParsedCommands, nil)
+	cmd := NewRunCommand(f)
+
+	cmd.SetArgs([]string{"deploy", "origin", "main"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing run command; error: %v", err)
+	}
+
+	if len(f.commands) != 2 || f.commands[0].String() != "git push origin main" || f.commands[1].String() != "notify --branch=main" {
+		t.Errorf("expected the arguments in place of the placeholders; got %v", f.commands)
+	}
+
+	f = newFakeKoolRun(fakeParsedCommands, nil)
+	cmd = NewRunCommand(f)
+
+	cmd.SetArgs([]string{"deploy", "origin"})
+
+	assertExecGotError(t, cmd, "script 'deploy' is missing the <branch> argument(s); run it as 'kool run deploy <remote> <branch>'")
+
+	if len(f.shell.(*shell.FakeShell).CalledInteractive) != 0 {
+		t.Error("should not run a script missing arguments")
+	}
+
+	f = newFakeKoolRun(map[string][]builder.Command{
+		"setup":  {builder.NewCommand("echo", "{{arg:1}}")},
+		"script": {&builder.FakeCommand{MockCmd: "script-cmd"}},
+	}, nil)
+	f.parser.(*parser.FakeParser).MockDependencies = map[string][]string{"script": {"setup"}}
+	cmd = NewRunCommand(f)
+
+	cmd.SetArgs([]string{"script", "arg"})
+
+	assertExecGotError(t, cmd, "script 'setup' is missing the <arg1> argument(s)")
+}
+
+func TestNewRunCommandWithGoTemplate(t *testing.T) {
+	f := newFakeKoolRun(map[string][]builder.Command{
+		"names": {builder.NewCommand("docker", "ps", "--format", "{{.Names}}")},
+	}, nil)
+	cmd := NewRunCommand(f)
+
+	cmd.SetArgs([]string{"names", "-a"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing run command; error: %v", err)
+	}
+
+	if len(f.commands) != 1 || f.commands[0].String() != "docker ps --format {{.Names}} -a" {
+		t.Errorf("expected the Go template kept and the arguments appended; got %v", f.commands)
+	}
+}
+
+func TestNewRunCommandConditionalSteps(t *testing.T) {
+	cwd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(cwd) }()
+
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "composer.json"), []byte("{}"), 0644)
+
+	steps, err := (&parser.KoolYaml{Scripts: map[string]interface{}{
+		"setup": []interface{}{
+			map[interface{}]interface{}{"command": "composer install", "if": `file.exists("composer.json")`},
+			map[interface{}]interface{}{"command": "deploy", "if": `env.STAGE == "ci"`},
+			map[interface{}]interface{}{"command": "serve", "if": `env.STAGE == "local"`},
+		},
+	}}).ParseCommands("setup")
+
+	if err != nil {
+		t.Fatalf("unexpected error parsing the steps: %v", err)
+	}
+
+	for _, verbose := range []bool{false, true} {
+		f := newFakeKoolRun(map[string][]builder.Command{"setup": steps}, nil)
+		if verbose {
+			f.env.Set("KOOL_VERBOSE", "1")
+		}
+		cmd := NewRunCommand(f)
+
+		// the conditions see the --cwd directory and the --env variables
+		cmd.SetArgs([]string{"--cwd", dir, "--env", "STAGE=ci", "setup"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Errorf("unexpected error executing run command; error: %v", err)
+		}
+
+		called := f.shell.(*shell.FakeShell).CalledInteractive
+
+		if !called["composer"] || !called["deploy"] || called["serve"] {
+			t.Errorf("expected just the steps with conditions holding to run; got %v", called)
+		}
+
+		if f.env.Get("STAGE") != "" {
+			t.Error("expected the --env variables to be restored")
+		}
+
+		expected := `$ skipping 'serve' on setup: the condition 'env.STAGE == "local"' does not hold`
+		outLines := f.shell.(*shell.FakeShell).OutLines
+
+		if verbose && (len(outLines) != 1 || outLines[0] != expected) {
+			t.Errorf("expected skipped step to be explained; got %v", outLines)
+		} else if !verbose && len(outLines) != 0 {
+			t.Errorf("should only tell the skipped steps on verbose mode; got %v", outLines)
+		}
+	}
+
+	f := newFakeKoolRun(map[string][]builder.Command{"setup": steps}, nil)
+	f.parser.(*parser.FakeParser).MockScriptParallel = map[string]bool{"setup": true}
+	cmd := NewRunCommand(f)
+
+	cmd.SetArgs([]string{"--env", "STAGE=local", "setup"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing run command; error: %v", err)
+	}
+
+	if forks := f.shell.(*shell.FakeShell).Forks; len(forks) != 1 || !forks[0].CalledInteractive["serve"] {
+		t.Errorf("expected just the parallel step with its condition holding to run; got %v", forks)
+	}
+}
+
 func TestNewRunCommandUsageTemplate(t *testing.T) {
 	f := newFakeKoolRun(nil, nil)
 	f.parser.(*parser.FakeParser).MockScripts = []string{"testing_script"}
@@ -203,6 +686,42 @@ func TestNewRunCommandUsageTemplate(t *testing.T) {
 	if !strings.Contains(usage, "testing_script") {
 		t.Error("did not find testing_script as available script on usage text")
 	}
+
+	if !strings.Contains(usage, "Examples:\nkool run testing_script\n"+runFreshExample) {
+		t.Errorf("expected examples out of the kool.yml scripts on usage text; got:\n%s", usage)
+	}
+
+	if strings.Contains(usage, "kool run composer install") {
+		t.Error("should not show the static examples when there are kool.yml scripts")
+	}
+}
+
+func TestNewRunCommandUsageStaticExamples(t *testing.T) {
+	f := newFakeKoolRun(nil, nil)
+	f.parser.(*parser.FakeParser).MockParseAvailableScriptsError = errors.New("kool.yml not found")
+	cmd := NewRunCommand(f)
+	SetRunUsageFunc(f, cmd)
+
+	cmd.SetArgs([]string{"--help"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing run command; error: %v", err)
+	}
+
+	usage := strings.Join(f.shell.(*shell.FakeShell).OutLines, "\n")
+
+	if !strings.Contains(usage, "Examples:\nkool run setup\nkool run composer install") {
+		t.Errorf("expected static examples on usage text; got:\n%s", usage)
+	}
+}
+
+func TestScriptExamples(t *testing.T) {
+	examples := scriptExamples([]string{"a", "b", "c", "d"})
+	expected := "kool run a\nkool run b\nkool run c\n" + runFreshExample
+
+	if examples != expected {
+		t.Errorf("expected examples '%s', got '%s'", expected, examples)
+	}
 }
 
 func TestNewRunCommandFailingUsageTemplate(t *testing.T) {
@@ -550,20 +1069,81 @@ func TestNewRunCommandWithEnvVariable(t *testing.T) {
 		return
 	}
 
-	if len(history) != 2 {
-		t.Error("failed to set '$VAR_TEST' to its original state")
-		return
-	}
-
 	if history[0] != "1" {
 		t.Errorf("expected to set '1' into '$VAR_TEST', did set '%s'", history[0])
 	}
 
-	if history[1] != "" {
-		t.Errorf("expected to set '$VAR_TEST' to an empty value after using it, did set to '%s'", history[0])
+	if _, set := f.env.Lookup("VAR_TEST"); set {
+		t.Error("expected '$VAR_TEST' to be unset again after using it, as it was not set before")
 	}
 }
 
+// envCapturingCommand records the variables set on the environment when run
+type envCapturingCommand struct {
+	*builder.FakeCommand
+
+	env  environment.EnvStorage
+	seen string
+}
+
+func (c *envCapturingCommand) Cmd() string {
+	c.seen = c.env.Get("VAR_TEST") + " " + c.env.Get("VAR_FILE")
+	return c.FakeCommand.Cmd()
+}
+
+func TestNewRunCommandWithEnvFile(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), "test.env")
+	_ = os.WriteFile(envFile, []byte("VAR_TEST=from-file\nVAR_FILE=1\n"), os.ModePerm)
+
+	f := newFakeKoolRun(nil, nil)
+	cmd := NewRunCommand(f)
+
+	cmd.SetArgs([]string{"--env-file", envFile, "--env=VAR_TEST=from-flag", "script"})
+
+	assertExecGotError(t, cmd, "script was not found in any kool.yml")
+
+	envsHistory := f.env.(*environment.FakeEnvStorage).EnvsHistory
+
+	if history := envsHistory["VAR_FILE"]; len(history) != 1 || history[0] != "1" {
+		t.Errorf("expected VAR_FILE to be set from the env file; got %v", history)
+	}
+
+	if history := envsHistory["VAR_TEST"]; len(history) != 2 || history[1] != "from-flag" {
+		t.Errorf("expected --env to take precedence over the env file; got %v", history)
+	}
+
+	for _, key := range []string{"VAR_FILE", "VAR_TEST"} {
+		if _, set := f.env.Lookup(key); set {
+			t.Errorf("expected %s to be unset again after running", key)
+		}
+	}
+
+	command := &envCapturingCommand{FakeCommand: &builder.FakeCommand{MockCmd: "cmd"}}
+	f = newFakeKoolRun(map[string][]builder.Command{"script": {command}}, nil)
+	f.env.Set("VAR_TEST", "original")
+	command.env = f.env
+	cmd = NewRunCommand(f)
+	cmd.SetArgs([]string{"--env-file", envFile, "script"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error executing run command; error: %v", err)
+	}
+
+	if command.seen != "from-file 1" {
+		t.Errorf("expected the env file variables to be set while running the command; got '%s'", command.seen)
+	}
+
+	if f.env.Get("VAR_TEST") != "original" {
+		t.Errorf("expected VAR_TEST to be set back to its original value; got '%s'", f.env.Get("VAR_TEST"))
+	}
+
+	f = newFakeKoolRun(nil, nil)
+	cmd = NewRunCommand(f)
+	cmd.SetArgs([]string{"--env-file", filepath.Join(t.TempDir(), "missing.env"), "script"})
+
+	assertExecGotError(t, cmd, "missing.env not found")
+}
+
 func TestNewRunCommandWithTypoErrorMultipleSimilar(t *testing.T) {
 	fakeParsedCommands := map[string][]builder.Command{
 		"script2": {
@@ -657,8 +1237,8 @@ func TestNewRunCommandWithTypoErrorMultipleSimilarCancelledFirstQuestion(t *test
 
 	cmd.SetArgs([]string{"script"})
 
-	if err := cmd.Execute(); err != nil {
-		t.Errorf("unexpected error %v", err)
+	if err := cmd.Execute(); !shell.IsUserCancelledError(err) {
+		t.Errorf("expected user cancelled error, got %v", err)
 	}
 
 	expected := "Operation Cancelled\n"
@@ -698,8 +1278,8 @@ func TestNewRunCommandWithTypoErrorMultipleSimilarCancelledSecondQuestion(t *tes
 
 	cmd.SetArgs([]string{"script"})
 
-	if err := cmd.Execute(); err != nil {
-		t.Errorf("unexpected error executing run command; error: %v", err)
+	if err := cmd.Execute(); !shell.IsUserCancelledError(err) {
+		t.Errorf("expected user cancelled error, got %v", err)
 	}
 
 	expected := "Operation Cancelled\n"
@@ -737,8 +1317,8 @@ func TestNewRunCommandWithTypoErrorCancelled(t *testing.T) {
 
 	cmd.SetArgs([]string{"script"})
 
-	if err := cmd.Execute(); err != nil {
-		t.Errorf("unexpected error %v", err)
+	if err := cmd.Execute(); !shell.IsUserCancelledError(err) {
+		t.Errorf("expected user cancelled error, got %v", err)
 	}
 
 	expected := "Operation Cancelled\n"
@@ -748,3 +1328,249 @@ func TestNewRunCommandWithTypoErrorCancelled(t *testing.T) {
 		t.Errorf("expecting warning '%s', got '%s'", expected, output)
 	}
 }
+
+func TestNewRunCommandWithCwd(t *testing.T) {
+	k := NewKoolRun()
+	k.env = environment.NewFakeEnvStorage()
+	k.env.Set("HOME", "")
+	tmp := t.TempDir()
+	k.env.Set("PWD", tmp)
+
+	if err := os.WriteFile(filepath.Join(tmp, "kool.yml"), []byte("scripts:\n  mark: touch marker\n"), os.ModePerm); err != nil {
+		t.Fatalf("failed creating temp kool.yml for testing: %v", err)
+	}
+
+	subdir := filepath.Join(tmp, "subdir")
+	if err := os.Mkdir(subdir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, _ := os.Getwd()
+
+	cmd := NewRunCommand(k)
+	cmd.SetArgs([]string{"--cwd", subdir, "mark"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error executing run command; error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(subdir, "marker")); err != nil {
+		t.Errorf("expected the script to run from within %s; error: %v", subdir, err)
+	}
+
+	if current, _ := os.Getwd(); current != wd {
+		t.Errorf("expected working directory to be restored to %s; got %s", wd, current)
+	}
+}
+
+func TestNewRunCommandWithMissingCwd(t *testing.T) {
+	fakeParsedCommands := map[string][]builder.Command{
+		"script": {
+			&builder.FakeCommand{MockCmd: "cmd1"},
+		},
+	}
+
+	f := newFakeKoolRun(fakeParsedCommands, nil)
+	cmd := NewRunCommand(f)
+	cmd.SetArgs([]string{"--cwd", filepath.Join(t.TempDir(), "missing"), "script"})
+
+	assertExecGotError(t, cmd, "missing does not exist")
+
+	if f.shell.(*shell.FakeShell).CalledInteractive["cmd1"] {
+		t.Error("should not run the script commands when --cwd does not exist")
+	}
+}
+
+func TestNewRunCommandFresh(t *testing.T) {
+	f := newFakeKoolRun(nil, nil)
+	cmd := NewRunCommand(f)
+	cmd.SetArgs([]string{"--fresh", "-e", "FOO=bar", "--no-mount", "node:20", "npm", "-v"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error executing run command; error: %v", err)
+	}
+
+	dockerShell := f.docker.shell.(*shell.FakeShell)
+
+	if !dockerShell.CalledInteractive["docker"] {
+		t.Fatal("did not run the docker container")
+	}
+
+	if args := strings.Join(dockerShell.ArgsInteractive["docker"], " "); args != "node:20 npm -v" {
+		t.Errorf("expected to run 'node:20 npm -v'; got '%s'", args)
+	}
+
+	argsAppend := strings.Join(f.docker.dockerRun.(*builder.FakeCommand).ArgsAppend, " ")
+
+	if !strings.Contains(argsAppend, "--env FOO=bar") {
+		t.Errorf("expected the environment variables to be passed on; got '%s'", argsAppend)
+	}
+
+	if strings.Contains(argsAppend, "--volume") {
+		t.Errorf("should not mount the current directory with --no-mount; got '%s'", argsAppend)
+	}
+
+	if f.shell.(*shell.FakeShell).CalledInteractive["docker"] {
+		t.Error("should not run any script with --fresh")
+	}
+}
+
+func TestNewRunCommandFreshMountsCurrentDirectory(t *testing.T) {
+	f := newFakeKoolRun(nil, nil)
+	cmd := NewRunCommand(f)
+	cmd.SetArgs([]string{"--fresh", "alpine", "ls"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error executing run command; error: %v", err)
+	}
+
+	workDir, _ := os.Getwd()
+
+	if argsAppend := strings.Join(f.docker.dockerRun.(*builder.FakeCommand).ArgsAppend, " "); !strings.Contains(argsAppend, "--volume "+workDir+":/app:delegated") {
+		t.Errorf("expected the current directory to be mounted; got '%s'", argsAppend)
+	}
+}
+
+func TestNewRunCommandFreshWithoutImage(t *testing.T) {
+	f := newFakeKoolRun(nil, nil)
+	cmd := NewRunCommand(f)
+	cmd.SetArgs([]string{"--fresh"})
+
+	assertExecGotError(t, cmd, "--fresh requires an IMAGE to run")
+}
+
+func TestRunWithoutKoolYml(t *testing.T) {
+	f := newFakeKoolRun(nil, nil)
+	f.parser.(*parser.FakeParser).MockAddLookupPathError = parser.ErrKoolYmlNotFound
+	f.env.Set("PWD", "/not/a/project")
+
+	for _, args := range [][]string{{"script"}, {}} {
+		err := f.Execute(args)
+
+		var noKoolYml *parser.ErrNoKoolYml
+		if !errors.As(err, &noKoolYml) || noKoolYml.Dir != "/not/a/project" {
+			t.Errorf("expected ErrNoKoolYml for args %v; got %v", args, err)
+		}
+	}
+
+	if f.parser.(*parser.FakeParser).CalledParse {
+		t.Error("should not parse scripts without a kool.yml")
+	}
+}
+
+func TestRunListScripts(t *testing.T) {
+	f := newFakeKoolRun(nil, nil)
+	f.parser.(*parser.FakeParser).MockScripts = []string{"setup", "test"}
+	cmd := NewRunCommand(f)
+	cmd.SetArgs([]string{"--list"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error listing scripts; error: %v", err)
+	}
+
+	if output := fmt.Sprint(f.shell.(*shell.FakeShell).OutLines); output != "[setup test]" {
+		t.Errorf("expected scripts listed one per line; got %s", output)
+	}
+
+	f = newFakeKoolRun(nil, nil)
+	f.parser.(*parser.FakeParser).MockScripts = []string{"setup", "test"}
+	cmd = NewRunCommand(f)
+	cmd.SetArgs([]string{"--list", "--json"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error listing scripts as JSON; error: %v", err)
+	}
+
+	expected := `[{"name":"setup","description":"","aliases":[]},{"name":"test","description":"","aliases":[]}]`
+
+	if output := f.shell.(*shell.FakeShell).OutLines; len(output) != 1 || output[0] != expected {
+		t.Errorf("expected JSON output %s; got %v", expected, output)
+	}
+
+	if f.parser.(*parser.FakeParser).CalledParse {
+		t.Error("should not run any script when listing")
+	}
+
+	f = newFakeKoolRun(nil, nil)
+	cmd = NewRunCommand(f)
+	cmd.SetArgs([]string{"--json"})
+
+	assertExecGotError(t, cmd, "--json can only be used along with --list")
+
+	f = newFakeKoolRun(nil, nil)
+	f.parser.(*parser.FakeParser).MockAddLookupPathError = parser.ErrKoolYmlNotFound
+	cmd = NewRunCommand(f)
+	cmd.SetArgs([]string{"--list"})
+
+	assertExecGotError(t, cmd, "No kool.yml found")
+}
+
+func TestSelectEnvironment(t *testing.T) {
+	var (
+		question = "Which kool.yml environment do you want to use?"
+		fakeEnv  = environment.NewFakeEnvStorage()
+		prompt   = &shell.FakePromptSelect{MockAnswer: map[string]string{question: "ci"}}
+		p        = &parser.FakeParser{MockEnvironments: []string{"ci", "local", "staging"}}
+	)
+
+	if err := selectEnvironment(fakeEnv, p, prompt, true); err != nil {
+		t.Fatalf("unexpected error selecting environment: %v", err)
+	}
+
+	if !prompt.CalledAsk || fakeEnv.Get("KOOL_ENV") != "ci" {
+		t.Errorf("expected the chosen environment on KOOL_ENV; got '%s'", fakeEnv.Get("KOOL_ENV"))
+	}
+
+	fakeEnv = environment.NewFakeEnvStorage()
+	prompt = &shell.FakePromptSelect{}
+
+	if err := selectEnvironment(fakeEnv, p, prompt, false); err != nil || prompt.CalledAsk || fakeEnv.Get("KOOL_ENV") != "" {
+		t.Errorf("expected to stay on the local environment off a terminal; got '%s' (err: %v)", fakeEnv.Get("KOOL_ENV"), err)
+	}
+
+	for _, environments := range [][]string{nil, {"local"}} {
+		p = &parser.FakeParser{MockEnvironments: environments}
+
+		if err := selectEnvironment(fakeEnv, p, prompt, true); err != nil || prompt.CalledAsk {
+			t.Errorf("expected no prompt for environments %v (err: %v)", environments, err)
+		}
+	}
+
+	p = &parser.FakeParser{MockParseEnvironmentsError: errors.New("parse error")}
+
+	if err := selectEnvironment(fakeEnv, p, prompt, true); err != nil || prompt.CalledAsk {
+		t.Errorf("expected parse errors to be left for the command; got %v", err)
+	}
+
+	fakeEnv.Set("KOOL_ENV", "staging")
+	p = &parser.FakeParser{MockEnvironments: []string{"ci"}}
+
+	if err := selectEnvironment(fakeEnv, p, prompt, true); err != nil || prompt.CalledAsk || fakeEnv.Get("KOOL_ENV") != "staging" {
+		t.Errorf("expected no prompt with KOOL_ENV already set (err: %v)", err)
+	}
+
+	fakeEnv = environment.NewFakeEnvStorage()
+	prompt = &shell.FakePromptSelect{MockError: map[string]error{question: shell.ErrUserCancelled}}
+
+	if err := selectEnvironment(fakeEnv, p, prompt, true); !errors.Is(err, shell.ErrUserCancelled) {
+		t.Errorf("expected cancelled prompt error; got %v", err)
+	}
+}
+
+func TestRunCommandSelectsEnvironment(t *testing.T) {
+	f := newFakeKoolRun(map[string][]builder.Command{"script": {&builder.FakeCommand{MockCmd: "cmd1"}}}, nil)
+	f.shell.(*shell.FakeShell).MockIsTerminal = true
+	f.parser.(*parser.FakeParser).MockEnvironments = []string{"ci"}
+	f.promptSelect = &shell.FakePromptSelect{MockAnswer: map[string]string{"Which kool.yml environment do you want to use?": "ci"}}
+
+	cmd := NewRunCommand(f)
+	cmd.SetArgs([]string{"script"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error executing run command; error: %v", err)
+	}
+
+	if !f.promptSelect.(*shell.FakePromptSelect).CalledAsk || f.env.Get("KOOL_ENV") != "ci" {
+		t.Errorf("expected to be asked for the environment; got KOOL_ENV '%s'", f.env.Get("KOOL_ENV"))
+	}
+}
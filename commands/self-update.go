@@ -2,6 +2,9 @@ package commands
 
 import (
 	"fmt"
+	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/network"
+	"kool-dev/kool/core/shell"
 	"kool-dev/kool/services/updater"
 	"strings"
 
@@ -9,10 +12,25 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// SelfUpdateAvailableExitCode is the exit code used by self-update
+// --check-only when there is a newer version of kool available
+const SelfUpdateAvailableExitCode int = 2
+
+// KoolSelfUpdateFlags holds the flags for the self-update command
+type KoolSelfUpdateFlags struct {
+	CheckOnly bool
+	Version   string
+	Refresh   bool
+}
+
 // KoolSelfUpdate holds handlers and functions to implement the self-update command logic
 type KoolSelfUpdate struct {
 	DefaultKoolService
+	Flags   *KoolSelfUpdateFlags
 	updater updater.Updater
+	env     environment.EnvStorage
+
+	promptSelect shell.PromptSelect
 }
 
 func AddKoolSelfUpdate(root *cobra.Command) {
@@ -28,12 +46,33 @@ func AddKoolSelfUpdate(root *cobra.Command) {
 func NewKoolSelfUpdate() *KoolSelfUpdate {
 	return &KoolSelfUpdate{
 		*newDefaultKoolService(),
+		&KoolSelfUpdateFlags{false, "", false},
 		&updater.DefaultUpdater{RootCommand: rootCmd},
+		environment.NewEnvStorage(),
+		shell.NewPromptSelect(),
 	}
 }
 
 // Execute runs the self-update logic with incoming arguments.
 func (s *KoolSelfUpdate) Execute(args []string) (err error) {
+	if err = network.CheckOnline(s.env, "kool self-update"); err != nil {
+		return
+	}
+
+	if s.Flags.Refresh {
+		if err = s.updater.ClearCache(); err != nil {
+			return fmt.Errorf("kool self-update failed clearing the release cache: %v", err)
+		}
+	}
+
+	if s.Flags.CheckOnly {
+		return s.checkOnly()
+	}
+
+	if s.Flags.Version != "" {
+		return s.updateToVersion()
+	}
+
 	if err = s.updater.CheckPermission(); err != nil {
 		return
 	}
@@ -43,12 +82,7 @@ func (s *KoolSelfUpdate) Execute(args []string) (err error) {
 	currentVersion = s.updater.GetCurrentVersion()
 
 	if latestVersion, err = s.updater.Update(currentVersion); err != nil {
-		if strings.Contains(strings.ToLower(err.Error()), "permission denied") {
-			s.Shell().Warning("Error: %s", err)
-
-			return fmt.Errorf("kool self-update failed: (maybe try again with sudo)")
-		}
-		return fmt.Errorf("kool self-update failed: %v", err)
+		return s.updateFailed(err)
 	}
 
 	if latestVersion.Equals(currentVersion) {
@@ -61,18 +95,129 @@ func (s *KoolSelfUpdate) Execute(args []string) (err error) {
 	return
 }
 
+// updateToVersion installs the exact version asked with --version,
+// asking for confirmation before going back to an older release
+func (s *KoolSelfUpdate) updateToVersion() (err error) {
+	var (
+		targetVersion semver.Version
+		found         bool
+	)
+
+	if targetVersion, err = semver.Parse(strings.TrimPrefix(s.Flags.Version, "v")); err != nil {
+		return fmt.Errorf("invalid version %s: %v", s.Flags.Version, err)
+	}
+
+	if err = s.updater.CheckPermission(); err != nil {
+		return
+	}
+
+	currentVersion := s.updater.GetCurrentVersion()
+
+	if targetVersion.Equals(currentVersion) {
+		s.Shell().Warning("You already have version ", currentVersion.String())
+		return
+	}
+
+	if found, err = s.updater.HasVersion(targetVersion); err != nil {
+		return fmt.Errorf("kool self-update failed looking up version %s: %v", targetVersion, err)
+	}
+
+	if !found {
+		return fmt.Errorf("kool version %s was not found in GitHub Releases", targetVersion)
+	}
+
+	if targetVersion.LT(currentVersion) {
+		if s.Shell().IsTerminal() {
+			var confirmed bool
+
+			if confirmed, err = s.promptSelect.Confirm("Do you want to downgrade kool from %s to %s?", currentVersion, targetVersion); err != nil {
+				return
+			}
+
+			if !confirmed {
+				return shell.ErrUserCancelled
+			}
+		} else {
+			s.Shell().Warning(fmt.Sprintf("Downgrading kool from %s to %s", currentVersion, targetVersion))
+		}
+	}
+
+	if err = s.updater.UpdateToVersion(targetVersion); err != nil {
+		return s.updateFailed(err)
+	}
+
+	s.Shell().Success("Successfully updated to version ", targetVersion.String())
+	s.Shell().Println("Please run 'kool info' now to validate your local environment.")
+	return
+}
+
+// updateFailed wraps an update error, hinting at sudo when
+// the binary could not be replaced for lack of permission
+func (s *KoolSelfUpdate) updateFailed(err error) error {
+	if strings.Contains(strings.ToLower(err.Error()), "permission denied") {
+		s.Shell().Warning("Error: %s", err)
+
+		return fmt.Errorf("kool self-update failed: (maybe try again with sudo)")
+	}
+	return fmt.Errorf("kool self-update failed: %v", err)
+}
+
+// checkOnly reports whether there is a newer version available without
+// updating; the exit code tells it apart for scripts and CI pipelines
+func (s *KoolSelfUpdate) checkOnly() (err error) {
+	var currentVersion, latestVersion semver.Version
+
+	currentVersion = s.updater.GetCurrentVersion()
+
+	if latestVersion, err = s.updater.GetLatestVersion(); err != nil {
+		return fmt.Errorf("kool self-update failed checking the latest version: %v", err)
+	}
+
+	if latestVersion.GT(currentVersion) {
+		err = shell.ErrExitable{
+			Err:  fmt.Errorf("kool %s is available (current version %s)", latestVersion, currentVersion),
+			Code: SelfUpdateAvailableExitCode,
+		}
+		return
+	}
+
+	s.Shell().Println(fmt.Sprintf("kool is up to date (version %s)", currentVersion))
+	return
+}
+
 // NewSelfUpdateCommand initializes new kool self-update command
-func NewSelfUpdateCommand(selfUpdate *KoolSelfUpdate) *cobra.Command {
+func NewSelfUpdateCommand(selfUpdate *KoolSelfUpdate) (cmd *cobra.Command) {
 	selfUpdateTask := NewKoolTask("Updating kool version", selfUpdate)
 	selfUpdateTask.SetFrameOutput(false)
 
-	return &cobra.Command{
+	cmd = &cobra.Command{
 		Use:   "self-update",
 		Short: "Update kool to the latest version",
-		Long:  "Checks the latest release of Kool in GitHub Releases, and downloads and replaces the local binary if a newer version is available.",
-		Args:  cobra.NoArgs,
-		RunE:  LongTaskCommandRunFunction(selfUpdateTask),
+		Long: `Checks the latest release of Kool in GitHub Releases, and downloads and replaces the local binary if a newer version is available.
+
+With --check-only nothing is downloaded; it exits with code 0 when kool is up to date
+or with code 2 when a newer version is available.
+
+With --version a specific release is installed instead of the latest one; installing
+an older version asks for confirmation first.
+
+The latest release found is cached for an hour to avoid hitting GitHub on every
+check; use --refresh to look it up again.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if selfUpdate.Flags.CheckOnly || selfUpdate.Flags.Version != "" {
+				return DefaultCommandRunFunction(selfUpdate)(cmd, args)
+			}
+
+			return LongTaskCommandRunFunction(selfUpdateTask)(cmd, args)
+		},
 
 		DisableFlagsInUseLine: true,
 	}
+
+	cmd.Flags().BoolVarP(&selfUpdate.Flags.CheckOnly, "check-only", "", false, "Only check whether a newer version is available, without updating")
+	cmd.Flags().StringVarP(&selfUpdate.Flags.Version, "version", "", "", "Install this specific version instead of the latest one")
+	cmd.Flags().BoolVarP(&selfUpdate.Flags.Refresh, "refresh", "", false, "Look up the latest release again instead of using the cached one")
+
+	return
 }
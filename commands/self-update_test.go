@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/network"
 	"kool-dev/kool/core/shell"
 	"kool-dev/kool/services/updater"
 	"testing"
@@ -12,12 +14,15 @@ import (
 func newFakeKoolSelfUpdate(currentVersion string, latestVersion string, errU, errP error) *KoolSelfUpdate {
 	selfUpdate := &KoolSelfUpdate{
 		*(newDefaultKoolService().Fake()),
+		&KoolSelfUpdateFlags{false, "", false},
 		&updater.FakeUpdater{
 			MockCurrentVersion:  currentVersion,
 			MockLatestVersion:   latestVersion,
 			MockErrorUpdate:     errU,
 			MockErrorPermission: errP,
 		},
+		environment.NewFakeEnvStorage(),
+		&shell.FakePromptSelect{},
 	}
 
 	selfUpdate.shell.(*shell.FakeShell).MockOutStream = io.Discard
@@ -115,3 +120,197 @@ func TestNewSelfUpdatePermissionErrorCommand(t *testing.T) {
 		t.Errorf("unexpected non-error executing self-update command")
 	}
 }
+
+func TestNewSelfUpdateCheckOnlyCommand(t *testing.T) {
+	f := newFakeKoolSelfUpdate("1.0.0", "1.0.0", nil, nil)
+	cmd := NewSelfUpdateCommand(f)
+	cmd.SetArgs([]string{"--check-only"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing self-update --check-only; error: %v", err)
+	}
+
+	fakeUpdater := f.updater.(*updater.FakeUpdater)
+
+	if !fakeUpdater.CalledGetLatestVersion {
+		t.Error("did not call GetLatestVersion")
+	}
+
+	if fakeUpdater.CalledUpdate || fakeUpdater.CalledCheckPermission {
+		t.Error("should not update when checking only")
+	}
+
+	expected := "kool is up to date (version 1.0.0)"
+
+	if output := fmt.Sprint(f.shell.(*shell.FakeShell).OutLines); output != fmt.Sprint([]string{expected}) {
+		t.Errorf("expecting output '%s', got '%s'", expected, output)
+	}
+
+	f = newFakeKoolSelfUpdate("1.0.0", "1.2.0", nil, nil)
+	cmd = NewSelfUpdateCommand(f)
+	cmd.SetArgs([]string{"--check-only"})
+
+	err := cmd.Execute()
+
+	if exitable, ok := err.(shell.ErrExitable); !ok || exitable.Code != SelfUpdateAvailableExitCode {
+		t.Errorf("expected exitable error with code %d; got %v", SelfUpdateAvailableExitCode, err)
+	} else if err.Error() != "kool 1.2.0 is available (current version 1.0.0)" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+
+	if f.updater.(*updater.FakeUpdater).CalledUpdate {
+		t.Error("should not update when checking only")
+	}
+
+	f = newFakeKoolSelfUpdate("1.0.0", "1.0.0", nil, nil)
+	f.updater.(*updater.FakeUpdater).MockErrorLatest = errors.New("api error")
+	cmd = NewSelfUpdateCommand(f)
+	cmd.SetArgs([]string{"--check-only"})
+
+	assertExecGotError(t, cmd, "kool self-update failed checking the latest version: api error")
+}
+
+func TestNewSelfUpdateVersionCommand(t *testing.T) {
+	f := newFakeKoolSelfUpdate("1.0.0", "1.0.0", nil, nil)
+	cmd := NewSelfUpdateCommand(f)
+	cmd.SetArgs([]string{"--version", "v1.2.0"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing self-update --version; error: %v", err)
+	}
+
+	fakeUpdater := f.updater.(*updater.FakeUpdater)
+
+	if !fakeUpdater.CalledCheckPermission || !fakeUpdater.CalledHasVersion {
+		t.Error("should check permission and the version existence before updating")
+	}
+
+	if fakeUpdater.CalledUpdate || fakeUpdater.UpdatedToVersion != "1.2.0" {
+		t.Errorf("expected update to version 1.2.0; got '%s'", fakeUpdater.UpdatedToVersion)
+	}
+
+	if len(f.promptSelect.(*shell.FakePromptSelect).CalledConfirm) != 0 {
+		t.Error("should not ask for confirmation when upgrading")
+	}
+
+	f = newFakeKoolSelfUpdate("1.0.0", "1.0.0", nil, nil)
+	cmd = NewSelfUpdateCommand(f)
+	cmd.SetArgs([]string{"--version", "1.0.0"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing self-update --version; error: %v", err)
+	}
+
+	if f.updater.(*updater.FakeUpdater).UpdatedToVersion != "" {
+		t.Error("should not update to the current version")
+	}
+
+	f = newFakeKoolSelfUpdate("1.0.0", "1.0.0", nil, nil)
+	cmd = NewSelfUpdateCommand(f)
+	cmd.SetArgs([]string{"--version", "not-a-version"})
+
+	assertExecGotError(t, cmd, "invalid version not-a-version")
+
+	f = newFakeKoolSelfUpdate("1.0.0", "1.0.0", nil, nil)
+	f.updater.(*updater.FakeUpdater).MockVersionNotFound = true
+	cmd = NewSelfUpdateCommand(f)
+	cmd.SetArgs([]string{"--version", "9.9.9"})
+
+	assertExecGotError(t, cmd, "kool version 9.9.9 was not found in GitHub Releases")
+
+	if f.updater.(*updater.FakeUpdater).UpdatedToVersion != "" {
+		t.Error("should not update to a missing version")
+	}
+
+	f = newFakeKoolSelfUpdate("1.0.0", "1.0.0", errors.New("open kool: permission denied"), nil)
+	cmd = NewSelfUpdateCommand(f)
+	cmd.SetArgs([]string{"--version", "1.1.0"})
+
+	assertExecGotError(t, cmd, "maybe try again with sudo")
+}
+
+func TestNewSelfUpdateVersionDowngradeCommand(t *testing.T) {
+	question := "Do you want to downgrade kool from %s to %s?"
+
+	f := newFakeKoolSelfUpdate("1.2.0", "1.2.0", nil, nil)
+	f.promptSelect.(*shell.FakePromptSelect).MockConfirm = map[string]bool{question: false}
+	cmd := NewSelfUpdateCommand(f)
+	cmd.SetArgs([]string{"--version", "1.0.0"})
+
+	if err := cmd.Execute(); !errors.Is(err, shell.ErrUserCancelled) {
+		t.Errorf("expected ErrUserCancelled declining the downgrade; got %v", err)
+	}
+
+	if f.updater.(*updater.FakeUpdater).UpdatedToVersion != "" {
+		t.Error("should not downgrade without confirmation")
+	}
+
+	f = newFakeKoolSelfUpdate("1.2.0", "1.2.0", nil, nil)
+	f.promptSelect.(*shell.FakePromptSelect).MockConfirm = map[string]bool{question: true}
+	cmd = NewSelfUpdateCommand(f)
+	cmd.SetArgs([]string{"--version", "1.0.0"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error confirming the downgrade; error: %v", err)
+	}
+
+	if f.updater.(*updater.FakeUpdater).UpdatedToVersion != "1.0.0" {
+		t.Error("expected downgrade to version 1.0.0")
+	}
+
+	f = newFakeKoolSelfUpdate("1.2.0", "1.2.0", nil, nil)
+	f.shell.(*shell.FakeShell).MockIsTerminal = false
+	cmd = NewSelfUpdateCommand(f)
+	cmd.SetArgs([]string{"--version", "1.0.0"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error downgrading without a terminal; error: %v", err)
+	}
+
+	if len(f.promptSelect.(*shell.FakePromptSelect).CalledConfirm) != 0 {
+		t.Error("should not prompt without a terminal")
+	}
+
+	if f.updater.(*updater.FakeUpdater).UpdatedToVersion != "1.0.0" {
+		t.Error("expected downgrade to version 1.0.0 without a terminal")
+	}
+}
+
+func TestNewSelfUpdateRefreshCommand(t *testing.T) {
+	f := newFakeKoolSelfUpdate("1.0.0", "1.0.0", nil, nil)
+	cmd := NewSelfUpdateCommand(f)
+	cmd.SetArgs([]string{"--check-only", "--refresh"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing self-update --refresh; error: %v", err)
+	}
+
+	if !f.updater.(*updater.FakeUpdater).CalledClearCache {
+		t.Error("did not clear the release cache with --refresh")
+	}
+
+	f = newFakeKoolSelfUpdate("1.0.0", "1.0.0", nil, nil)
+	f.updater.(*updater.FakeUpdater).MockErrorClearCache = errors.New("clear cache error")
+	f.shell.(*shell.FakeShell).MockErrStream = io.Discard
+	cmd = NewSelfUpdateCommand(f)
+	cmd.SetArgs([]string{"--refresh"})
+
+	assertExecGotError(t, cmd, "clear cache error")
+
+	if f.updater.(*updater.FakeUpdater).CalledUpdate {
+		t.Error("should not update after failing to clear the release cache")
+	}
+}
+
+func TestNewSelfUpdateOfflineCommand(t *testing.T) {
+	f := newFakeKoolSelfUpdate("1.0.0", "1.1.0", nil, nil)
+	f.env.Set("KOOL_OFFLINE", "true")
+
+	if err := f.Execute(nil); !errors.Is(err, network.ErrOffline) {
+		t.Errorf("expected offline error; got %v", err)
+	}
+
+	if f.updater.(*updater.FakeUpdater).CalledUpdate || f.updater.(*updater.FakeUpdater).CalledGetLatestVersion {
+		t.Error("should not look up releases when offline")
+	}
+}
@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/shell"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// KoolServicesFlags holds the flags for the kool services command
+type KoolServicesFlags struct {
+	Format string
+}
+
+// KoolServices holds handlers and functions to list the compose services
+type KoolServices struct {
+	DefaultKoolService
+	Flags *KoolServicesFlags
+
+	list builder.Command
+}
+
+// NewKoolServices creates a new handler for listing services with default dependencies
+func NewKoolServices() *KoolServices {
+	return &KoolServices{
+		*newDefaultKoolService(),
+		&KoolServicesFlags{"plain"},
+		builder.NewComposeCommand("config", "--services"),
+	}
+}
+
+// List returns the service names defined in the resolved compose config
+func (s *KoolServices) List() (services []string, err error) {
+	var output string
+
+	if output, err = s.Shell().Exec(s.list); err != nil {
+		return
+	}
+
+	services = []string{}
+
+	for _, service := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		if service = strings.TrimSpace(service); service != "" {
+			services = append(services, service)
+		}
+	}
+
+	return
+}
+
+// Pick prompts the user to select one of the compose services
+func (s *KoolServices) Pick(prompt shell.PromptSelect) (service string, err error) {
+	var services []string
+
+	if services, err = s.List(); err != nil {
+		return
+	}
+
+	if len(services) == 0 {
+		err = errors.New("there are no services defined to pick from")
+		return
+	}
+
+	service, err = prompt.Ask("Which service do you want to use?", services)
+	return
+}
+
+// Execute prints out the services list in the requested format
+func (s *KoolServices) Execute(args []string) (err error) {
+	var (
+		services []string
+		encoded  []byte
+	)
+
+	if s.Flags.Format != "plain" && s.Flags.Format != "json" {
+		err = fmt.Errorf("invalid format '%s'; expected plain or json", s.Flags.Format)
+		return
+	}
+
+	if services, err = s.List(); err != nil {
+		return
+	}
+
+	if s.Flags.Format == "json" {
+		if encoded, err = json.Marshal(services); err != nil {
+			return
+		}
+
+		s.Shell().Println(string(encoded))
+		return
+	}
+
+	for _, service := range services {
+		s.Shell().Println(service)
+	}
+
+	return
+}
+
+// NewServicesCommand initializes new kool services command
+func NewServicesCommand(services *KoolServices) (cmd *cobra.Command) {
+	cmd = &cobra.Command{
+		Use:   "services",
+		Short: "List the services defined in docker-compose.yml",
+		Long: `List the names of the services defined in the resolved docker-compose.yml
+configuration, one per line, so it can be easily used in scripts.`,
+		Args: cobra.NoArgs,
+		RunE: DefaultCommandRunFunction(services),
+
+		DisableFlagsInUseLine: true,
+	}
+
+	cmd.Flags().StringVarP(&services.Flags.Format, "format", "", "plain", "Output format (plain or json)")
+	_ = cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"plain", "json"}, cobra.ShellCompDirectiveNoFileComp))
+
+	return
+}
+
+func AddKoolServices(root *cobra.Command) {
+	root.AddCommand(NewServicesCommand(NewKoolServices()))
+}
+
+// serviceArgs wraps the positional args validator so a missing SERVICE
+// is not an error on a terminal, where it is picked from a list instead
+func serviceArgs(service KoolService, validator cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) (err error) {
+		if err = validator(cmd, args); err != nil && len(args) == 0 && service.Shell().IsTerminal() {
+			err = nil
+		}
+		return
+	}
+}
+
+// compListServices lists the compose services for shell completion
+func compListServices(toComplete string) (services []string) {
+	all, err := NewKoolServices().List()
+
+	if err != nil {
+		return nil
+	}
+
+	for _, service := range all {
+		if strings.HasPrefix(service, toComplete) {
+			services = append(services, service)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"errors"
+	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/shell"
+	"testing"
+)
+
+func newFakeKoolServices(output string, err error) *KoolServices {
+	return &KoolServices{
+		*(newDefaultKoolService().Fake()),
+		&KoolServicesFlags{"plain"},
+		&builder.FakeCommand{MockCmd: "services", MockExecOut: output, MockExecError: err},
+	}
+}
+
+func TestNewKoolServices(t *testing.T) {
+	s := NewKoolServices()
+
+	if s.Flags.Format != "plain" {
+		t.Errorf("unexpected default format: %s", s.Flags.Format)
+	}
+
+	if s.list.String() != "docker compose config --services" {
+		t.Errorf("unexpected list command on default KoolServices instance: %s", s.list.String())
+	}
+}
+
+func TestServicesCommand(t *testing.T) {
+	services := newFakeKoolServices("app\r\ndatabase\n\ncache\n", nil)
+	cmd := NewServicesCommand(services)
+	cmd.SetArgs([]string{})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing services command; error: %v", err)
+	}
+
+	if lines := services.shell.(*shell.FakeShell).OutLines; len(lines) != 3 || lines[0] != "app" || lines[1] != "database" || lines[2] != "cache" {
+		t.Errorf("unexpected services output: %v", lines)
+	}
+}
+
+func TestServicesCommandJSON(t *testing.T) {
+	services := newFakeKoolServices("app\ndatabase", nil)
+	cmd := NewServicesCommand(services)
+	cmd.SetArgs([]string{"--format", "json"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing services command; error: %v", err)
+	}
+
+	if lines := services.shell.(*shell.FakeShell).OutLines; len(lines) != 1 || lines[0] != `["app","database"]` {
+		t.Errorf("unexpected services JSON output: %v", lines)
+	}
+
+	services = newFakeKoolServices("", nil)
+	cmd = NewServicesCommand(services)
+	cmd.SetArgs([]string{"--format", "json"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing services command; error: %v", err)
+	}
+
+	if lines := services.shell.(*shell.FakeShell).OutLines; len(lines) != 1 || lines[0] != `[]` {
+		t.Errorf("unexpected empty services JSON output: %v", lines)
+	}
+}
+
+func TestServicesCommandErrors(t *testing.T) {
+	cmd := NewServicesCommand(newFakeKoolServices("", errors.New("config error")))
+	cmd.SetArgs([]string{})
+
+	assertExecGotError(t, cmd, "config error")
+
+	cmd = NewServicesCommand(newFakeKoolServices("app", nil))
+	cmd.SetArgs([]string{"--format", "yaml"})
+
+	assertExecGotError(t, cmd, "invalid format 'yaml'")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"kool-dev/kool/core/builder"
 	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/network"
 	"regexp"
 	"strings"
 
@@ -65,7 +66,11 @@ func (s *KoolShare) validSubdomain(subdomain string) bool {
 func (s *KoolShare) Execute(args []string) (err error) {
 	var isRunning bool
 
-	if isRunning, _, _, err = s.status.getServiceInfo(s.Flags.Service); err != nil {
+	if err = network.CheckOnline(s.env, "kool share"); err != nil {
+		return
+	}
+
+	if isRunning, _, _, _, err = s.status.getServiceInfo(s.Flags.Service); err != nil {
 		return
 	}
 
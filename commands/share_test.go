@@ -105,3 +105,11 @@ func TestShareCommandSetFlags(t *testing.T) {
 		t.Error("failed setting subdomain")
 	}
 }
+
+func TestShareCommandOffline(t *testing.T) {
+	share := newFakeShareService()
+	share.env.Set("KOOL_OFFLINE", "true")
+
+	cmd := NewShareCommand(share)
+	assertExecGotError(t, cmd, "kool share needs network access")
+}
@@ -0,0 +1,161 @@
+package commands
+
+import (
+	"fmt"
+	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/shell"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// KoolShellFlags holds the flags for the kool shell command
+type KoolShellFlags struct {
+	Shell   string
+	History bool
+}
+
+// shellHistoryFile is where the shell history is kept within the container
+const shellHistoryFile = "/tmp/.kool_shell_history"
+
+// KoolShell holds handlers and functions to open an interactive
+// shell inside a service container
+type KoolShell struct {
+	DefaultKoolService
+	Flags *KoolShellFlags
+
+	exec         *KoolExec
+	services     *KoolServices
+	promptSelect shell.PromptSelect
+	env          environment.EnvStorage
+	copy         builder.Command
+}
+
+// NewKoolShell creates a new handler for the shell logic
+func NewKoolShell() *KoolShell {
+	return &KoolShell{
+		*newDefaultKoolService(),
+		&KoolShellFlags{"", false},
+		NewKoolExec(),
+		NewKoolServices(),
+		shell.NewPromptSelect(),
+		environment.NewEnvStorage(),
+		builder.NewComposeCommand("cp", "--archive"),
+	}
+}
+
+func AddKoolShell(root *cobra.Command) {
+	root.AddCommand(NewShellCommand(NewKoolShell()))
+}
+
+// Execute runs the shell logic with incoming arguments.
+func (s *KoolShell) Execute(args []string) (err error) {
+	var (
+		service  string
+		shellBin = s.Flags.Shell
+	)
+
+	if len(args) > 0 {
+		service = args[0]
+	} else if service, err = s.services.Pick(s.promptSelect); err != nil {
+		return
+	}
+
+	s.exec.Shell().SetInStream(s.Shell().InStream())
+	s.exec.Shell().SetOutStream(s.Shell().OutStream())
+	s.exec.Shell().SetErrStream(s.Shell().ErrStream())
+
+	if shellBin == "" {
+		if shellBin, err = s.exec.DetectShell(service); err != nil {
+			return
+		}
+	}
+
+	if !s.Flags.History && !s.env.IsTrue("KOOL_SHELL_HISTORY") {
+		err = s.exec.Execute([]string{service, shellBin})
+		return
+	}
+
+	var historyFile string
+
+	if historyFile, err = s.historyFile(service); err != nil {
+		return
+	}
+
+	// the history is copied in and out of the container since
+	// it is already running and no volume can be mounted on it
+	if _, err = s.Shell().Exec(s.copy, historyFile, service+":"+shellHistoryFile); err != nil {
+		err = fmt.Errorf("failed copying the shell history into service %s: %v", service, err)
+		return
+	}
+
+	s.exec.Flags.EnvVariables = append(s.exec.Flags.EnvVariables,
+		"HISTFILE="+shellHistoryFile,
+		"SAVEHIST=10000",
+	)
+
+	err = s.exec.Execute([]string{service, shellBin})
+
+	if _, copyErr := s.Shell().Exec(s.copy, service+":"+shellHistoryFile, historyFile); copyErr != nil {
+		s.Shell().Warning(fmt.Sprintf("failed saving the shell history of service %s: %v", service, copyErr))
+	}
+	return
+}
+
+// historyFile returns the host file keeping the shell history of
+// the service for the current project, creating it when missing
+func (s *KoolShell) historyFile(service string) (historyFile string, err error) {
+	var (
+		project = s.env.Get("COMPOSE_PROJECT_NAME")
+		file    *os.File
+	)
+
+	if project == "" {
+		project = s.env.Get("KOOL_NAME")
+	}
+
+	historyFile = filepath.Join(s.env.Get("HOME"), "kool", "history", fmt.Sprintf("%s_%s", project, service))
+
+	if err = os.MkdirAll(filepath.Dir(historyFile), 0755); err != nil {
+		return
+	}
+
+	if file, err = os.OpenFile(historyFile, os.O_CREATE|os.O_RDONLY, 0600); err != nil {
+		return
+	}
+
+	err = file.Close()
+	return
+}
+
+// NewShellCommand initializes new kool shell command
+func NewShellCommand(shell *KoolShell) (shellCmd *cobra.Command) {
+	shellCmd = &cobra.Command{
+		Use:   "shell SERVICE",
+		Short: "Open an interactive shell inside a running service container",
+		Long: `Open an interactive shell inside the specified SERVICE container. The first
+shell available among bash, zsh and sh is used, unless one is picked with --shell.
+When SERVICE is omitted on a terminal, it can be picked from the services list.
+
+With --history (or KOOL_SHELL_HISTORY=true), the shell history is kept on the host
+under ~/kool/history and carried over between sessions.`,
+		Args: serviceArgs(shell, cobra.ExactArgs(1)),
+		RunE: DefaultCommandRunFunction(shell),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			return compListServices(toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+
+		DisableFlagsInUseLine: true,
+	}
+
+	shellCmd.Flags().StringVarP(&shell.Flags.Shell, "shell", "s", "", "Shell to open instead of probing for bash, zsh or sh")
+	shellCmd.Flags().BoolVarP(&shell.Flags.History, "history", "", false, "Keep the shell history across sessions")
+	_ = shellCmd.RegisterFlagCompletionFunc("shell", cobra.FixedCompletions(shellCandidates, cobra.ShellCompDirectiveNoFileComp))
+	return
+}
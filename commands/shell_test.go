@@ -0,0 +1,199 @@
+package commands
+
+import (
+	"errors"
+	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/shell"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newFakeKoolShell() *KoolShell {
+	return &KoolShell{
+		*(newDefaultKoolService().Fake()),
+		&KoolShellFlags{"", false},
+		newFakeKoolExec(),
+		newFakeKoolServices("app\ndatabase", nil),
+		&shell.FakePromptSelect{},
+		environment.NewFakeEnvStorage(),
+		&builder.FakeCommand{MockCmd: "cp"},
+	}
+}
+
+func TestNewKoolShell(t *testing.T) {
+	k := NewKoolShell()
+
+	if _, ok := k.DefaultKoolService.shell.(*shell.DefaultShell); !ok {
+		t.Errorf("unexpected shell.Shell on default KoolShell instance")
+	}
+
+	if k.Flags == nil || k.Flags.Shell != "" {
+		t.Errorf("bad default Flags on default KoolShell instance")
+	}
+
+	if k.exec == nil {
+		t.Errorf("exec handler not initialized on default KoolShell instance")
+	}
+
+	if k.services == nil {
+		t.Errorf("services handler not initialized on default KoolShell instance")
+	}
+
+	if _, ok := k.promptSelect.(*shell.DefaultPromptSelect); !ok {
+		t.Errorf("unexpected shell.PromptSelect on default KoolShell instance")
+	}
+}
+
+func TestNewShellCommand(t *testing.T) {
+	f := newFakeKoolShell()
+	cmd := NewShellCommand(f)
+	cmd.SetArgs([]string{"app"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error executing shell command; error: %v", err)
+	}
+
+	fakeShell := f.exec.shell.(*shell.FakeShell)
+
+	if !fakeShell.CalledInteractive["exec"] {
+		t.Fatal("did not call Interactive on exec command")
+	}
+
+	if args := strings.Join(fakeShell.ArgsInteractive["exec"], " "); args != "app bash" {
+		t.Errorf("expected to open 'bash' on service app; got '%s'", args)
+	}
+}
+
+func TestShellFlagNewShellCommand(t *testing.T) {
+	f := newFakeKoolShell()
+	f.exec.composeExec.(*builder.FakeCommand).MockExecError = errors.New("probe error")
+	cmd := NewShellCommand(f)
+	cmd.SetArgs([]string{"--shell", "fish", "app"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error executing shell command; error: %v", err)
+	}
+
+	if args := strings.Join(f.exec.shell.(*shell.FakeShell).ArgsInteractive["exec"], " "); args != "app fish" {
+		t.Errorf("expected to open 'fish' on service app; got '%s'", args)
+	}
+}
+
+func TestNoShellFoundNewShellCommand(t *testing.T) {
+	f := newFakeKoolShell()
+	f.exec.composeExec.(*builder.FakeCommand).MockExecError = errors.New("probe error")
+	cmd := NewShellCommand(f)
+	cmd.SetArgs([]string{"app"})
+
+	assertExecGotError(t, cmd, "use --shell to pick one")
+
+	if f.exec.shell.(*shell.FakeShell).CalledInteractive["exec"] {
+		t.Error("should not open a shell when none was found")
+	}
+}
+
+func TestPickServiceNewShellCommand(t *testing.T) {
+	f := newFakeKoolShell()
+	f.promptSelect.(*shell.FakePromptSelect).MockAnswer = map[string]string{
+		"Which service do you want to use?": "database",
+	}
+	cmd := NewShellCommand(f)
+	cmd.SetArgs([]string{})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error executing shell command; error: %v", err)
+	}
+
+	if !f.promptSelect.(*shell.FakePromptSelect).CalledAsk {
+		t.Error("did not prompt for the service")
+	}
+
+	if args := strings.Join(f.exec.shell.(*shell.FakeShell).ArgsInteractive["exec"], " "); args != "database bash" {
+		t.Errorf("expected to open 'bash' on service database; got '%s'", args)
+	}
+}
+
+func TestPickServiceNoServicesNewShellCommand(t *testing.T) {
+	f := newFakeKoolShell()
+	f.services = newFakeKoolServices("", nil)
+	cmd := NewShellCommand(f)
+	cmd.SetArgs([]string{})
+
+	assertExecGotError(t, cmd, "there are no services defined to pick from")
+}
+
+func TestNonTerminalNoServiceNewShellCommand(t *testing.T) {
+	f := newFakeKoolShell()
+	f.shell.(*shell.FakeShell).MockIsTerminal = false
+	cmd := NewShellCommand(f)
+	cmd.SetArgs([]string{})
+
+	assertExecGotError(t, cmd, "accepts 1 arg(s), received 0")
+
+	if f.promptSelect.(*shell.FakePromptSelect).CalledAsk {
+		t.Error("should not prompt for the service on non-TTY")
+	}
+}
+
+func TestHistoryNewShellCommand(t *testing.T) {
+	f := newFakeKoolShell()
+	home := t.TempDir()
+	f.env.Set("HOME", home)
+	f.env.Set("KOOL_NAME", "project")
+	cmd := NewShellCommand(f)
+	cmd.SetArgs([]string{"--history", "app"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error executing shell command; error: %v", err)
+	}
+
+	historyFile := filepath.Join(home, "kool", "history", "project_app")
+
+	if _, err := os.Stat(historyFile); err != nil {
+		t.Errorf("expected history file %s to be created; error: %v", historyFile, err)
+	}
+
+	if !f.shell.(*shell.FakeShell).CalledExec["cp"] {
+		t.Error("did not copy the shell history")
+	}
+
+	if envs := strings.Join(f.exec.Flags.EnvVariables, " "); !strings.Contains(envs, "HISTFILE="+shellHistoryFile) {
+		t.Errorf("expected HISTFILE to be set; got '%s'", envs)
+	}
+
+	if args := strings.Join(f.exec.shell.(*shell.FakeShell).ArgsInteractive["exec"], " "); !strings.HasSuffix(args, "app bash") {
+		t.Errorf("expected to open 'bash' on service app; got '%s'", args)
+	}
+}
+
+func TestHistoryConfigNewShellCommand(t *testing.T) {
+	f := newFakeKoolShell()
+	f.env.Set("HOME", t.TempDir())
+	f.env.Set("KOOL_SHELL_HISTORY", "true")
+	f.copy.(*builder.FakeCommand).MockExecError = errors.New("cp error")
+	cmd := NewShellCommand(f)
+	cmd.SetArgs([]string{"app"})
+
+	assertExecGotError(t, cmd, "failed copying the shell history into service app")
+
+	if f.exec.shell.(*shell.FakeShell).CalledInteractive["exec"] {
+		t.Error("should not open a shell when the history could not be copied")
+	}
+}
+
+func TestNoHistoryNewShellCommand(t *testing.T) {
+	f := newFakeKoolShell()
+	cmd := NewShellCommand(f)
+	cmd.SetArgs([]string{"app"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error executing shell command; error: %v", err)
+	}
+
+	if f.shell.(*shell.FakeShell).CalledExec["cp"] {
+		t.Error("should not copy the shell history unless asked to")
+	}
+}
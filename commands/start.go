@@ -1,22 +1,35 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
 	"kool-dev/kool/core/builder"
 	"kool-dev/kool/core/environment"
 	"kool-dev/kool/core/network"
+	"kool-dev/kool/core/shell"
 	"kool-dev/kool/services/checker"
 	"kool-dev/kool/services/updater"
+	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
 )
 
+// pullSizeThreshold is the estimated images download size
+// over which kool start --pull-estimate asks for confirmation
+var pullSizeThreshold int64 = 1 << 30
+
 // KoolStartFlags holds the flags for the kool start command
 type KoolStartFlags struct {
-	Foreground bool
-	Rebuild    bool
-	Profile    string
+	Foreground    bool
+	Rebuild       bool
+	Profile       string
+	ForceRecreate bool
+	NoPortCheck   bool
+	PullEstimate  bool
+	RemoveOrphans bool
+	Attach        []string
 }
 
 // KoolStart holds handlers and functions for starting containers logic
@@ -28,8 +41,18 @@ type KoolStart struct {
 	net        network.Handler
 	envStorage environment.EnvStorage
 	start      builder.Command
-
-	rebuilder KoolService
+	running    builder.Command
+	config     builder.Command
+	portOwner  builder.Command
+	services   builder.Command
+	containers builder.Command
+
+	images       builder.Command
+	imageInspect builder.Command
+	manifest     builder.Command
+
+	promptSelect shell.PromptSelect
+	rebuilder    KoolService
 }
 
 // KoolRebuild holds handlers for updating the service's images
@@ -42,12 +65,31 @@ type KoolRebuild struct {
 // NewStartCommand initializes new kool start Cobra command
 func NewStartCommand(start *KoolStart) (startCmd *cobra.Command) {
 	startCmd = &cobra.Command{
-		Use:        "start [SERVICE...]",
-		SuggestFor: []string{"up"},
-		Short:      "Start service containers defined in docker-compose.yml",
+		Use:     "start [SERVICE...]",
+		Aliases: []string{"up"},
+		Short:   "Start service containers defined in docker-compose.yml",
 		Long: `Start one or more specified [SERVICE] containers. If no [SERVICE] is provided,
-all containers are started. If the containers are already running, they are recreated.`,
+all containers are started. Containers already running and up to date are left
+untouched, unless --force-recreate is given.
+
+Before starting, the host ports published by the services are checked for
+conflicts with other processes or containers; use --no-port-check to skip it.
+
+Containers left over from services no longer in the compose file are reported;
+on a terminal you are asked whether to remove them, otherwise start fails unless
+--remove-orphans is given to remove them along.
+
+With --attach, only the output of the given services is streamed, while the
+others run quietly; it implies --foreground.
+
+With --pull-estimate, the download size of the images still to be pulled is
+estimated from the registry and, when large, confirmation is asked before going on.
+
+'kool up' is an alias for this command and accepts the very same flags.`,
 		RunE: DefaultCommandRunFunction(CheckNewVersion(start, &updater.DefaultUpdater{RootCommand: rootCmd}, version == DEV_VERSION)),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return compListServices(toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
 
 		DisableFlagsInUseLine: true,
 	}
@@ -55,6 +97,11 @@ all containers are started. If the containers are already running, they are recr
 	startCmd.Flags().BoolVarP(&start.Flags.Foreground, "foreground", "f", false, "Start containers in foreground mode")
 	startCmd.Flags().BoolVarP(&start.Flags.Rebuild, "rebuild", "b", false, "Updates and builds service's images")
 	startCmd.Flags().StringVarP(&start.Flags.Profile, "profile", "", "", "Specify a profile to enable")
+	startCmd.Flags().BoolVarP(&start.Flags.ForceRecreate, "force-recreate", "", false, "Recreate containers even if they are already running")
+	startCmd.Flags().BoolVarP(&start.Flags.NoPortCheck, "no-port-check", "", false, "Skip checking whether the host ports to be published are already in use")
+	startCmd.Flags().BoolVarP(&start.Flags.PullEstimate, "pull-estimate", "", false, "Estimate the download size of the images to be pulled, asking to confirm large ones")
+	startCmd.Flags().BoolVarP(&start.Flags.RemoveOrphans, "remove-orphans", "", false, "Remove containers for services no longer defined in the compose file")
+	startCmd.Flags().StringArrayVarP(&start.Flags.Attach, "attach", "", []string{}, "Only stream the output of the given service, in foreground mode (can be repeated)")
 
 	return
 }
@@ -65,15 +112,24 @@ func NewKoolStart() *KoolStart {
 	defaultKoolService := newDefaultKoolService()
 	return &KoolStart{
 		*defaultKoolService,
-		&KoolStartFlags{false, false, ""},
+		&KoolStartFlags{false, false, "", false, false, false, false, []string{}},
 		checker.NewChecker(defaultKoolService.shell),
 		network.NewHandler(defaultKoolService.shell),
 		environment.NewEnvStorage(),
-		builder.NewCommand("docker", "compose", "up", "--force-recreate"),
+		builder.NewComposeCommand("up"),
+		builder.NewComposeCommand("ps", "--services", "--filter", "status=running"),
+		builder.NewComposeCommand("config", "--format", "json"),
+		builder.NewCommand("docker", "ps", "--format", "{{.Names}}"),
+		builder.NewComposeCommand("config", "--services"),
+		builder.NewComposeCommand("ps", "-a", "--format", "{{.Service}}"),
+		builder.NewComposeCommand("config", "--images"),
+		builder.NewCommand("docker", "image", "inspect", "--format", "{{.Id}}"),
+		builder.NewCommand("docker", "manifest", "inspect", "--verbose"),
+		shell.NewPromptSelect(),
 		&KoolRebuild{
 			*newDefaultKoolService(),
-			builder.NewCommand("docker", "compose", "pull"),
-			builder.NewCommand("docker", "compose", "build", "--pull"),
+			builder.NewComposeCommand("pull"),
+			builder.NewComposeCommand("build", "--pull"),
 		},
 	}
 }
@@ -95,6 +151,10 @@ func (r *KoolRebuild) Execute(args []string) (err error) {
 // Execute runs the start logic with incoming arguments
 func (s *KoolStart) Execute(args []string) (err error) {
 	if s.Flags.Rebuild {
+		if err = network.CheckOnline(s.envStorage, "pulling images for --rebuild"); err != nil {
+			return
+		}
+
 		if err = s.rebuild(); err != nil {
 			return
 		}
@@ -104,10 +164,31 @@ func (s *KoolStart) Execute(args []string) (err error) {
 		s.start.AppendArgs("--profile", s.Flags.Profile)
 	}
 
+	if len(s.Flags.Attach) > 0 {
+		if err = s.checkAttach(); err != nil {
+			return
+		}
+
+		s.Flags.Foreground = true
+
+		for _, service := range s.Flags.Attach {
+			s.start.AppendArgs("--attach", service)
+		}
+	}
+
 	if !s.Flags.Foreground {
 		s.start.AppendArgs("-d")
 	}
 
+	if s.Flags.ForceRecreate {
+		s.start.AppendArgs("--force-recreate")
+	}
+
+	if network.Offline(s.envStorage) {
+		// only images already available locally can be used
+		s.start.AppendArgs("--pull", "never")
+	}
+
 	if err = s.checkDependencies(); err != nil {
 		if strings.HasPrefix(err.Error(), "no configuration file provided: not found") {
 			err = fmt.Errorf("could not find docker-compose.yml - check your current working directory.\n\n[err: %v]", err)
@@ -115,7 +196,421 @@ func (s *KoolStart) Execute(args []string) (err error) {
 		return
 	}
 
-	err = s.Shell().Interactive(s.start, args...)
+	var running []string
+
+	if s.Flags.PullEstimate {
+		if err = network.CheckOnline(s.envStorage, "estimating the images download size"); err != nil {
+			return
+		}
+
+		if err = s.checkPullSize(); err != nil {
+			return
+		}
+	}
+
+	if err = s.checkOrphans(); err != nil {
+		return
+	}
+
+	// running services keep their ports, so those are not conflicts
+	if !s.Flags.ForceRecreate || !s.Flags.NoPortCheck {
+		running = s.runningServices()
+	}
+
+	if !s.Flags.ForceRecreate {
+		s.reportRunning(args, running)
+	}
+
+	if !s.Flags.NoPortCheck {
+		if err = s.checkPorts(args, running); err != nil {
+			return
+		}
+	}
+
+	if err = s.Shell().Interactive(s.start, args...); err != nil {
+		return
+	}
+
+	if !s.Flags.Foreground {
+		shell.EmitEvent(shell.Event{Type: shell.EventServiceUp, Services: args})
+	}
+
+	return
+}
+
+// checkAttach makes sure the services given to --attach are defined
+func (s *KoolStart) checkAttach() (err error) {
+	var (
+		output  string
+		defined = make(map[string]bool)
+		unknown []string
+	)
+
+	if output, err = s.Shell().Exec(s.services); err != nil {
+		return
+	}
+
+	for _, service := range strings.Fields(output) {
+		defined[service] = true
+	}
+
+	for _, service := range s.Flags.Attach {
+		if !defined[service] {
+			unknown = append(unknown, service)
+		}
+	}
+
+	if len(unknown) > 0 {
+		err = fmt.Errorf("unknown service(s) to attach to: %s; check the services with 'kool services'", strings.Join(unknown, ", "))
+	}
+
+	return
+}
+
+// checkOrphans looks for containers of the project whose services are
+// no longer defined in the compose file; they get removed along the start
+// with --remove-orphans, or upon confirmation on a terminal
+func (s *KoolStart) checkOrphans() (err error) {
+	var (
+		orphans []string
+		remove  bool
+	)
+
+	if s.Flags.RemoveOrphans {
+		s.start.AppendArgs("--remove-orphans")
+		return
+	}
+
+	if orphans = s.orphanServices(); len(orphans) == 0 {
+		return
+	}
+
+	message := fmt.Sprintf("Found containers for services no longer in the compose file: %s", strings.Join(orphans, ", "))
+
+	if !s.Shell().IsTerminal() {
+		err = fmt.Errorf("%s; use --remove-orphans to remove them", message)
+		return
+	}
+
+	s.Shell().Warning(message)
+
+	if remove, err = s.promptSelect.Confirm("Do you want to remove them?"); err != nil {
+		return
+	}
+
+	if remove {
+		s.start.AppendArgs("--remove-orphans")
+	}
+
+	return
+}
+
+// orphanServices lists the services which still have containers of the
+// project but are not defined in the compose file anymore; failing to
+// read the config or the containers is not critical
+func (s *KoolStart) orphanServices() (orphans []string) {
+	var (
+		output  string
+		err     error
+		defined = make(map[string]bool)
+		found   = make(map[string]bool)
+	)
+
+	if output, err = s.Shell().Exec(s.services); err != nil {
+		return
+	}
+
+	for _, service := range strings.Fields(output) {
+		defined[service] = true
+	}
+
+	if len(defined) == 0 {
+		return
+	}
+
+	if output, err = s.Shell().Exec(s.containers); err != nil {
+		return
+	}
+
+	for _, service := range strings.Fields(output) {
+		if !defined[service] && !found[service] {
+			found[service] = true
+			orphans = append(orphans, service)
+		}
+	}
+
+	sort.Strings(orphans)
+	return
+}
+
+// checkPullSize estimates the download size of the images not yet
+// pulled, asking whether to go on when it is over pullSizeThreshold;
+// images the registry does not report sizes for are left out
+func (s *KoolStart) checkPullSize() (err error) {
+	var (
+		output  string
+		total   int64
+		missing int
+		proceed bool
+	)
+
+	if output, err = s.Shell().Exec(s.images); err != nil {
+		// not being able to list the images should not prevent
+		// starting, docker compose itself will report it
+		err = nil
+		return
+	}
+
+	for _, image := range strings.Fields(output) {
+		if _, inspectErr := s.Shell().Exec(s.imageInspect, image); inspectErr == nil {
+			continue
+		}
+
+		missing++
+
+		if size, ok := s.imageSize(image); ok {
+			total += size
+		}
+	}
+
+	if total == 0 {
+		return
+	}
+
+	s.Shell().Info(fmt.Sprintf("About %s of images to pull for %d image(s)", formatSize(total), missing))
+
+	if total < pullSizeThreshold {
+		return
+	}
+
+	if !s.Shell().IsTerminal() {
+		s.Shell().Warning(fmt.Sprintf("Pulling a large amount of data (about %s)", formatSize(total)))
+		return
+	}
+
+	if proceed, err = s.promptSelect.Confirm("Do you want to download about %s of images?", formatSize(total)); err != nil {
+		return
+	}
+
+	if !proceed {
+		err = shell.ErrUserCancelled
+	}
+
+	return
+}
+
+// imageSize sums the compressed layers size of the image manifest
+// for the current platform, as reported by the registry
+func (s *KoolStart) imageSize(image string) (size int64, ok bool) {
+	type platformManifest struct {
+		Descriptor struct {
+			Platform struct {
+				Architecture string `json:"architecture"`
+				OS           string `json:"os"`
+			} `json:"platform"`
+		} `json:"Descriptor"`
+		SchemaV2Manifest struct {
+			Layers []struct {
+				Size int64 `json:"size"`
+			} `json:"layers"`
+		} `json:"SchemaV2Manifest"`
+	}
+
+	var (
+		output    string
+		err       error
+		manifests []platformManifest
+		single    platformManifest
+	)
+
+	if output, err = s.Shell().Exec(s.manifest, image); err != nil {
+		return
+	}
+
+	// multi-platform images report a list of manifests
+	if err = json.Unmarshal([]byte(output), &manifests); err != nil {
+		if err = json.Unmarshal([]byte(output), &single); err != nil {
+			return
+		}
+
+		manifests = []platformManifest{single}
+	}
+
+	for _, manifest := range manifests {
+		platform := manifest.Descriptor.Platform
+
+		if len(manifests) > 1 && (platform.OS != "linux" || platform.Architecture != runtime.GOARCH) {
+			continue
+		}
+
+		for _, layer := range manifest.SchemaV2Manifest.Layers {
+			size += layer.Size
+		}
+
+		ok = size > 0
+		return
+	}
+
+	return
+}
+
+// formatSize formats the bytes size for humans
+func formatSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// runningServices lists the services already running; failing
+// to fetch them is not critical
+func (s *KoolStart) runningServices() (running []string) {
+	output, err := s.Shell().Exec(s.running)
+
+	if err != nil {
+		return
+	}
+
+	for _, service := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		if service = strings.TrimSpace(service); service != "" {
+			running = append(running, service)
+		}
+	}
+
+	return
+}
+
+// reportRunning prints out the services already running which won't be recreated
+func (s *KoolStart) reportRunning(services []string, running []string) {
+	var (
+		wanted    = make(map[string]bool)
+		untouched []string
+	)
+
+	for _, service := range services {
+		wanted[service] = true
+	}
+
+	for _, service := range running {
+		if len(wanted) == 0 || wanted[service] {
+			untouched = append(untouched, service)
+		}
+	}
+
+	if len(untouched) > 0 {
+		s.Shell().Info(fmt.Sprintf("Already running (use --force-recreate to recreate): %s", strings.Join(untouched, ", ")))
+	}
+}
+
+// checkPorts looks for host ports to be published by the services which
+// are already in use, asking whether to start anyway on a terminal
+func (s *KoolStart) checkPorts(services []string, running []string) (err error) {
+	var (
+		conflicts = s.portConflicts(services, running)
+		proceed   bool
+	)
+
+	if len(conflicts) == 0 {
+		return
+	}
+
+	message := fmt.Sprintf("Host port(s) already in use: %s", strings.Join(conflicts, "; "))
+
+	if !s.Shell().IsTerminal() {
+		err = fmt.Errorf("%s; free them up or use --no-port-check to skip this check", message)
+		return
+	}
+
+	s.Shell().Warning(message)
+
+	if proceed, err = s.promptSelect.Confirm("Do you want to start the services anyway?"); err != nil {
+		return
+	}
+
+	if !proceed {
+		err = shell.ErrUserCancelled
+	}
+
+	return
+}
+
+// portConflicts lists the host ports published by the services to be
+// started (but not yet running) which are taken, along with the container
+// holding it when that is the case; failing to read the config is not
+// critical, as docker compose itself will report it
+func (s *KoolStart) portConflicts(services []string, running []string) (conflicts []string) {
+	var (
+		output   string
+		err      error
+		names    []string
+		skip     = make(map[string]bool)
+		wanted   = make(map[string]bool)
+		composed struct {
+			Services map[string]struct {
+				Ports []struct {
+					HostIP    string      `json:"host_ip"`
+					Published interface{} `json:"published"`
+					Protocol  string      `json:"protocol"`
+				} `json:"ports"`
+			} `json:"services"`
+		}
+	)
+
+	if output, err = s.Shell().Exec(s.config); err != nil {
+		return
+	}
+
+	if err = json.Unmarshal([]byte(output), &composed); err != nil {
+		return
+	}
+
+	for _, service := range running {
+		skip[service] = true
+	}
+
+	for _, service := range services {
+		wanted[service] = true
+	}
+
+	for name := range composed.Services {
+		if !skip[name] && (len(wanted) == 0 || wanted[name]) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, port := range composed.Services[name].Ports {
+			published := fmt.Sprint(port.Published)
+
+			if port.Published == nil || published == "" || (port.Protocol != "" && port.Protocol != "tcp") {
+				continue
+			}
+
+			if !network.PortInUse(port.HostIP, published) {
+				continue
+			}
+
+			conflict := fmt.Sprintf("%s (service %s)", published, name)
+
+			if owner, ownerErr := s.Shell().Exec(s.portOwner, "--filter", "publish="+published); ownerErr == nil && strings.TrimSpace(owner) != "" {
+				conflict = fmt.Sprintf("%s (service %s, taken by container %s)", published, name, strings.Join(strings.Fields(owner), ", "))
+			}
+
+			conflicts = append(conflicts, conflict)
+		}
+	}
+
 	return
 }
 
@@ -3,12 +3,15 @@ package commands
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"kool-dev/kool/core/builder"
 	"kool-dev/kool/core/environment"
 	"kool-dev/kool/core/network"
 	"kool-dev/kool/core/shell"
 	"kool-dev/kool/services/checker"
+	"net"
+	"runtime"
 	"strings"
 	"testing"
 
@@ -29,6 +32,15 @@ func newFakeKoolStart() *KoolStart {
 		&network.FakeHandler{},
 		environment.NewFakeEnvStorage(),
 		&builder.FakeCommand{MockCmd: "start"},
+		&builder.FakeCommand{MockCmd: "running"},
+		&builder.FakeCommand{MockCmd: "config"},
+		&builder.FakeCommand{MockCmd: "port-owner"},
+		&builder.FakeCommand{MockCmd: "services"},
+		&builder.FakeCommand{MockCmd: "containers"},
+		&builder.FakeCommand{MockCmd: "images"},
+		&builder.FakeCommand{MockCmd: "image-inspect"},
+		&builder.FakeCommand{MockCmd: "manifest"},
+		&shell.FakePromptSelect{},
 		&KoolRebuild{
 			*newFakedKoolServiceWithStderr(),
 			&builder.FakeCommand{MockCmd: "pull"},
@@ -53,6 +65,19 @@ func TestStartAllCommand(t *testing.T) {
 	}
 }
 
+func TestStartCommandUpAlias(t *testing.T) {
+	root := NewRootCmd(environment.NewFakeEnvStorage())
+	root.AddCommand(NewStartCommand(newFakeKoolStart()))
+
+	cmd, _, err := root.Find([]string{"up", "--rebuild"})
+
+	if err != nil || cmd.Name() != "start" {
+		t.Errorf("expected 'up' to be an alias for start; got %v (err: %v)", cmd, err)
+	} else if cmd.Flags().Lookup("rebuild") == nil {
+		t.Error("expected 'up' alias to share start flags")
+	}
+}
+
 func TestStartForegroundFlag(t *testing.T) {
 	koolStart := newFakeKoolStart()
 
@@ -78,6 +103,45 @@ func TestStartForegroundFlag(t *testing.T) {
 	}
 }
 
+func TestStartSkipsRunningServices(t *testing.T) {
+	koolStart := newFakeKoolStart()
+	koolStart.running.(*builder.FakeCommand).MockExecOut = "app\ndatabase"
+
+	if err := koolStart.Execute([]string{"app", "cache"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, arg := range koolStart.start.(*builder.FakeCommand).ArgsAppend {
+		if arg == "--force-recreate" {
+			t.Error("should not force recreating containers by default")
+		}
+	}
+
+	fakeShell := koolStart.shell.(*shell.FakeShell)
+	expected := "Already running (use --force-recreate to recreate): app"
+
+	if !fakeShell.CalledInfo || len(fakeShell.InfoOutput) == 0 || fakeShell.InfoOutput[0] != expected {
+		t.Errorf("expected info '%s'; got %v", expected, fakeShell.InfoOutput)
+	}
+
+	koolStart = newFakeKoolStart()
+	koolStart.running.(*builder.FakeCommand).MockExecOut = "app"
+	koolStart.Flags.ForceRecreate = true
+	koolStart.Flags.NoPortCheck = true
+
+	if err := koolStart.Execute(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if args := koolStart.start.(*builder.FakeCommand).ArgsAppend; len(args) != 2 || args[1] != "--force-recreate" {
+		t.Errorf("expected --force-recreate on start; got %v", args)
+	}
+
+	if koolStart.shell.(*shell.FakeShell).CalledExec["running"] {
+		t.Error("should not check running services when forcing recreation without port check")
+	}
+}
+
 func TestStartRebuildFlag(t *testing.T) {
 	koolStart := newFakeKoolStart()
 
@@ -195,3 +259,316 @@ func startedServicesAreEqual(a, b []string) bool {
 	}
 	return true
 }
+
+func newFakeKoolStartWithPort(t *testing.T) (koolStart *KoolStart, port string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		listener.Close()
+	})
+
+	_, port, _ = net.SplitHostPort(listener.Addr().String())
+
+	koolStart = newFakeKoolStart()
+	koolStart.config.(*builder.FakeCommand).MockExecOut = fmt.Sprintf(
+		`{"services":{"app":{"ports":[{"host_ip":"127.0.0.1","published":"%s","protocol":"tcp","target":80}]},"database":{}}}`,
+		port,
+	)
+	return
+}
+
+func TestStartPortConflictNonTerminal(t *testing.T) {
+	koolStart, port := newFakeKoolStartWithPort(t)
+	koolStart.shell.(*shell.FakeShell).MockIsTerminal = false
+	koolStart.portOwner.(*builder.FakeCommand).MockExecOut = "other_app_1\n"
+
+	err := koolStart.Execute(nil)
+
+	expected := fmt.Sprintf("Host port(s) already in use: %s (service app, taken by container other_app_1)", port)
+	if err == nil || !strings.Contains(err.Error(), expected) || !strings.Contains(err.Error(), "--no-port-check") {
+		t.Errorf("expected port conflict error '%s'; got %v", expected, err)
+	}
+
+	if koolStart.shell.(*shell.FakeShell).CalledInteractive["start"] {
+		t.Error("should not start the services on port conflicts")
+	}
+}
+
+func TestStartPortConflictPrompt(t *testing.T) {
+	koolStart, _ := newFakeKoolStartWithPort(t)
+
+	if err := koolStart.Execute(nil); !errors.Is(err, shell.ErrUserCancelled) {
+		t.Errorf("expected start to be cancelled; got %v", err)
+	}
+
+	if len(koolStart.promptSelect.(*shell.FakePromptSelect).CalledConfirm) != 1 {
+		t.Error("expected to ask whether to start anyway")
+	}
+
+	koolStart, _ = newFakeKoolStartWithPort(t)
+	koolStart.promptSelect.(*shell.FakePromptSelect).MockConfirm = map[string]bool{
+		"Do you want to start the services anyway?": true,
+	}
+
+	if err := koolStart.Execute(nil); err != nil {
+		t.Errorf("unexpected error starting anyway; error: %v", err)
+	}
+
+	if !koolStart.shell.(*shell.FakeShell).CalledInteractive["start"] {
+		t.Error("expected to start the services anyway")
+	}
+}
+
+func TestStartPortConflictSkipped(t *testing.T) {
+	koolStart, _ := newFakeKoolStartWithPort(t)
+	koolStart.running.(*builder.FakeCommand).MockExecOut = "app"
+
+	if err := koolStart.Execute(nil); err != nil {
+		t.Errorf("ports of running services should not be conflicts; error: %v", err)
+	}
+
+	koolStart, _ = newFakeKoolStartWithPort(t)
+	koolStart.Flags.NoPortCheck = true
+
+	if err := koolStart.Execute(nil); err != nil {
+		t.Errorf("unexpected error with --no-port-check; error: %v", err)
+	}
+
+	if koolStart.shell.(*shell.FakeShell).CalledExec["config"] {
+		t.Error("should not check ports with --no-port-check")
+	}
+}
+
+func newFakeKoolStartWithPull(layerSize int64) *KoolStart {
+	koolStart := newFakeKoolStart()
+	koolStart.Flags.PullEstimate = true
+	koolStart.images.(*builder.FakeCommand).MockExecOut = "app:latest\ndatabase:latest\n"
+	koolStart.imageInspect.(*builder.FakeCommand).MockExecError = errors.New("no such image")
+	koolStart.manifest.(*builder.FakeCommand).MockExecOut = fmt.Sprintf(
+		`[{"Descriptor":{"platform":{"architecture":"other","os":"linux"}},"SchemaV2Manifest":{"layers":[{"size":1}]}},`+
+			`{"Descriptor":{"platform":{"architecture":"%s","os":"linux"}},"SchemaV2Manifest":{"layers":[{"size":%d},{"size":%d}]}}]`,
+		runtime.GOARCH, layerSize, layerSize,
+	)
+	return koolStart
+}
+
+func TestStartPullEstimate(t *testing.T) {
+	koolStart := newFakeKoolStartWithPull(1024)
+
+	if size, ok := koolStart.imageSize("app:latest"); !ok || size != 2048 {
+		t.Errorf("expected image size 2048 for the current platform; got %d (%v)", size, ok)
+	}
+
+	if err := koolStart.Execute(nil); err != nil {
+		t.Errorf("unexpected error estimating small pulls; error: %v", err)
+	}
+
+	if len(koolStart.promptSelect.(*shell.FakePromptSelect).CalledConfirm) != 0 {
+		t.Error("should not ask to confirm small pulls")
+	}
+
+	koolStart = newFakeKoolStartWithPull(pullSizeThreshold)
+
+	if err := koolStart.Execute(nil); !errors.Is(err, shell.ErrUserCancelled) {
+		t.Errorf("expected start to be cancelled; got %v", err)
+	}
+
+	if koolStart.shell.(*shell.FakeShell).CalledInteractive["start"] {
+		t.Error("should not start the services when the pull is declined")
+	}
+
+	koolStart = newFakeKoolStartWithPull(pullSizeThreshold)
+	koolStart.shell.(*shell.FakeShell).MockIsTerminal = false
+
+	if err := koolStart.Execute(nil); err != nil {
+		t.Errorf("unexpected error on non-terminal large pulls; error: %v", err)
+	}
+
+	if !koolStart.shell.(*shell.FakeShell).CalledWarning {
+		t.Error("expected a warning about the large pull")
+	}
+}
+
+func TestStartPullEstimateSkipped(t *testing.T) {
+	koolStart := newFakeKoolStartWithPull(pullSizeThreshold)
+	koolStart.manifest.(*builder.FakeCommand).MockExecError = errors.New("offline")
+
+	if err := koolStart.Execute(nil); err != nil {
+		t.Errorf("unexpected error when sizes are not available; error: %v", err)
+	}
+
+	if len(koolStart.promptSelect.(*shell.FakePromptSelect).CalledConfirm) != 0 {
+		t.Error("should not ask to confirm when sizes are not available")
+	}
+
+	koolStart = newFakeKoolStartWithPull(pullSizeThreshold)
+	koolStart.imageInspect.(*builder.FakeCommand).MockExecError = nil
+
+	if err := koolStart.Execute(nil); err != nil {
+		t.Errorf("unexpected error with images already pulled; error: %v", err)
+	}
+
+	if koolStart.shell.(*shell.FakeShell).CalledExec["manifest"] {
+		t.Error("should not estimate images already pulled")
+	}
+
+	koolStart = newFakeKoolStartWithPull(pullSizeThreshold)
+	koolStart.Flags.PullEstimate = false
+
+	if err := koolStart.Execute(nil); err != nil {
+		t.Errorf("unexpected error; error: %v", err)
+	}
+
+	if koolStart.shell.(*shell.FakeShell).CalledExec["images"] {
+		t.Error("should only estimate pulls with --pull-estimate")
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	var testCases = map[int64]string{
+		512:             "512 B",
+		2048:            "2.0 KB",
+		5 * 1024 * 1024: "5.0 MB",
+		3 << 29:         "1.5 GB",
+	}
+
+	for size, expected := range testCases {
+		if formatted := formatSize(size); formatted != expected {
+			t.Errorf("expected %d formatted as '%s'; got '%s'", size, expected, formatted)
+		}
+	}
+}
+
+func newFakeKoolStartWithOrphans() *KoolStart {
+	koolStart := newFakeKoolStart()
+	koolStart.services.(*builder.FakeCommand).MockExecOut = "app\ndatabase\n"
+	koolStart.containers.(*builder.FakeCommand).MockExecOut = "app\ncache\ndatabase\ncache\nqueue\n"
+	return koolStart
+}
+
+func TestStartOrphans(t *testing.T) {
+	koolStart := newFakeKoolStartWithOrphans()
+
+	if orphans := koolStart.orphanServices(); fmt.Sprint(orphans) != "[cache queue]" {
+		t.Errorf("expected orphan services [cache queue]; got %v", orphans)
+	}
+
+	koolStart.shell.(*shell.FakeShell).MockIsTerminal = false
+
+	err := koolStart.Execute(nil)
+
+	if err == nil || !strings.Contains(err.Error(), "cache, queue") || !strings.Contains(err.Error(), "--remove-orphans") {
+		t.Errorf("expected orphans error requiring --remove-orphans; got %v", err)
+	}
+
+	if koolStart.shell.(*shell.FakeShell).CalledInteractive["start"] {
+		t.Error("should not start the services with orphans on non-interactive mode")
+	}
+
+	koolStart = newFakeKoolStartWithOrphans()
+	koolStart.promptSelect.(*shell.FakePromptSelect).MockConfirm = map[string]bool{"Do you want to remove them?": true}
+
+	if err = koolStart.Execute(nil); err != nil {
+		t.Errorf("unexpected error removing orphans; error: %v", err)
+	}
+
+	if args := koolStart.start.(*builder.FakeCommand).ArgsAppend; !strings.Contains(fmt.Sprint(args), "--remove-orphans") {
+		t.Errorf("expected --remove-orphans upon confirmation; got %v", args)
+	}
+
+	koolStart = newFakeKoolStartWithOrphans()
+
+	if err = koolStart.Execute(nil); err != nil {
+		t.Errorf("unexpected error keeping orphans; error: %v", err)
+	}
+
+	if args := koolStart.start.(*builder.FakeCommand).ArgsAppend; strings.Contains(fmt.Sprint(args), "--remove-orphans") {
+		t.Errorf("should not remove orphans when declined; got %v", args)
+	}
+
+	if !koolStart.shell.(*shell.FakeShell).CalledInteractive["start"] {
+		t.Error("should start the services when declining to remove orphans")
+	}
+}
+
+func TestStartRemoveOrphansFlag(t *testing.T) {
+	koolStart := newFakeKoolStartWithOrphans()
+	koolStart.shell.(*shell.FakeShell).MockIsTerminal = false
+	cmd := NewStartCommand(koolStart)
+	cmd.SetArgs([]string{"--remove-orphans"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error starting with --remove-orphans; error: %v", err)
+	}
+
+	if args := koolStart.start.(*builder.FakeCommand).ArgsAppend; !strings.Contains(fmt.Sprint(args), "--remove-orphans") {
+		t.Errorf("expected --remove-orphans to be passed along; got %v", args)
+	}
+
+	if koolStart.shell.(*shell.FakeShell).CalledExec["containers"] {
+		t.Error("should not look for orphans with --remove-orphans")
+	}
+}
+
+func TestStartOffline(t *testing.T) {
+	koolStart := newFakeKoolStart()
+	koolStart.envStorage.Set("KOOL_OFFLINE", "true")
+
+	if err := koolStart.Execute(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if args := strings.Join(koolStart.start.(*builder.FakeCommand).ArgsAppend, " "); !strings.Contains(args, "--pull never") {
+		t.Errorf("expected --pull never when offline; got '%s'", args)
+	}
+
+	koolStart = newFakeKoolStart()
+	koolStart.envStorage.Set("KOOL_OFFLINE", "true")
+	koolStart.Flags.Rebuild = true
+
+	if err := koolStart.Execute(nil); !errors.Is(err, network.ErrOffline) {
+		t.Errorf("expected offline error for --rebuild; got %v", err)
+	}
+
+	if koolStart.rebuilder.(*KoolRebuild).shell.(*shell.FakeShell).CalledInteractive["pull"] {
+		t.Error("should not pull images when offline")
+	}
+}
+
+func TestStartAttachFlag(t *testing.T) {
+	koolStart := newFakeKoolStart()
+	koolStart.services.(*builder.FakeCommand).MockExecOut = "app\ndatabase\ncache"
+
+	cmd := NewStartCommand(koolStart)
+	cmd.SetArgs([]string{"--attach", "app", "--attach", "cache"})
+
+	if _, err := execStartCommand(cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	args := strings.Join(koolStart.start.(*builder.FakeCommand).ArgsAppend, " ")
+
+	if !strings.Contains(args, "--attach app --attach cache") {
+		t.Errorf("expected the services to attach to; got '%s'", args)
+	}
+
+	if strings.Contains(args, "-d") {
+		t.Errorf("--attach should start in foreground; got '%s'", args)
+	}
+
+	koolStart = newFakeKoolStart()
+	koolStart.services.(*builder.FakeCommand).MockExecOut = "app\ndatabase"
+	koolStart.Flags.Attach = []string{"app", "web"}
+
+	if err := koolStart.Execute(nil); err == nil || !strings.Contains(err.Error(), "unknown service(s) to attach to: web") {
+		t.Errorf("expected error for unknown service; got %v", err)
+	}
+
+	if koolStart.shell.(*shell.FakeShell).CalledInteractive["start"] {
+		t.Error("should not start with unknown services to attach to")
+	}
+}
@@ -1,20 +1,32 @@
 package commands
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"kool-dev/kool/core/builder"
 	"kool-dev/kool/core/environment"
 	"kool-dev/kool/core/network"
 	"kool-dev/kool/core/shell"
 	"kool-dev/kool/services/checker"
+	"sort"
 	"strings"
 	"sync"
+	"text/template"
 
 	"github.com/spf13/cobra"
 )
 
+// KoolStatusFlags holds the flags for the kool status command
+type KoolStatusFlags struct {
+	Quiet  bool
+	Format string
+}
+
 // KoolStatus holds handlers and functions to implement the status command logic
 type KoolStatus struct {
 	DefaultKoolService
+	Flags *KoolStatusFlags
 
 	check checker.Checker
 	net   network.Handler
@@ -23,16 +35,33 @@ type KoolStatus struct {
 	getServicesCmd          builder.Command
 	getServiceIDCmd         builder.Command
 	getServiceStatusPortCmd builder.Command
+	getServiceHealthCmd     builder.Command
+	getRunningIDsCmd        builder.Command
+	getConfigCmd            builder.Command
+	getServiceImageCmd      builder.Command
+	getImageIDCmd           builder.Command
 
 	table shell.TableWriter
 }
 
 type statusService struct {
 	service, state, ports string
-	running               string
+	running, health       string
+	drifted               bool
 	err                   error
 }
 
+// statusFormatRow is the data given to the --format template for each
+// service, and the object printed for it with --format json
+type statusFormatRow struct {
+	Name         string `json:"name"`
+	State        string `json:"state"`
+	Ports        string `json:"ports"`
+	Health       string `json:"health"`
+	Running      bool   `json:"running"`
+	ImageChanged bool   `json:"image_changed"`
+}
+
 func AddKoolStatus(root *cobra.Command) {
 	var (
 		status    = NewKoolStatus()
@@ -47,19 +76,45 @@ func NewKoolStatus() *KoolStatus {
 	defaultKoolService := newDefaultKoolService()
 	return &KoolStatus{
 		*defaultKoolService,
+		&KoolStatusFlags{false, ""},
 		checker.NewChecker(defaultKoolService.shell),
 		network.NewHandler(defaultKoolService.shell),
 		environment.NewEnvStorage(),
-		builder.NewCommand("docker", "compose", "config", "--services"),
-		builder.NewCommand("docker", "compose", "ps", "--all", "--quiet"),
+		builder.NewComposeCommand("config", "--services"),
+		builder.NewComposeCommand("ps", "--all", "--quiet"),
 		builder.NewCommand("docker", "ps", "--all", "--format", "{{.Status}}|{{.Ports}}"),
+		builder.NewCommand("docker", "inspect", "--format", "{{if .State.Health}}{{.State.Health.Status}}{{end}}"),
+		builder.NewComposeCommand("ps", "--quiet"),
+		builder.NewComposeCommand("config", "--format", "json"),
+		builder.NewCommand("docker", "inspect", "--format", "{{.Image}}|{{.Config.Image}}"),
+		builder.NewCommand("docker", "image", "inspect", "--format", "{{.Id}}"),
 		shell.NewTableWriter(),
 	}
 }
 
 // Execute runs the status logic with incoming arguments.
 func (s *KoolStatus) Execute(args []string) (err error) {
-	var services []string
+	var (
+		services []string
+		format   *template.Template
+	)
+
+	if s.Flags.Quiet {
+		if s.Flags.Format != "" {
+			err = fmt.Errorf("--quiet and --format cannot be used together")
+			return
+		}
+
+		err = s.printRunningIDs(args)
+		return
+	}
+
+	if s.Flags.Format != "" && s.Flags.Format != "json" {
+		if format, err = template.New("status").Option("missingkey=error").Parse(s.Flags.Format); err != nil {
+			err = fmt.Errorf("invalid --format template: %v", err)
+			return
+		}
+	}
 
 	if err = s.checkDependencies(); err != nil {
 		return
@@ -67,15 +122,22 @@ func (s *KoolStatus) Execute(args []string) (err error) {
 
 	if services, err = s.getServices(); err != nil {
 		return
-	} else if len(services) == 0 {
-		s.Shell().Warning("No services found.")
+	}
+
+	if services, err = filterServices(services, args); err != nil {
 		return
 	}
 
-	chStatus := make(chan *statusService, len(services))
+	if len(services) == 0 {
+		s.Shell().Warning("No services found.")
+		return
+	}
 
-	s.table.SetWriter(s.Shell().OutStream())
-	s.table.AppendHeader("Service", "Running", "Ports", "State")
+	var (
+		chStatus = make(chan *statusService, len(services))
+		declared = s.declaredImages()
+		statuses []*statusService
+	)
 
 	go func() {
 		var wg sync.WaitGroup
@@ -84,7 +146,7 @@ func (s *KoolStatus) Execute(args []string) (err error) {
 
 		for _, service := range services {
 			wg.Add(1)
-			go s.fetchServiceInfo(service, chStatus, &wg)
+			go s.fetchServiceInfo(service, declared[service], chStatus, &wg)
 		}
 
 		wg.Wait()
@@ -96,11 +158,234 @@ func (s *KoolStatus) Execute(args []string) (err error) {
 			return
 		}
 
-		s.table.AppendRow(ss.service, ss.running, ss.ports, ss.state)
+		statuses = append(statuses, ss)
+	}
+
+	if s.Flags.Format == "json" {
+		err = s.printJSON(statuses)
+		return
+	}
+
+	if format != nil {
+		err = s.printFormatted(format, statuses)
+		return
+	}
+
+	s.printTable(statuses)
+	return
+}
+
+// filterServices narrows the services down to the given ones, in the
+// order they are defined; all of them are kept when none is given
+func filterServices(services, only []string) (filtered []string, err error) {
+	if len(only) == 0 {
+		filtered = services
+		return
+	}
+
+	var (
+		defined = make(map[string]bool)
+		wanted  = make(map[string]bool)
+		unknown []string
+	)
+
+	for _, service := range services {
+		defined[service] = true
+	}
+
+	for _, service := range only {
+		if !defined[service] {
+			unknown = append(unknown, service)
+		}
+
+		wanted[service] = true
+	}
+
+	if len(unknown) > 0 {
+		err = fmt.Errorf("unknown service(s): %s; check the services with 'kool services'", strings.Join(unknown, ", "))
+		return
+	}
+
+	for _, service := range services {
+		if wanted[service] {
+			filtered = append(filtered, service)
+		}
+	}
+
+	return
+}
+
+// printTable renders the services status table, warning
+// about the ones that need to be recreated for an image change
+func (s *KoolStatus) printTable(statuses []*statusService) {
+	var drifted []string
+
+	s.table.SetWriter(s.Shell().OutStream())
+	s.table.AppendHeader("Service", "Running", "Health", "Ports", "State")
+
+	for _, ss := range statuses {
+		running := ss.running
+
+		if ss.drifted {
+			running += " (image changed)"
+			drifted = append(drifted, ss.service)
+		}
+
+		s.table.AppendRow(ss.service, running, ss.health, ss.ports, ss.state)
 	}
 
 	s.table.SortBy(1)
 	s.table.Render()
+
+	if len(drifted) > 0 {
+		sort.Strings(drifted)
+		s.Shell().Warning(fmt.Sprintf(
+			"Service(s) %s not running the current image; recreate them with 'kool start --force-recreate %s'",
+			strings.Join(drifted, ", "),
+			strings.Join(drifted, " "),
+		))
+	}
+}
+
+// formatRows sorts the services by name into the data
+// exposed through the --format template and JSON output
+func formatRows(statuses []*statusService) (rows []statusFormatRow) {
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].service < statuses[j].service
+	})
+
+	rows = make([]statusFormatRow, 0, len(statuses))
+
+	for _, ss := range statuses {
+		rows = append(rows, statusFormatRow{
+			Name:         ss.service,
+			State:        ss.state,
+			Ports:        ss.ports,
+			Health:       ss.health,
+			Running:      ss.running == "Running",
+			ImageChanged: ss.drifted,
+		})
+	}
+
+	return
+}
+
+// printFormatted executes the --format template for each service,
+// sorted by name, printing one line per service
+func (s *KoolStatus) printFormatted(format *template.Template, statuses []*statusService) (err error) {
+	for _, row := range formatRows(statuses) {
+		var line strings.Builder
+
+		if err = format.Execute(&line, row); err != nil {
+			err = fmt.Errorf("failed executing --format template: %v", err)
+			return
+		}
+
+		s.Shell().Println(line.String())
+	}
+
+	return
+}
+
+// printJSON prints the services, sorted by name, as a JSON array
+func (s *KoolStatus) printJSON(statuses []*statusService) (err error) {
+	var encoded bytes.Buffer
+
+	// keep ports like 0.0.0.0:80->80/tcp readable
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+
+	if err = encoder.Encode(formatRows(statuses)); err != nil {
+		return
+	}
+
+	s.Shell().Println(strings.TrimSpace(encoded.String()))
+	return
+}
+
+// declaredImages maps the services to the images declared for them in the
+// compose file; services built locally have none, and failing to read the
+// config is not critical, it just leaves out checking for image changes
+func (s *KoolStatus) declaredImages() (images map[string]string) {
+	var (
+		output   string
+		err      error
+		composed struct {
+			Services map[string]struct {
+				Image string `json:"image"`
+			} `json:"services"`
+		}
+	)
+
+	images = make(map[string]string)
+
+	if output, err = s.Shell().Exec(s.getConfigCmd); err != nil {
+		return
+	}
+
+	if err = json.Unmarshal([]byte(output), &composed); err != nil {
+		return
+	}
+
+	for service, config := range composed.Services {
+		images[service] = config.Image
+	}
+
+	return
+}
+
+// imageDrifted tells whether the service container runs an image other than
+// the one now tagged with the declared image (or, for services built locally,
+// with the image it was created from), like when it got rebuilt or pulled again
+// afterwards; images not found locally are not taken as changes
+func (s *KoolStatus) imageDrifted(service, declared string) bool {
+	var (
+		serviceID, output, expectedID string
+		err                           error
+	)
+
+	if serviceID, err = s.Shell().Exec(s.getServiceIDCmd, service); err != nil || serviceID == "" {
+		return false
+	}
+
+	if output, err = s.Shell().Exec(s.getServiceImageCmd, serviceID); err != nil || output == "" {
+		return false
+	}
+
+	current := strings.SplitN(output, "|", 2)
+
+	if declared == "" && len(current) > 1 {
+		declared = current[1]
+	}
+
+	if declared == "" {
+		return false
+	}
+
+	if expectedID, err = s.Shell().Exec(s.getImageIDCmd, declared); err != nil || expectedID == "" {
+		return false
+	}
+
+	return expectedID != current[0]
+}
+
+// printRunningIDs prints out only the IDs of the running
+// containers, one per line, optionally just for the given services
+func (s *KoolStatus) printRunningIDs(services []string) (err error) {
+	var output string
+
+	if err = s.check.Check(); err != nil {
+		return
+	}
+
+	if output, err = s.Shell().Exec(s.getRunningIDsCmd, services...); err != nil {
+		return
+	}
+
+	for _, id := range strings.Fields(output) {
+		s.Shell().Println(id)
+	}
+
 	return
 }
 
@@ -158,31 +443,46 @@ func (s *KoolStatus) getServices() (services []string, err error) {
 	return
 }
 
-func (s *KoolStatus) fetchServiceInfo(service string, chStatus chan *statusService, wg *sync.WaitGroup) {
+func (s *KoolStatus) fetchServiceInfo(service, declaredImage string, chStatus chan *statusService, wg *sync.WaitGroup) {
 	var isRunning bool
 
 	defer wg.Done()
 
-	ss := &statusService{service: service, running: "Not running"}
-	isRunning, ss.state, ss.ports, ss.err = s.getServiceInfo(service)
+	ss := &statusService{service: service, running: "Not running", health: "none"}
+	isRunning, ss.state, ss.ports, ss.health, ss.err = s.getServiceInfo(service)
 	if isRunning {
 		ss.running = "Running"
+		ss.drifted = s.imageDrifted(service, declaredImage)
 	}
 
 	chStatus <- ss
 }
 
-func (s *KoolStatus) getServiceInfo(service string) (isRunning bool, status, port string, err error) {
+func (s *KoolStatus) getServiceInfo(service string) (isRunning bool, status, port, health string, err error) {
 	var serviceID string
+
+	health = "none"
+
 	if serviceID, err = s.Shell().Exec(s.getServiceIDCmd, service); err == nil && serviceID != "" {
 		status, port = s.getStatusPort(serviceID)
 		if strings.HasPrefix(status, "Up") {
 			isRunning = true
+			health = s.getHealth(serviceID)
 		}
 	}
 	return
 }
 
+// getHealth parses the container healthcheck state (healthy, unhealthy
+// or starting), which is "none" when there is no healthcheck configured
+func (s *KoolStatus) getHealth(serviceID string) (health string) {
+	if health, _ = s.Shell().Exec(s.getServiceHealthCmd, serviceID); health == "" {
+		health = "none"
+	}
+
+	return
+}
+
 func (s *KoolStatus) getStatusPort(serviceID string) (status string, port string) {
 	var output string
 
@@ -207,11 +507,41 @@ func NewStatusCommand(status *KoolStatus) *cobra.Command {
 
 	statusTask.SetFrameOutput(false)
 
-	return &cobra.Command{
-		Use:   "status",
-		Short: "Show the status of all service containers",
-		RunE:  LongTaskCommandRunFunction(statusTask),
+	statusCmd := &cobra.Command{
+		Use:     "status [SERVICE...]",
+		Aliases: []string{"ps"},
+		Short:   "Show the status of all service containers",
+		Long: `Show the status of all service containers, or just of the given SERVICEs.
+With --quiet only the IDs of the running containers are printed, one per line, for
+piping into other docker commands.
+
+Services whose container runs an image other than the current one for the service
+(like after rebuilding or pulling it again, or changing it in the compose file) are
+flagged, as they need to be recreated for the change to take effect.
+
+With --format each service is printed through the given Go template instead of the
+table, one line per service. The available fields are .Name, .State, .Ports, .Health,
+.Running and .ImageChanged (the last two are booleans). Use --format json to print
+all services as a JSON array instead, with the same fields in snake case.`,
+		Example: `docker stats $(kool status -q app)
+kool status app database
+kool status --format '{{.Name}} {{.State}}'
+kool status --format '{{if .Running}}{{.Name}}{{end}}'
+kool status --format json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if status.Flags.Quiet || status.Flags.Format != "" {
+				// keep the output clean for piping
+				return DefaultCommandRunFunction(status)(cmd, args)
+			}
+
+			return LongTaskCommandRunFunction(statusTask)(cmd, args)
+		},
 
 		DisableFlagsInUseLine: true,
 	}
+
+	statusCmd.Flags().BoolVarP(&status.Flags.Quiet, "quiet", "q", false, "Only print the IDs of the running containers")
+	statusCmd.Flags().StringVar(&status.Flags.Format, "format", "", "Print each service using the given Go template, or all of them as JSON with 'json'")
+
+	return statusCmd
 }
@@ -25,12 +25,18 @@ func (f *FakeRaceShell) Exec(command builder.Command, extraArgs ...string) (stri
 func newFakeKoolStatus() *KoolStatus {
 	fs := &KoolStatus{
 		*(newDefaultKoolService().Fake()),
+		&KoolStatusFlags{false, ""},
 		&checker.FakeChecker{},
 		&network.FakeHandler{},
 		environment.NewFakeEnvStorage(),
 		&builder.FakeCommand{},
 		&builder.FakeCommand{},
 		&builder.FakeCommand{},
+		&builder.FakeCommand{},
+		&builder.FakeCommand{MockCmd: "ids"},
+		&builder.FakeCommand{MockCmd: "config"},
+		&builder.FakeCommand{MockCmd: "image"},
+		&builder.FakeCommand{MockCmd: "image-id"},
 		&shell.FakeTableWriter{},
 	}
 
@@ -67,6 +73,10 @@ func TestNewKoolStatus(t *testing.T) {
 		t.Errorf("unexpected builder.Command on default KoolStatus instance")
 	}
 
+	if _, ok := k.getServiceHealthCmd.(*builder.DefaultCommand); !ok {
+		t.Errorf("unexpected builder.Command on default KoolStatus instance")
+	}
+
 	if _, ok := k.table.(*shell.DefaultTableWriter); !ok {
 		t.Errorf("unexpected shell.TableWriter on default KoolStatus instance")
 	}
@@ -85,8 +95,32 @@ func TestStatusCommand(t *testing.T) {
 		t.Errorf("unexpected error executing status command; error: %v", err)
 	}
 
-	expected := `Service | Running | Ports | State
-app | Running | 0.0.0.0:80->80/tcp, 9000/tcp | Up About an hour`
+	expected := `Service | Running | Health | Ports | State
+app | Running | none | 0.0.0.0:80->80/tcp, 9000/tcp | Up About an hour`
+
+	output := strings.TrimSpace(f.table.(*shell.FakeTableWriter).TableOut)
+
+	if output != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, output)
+	}
+}
+
+func TestHealthStatusCommand(t *testing.T) {
+	f := newFakeKoolStatus()
+
+	f.getServicesCmd.(*builder.FakeCommand).MockExecOut = "app"
+	f.getServiceIDCmd.(*builder.FakeCommand).MockExecOut = "100"
+	f.getServiceStatusPortCmd.(*builder.FakeCommand).MockExecOut = "Up 2 minutes (unhealthy)|"
+	f.getServiceHealthCmd.(*builder.FakeCommand).MockExecOut = "unhealthy"
+
+	cmd := NewStatusCommand(f)
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing status command; error: %v", err)
+	}
+
+	expected := `Service | Running | Health | Ports | State
+app | Running | unhealthy |  | Up 2 minutes (unhealthy)`
 
 	output := strings.TrimSpace(f.table.(*shell.FakeTableWriter).TableOut)
 
@@ -95,6 +129,49 @@ app | Running | 0.0.0.0:80->80/tcp, 9000/tcp | Up About an hour`
 	}
 }
 
+func TestQuietStatusCommand(t *testing.T) {
+	f := newFakeKoolStatus()
+
+	f.getRunningIDsCmd.(*builder.FakeCommand).MockExecOut = "100\n200"
+
+	cmd := NewStatusCommand(f)
+	cmd.SetArgs([]string{"-q", "app", "worker"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing status command; error: %v", err)
+	}
+
+	fakeShell := f.shell.(*shell.FakeShell)
+
+	if strings.Join(fakeShell.OutLines, ",") != "100,200" {
+		t.Errorf("expected only the container IDs on the output; got %v", fakeShell.OutLines)
+	}
+
+	if f.table.(*shell.FakeTableWriter).TableOut != "" {
+		t.Error("should not render the status table on quiet mode")
+	}
+
+	if fakeShell.CalledExec[f.getServicesCmd.Cmd()] {
+		t.Error("should not fetch the services info on quiet mode")
+	}
+
+	f = newFakeKoolStatus()
+	f.check.(*checker.FakeChecker).MockError = errors.New("check error")
+	cmd = NewStatusCommand(f)
+	cmd.SetArgs([]string{"--quiet"})
+
+	assertExecGotError(t, cmd, "check error")
+}
+
+func TestStatusCommandPsAlias(t *testing.T) {
+	root := NewRootCmd(environment.NewFakeEnvStorage())
+	root.AddCommand(NewStatusCommand(newFakeKoolStatus()))
+
+	if cmd, _, err := root.Find([]string{"ps"}); err != nil || cmd.Name() != "status" {
+		t.Errorf("expected 'ps' to be an alias for status; got %v (err: %v)", cmd, err)
+	}
+}
+
 func TestNotRunningStatusCommand(t *testing.T) {
 	f := newFakeKoolStatus()
 
@@ -108,8 +185,8 @@ func TestNotRunningStatusCommand(t *testing.T) {
 		t.Errorf("unexpected error executing status command; error: %v", err)
 	}
 
-	expected := `Service | Running | Ports | State
-app | Not running |  | Exited an hour ago`
+	expected := `Service | Running | Health | Ports | State
+app | Not running | none |  | Exited an hour ago`
 
 	output := strings.TrimSpace(f.table.(*shell.FakeTableWriter).TableOut)
 
@@ -130,8 +207,8 @@ func TestNoStatusPortStatusCommand(t *testing.T) {
 		t.Errorf("unexpected error executing status command; error: %v", err)
 	}
 
-	expected := `Service | Running | Ports | State
-app | Not running |  |`
+	expected := `Service | Running | Health | Ports | State
+app | Not running | none |  |`
 
 	output := strings.TrimSpace(f.table.(*shell.FakeTableWriter).TableOut)
 
@@ -221,12 +298,18 @@ func TestFailedGetServiceIDStatusCommand(t *testing.T) {
 func TestServicesOrderStatusCommand(t *testing.T) {
 	f := &KoolStatus{
 		*(newDefaultKoolService().Fake()),
+		&KoolStatusFlags{false, ""},
 		&checker.FakeChecker{},
 		&network.FakeHandler{},
 		environment.NewFakeEnvStorage(),
 		&builder.FakeCommand{},
 		&builder.FakeCommand{},
 		&builder.FakeCommand{},
+		&builder.FakeCommand{},
+		&builder.FakeCommand{MockCmd: "ids"},
+		&builder.FakeCommand{MockCmd: "config"},
+		&builder.FakeCommand{MockCmd: "image"},
+		&builder.FakeCommand{MockCmd: "image-id"},
 		&shell.FakeTableWriter{},
 	}
 
@@ -247,9 +330,9 @@ app`
 		t.Errorf("unexpected error executing status command; error: %v", err)
 	}
 
-	expected := `Service | Running | Ports | State
-app | Not running |  | output
-cache | Not running |  | output`
+	expected := `Service | Running | Health | Ports | State
+app | Not running | none |  | output
+cache | Not running | none |  | output`
 
 	output := strings.TrimSpace(f.table.(*shell.FakeTableWriter).TableOut)
 
@@ -257,3 +340,171 @@ cache | Not running |  | output`
 		t.Errorf("Expected '%s', got '%s'", expected, output)
 	}
 }
+
+func TestImageChangedStatusCommand(t *testing.T) {
+	f := newFakeKoolStatus()
+
+	f.getServicesCmd.(*builder.FakeCommand).MockExecOut = "app"
+	f.getServiceIDCmd.(*builder.FakeCommand).MockExecOut = "100"
+	f.getServiceStatusPortCmd.(*builder.FakeCommand).MockExecOut = "Up 2 minutes|"
+	f.getConfigCmd.(*builder.FakeCommand).MockExecOut = `{"services":{"app":{"image":"kooldev/php:8.2"}}}`
+	f.getServiceImageCmd.(*builder.FakeCommand).MockExecOut = "sha256:old|kooldev/php:8.1"
+	f.getImageIDCmd.(*builder.FakeCommand).MockExecOut = "sha256:new"
+
+	cmd := NewStatusCommand(f)
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing status command; error: %v", err)
+	}
+
+	expected := `Service | Running | Health | Ports | State
+app | Running (image changed) | none |  | Up 2 minutes`
+
+	if output := strings.TrimSpace(f.table.(*shell.FakeTableWriter).TableOut); output != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, output)
+	}
+
+	if warning := fmt.Sprint(f.shell.(*shell.FakeShell).WarningOutput...); !strings.Contains(warning, "app not running the current image") || !strings.Contains(warning, "kool start --force-recreate app") {
+		t.Errorf("expected warning about the changed image; got '%s'", warning)
+	}
+}
+
+func TestImageDrifted(t *testing.T) {
+	f := newFakeKoolStatus()
+	f.getServiceIDCmd.(*builder.FakeCommand).MockExecOut = "100"
+	f.getServiceImageCmd.(*builder.FakeCommand).MockExecOut = "sha256:same|my-app-app"
+	f.getImageIDCmd.(*builder.FakeCommand).MockExecOut = "sha256:same"
+
+	if f.imageDrifted("app", "") {
+		t.Error("should not flag a container running the current image")
+	}
+
+	f.getImageIDCmd.(*builder.FakeCommand).MockExecOut = "sha256:rebuilt"
+
+	if !f.imageDrifted("app", "") {
+		t.Error("should flag a container whose image got rebuilt")
+	}
+
+	f.getImageIDCmd.(*builder.FakeCommand).MockExecError = errors.New("no such image")
+
+	if f.imageDrifted("app", "kooldev/php:8.2") {
+		t.Error("should not flag when the declared image is not found locally")
+	}
+}
+
+func TestFormatStatusCommand(t *testing.T) {
+	f := newFakeKoolStatus()
+
+	f.getServicesCmd.(*builder.FakeCommand).MockExecOut = "cache\napp"
+	f.getServiceIDCmd.(*builder.FakeCommand).MockExecOut = "100"
+	f.getServiceStatusPortCmd.(*builder.FakeCommand).MockExecOut = "Up 2 minutes|0.0.0.0:80->80/tcp"
+
+	cmd := NewStatusCommand(f)
+	cmd.SetArgs([]string{"--format", "{{.Name}} {{.State}} {{.Running}}"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing status command; error: %v", err)
+	}
+
+	expected := []string{"app Up 2 minutes true", "cache Up 2 minutes true"}
+	output := f.shell.(*shell.FakeShell).OutLines
+
+	if strings.Join(output, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected output %v, got %v", expected, output)
+	}
+
+	if f.table.(*shell.FakeTableWriter).TableOut != "" {
+		t.Error("should not render the table when using --format")
+	}
+}
+
+func TestInvalidFormatStatusCommand(t *testing.T) {
+	f := newFakeKoolStatus()
+	cmd := NewStatusCommand(f)
+	cmd.SetArgs([]string{"--format", "{{.Name"})
+
+	assertExecGotError(t, cmd, "invalid --format template")
+
+	f = newFakeKoolStatus()
+	f.getServicesCmd.(*builder.FakeCommand).MockExecOut = "app"
+	cmd = NewStatusCommand(f)
+	cmd.SetArgs([]string{"--format", "{{.Missing}}"})
+
+	assertExecGotError(t, cmd, "failed executing --format template")
+
+	f = newFakeKoolStatus()
+	cmd = NewStatusCommand(f)
+	cmd.SetArgs([]string{"--quiet", "--format", "{{.Name}}"})
+
+	assertExecGotError(t, cmd, "cannot be used together")
+}
+
+func TestStatusCommandFilteredByServices(t *testing.T) {
+	f := newFakeKoolStatus()
+
+	f.getServicesCmd.(*builder.FakeCommand).MockExecOut = "cache\napp\ndatabase"
+	f.getServiceIDCmd.(*builder.FakeCommand).MockExecOut = "100"
+	f.getServiceStatusPortCmd.(*builder.FakeCommand).MockExecOut = "Up 2 minutes|"
+
+	cmd := NewStatusCommand(f)
+	cmd.SetArgs([]string{"--format", "{{.Name}}", "database", "app"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing status command; error: %v", err)
+	}
+
+	expected := []string{"app", "database"}
+	output := f.shell.(*shell.FakeShell).OutLines
+
+	if strings.Join(output, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected output %v, got %v", expected, output)
+	}
+
+	f = newFakeKoolStatus()
+
+	f.getServicesCmd.(*builder.FakeCommand).MockExecOut = "cache\napp"
+	f.getServiceIDCmd.(*builder.FakeCommand).MockExecOut = "100"
+	f.getServiceStatusPortCmd.(*builder.FakeCommand).MockExecOut = "Up 2 minutes|"
+
+	cmd = NewStatusCommand(f)
+	cmd.SetArgs([]string{"app"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing status command; error: %v", err)
+	}
+
+	if tableOut := f.table.(*shell.FakeTableWriter).TableOut; !strings.Contains(tableOut, "app") || strings.Contains(tableOut, "cache") {
+		t.Errorf("expected the table to have just the app service; got %s", tableOut)
+	}
+
+	f = newFakeKoolStatus()
+	f.getServicesCmd.(*builder.FakeCommand).MockExecOut = "app"
+	cmd = NewStatusCommand(f)
+	cmd.SetArgs([]string{"app", "web"})
+
+	assertExecGotError(t, cmd, "unknown service(s): web")
+}
+
+func TestJSONFormatStatusCommand(t *testing.T) {
+	f := newFakeKoolStatus()
+
+	f.getServicesCmd.(*builder.FakeCommand).MockExecOut = "cache\napp"
+	f.getServiceIDCmd.(*builder.FakeCommand).MockExecOut = "100"
+	f.getServiceStatusPortCmd.(*builder.FakeCommand).MockExecOut = "Up 2 minutes|0.0.0.0:80->80/tcp"
+	f.getServiceHealthCmd.(*builder.FakeCommand).MockExecOut = "healthy"
+
+	cmd := NewStatusCommand(f)
+	cmd.SetArgs([]string{"--format", "json"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing status command; error: %v", err)
+	}
+
+	expected := `[{"name":"app","state":"Up 2 minutes","ports":"0.0.0.0:80->80/tcp","health":"healthy","running":true,"image_changed":false},` +
+		`{"name":"cache","state":"Up 2 minutes","ports":"0.0.0.0:80->80/tcp","health":"healthy","running":true,"image_changed":false}]`
+	output := f.shell.(*shell.FakeShell).OutLines
+
+	if len(output) != 1 || output[0] != expected {
+		t.Errorf("expected output %s, got %v", expected, output)
+	}
+}
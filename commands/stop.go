@@ -1,8 +1,14 @@
 package commands
 
 import (
+	"fmt"
 	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/shell"
 	"kool-dev/kool/services/checker"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -11,6 +17,8 @@ import (
 // KoolStopFlags holds the flags for the kool stop command
 type KoolStopFlags struct {
 	Purge bool
+	All   bool
+	Yes   bool
 }
 
 // KoolStop holds handlers and functions to implement the stop command logic
@@ -21,6 +29,10 @@ type KoolStop struct {
 	check checker.Checker
 	down  builder.Command
 	rm    builder.Command
+
+	projects     builder.Command
+	downProject  builder.Command
+	promptSelect shell.PromptSelect
 }
 
 func AddKoolStop(root *cobra.Command) {
@@ -37,10 +49,13 @@ func NewKoolStop() *KoolStop {
 	defaultKoolService := newDefaultKoolService()
 	return &KoolStop{
 		*defaultKoolService,
-		&KoolStopFlags{false},
+		&KoolStopFlags{false, false, false},
 		checker.NewChecker(defaultKoolService.shell),
-		builder.NewCommand("docker", "compose", "down"),
-		builder.NewCommand("docker", "compose", "rm"),
+		builder.NewComposeCommand("down"),
+		builder.NewComposeCommand("rm"),
+		builder.NewCommand("docker", "ps", "--filter", "label=com.docker.compose.project", "--format", `{{.Label "com.docker.compose.project"}}|{{.Label "com.docker.compose.project.working_dir"}}`),
+		builder.NewComposeCommand(),
+		shell.NewPromptSelect(),
 	}
 }
 
@@ -52,6 +67,11 @@ func (s *KoolStop) Execute(args []string) (err error) {
 		return
 	}
 
+	if s.Flags.All {
+		err = s.stopAll(args)
+		return
+	}
+
 	if len(args) == 0 {
 		s.down.AppendArgs("--remove-orphans")
 
@@ -80,6 +100,109 @@ func (s *KoolStop) Execute(args []string) (err error) {
 	return
 }
 
+// stopAll stops every running kool project on the machine; projects
+// are found by the compose labels of the running containers, and
+// those with a kool.yml file in their directory are kool ones
+func (s *KoolStop) stopAll(args []string) (err error) {
+	var (
+		projects []string
+		stopped  []string
+		failed   []string
+		proceed  bool
+	)
+
+	if len(args) > 0 {
+		err = fmt.Errorf("--all cannot be used along with specific services")
+		return
+	}
+
+	if projects, err = s.runningProjects(); err != nil {
+		return
+	}
+
+	if len(projects) == 0 {
+		s.Shell().Warning("There are no kool projects running")
+		return
+	}
+
+	if !s.Flags.Yes {
+		if !s.Shell().IsTerminal() {
+			err = fmt.Errorf("stopping all projects requires confirmation; use --yes to confirm on non-TTY")
+			return
+		}
+
+		question := "Do you want to stop all these projects: %s?"
+		if s.Flags.Purge {
+			question = "Do you want to stop all these projects and remove their volumes: %s?"
+		}
+
+		if proceed, err = s.promptSelect.Confirm(question, strings.Join(projects, ", ")); err != nil {
+			return
+		}
+
+		if !proceed {
+			err = shell.ErrUserCancelled
+			return
+		}
+	}
+
+	for _, project := range projects {
+		downArgs := []string{"-p", project, "down", "--remove-orphans"}
+
+		if s.Flags.Purge {
+			downArgs = append(downArgs, "--volumes")
+		}
+
+		if downErr := s.Shell().Interactive(s.downProject, downArgs...); downErr != nil {
+			s.Shell().Warning(fmt.Sprintf("Failed stopping project %s: %v", project, downErr))
+			failed = append(failed, project)
+			continue
+		}
+
+		stopped = append(stopped, project)
+	}
+
+	if len(stopped) > 0 {
+		s.Shell().Success(fmt.Sprintf("Stopped %d project(s): %s", len(stopped), strings.Join(stopped, ", ")))
+	}
+
+	if len(failed) > 0 {
+		err = fmt.Errorf("failed stopping %d project(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+
+	return
+}
+
+// runningProjects lists the kool projects with running containers
+func (s *KoolStop) runningProjects() (projects []string, err error) {
+	var (
+		output string
+		seen   = make(map[string]bool)
+	)
+
+	if output, err = s.Shell().Exec(s.projects); err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		// project|working_dir
+		parts := strings.SplitN(strings.TrimSpace(line), "|", 2)
+
+		if len(parts) != 2 || parts[0] == "" || seen[parts[0]] {
+			continue
+		}
+
+		seen[parts[0]] = true
+
+		if _, statErr := os.Stat(filepath.Join(parts[1], "kool.yml")); parts[1] != "" && statErr == nil {
+			projects = append(projects, parts[0])
+		}
+	}
+
+	sort.Strings(projects)
+	return
+}
+
 // NewStopCommand initializes new kool stop command
 func NewStopCommand(stop *KoolStop) (stopCmd *cobra.Command) {
 	var task = NewKoolTask("Stopping all service containers", stop)
@@ -90,12 +213,25 @@ func NewStopCommand(stop *KoolStop) (stopCmd *cobra.Command) {
 		SuggestFor: []string{"down"},
 		Short:      "Stop and destroy running service containers",
 		Long: `Stop and destroy the specified [SERVICE] containers, which were started
-using 'kool start'. If no [SERVICE] is provided, all running containers are stopped.`,
-		RunE: DefaultCommandRunFunction(task),
+using 'kool start'. If no [SERVICE] is provided, all running containers are stopped.
+
+With --all, every running kool project on the machine is stopped, not just the
+current one; projects are found by the docker compose labels of the running
+containers and must have a kool.yml file. It asks for confirmation first,
+unless --yes is given.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if stop.Flags.All {
+				return DefaultCommandRunFunction(stop)(cmd, args)
+			}
+
+			return DefaultCommandRunFunction(task)(cmd, args)
+		},
 
 		DisableFlagsInUseLine: true,
 	}
 
 	stopCmd.Flags().BoolVarP(&stop.Flags.Purge, "purge", "", false, "Remove all persistent data from volume mounts on containers")
+	stopCmd.Flags().BoolVarP(&stop.Flags.All, "all", "a", false, "Stop all running kool projects on this machine")
+	stopCmd.Flags().BoolVarP(&stop.Flags.Yes, "yes", "y", false, "Do not ask for confirmation when stopping all projects")
 	return
 }
@@ -2,20 +2,27 @@ package commands
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"kool-dev/kool/core/builder"
 	"kool-dev/kool/core/shell"
 	"kool-dev/kool/services/checker"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
 func newFakeKoolStop() *KoolStop {
 	fs := &KoolStop{
 		*(newDefaultKoolService().Fake()),
-		&KoolStopFlags{false},
+		&KoolStopFlags{false, false, false},
 		&checker.FakeChecker{},
 		&builder.FakeCommand{},
 		&builder.FakeCommand{},
+		&builder.FakeCommand{MockCmd: "projects"},
+		&builder.FakeCommand{MockCmd: "down-project"},
+		&shell.FakePromptSelect{},
 	}
 	fs.shell.(*shell.FakeShell).MockErrStream = io.Discard
 	fs.shell.(*shell.FakeShell).MockOutStream = io.Discard
@@ -134,3 +141,119 @@ func TestNewFailingDependenciesCheckStopCommand(t *testing.T) {
 
 	assertExecGotError(t, cmd, "check error")
 }
+
+func newFakeKoolStopAll(t *testing.T) *KoolStop {
+	var (
+		f       = newFakeKoolStop()
+		koolDir = t.TempDir()
+		other   = t.TempDir()
+	)
+
+	if err := os.WriteFile(filepath.Join(koolDir, "kool.yml"), []byte("scripts: {}"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	f.Flags.All = true
+	f.projects.(*builder.FakeCommand).MockExecOut = strings.Join([]string{
+		"web|" + koolDir,
+		"web|" + koolDir,
+		"api|" + koolDir,
+		"other|" + other,
+		"detached|",
+	}, "\n")
+
+	return f
+}
+
+func TestNewStopAllCommand(t *testing.T) {
+	f := newFakeKoolStopAll(t)
+	f.promptSelect.(*shell.FakePromptSelect).MockConfirm = map[string]bool{
+		"Do you want to stop all these projects: %s?": true,
+	}
+
+	cmd := NewStopCommand(f)
+	cmd.SetArgs([]string{"--all"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing stop --all command; error: %v", err)
+	}
+
+	fakeShell := f.shell.(*shell.FakeShell)
+	prompt := f.promptSelect.(*shell.FakePromptSelect)
+
+	if len(prompt.CalledConfirm) != 1 {
+		t.Error("expected to ask for confirmation")
+	}
+
+	if args := strings.Join(fakeShell.ArgsInteractive["down-project"], " "); args != "-p web down --remove-orphans" {
+		t.Errorf("unexpected arguments stopping the last project: %s", args)
+	}
+
+	if output := fmt.Sprint(fakeShell.SuccessOutput...); output != "Stopped 2 project(s): api, web" {
+		t.Errorf("unexpected success message: %s", output)
+	}
+}
+
+func TestNewStopAllCommandCancelled(t *testing.T) {
+	f := newFakeKoolStopAll(t)
+
+	if err := f.Execute(nil); !errors.Is(err, shell.ErrUserCancelled) {
+		t.Errorf("expected stop --all to be cancelled; got %v", err)
+	}
+
+	if f.shell.(*shell.FakeShell).CalledInteractive["down-project"] {
+		t.Error("should not stop projects without confirmation")
+	}
+
+	f = newFakeKoolStopAll(t)
+	f.shell.(*shell.FakeShell).MockIsTerminal = false
+
+	if err := f.Execute(nil); err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("expected confirmation error on non-TTY; got %v", err)
+	}
+}
+
+func TestNewStopAllCommandYesPurge(t *testing.T) {
+	f := newFakeKoolStopAll(t)
+	f.Flags.Yes = true
+	f.Flags.Purge = true
+
+	if err := f.Execute(nil); err != nil {
+		t.Errorf("unexpected error; error: %v", err)
+	}
+
+	if len(f.promptSelect.(*shell.FakePromptSelect).CalledConfirm) != 0 {
+		t.Error("should not ask for confirmation with --yes")
+	}
+
+	if args := strings.Join(f.shell.(*shell.FakeShell).ArgsInteractive["down-project"], " "); args != "-p web down --remove-orphans --volumes" {
+		t.Errorf("unexpected arguments stopping project with --purge: %s", args)
+	}
+}
+
+func TestNewStopAllCommandErrors(t *testing.T) {
+	f := newFakeKoolStopAll(t)
+	f.Flags.Yes = true
+	f.downProject.(*builder.FakeCommand).MockInteractiveError = errors.New("down error")
+
+	if err := f.Execute(nil); err == nil || err.Error() != "failed stopping 2 project(s): api, web" {
+		t.Errorf("expected failed projects error; got %v", err)
+	}
+
+	f = newFakeKoolStopAll(t)
+
+	if err := f.Execute([]string{"app"}); err == nil || !strings.Contains(err.Error(), "--all cannot be used along with specific services") {
+		t.Errorf("expected error using --all with services; got %v", err)
+	}
+
+	f = newFakeKoolStopAll(t)
+	f.projects.(*builder.FakeCommand).MockExecOut = ""
+
+	if err := f.Execute(nil); err != nil {
+		t.Errorf("unexpected error with no projects running; error: %v", err)
+	}
+
+	if output := fmt.Sprint(f.shell.(*shell.FakeShell).WarningOutput...); output != "There are no kool projects running" {
+		t.Errorf("unexpected warning: %s", output)
+	}
+}
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"fmt"
+	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/parser"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// KoolValidate holds handlers and functions to validate kool.yml files
+type KoolValidate struct {
+	DefaultKoolService
+
+	env environment.EnvStorage
+}
+
+// NewKoolValidate creates a new handler for validating kool.yml files
+func NewKoolValidate() *KoolValidate {
+	return &KoolValidate{
+		*newDefaultKoolService(),
+		environment.NewEnvStorage(),
+	}
+}
+
+func AddKoolValidate(root *cobra.Command) {
+	root.AddCommand(NewValidateCommand(NewKoolValidate()))
+}
+
+// Execute validates the given kool.yml file, or the one in the current
+// working directory, printing out every problem found
+func (v *KoolValidate) Execute(args []string) (err error) {
+	var (
+		file     string
+		problems []parser.ValidationProblem
+	)
+
+	if len(args) > 0 {
+		file = args[0]
+	} else if file, err = v.lookupKoolYaml(); err != nil {
+		return
+	}
+
+	if problems, err = parser.ValidateKoolYaml(file); err != nil {
+		err = fmt.Errorf("failed parsing %s: %v", file, err)
+		return
+	}
+
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			v.Shell().Warning(fmt.Sprintf("%s: %s", file, problem))
+		}
+
+		err = fmt.Errorf("%s is invalid: %d problem(s) found", file, len(problems))
+		return
+	}
+
+	v.Shell().Success(file, " is valid")
+	return
+}
+
+func (v *KoolValidate) lookupKoolYaml() (file string, err error) {
+	for _, name := range []string{"kool.yml", "kool.yaml"} {
+		if _, statErr := os.Stat(filepath.Join(v.env.Get("PWD"), name)); statErr == nil {
+			file = filepath.Join(v.env.Get("PWD"), name)
+			return
+		}
+	}
+
+	err = &parser.ErrNoKoolYml{Dir: v.env.Get("PWD")}
+	return
+}
+
+// NewValidateCommand initializes new kool validate command
+func NewValidateCommand(validate *KoolValidate) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate [FILE]",
+		Short: "Check the kool.yml file for structural problems",
+		Long: `Check the kool.yml file in the current working directory (or the given FILE)
+for structural problems, like unknown keys, scripts that are not a command string or
+a list of command strings, and bootstrap scripts that are not defined. Every problem
+is reported with its line and path, and the command exits non-zero if any is found.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: DefaultCommandRunFunction(validate),
+
+		DisableFlagsInUseLine: true,
+	}
+}
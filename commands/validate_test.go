@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/shell"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newFakeKoolValidate() *KoolValidate {
+	return &KoolValidate{
+		*(newDefaultKoolService().Fake()),
+		environment.NewFakeEnvStorage(),
+	}
+}
+
+func TestNewKoolValidate(t *testing.T) {
+	k := NewKoolValidate()
+
+	if _, ok := k.DefaultKoolService.shell.(*shell.DefaultShell); !ok {
+		t.Errorf("unexpected shell.Shell on default KoolValidate instance")
+	}
+
+	if _, ok := k.env.(*environment.DefaultEnvStorage); !ok {
+		t.Errorf("unexpected environment.EnvStorage on default KoolValidate instance")
+	}
+}
+
+func TestValidateCommand(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "kool.yml"), []byte("scripts:\n  test: echo test\n"), os.ModePerm)
+
+	f := newFakeKoolValidate()
+	f.env.Set("PWD", dir)
+
+	cmd := NewValidateCommand(f)
+	cmd.SetArgs([]string{})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error validating kool.yml; error: %v", err)
+	}
+
+	if !f.shell.(*shell.FakeShell).CalledSuccess {
+		t.Error("did not report kool.yml as valid")
+	}
+}
+
+func TestInvalidValidateCommand(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "other.yml")
+	_ = os.WriteFile(file, []byte("scripts:\n  test: echo test\nsetup: true\n"), os.ModePerm)
+
+	f := newFakeKoolValidate()
+	cmd := NewValidateCommand(f)
+	cmd.SetArgs([]string{file})
+
+	assertExecGotError(t, cmd, "is invalid: 1 problem(s) found")
+
+	if output := f.shell.(*shell.FakeShell).WarningOutput; len(output) != 1 || output[0] != file+": line 3: setup: unknown key" {
+		t.Errorf("unexpected problems output: %v", output)
+	}
+
+	f = newFakeKoolValidate()
+	f.env.Set("PWD", t.TempDir())
+	cmd = NewValidateCommand(f)
+	cmd.SetArgs([]string{})
+
+	assertExecGotError(t, cmd, "No kool.yml found in ")
+}
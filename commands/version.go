@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// commit and buildDate are set at build time along with the version
+var (
+	commit    = "unknown"
+	buildDate = "unknown"
+)
+
+// KoolVersionFlags holds the flags for the kool version command
+type KoolVersionFlags struct {
+	Short bool
+	JSON  bool
+}
+
+// KoolVersion holds handlers and functions to implement the version command logic
+type KoolVersion struct {
+	DefaultKoolService
+	Flags *KoolVersionFlags
+}
+
+// koolVersionInfo is the --json output of the version command
+type koolVersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+}
+
+func AddKoolVersion(root *cobra.Command) {
+	root.AddCommand(NewVersionCommand(NewKoolVersion()))
+}
+
+// NewKoolVersion creates a new handler for version logic
+func NewKoolVersion() *KoolVersion {
+	return &KoolVersion{
+		*newDefaultKoolService(),
+		&KoolVersionFlags{false, false},
+	}
+}
+
+// Execute runs the version logic with incoming arguments.
+func (v *KoolVersion) Execute(args []string) (err error) {
+	if v.Flags.Short && v.Flags.JSON {
+		err = fmt.Errorf("--short and --json cannot be used together")
+		return
+	}
+
+	if v.Flags.Short {
+		v.Shell().Println(version)
+		return
+	}
+
+	if v.Flags.JSON {
+		var encoded []byte
+
+		if encoded, err = json.Marshal(koolVersionInfo{version, commit, buildDate}); err != nil {
+			return
+		}
+
+		v.Shell().Println(string(encoded))
+		return
+	}
+
+	v.Shell().Println(fmt.Sprintf("kool version %s", version))
+	return
+}
+
+// NewVersionCommand initializes new kool version command
+func NewVersionCommand(version *KoolVersion) (cmd *cobra.Command) {
+	cmd = &cobra.Command{
+		Use:   "version",
+		Short: "Print the kool version",
+		Long: `Print the kool version, the same as 'kool --version'. Use --short to print
+only the bare version number, or --json to print the version along with the commit
+and build date kool was built from.`,
+		Args: cobra.NoArgs,
+		RunE: DefaultCommandRunFunction(version),
+
+		DisableFlagsInUseLine: true,
+	}
+
+	cmd.Flags().BoolVarP(&version.Flags.Short, "short", "", false, "Print only the version number")
+	cmd.Flags().BoolVarP(&version.Flags.JSON, "json", "", false, "Print the version, commit and build date as JSON")
+
+	return
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"encoding/json"
+	"kool-dev/kool/core/shell"
+	"testing"
+)
+
+func newFakeKoolVersion() *KoolVersion {
+	return &KoolVersion{
+		*(newDefaultKoolService().Fake()),
+		&KoolVersionFlags{false, false},
+	}
+}
+
+func TestVersionCommand(t *testing.T) {
+	f := newFakeKoolVersion()
+	cmd := NewVersionCommand(f)
+	cmd.SetArgs([]string{})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing version command; error: %v", err)
+	}
+
+	if output := f.shell.(*shell.FakeShell).OutLines; len(output) != 1 || output[0] != "kool version "+version {
+		t.Errorf("unexpected version output: %v", output)
+	}
+}
+
+func TestShortVersionCommand(t *testing.T) {
+	f := newFakeKoolVersion()
+	cmd := NewVersionCommand(f)
+	cmd.SetArgs([]string{"--short"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing version command; error: %v", err)
+	}
+
+	if output := f.shell.(*shell.FakeShell).OutLines; len(output) != 1 || output[0] != version {
+		t.Errorf("expected only the version number; got %v", output)
+	}
+}
+
+func TestJSONVersionCommand(t *testing.T) {
+	f := newFakeKoolVersion()
+	cmd := NewVersionCommand(f)
+	cmd.SetArgs([]string{"--json"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("unexpected error executing version command; error: %v", err)
+	}
+
+	var info map[string]string
+
+	if err := json.Unmarshal([]byte(f.shell.(*shell.FakeShell).OutLines[0]), &info); err != nil {
+		t.Fatalf("failed parsing JSON output: %v", err)
+	}
+
+	if info["version"] != version || info["commit"] != commit || info["build_date"] != buildDate {
+		t.Errorf("unexpected JSON version info: %v", info)
+	}
+
+	f = newFakeKoolVersion()
+	cmd = NewVersionCommand(f)
+	cmd.SetArgs([]string{"--json", "--short"})
+
+	assertExecGotError(t, cmd, "--short and --json cannot be used together")
+}
@@ -9,6 +9,8 @@ const (
 	TypePrompt
 	TypeRecipe
 	TypeMerge
+	TypePatch
+	TypeDownload
 )
 
 // ActionSet represents a set of single actions or a question
@@ -36,6 +38,17 @@ type Action struct {
 	Prompt  string       `yaml:"prompt"`
 	Default string       `yaml:"default"`
 	Options []*ActionSet `yaml:"options"`
+	// patch
+	Patch   string `yaml:"patch"`
+	Insert  string `yaml:"insert"`
+	After   string `yaml:"after"`
+	Before  string `yaml:"before"`
+	Unless  string `yaml:"unless"`
+	Replace string `yaml:"replace"`
+	With    string `yaml:"with"`
+	// download
+	Download string `yaml:"download"`
+	SHA256   string `yaml:"sha256"`
 }
 
 // Type tells the actual implementation of this action
@@ -60,5 +73,13 @@ func (a *Action) Type() ActionType {
 		return TypeMerge
 	}
 
+	if a.Patch != "" {
+		return TypePatch
+	}
+
+	if a.Download != "" {
+		return TypeDownload
+	}
+
 	return TypeUnknown
 }
@@ -84,6 +84,34 @@ func TestParseActionMerge(t *testing.T) {
 	})
 }
 
+func TestParseActionPatch(t *testing.T) {
+	t.Run("Parse patch basic", func(t *testing.T) {
+		a := parseAction("patch: 'nginx.conf'\ninsert: 'gzip on;'\nafter: '^http'", t)
+
+		if a.Patch != "nginx.conf" || a.Insert != "gzip on;" || a.After != "^http" {
+			t.Errorf("failed parsing ActionPatch - expected nginx.conf, gzip on;, ^http: %v", a)
+		}
+
+		if a.Type() != TypePatch {
+			t.Errorf("failed parsing ActionPatch type; got: %v - %+v", a.Type(), a)
+		}
+	})
+}
+
+func TestParseActionDownload(t *testing.T) {
+	t.Run("Parse download basic", func(t *testing.T) {
+		a := parseAction("download: 'https://example.com/tool'\ndst: 'bin/tool'\nsha256: 'abc'", t)
+
+		if a.Download != "https://example.com/tool" || a.Dst != "bin/tool" || a.SHA256 != "abc" {
+			t.Errorf("failed parsing ActionDownload: %v", a)
+		}
+
+		if a.Type() != TypeDownload {
+			t.Errorf("failed parsing ActionDownload type; got: %v - %+v", a.Type(), a)
+		}
+	})
+}
+
 func TestParseActionSets(t *testing.T) {
 	t.Run("Parse no steps", func(t *testing.T) {
 		s := new(ActionSet)
@@ -0,0 +1,17 @@
+package automate
+
+import (
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// unifiedDiff builds a unified diff between the current
+// contents of a file and the contents about to be written
+func unifiedDiff(path string, current, proposed []byte) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(current)),
+		B:        difflib.SplitLines(string(proposed)),
+		FromFile: path,
+		ToFile:   path,
+		Context:  3,
+	})
+}
@@ -0,0 +1,198 @@
+package automate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"kool-dev/kool/core/network"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// DownloadProgress gets notified about the bytes written so far for the
+// destination file; total is -1 when the server does not tell the size
+type DownloadProgress func(dst string, written, total int64)
+
+// downloadRetries is how many times a failed download is retried
+var downloadRetries = 3
+
+// downloadRetryDelay is how long we wait before retrying a download
+var downloadRetryDelay = 2 * time.Second
+
+// downloadTimeout bounds each download attempt, so a stalled
+// server does not hang the preset or create forever
+var downloadTimeout = 10 * time.Minute
+
+// errDownloadNoRetry wraps download failures not worth retrying
+type errDownloadNoRetry struct {
+	err error
+}
+
+func (e *errDownloadNoRetry) Error() string {
+	return e.err.Error()
+}
+
+func (e *Executor) download(action *Action) (err error) {
+	// defaults to the URL file name
+	if action.Dst == "" {
+		action.Dst = filepath.Base(strings.SplitN(action.Download, "?", 2)[0])
+	}
+
+	if e.diff {
+		e.sh.Println("→ would download", action.Download, "to", action.Dst)
+		return
+	}
+
+	if action.SHA256 != "" {
+		if sum, sumErr := e.fileSHA256(action.Dst); sumErr == nil && strings.EqualFold(sum, action.SHA256) {
+			e.sh.Println("→ skipping", action.Dst, "(unchanged)")
+			e.skipped = append(e.skipped, action.Dst)
+			return
+		}
+	}
+
+	if err = network.CheckOnline(e.env, "downloading "+action.Download); err != nil {
+		return
+	}
+
+	e.sh.Println("→ downloading", action.Download, "to", action.Dst)
+
+	for attempt := 1; ; attempt++ {
+		if err = e.fetch(action); err == nil {
+			break
+		}
+
+		if _, noRetry := err.(*errDownloadNoRetry); noRetry || attempt > downloadRetries {
+			err = fmt.Errorf("failed downloading %s: %v", action.Download, err)
+			return
+		}
+
+		e.sh.Warning(fmt.Sprintf("Download of %s failed (%v); retrying (%d/%d)", action.Download, err, attempt, downloadRetries))
+		time.Sleep(downloadRetryDelay)
+	}
+
+	e.written = append(e.written, action.Dst)
+	return
+}
+
+// fetch streams the URL contents into a temporary file, verifying
+// its checksum before moving it to the destination; the partial
+// file is removed upon any failure
+func (e *Executor) fetch(action *Action) (err error) {
+	var (
+		resp    *http.Response
+		file    afero.File
+		partial = action.Dst + ".download"
+		hasher  hash.Hash
+		req     *http.Request
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	defer cancel()
+
+	defer func() {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", downloadTimeout)
+		}
+	}()
+
+	if req, err = http.NewRequestWithContext(ctx, http.MethodGet, action.Download, nil); err != nil {
+		err = &errDownloadNoRetry{err}
+		return
+	}
+
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		return
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		err = fmt.Errorf("bad response status %d", resp.StatusCode)
+		if resp.StatusCode < 500 {
+			err = &errDownloadNoRetry{err}
+		}
+		return
+	}
+
+	if dir := filepath.Dir(action.Dst); dir != "." {
+		if err = e.local.MkdirAll(dir, 0755); err != nil {
+			return
+		}
+	}
+
+	if file, err = e.local.OpenFile(partial, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755); err != nil {
+		return
+	}
+
+	defer func() {
+		_ = file.Close()
+
+		if err != nil {
+			_ = e.local.Remove(partial)
+		}
+	}()
+
+	hasher = sha256.New()
+
+	var writer io.Writer = io.MultiWriter(file, hasher)
+	if e.downloadProgress != nil {
+		writer = io.MultiWriter(writer, &progressWriter{action.Dst, resp.ContentLength, 0, e.downloadProgress})
+	}
+
+	if _, err = io.Copy(writer, resp.Body); err != nil {
+		return
+	}
+
+	if sum := hex.EncodeToString(hasher.Sum(nil)); action.SHA256 != "" && !strings.EqualFold(sum, action.SHA256) {
+		err = &errDownloadNoRetry{fmt.Errorf("checksum mismatch (expected %s, got %s)", action.SHA256, sum)}
+		return
+	}
+
+	if err = file.Close(); err != nil {
+		return
+	}
+
+	err = e.local.Rename(partial, action.Dst)
+	return
+}
+
+func (e *Executor) fileSHA256(path string) (sum string, err error) {
+	var file afero.File
+
+	if file, err = e.local.Open(path); err != nil {
+		return
+	}
+
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err = io.Copy(hasher, file); err != nil {
+		return
+	}
+
+	sum = hex.EncodeToString(hasher.Sum(nil))
+	return
+}
+
+type progressWriter struct {
+	dst            string
+	total, written int64
+	notify         DownloadProgress
+}
+
+func (p *progressWriter) Write(data []byte) (n int, err error) {
+	n = len(data)
+	p.written += int64(n)
+	p.notify(p.dst, p.written, p.total)
+	return
+}
@@ -0,0 +1,196 @@
+package automate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"kool-dev/kool/core/network"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestExecutorDownload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("binary contents"))
+	}))
+	defer server.Close()
+
+	var progress int64
+
+	e := newFakeExecutor("")
+	e.SetDownloadProgress(func(dst string, written, total int64) {
+		if dst != "bin/tool" {
+			t.Errorf("unexpected progress destination: %s", dst)
+		}
+		progress = written
+	})
+
+	action := &Action{Download: server.URL + "/tool", Dst: "bin/tool", SHA256: sha256Hex("binary contents")}
+
+	if err := e.Do([]*ActionSet{{Actions: []*Action{action}}}); err != nil {
+		t.Fatalf("unexpected error downloading: %v", err)
+	}
+
+	if data, _ := afero.ReadFile(e.local, "bin/tool"); string(data) != "binary contents" {
+		t.Errorf("unexpected downloaded contents: %s", string(data))
+	}
+
+	if progress != int64(len("binary contents")) {
+		t.Errorf("progress hook was not notified; got %d", progress)
+	}
+
+	if exists, _ := afero.Exists(e.local, "bin/tool.download"); exists {
+		t.Error("temporary download file should have been moved")
+	}
+
+	// downloading again with a matching checksum is a no-op
+	if err := e.Do([]*ActionSet{{Actions: []*Action{action}}}); err != nil {
+		t.Fatalf("unexpected error downloading again: %v", err)
+	}
+
+	if written, skipped := e.Summary(); len(written) != 1 || len(skipped) != 1 {
+		t.Errorf("unexpected summary; written: %v skipped: %v", written, skipped)
+	}
+}
+
+func TestExecutorDownloadChecksumMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("tampered"))
+	}))
+	defer server.Close()
+
+	e := newFakeExecutor("")
+
+	err := e.Do([]*ActionSet{{Actions: []*Action{{Download: server.URL + "/tool", SHA256: sha256Hex("original")}}}})
+
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("expected checksum mismatch error; got %v", err)
+	}
+
+	for _, file := range []string{"tool", "tool.download"} {
+		if exists, _ := afero.Exists(e.local, file); exists {
+			t.Errorf("file %s should have been cleaned up", file)
+		}
+	}
+}
+
+func TestExecutorDownloadRetries(t *testing.T) {
+	originalDelay := downloadRetryDelay
+	downloadRetryDelay = time.Millisecond
+	defer func() { downloadRetryDelay = originalDelay }()
+
+	var calls int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls++; calls < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	e := newFakeExecutor("")
+
+	if err := e.Do([]*ActionSet{{Actions: []*Action{{Download: server.URL + "/file.txt?v=1"}}}}); err != nil {
+		t.Fatalf("unexpected error downloading: %v", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("expected 3 download attempts; got %d", calls)
+	}
+
+	assertFileContents(t, e, "ok")
+
+	calls = 0
+	notFound := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer notFound.Close()
+
+	if err := e.Do([]*ActionSet{{Actions: []*Action{{Download: notFound.URL + "/missing"}}}}); err == nil || !strings.Contains(err.Error(), "bad response status 404") {
+		t.Errorf("expected bad status error; got %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("client errors should not be retried; got %d attempts", calls)
+	}
+}
+
+func TestExecutorDownloadTimeout(t *testing.T) {
+	originalTimeout, originalRetries := downloadTimeout, downloadRetries
+	downloadTimeout, downloadRetries = 50*time.Millisecond, 0
+	defer func() { downloadTimeout, downloadRetries = originalTimeout, originalRetries }()
+
+	stalled := make(chan bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		_, _ = w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+
+		select {
+		case <-stalled:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(stalled)
+
+	e := newFakeExecutor("")
+
+	err := e.Do([]*ActionSet{{Actions: []*Action{{Download: server.URL + "/tool"}}}})
+
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Errorf("expected timeout error; got %v", err)
+	}
+
+	if exists, _ := afero.Exists(e.local, "tool.download"); exists {
+		t.Error("partial download should have been cleaned up")
+	}
+}
+
+func TestExecutorDownloadOffline(t *testing.T) {
+	var calls int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer server.Close()
+
+	e := newFakeExecutor("")
+	e.env.Set("KOOL_OFFLINE", "true")
+
+	err := e.Do([]*ActionSet{{Actions: []*Action{{Download: server.URL + "/tool"}}}})
+
+	if !errors.Is(err, network.ErrOffline) {
+		t.Errorf("expected offline error; got %v", err)
+	}
+
+	if calls != 0 {
+		t.Errorf("should not download when offline; got %d requests", calls)
+	}
+}
+
+func TestExecutorDownloadDiffMode(t *testing.T) {
+	e := newFakeExecutor("")
+	e.SetDiffMode(true)
+
+	if err := e.Do([]*ActionSet{{Actions: []*Action{{Download: "http://localhost/tool", Dst: "tool"}}}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if exists, _ := afero.Exists(e.local, "tool"); exists {
+		t.Error("should not download in diff mode")
+	}
+}
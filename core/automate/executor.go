@@ -1,12 +1,16 @@
 package automate
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/environment"
 	"kool-dev/kool/core/shell"
 	"kool-dev/kool/services/yamler"
 	"os"
+	"path/filepath"
+	"slices"
 	"time"
 
 	"github.com/spf13/afero"
@@ -18,24 +22,79 @@ type RetrieveSource func(string) ([]byte, error)
 
 type Executor struct {
 	sh            shell.Shell
+	env           environment.EnvStorage
 	getFromSource RetrieveSource
 	local         afero.Fs
 	prompter      shell.PromptSelect
 
 	// promptState is a map of prompt answers
 	promptState map[string]string
+
+	// force overwrites existing files without asking
+	force bool
+
+	// diff only shows what would change, without changing anything
+	diff bool
+
+	// written and skipped keep track of the copied files
+	written []string
+	skipped []string
+
+	// backups maps the overwritten files to the backups of their
+	// original content, so they can be restored (see Backups)
+	backups map[string]string
+
+	// downloadProgress gets notified as downloads make progress
+	downloadProgress DownloadProgress
 }
 
+const (
+	conflictOverwrite = "Overwrite"
+	conflictSkip      = "Skip"
+	conflictDiff      = "Show diff"
+)
+
 func NewExecutor(sh shell.Shell, fn RetrieveSource) *Executor {
 	return &Executor{
 		sh:            sh,
+		env:           environment.NewEnvStorage(),
 		getFromSource: fn,
 		local:         afero.NewOsFs(),
 		prompter:      shell.NewPromptSelect(),
 		promptState:   make(map[string]string),
+		backups:       make(map[string]string),
 	}
 }
 
+// SetForce tells whether existing files should be
+// overwritten without prompting for confirmation
+func (e *Executor) SetForce(force bool) {
+	e.force = force
+}
+
+// SetDiffMode tells whether the executor should only show a diff
+// of the files it would change, without actually changing them
+func (e *Executor) SetDiffMode(diff bool) {
+	e.diff = diff
+}
+
+// SetDownloadProgress sets a hook to be notified about the
+// progress of downloads, useful for indicating large ones
+func (e *Executor) SetDownloadProgress(fn DownloadProgress) {
+	e.downloadProgress = fn
+}
+
+// Backups returns the files overwritten so far, mapped to the backups
+// of their original content (both as absolute paths)
+func (e *Executor) Backups() map[string]string {
+	return e.backups
+}
+
+// Summary returns the files written and skipped so far
+func (e *Executor) Summary() (written, skipped []string) {
+	return e.written, e.skipped
+}
+
 func (e *Executor) Do(steps []*ActionSet) (err error) {
 	var (
 		step   *ActionSet
@@ -67,6 +126,14 @@ func (e *Executor) Do(steps []*ActionSet) (err error) {
 				if err = e.merge(action); err != nil {
 					return
 				}
+			case TypePatch:
+				if err = e.patch(action); err != nil {
+					return
+				}
+			case TypeDownload:
+				if err = e.download(action); err != nil {
+					return
+				}
 			case TypePrompt:
 				if err = e.prompt(action); err != nil {
 					return
@@ -81,6 +148,34 @@ func (e *Executor) Do(steps []*ActionSet) (err error) {
 	return
 }
 
+// backup renames the file about to be overwritten, keeping its original
+// content; a file already backed up (for being written more than once)
+// is just overwritten, so the backup holds the original content still
+func (e *Executor) backup(dst string) (renamedFile string, err error) {
+	var abs string
+
+	if abs, err = filepath.Abs(dst); err != nil {
+		return
+	}
+
+	if _, backedUp := e.backups[abs]; backedUp {
+		return
+	}
+
+	renamedFile = fmt.Sprintf("%s.bak.%s", dst, time.Now().Format("20060102"))
+
+	if err = e.local.Rename(dst, renamedFile); err != nil {
+		return
+	}
+
+	if e.backups == nil {
+		e.backups = make(map[string]string)
+	}
+
+	e.backups[abs], _ = filepath.Abs(renamedFile)
+	return
+}
+
 func (e *Executor) copy(action *Action) (err error) {
 	var (
 		data []byte
@@ -100,18 +195,34 @@ func (e *Executor) copy(action *Action) (err error) {
 		return
 	}
 
+	if e.diff {
+		err = e.showDiff(action.Dst, data)
+		return
+	}
+
 	if _, statErr := e.local.Stat(action.Dst); !os.IsNotExist(statErr) {
-		renamedFile := fmt.Sprintf("%s.bak.%s", action.Dst, time.Now().Format("20060102"))
+		var overwrite bool
 
-		e.sh.Warning(fmt.Sprintf(
-			"File %s already exists, overriding. (backup is %s)",
-			action.Dst,
-			renamedFile,
-		))
+		if overwrite, err = e.resolveConflict(action.Dst, data); err != nil || !overwrite {
+			if err == nil {
+				e.skipped = append(e.skipped, action.Dst)
+			}
+			return
+		}
+
+		var backup string
 
-		if err = e.local.Rename(action.Dst, renamedFile); err != nil {
+		if backup, err = e.backup(action.Dst); err != nil {
 			return
 		}
+
+		if backup != "" {
+			e.sh.Warning(fmt.Sprintf(
+				"File %s already exists, overriding. (backup is %s)",
+				action.Dst,
+				backup,
+			))
+		}
 	}
 
 	if file, err = e.local.OpenFile(action.Dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm); err != nil {
@@ -132,6 +243,87 @@ func (e *Executor) copy(action *Action) (err error) {
 	}
 
 	_ = file.Close()
+
+	e.written = append(e.written, action.Dst)
+	return
+}
+
+// resolveConflict decides whether an existing file should be overwritten
+// with the given data; unless forced, the user gets prompted for it, and
+// in non-interactive environments the file is left untouched
+func (e *Executor) resolveConflict(path string, data []byte) (overwrite bool, err error) {
+	var current []byte
+
+	if current, err = afero.ReadFile(e.local, path); err != nil {
+		return
+	}
+
+	if bytes.Equal(current, data) {
+		e.sh.Println("→ skipping", path, "(unchanged)")
+		return
+	}
+
+	if e.force {
+		overwrite = true
+		return
+	}
+
+	if !e.sh.IsTerminal() {
+		e.sh.Warning(fmt.Sprintf("File %s already exists, skipping. (use --force to overwrite)", path))
+		return
+	}
+
+	for {
+		var (
+			answer string
+			diff   string
+		)
+
+		if answer, err = e.prompter.Ask(
+			fmt.Sprintf("File %s already exists and differs, what do you want to do", path),
+			[]string{conflictOverwrite, conflictSkip, conflictDiff},
+		); err != nil {
+			return
+		}
+
+		switch answer {
+		case conflictOverwrite:
+			overwrite = true
+			return
+		case conflictDiff:
+			if diff, err = unifiedDiff(path, current, data); err != nil {
+				return
+			}
+			e.sh.Println(shell.ColorizeDiff(diff))
+		default:
+			return
+		}
+	}
+}
+
+// showDiff prints out the unified diff between the current
+// contents of the file and the data that would be written
+func (e *Executor) showDiff(path string, data []byte) (err error) {
+	var current []byte
+
+	if current, err = afero.ReadFile(e.local, path); err != nil {
+		if !os.IsNotExist(err) {
+			return
+		}
+		err = nil
+	}
+
+	if bytes.Equal(current, data) {
+		e.sh.Println("→ no changes to", path)
+		return
+	}
+
+	var diff string
+	if diff, err = unifiedDiff(path, current, data); err != nil {
+		return
+	}
+
+	e.sh.Println(shell.ColorizeDiff(diff))
 	return
 }
 
@@ -185,10 +377,74 @@ func (e *Executor) merge(action *Action) (err error) {
 		return
 	}
 
+	if e.diff {
+		if data, err = yamler.EncodeYAML(into); err != nil {
+			return
+		}
+
+		err = e.showDiff(action.Dst, data)
+		return
+	}
+
 	err = new(yamler.DefaultOutputWritter).WriteYAML(action.Dst, into)
 	return
 }
 
+func (e *Executor) patch(action *Action) (err error) {
+	var (
+		current, patched []byte
+		info             os.FileInfo
+	)
+
+	e.sh.Println("→ patching", action.Patch)
+
+	if info, err = e.local.Stat(action.Patch); err != nil {
+		if os.IsNotExist(err) {
+			err = fmt.Errorf("patch destiny file '%s' does not exist", action.Patch)
+		}
+		return
+	}
+
+	if current, err = afero.ReadFile(e.local, action.Patch); err != nil {
+		return
+	}
+
+	if patched, err = patchContents(action, current); err != nil {
+		return
+	}
+
+	if e.diff {
+		err = e.showDiff(action.Patch, patched)
+		return
+	}
+
+	if bytes.Equal(current, patched) {
+		e.sh.Println("→ skipping", action.Patch, "(already patched)")
+		e.skipped = append(e.skipped, action.Patch)
+		return
+	}
+
+	// files written earlier on are either new or backed up already
+	if !slices.Contains(e.written, action.Patch) {
+		var backup string
+
+		if backup, err = e.backup(action.Patch); err != nil {
+			return
+		}
+
+		if backup != "" {
+			e.sh.Println("  original kept as", backup)
+		}
+	}
+
+	if err = afero.WriteFile(e.local, action.Patch, patched, info.Mode()); err != nil {
+		return
+	}
+
+	e.written = append(e.written, action.Patch)
+	return
+}
+
 func (e *Executor) prompt(action *Action) (err error) {
 	var (
 		optionsList []string
@@ -258,6 +514,11 @@ func (e *Executor) scripts(action *Action) (err error) {
 
 	// all commands have parsed succussfully; now execute them
 	for _, command = range commands {
+		if e.diff {
+			e.sh.Println("→ would exec:", command.String())
+			continue
+		}
+
 		e.sh.Println("→ exec:", command.String())
 		if err = e.sh.Interactive(command); err != nil {
 			return
@@ -0,0 +1,331 @@
+package automate
+
+import (
+	"errors"
+	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/shell"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+const conflictQuestion = "File file.txt already exists and differs, what do you want to do"
+
+func newFakeExecutor(source string) *Executor {
+	return &Executor{
+		sh:  &shell.FakeShell{},
+		env: environment.NewFakeEnvStorage(),
+		getFromSource: func(string) ([]byte, error) {
+			return []byte(source), nil
+		},
+		local:       afero.NewMemMapFs(),
+		prompter:    &shell.FakePromptSelect{},
+		promptState: make(map[string]string),
+	}
+}
+
+func doCopy(t *testing.T, e *Executor) {
+	if err := e.Do([]*ActionSet{{Actions: []*Action{{Src: "file.txt"}}}}); err != nil {
+		t.Fatalf("unexpected error copying file: %v", err)
+	}
+}
+
+func assertFileContents(t *testing.T, e *Executor, expected string) {
+	if data, _ := afero.ReadFile(e.local, "file.txt"); string(data) != expected {
+		t.Errorf("expected file contents '%s'; got '%s'", expected, string(data))
+	}
+}
+
+func TestExecutorCopyNewFile(t *testing.T) {
+	e := newFakeExecutor("new")
+
+	doCopy(t, e)
+
+	assertFileContents(t, e, "new")
+
+	if written, skipped := e.Summary(); len(written) != 1 || len(skipped) != 0 {
+		t.Errorf("unexpected summary; written: %v skipped: %v", written, skipped)
+	}
+}
+
+func TestExecutorCopyUnchangedFile(t *testing.T) {
+	e := newFakeExecutor("same")
+	_ = afero.WriteFile(e.local, "file.txt", []byte("same"), 0644)
+
+	doCopy(t, e)
+
+	if e.prompter.(*shell.FakePromptSelect).CalledAsk {
+		t.Error("should not prompt for unchanged files")
+	}
+
+	if written, skipped := e.Summary(); len(written) != 0 || len(skipped) != 1 {
+		t.Errorf("unexpected summary; written: %v skipped: %v", written, skipped)
+	}
+}
+
+func TestExecutorCopyConflictNonInteractive(t *testing.T) {
+	e := newFakeExecutor("new")
+	_ = afero.WriteFile(e.local, "file.txt", []byte("custom"), 0644)
+
+	doCopy(t, e)
+
+	assertFileContents(t, e, "custom")
+
+	if !strings.Contains(e.sh.(*shell.FakeShell).WarningOutput[0].(string), "skipping") {
+		t.Errorf("expected skipping warning; got %v", e.sh.(*shell.FakeShell).WarningOutput)
+	}
+
+	e.SetForce(true)
+	doCopy(t, e)
+
+	assertFileContents(t, e, "new")
+
+	if written, skipped := e.Summary(); len(written) != 1 || len(skipped) != 1 {
+		t.Errorf("unexpected summary; written: %v skipped: %v", written, skipped)
+	}
+}
+
+func TestExecutorCopyBackups(t *testing.T) {
+	e := newFakeExecutor("new")
+	e.SetForce(true)
+	_ = afero.WriteFile(e.local, "file.txt", []byte("custom"), 0644)
+
+	doCopy(t, e)
+	doCopy(t, e)
+
+	assertFileContents(t, e, "new")
+
+	backups := e.Backups()
+	abs, _ := filepath.Abs("file.txt")
+
+	if len(backups) != 1 || backups[abs] != abs+".bak."+time.Now().Format("20060102") {
+		t.Fatalf("expected the backup of file.txt to be tracked; got %v", backups)
+	}
+
+	if data, _ := afero.ReadFile(e.local, "file.txt.bak."+time.Now().Format("20060102")); string(data) != "custom" {
+		t.Errorf("expected the backup to keep the original content; got '%s'", string(data))
+	}
+}
+
+func TestExecutorCopyConflictPrompt(t *testing.T) {
+	e := newFakeExecutor("new")
+	e.sh.(*shell.FakeShell).MockIsTerminal = true
+	_ = afero.WriteFile(e.local, "file.txt", []byte("custom"), 0644)
+
+	prompter := e.prompter.(*shell.FakePromptSelect)
+	prompter.MockAnswer = map[string]string{conflictQuestion: conflictSkip}
+
+	doCopy(t, e)
+
+	if !prompter.CalledAsk {
+		t.Error("should prompt for conflicting files")
+	}
+
+	assertFileContents(t, e, "custom")
+
+	prompter.MockAnswer[conflictQuestion] = conflictOverwrite
+
+	doCopy(t, e)
+
+	assertFileContents(t, e, "new")
+
+	prompter.MockError = map[string]error{conflictQuestion: errors.New("prompt error")}
+	_ = afero.WriteFile(e.local, "file.txt", []byte("custom"), 0644)
+
+	if err := e.Do([]*ActionSet{{Actions: []*Action{{Src: "file.txt"}}}}); err == nil || err.Error() != "prompt error" {
+		t.Errorf("expected prompt error; got %v", err)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	diff, err := unifiedDiff("file.txt", []byte("a\nb\n"), []byte("a\nc\n"))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(diff, "-b") || !strings.Contains(diff, "+c") || !strings.Contains(diff, "--- file.txt") {
+		t.Errorf("unexpected diff output: %s", diff)
+	}
+}
+
+func TestExecutorDiffMode(t *testing.T) {
+	e := newFakeExecutor("new\n")
+	e.SetDiffMode(true)
+	_ = afero.WriteFile(e.local, "file.txt", []byte("custom\n"), 0644)
+
+	if err := e.Do([]*ActionSet{{Actions: []*Action{
+		{Src: "file.txt"},
+		{Scripts: []string{"echo foo"}},
+	}}}); err != nil {
+		t.Fatalf("unexpected error on diff mode: %v", err)
+	}
+
+	assertFileContents(t, e, "custom\n")
+
+	out := strings.Join(e.sh.(*shell.FakeShell).OutLines, "\n")
+
+	if !strings.Contains(out, "-custom") || !strings.Contains(out, "+new") {
+		t.Errorf("expected diff output; got: %s", out)
+	}
+
+	if !strings.Contains(out, "→ would exec: echo foo") {
+		t.Errorf("expected scripts to be skipped; got: %s", out)
+	}
+
+	if e.sh.(*shell.FakeShell).CalledInteractive["echo"] {
+		t.Error("should not execute scripts on diff mode")
+	}
+
+	if written, skipped := e.Summary(); len(written) != 0 || len(skipped) != 0 {
+		t.Errorf("unexpected summary; written: %v skipped: %v", written, skipped)
+	}
+}
+
+func TestExecutorPatch(t *testing.T) {
+	const conf = "http {\n    sendfile on;\n}\n"
+
+	for name, tc := range map[string]struct {
+		action   *Action
+		expected string
+	}{
+		"append": {
+			&Action{Patch: "file.txt", Insert: "# end"},
+			conf + "# end\n",
+		},
+		"after anchor": {
+			&Action{Patch: "file.txt", Insert: "    gzip on;", After: `^http \{`},
+			"http {\n    gzip on;\n    sendfile on;\n}\n",
+		},
+		"before anchor": {
+			&Action{Patch: "file.txt", Insert: "    gzip on;", Before: `^\}`},
+			"http {\n    sendfile on;\n    gzip on;\n}\n",
+		},
+		"replace": {
+			&Action{Patch: "file.txt", Replace: `sendfile \w+;`, With: "sendfile off;"},
+			"http {\n    sendfile off;\n}\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			e := newFakeExecutor("")
+			_ = afero.WriteFile(e.local, "file.txt", []byte(conf), 0644)
+
+			if err := e.Do([]*ActionSet{{Actions: []*Action{tc.action}}}); err != nil {
+				t.Fatalf("unexpected error patching file: %v", err)
+			}
+
+			assertFileContents(t, e, tc.expected)
+
+			if written, skipped := e.Summary(); len(written) != 1 || len(skipped) != 0 {
+				t.Errorf("unexpected summary; written: %v skipped: %v", written, skipped)
+			}
+
+			// applying the very same patch again changes nothing
+			if err := e.Do([]*ActionSet{{Actions: []*Action{tc.action}}}); err != nil {
+				t.Fatalf("unexpected error patching file again: %v", err)
+			}
+
+			assertFileContents(t, e, tc.expected)
+
+			if written, skipped := e.Summary(); len(written) != 1 || len(skipped) != 1 {
+				t.Errorf("patch should be idempotent; written: %v skipped: %v", written, skipped)
+			}
+		})
+	}
+}
+
+func TestExecutorPatchAlreadyApplied(t *testing.T) {
+	e := newFakeExecutor("")
+	_ = afero.WriteFile(e.local, "file.txt", []byte("client_max_body_size 10M;\n"), 0644)
+
+	action := &Action{Patch: "file.txt", Insert: "client_max_body_size 100M;", Unless: `client_max_body_size`}
+
+	if err := e.Do([]*ActionSet{{Actions: []*Action{action}}}); err != nil {
+		t.Fatalf("unexpected error patching file: %v", err)
+	}
+
+	assertFileContents(t, e, "client_max_body_size 10M;\n")
+
+	if !strings.Contains(strings.Join(e.sh.(*shell.FakeShell).OutLines, "\n"), "→ skipping file.txt (already patched)") {
+		t.Errorf("should report the file as already patched; got %v", e.sh.(*shell.FakeShell).OutLines)
+	}
+}
+
+func TestExecutorPatchReplaceUnless(t *testing.T) {
+	e := newFakeExecutor("")
+	_ = afero.WriteFile(e.local, "file.txt", []byte("foo\n"), 0644)
+
+	action := &Action{Patch: "file.txt", Replace: `foo`, With: "foobar", Unless: `foobar`}
+
+	for i := 0; i < 2; i++ {
+		if err := e.Do([]*ActionSet{{Actions: []*Action{action}}}); err != nil {
+			t.Fatalf("unexpected error patching file: %v", err)
+		}
+
+		assertFileContents(t, e, "foobar\n")
+	}
+
+	if written, skipped := e.Summary(); len(written) != 1 || len(skipped) != 1 {
+		t.Errorf("patch should be applied only once; written: %v skipped: %v", written, skipped)
+	}
+}
+
+func TestExecutorPatchBackups(t *testing.T) {
+	e := newFakeExecutor("")
+	_ = afero.WriteFile(e.local, "file.txt", []byte("a\n"), 0644)
+
+	for _, insert := range []string{"b", "c"} {
+		if err := e.Do([]*ActionSet{{Actions: []*Action{{Patch: "file.txt", Insert: insert}}}}); err != nil {
+			t.Fatalf("unexpected error patching file: %v", err)
+		}
+	}
+
+	assertFileContents(t, e, "a\nb\nc\n")
+
+	backup := "file.txt.bak." + time.Now().Format("20060102")
+	abs, _ := filepath.Abs("file.txt")
+
+	if backups := e.Backups(); len(backups) != 1 || !strings.HasSuffix(backups[abs], backup) {
+		t.Fatalf("expected the patched file to be backed up once; got %v", backups)
+	}
+
+	if data, _ := afero.ReadFile(e.local, backup); string(data) != "a\n" {
+		t.Errorf("expected the backup to keep the original content; got '%s'", string(data))
+	}
+}
+
+func TestExecutorPatchErrors(t *testing.T) {
+	for action, expected := range map[*Action]string{
+		{Patch: "missing.txt", Insert: "x"}:                       "patch destiny file 'missing.txt' does not exist",
+		{Patch: "file.txt"}:                                       "needs either 'insert' or 'replace'",
+		{Patch: "file.txt", Insert: "x", After: "nope"}:           "could not find 'nope' for patching file.txt",
+		{Patch: "file.txt", Replace: "("}:                         "bad replace pattern",
+		{Patch: "file.txt", Replace: "content", With: "content2"}: "changes the file again on every run; add an 'unless' pattern",
+	} {
+		e := newFakeExecutor("")
+		_ = afero.WriteFile(e.local, "file.txt", []byte("content\n"), 0644)
+
+		if err := e.Do([]*ActionSet{{Actions: []*Action{action}}}); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error '%s'; got %v", expected, err)
+		}
+	}
+}
+
+func TestExecutorPatchDiffMode(t *testing.T) {
+	e := newFakeExecutor("")
+	e.SetDiffMode(true)
+	_ = afero.WriteFile(e.local, "file.txt", []byte("a\n"), 0644)
+
+	if err := e.Do([]*ActionSet{{Actions: []*Action{{Patch: "file.txt", Insert: "b"}}}}); err != nil {
+		t.Fatalf("unexpected error patching file: %v", err)
+	}
+
+	assertFileContents(t, e, "a\n")
+
+	if !strings.Contains(strings.Join(e.sh.(*shell.FakeShell).OutLines, "\n"), "+b") {
+		t.Errorf("expected diff output; got %v", e.sh.(*shell.FakeShell).OutLines)
+	}
+}
@@ -0,0 +1,110 @@
+package automate
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// patchContents applies the patch action to the given file contents. It
+// either inserts a block of text (appending it or anchoring it after or
+// before the first line matching a regex) or replaces every match of a
+// regex. Patching is idempotent: patches are skipped when the unless regex
+// matches, inserts when the text is already present, and replaces that
+// would change their own output again need an unless regex; so the result
+// may equal the input.
+func patchContents(action *Action, current []byte) (patched []byte, err error) {
+	var re, unless *regexp.Regexp
+
+	if action.Replace == "" && action.Insert == "" {
+		err = fmt.Errorf("patch action for %s needs either 'insert' or 'replace'", action.Patch)
+		return
+	}
+
+	if action.Unless != "" {
+		if unless, err = regexp.Compile(action.Unless); err != nil {
+			err = fmt.Errorf("bad unless pattern for patching %s: %v", action.Patch, err)
+			return
+		}
+
+		if unless.Match(current) {
+			// already applied
+			patched = current
+			return
+		}
+	}
+
+	if action.Replace != "" {
+		if re, err = regexp.Compile(action.Replace); err != nil {
+			err = fmt.Errorf("bad replace pattern for patching %s: %v", action.Patch, err)
+			return
+		}
+
+		patched = re.ReplaceAll(current, []byte(action.With))
+
+		// like replacing foo with foobar, which would grow on every run
+		if unless == nil && !bytes.Equal(patched, re.ReplaceAll(patched, []byte(action.With))) {
+			patched = nil
+			err = fmt.Errorf("replacing '%s' in %s changes the file again on every run; add an 'unless' pattern matching the patched contents", action.Replace, action.Patch)
+		}
+		return
+	}
+
+	var (
+		content = string(current)
+		insert  = action.Insert
+	)
+
+	if unless == nil && strings.Contains(content, strings.TrimRight(insert, "\n")) {
+		// already applied
+		patched = current
+		return
+	}
+
+	if !strings.HasSuffix(insert, "\n") {
+		insert += "\n"
+	}
+
+	if action.After == "" && action.Before == "" {
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+
+		patched = []byte(content + insert)
+		return
+	}
+
+	var (
+		anchor = action.After
+		lines  = strings.SplitAfter(content, "\n")
+	)
+
+	if anchor == "" {
+		anchor = action.Before
+	}
+
+	if re, err = regexp.Compile(anchor); err != nil {
+		err = fmt.Errorf("bad anchor pattern for patching %s: %v", action.Patch, err)
+		return
+	}
+
+	for i, line := range lines {
+		if !re.MatchString(strings.TrimRight(line, "\n")) {
+			continue
+		}
+
+		if action.After != "" {
+			if !strings.HasSuffix(line, "\n") {
+				lines[i] += "\n"
+			}
+			i++
+		}
+
+		patched = []byte(strings.Join(lines[:i], "") + insert + strings.Join(lines[i:], ""))
+		return
+	}
+
+	err = fmt.Errorf("could not find '%s' for patching %s", anchor, action.Patch)
+	return
+}
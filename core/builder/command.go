@@ -38,13 +38,27 @@ func NewCommand(command string, args ...string) *DefaultCommand {
 	return &DefaultCommand{command, args}
 }
 
+// NewComposeCommand creates a new docker compose command; the shell
+// running it resolves the Docker Compose actually available, so commands
+// should always be built in the V2 plugin form through this helper.
+func NewComposeCommand(args ...string) *DefaultCommand {
+	return NewCommand("docker", append([]string{"compose"}, args...)...)
+}
+
 // ParseCommand transforms a command line string into separated
 // command name and arguments list, expanding environment variables
 // if any.
 func ParseCommand(line string) (command *DefaultCommand, err error) {
+	return SplitCommand(os.ExpandEnv(line))
+}
+
+// SplitCommand transforms a command line string into separated
+// command name and arguments list, as it is (without expanding
+// environment variables).
+func SplitCommand(line string) (command *DefaultCommand, err error) {
 	var parsed []string
 
-	if parsed, err = splitFn(os.ExpandEnv(line)); err != nil {
+	if parsed, err = splitFn(line); err != nil {
 		return
 	}
 
@@ -86,3 +100,61 @@ func (c *DefaultCommand) Parse(line string) (err error) {
 func (c *DefaultCommand) Copy() Command {
 	return NewCommand(c.command, c.args...)
 }
+
+// IsCompose tells whether the given command is a docker compose invocation
+func IsCompose(command Command) bool {
+	var args = command.Args()
+
+	return command.Cmd() == "docker" && len(args) > 0 && args[0] == "compose"
+}
+
+// ComposeV1 returns a copy of the given docker compose command running
+// the standalone docker-compose (V1) binary instead of the V2 plugin;
+// any other command is returned as it is
+func ComposeV1(command Command) Command {
+	if !IsCompose(command) {
+		return command
+	}
+
+	return NewCommand("docker-compose", command.Args()[1:]...)
+}
+
+// ComposeV2 returns a copy of the given standalone docker-compose (V1)
+// command running the V2 plugin instead; any other command is returned as it is
+func ComposeV2(command Command) Command {
+	if command.Cmd() != "docker-compose" {
+		return command
+	}
+
+	return NewComposeCommand(command.Args()...)
+}
+
+// VerboseCompose returns a copy of the given command raising the verbosity
+// of docker compose invocations by enabling the docker CLI debug mode;
+// any other command is returned as it is
+func VerboseCompose(command Command) Command {
+	if !IsCompose(command) {
+		return command
+	}
+
+	return NewCommand("docker", append([]string{"--debug"}, command.Args()...)...)
+}
+
+// ComposeProject returns a copy of the given command setting the compose
+// project name on docker compose invocations, unless one is already given;
+// any other command (or an empty project name) is returned as it is
+func ComposeProject(command Command, project string) Command {
+	var args = command.Args()
+
+	if project == "" || !IsCompose(command) {
+		return command
+	}
+
+	for _, arg := range args[1:] {
+		if arg == "-p" || arg == "--project-name" || strings.HasPrefix(arg, "--project-name=") {
+			return command
+		}
+	}
+
+	return NewCommand("docker", append([]string{"compose", "-p", project}, args[1:]...)...)
+}
@@ -61,6 +61,21 @@ func TestParseCommandWithUndefinedEnvironmentVariable(t *testing.T) {
 	}
 }
 
+func TestSplitCommand(t *testing.T) {
+	os.Setenv("ENVVAR", "value")
+	line := "exec --opt '$ENVVAR' a$HOME"
+	cmd, err := SplitCommand(line)
+
+	if err != nil {
+		t.Errorf("failed splitting proper command line; error: %s", err)
+		return
+	}
+
+	if len(cmd.args) != 3 || cmd.args[1] != "$ENVVAR" || cmd.args[2] != "a$HOME" {
+		t.Errorf("SplitCommand should not expand environment variables; given %s got %v", line, cmd.String())
+	}
+}
+
 func TestAppendArgs(t *testing.T) {
 	cmd := NewCommand("echo", "x1")
 
@@ -156,3 +171,103 @@ func TestCopy(t *testing.T) {
 		t.Error("unintended change on original command by changing the copy")
 	}
 }
+
+func TestVerboseCompose(t *testing.T) {
+	c := NewCommand("docker", "compose", "up", "-d")
+
+	if verbose := VerboseCompose(c); verbose.String() != "docker --debug compose up -d" {
+		t.Errorf("unexpected verbose compose command: %s", verbose.String())
+	}
+
+	if c.String() != "docker compose up -d" {
+		t.Errorf("unintended change on original command: %s", c.String())
+	}
+
+	for _, other := range []*DefaultCommand{
+		NewCommand("docker", "ps"),
+		NewCommand("docker"),
+		NewCommand("npm", "compose"),
+	} {
+		if verbose := VerboseCompose(other); verbose != Command(other) {
+			t.Errorf("unexpected change on non compose command: %s", verbose.String())
+		}
+	}
+}
+
+func TestComposeProject(t *testing.T) {
+	c := NewCommand("docker", "compose", "up", "-d")
+
+	if project := ComposeProject(c, "my-project"); project.String() != "docker compose -p my-project up -d" {
+		t.Errorf("unexpected compose command with project: %s", project.String())
+	}
+
+	if c.String() != "docker compose up -d" {
+		t.Errorf("unintended change on original command: %s", c.String())
+	}
+
+	for _, unchanged := range []*DefaultCommand{
+		NewCommand("docker", "compose", "-p", "other", "up"),
+		NewCommand("docker", "compose", "--project-name=other", "up"),
+		NewCommand("docker", "ps"),
+		NewCommand("npm", "compose"),
+	} {
+		if project := ComposeProject(unchanged, "my-project"); project != Command(unchanged) {
+			t.Errorf("unexpected change on command: %s", project.String())
+		}
+	}
+
+	if project := ComposeProject(c, ""); project != Command(c) {
+		t.Errorf("unexpected change with empty project name: %s", project.String())
+	}
+}
+
+func TestComposeV1(t *testing.T) {
+	c := NewCommand("docker", "compose", "-p", "my-project", "up", "-d")
+
+	if !IsCompose(c) {
+		t.Error("expected docker compose command to be told as compose")
+	}
+
+	if v1 := ComposeV1(c); v1.String() != "docker-compose -p my-project up -d" {
+		t.Errorf("unexpected compose V1 command: %s", v1.String())
+	}
+
+	if c.String() != "docker compose -p my-project up -d" {
+		t.Errorf("unintended change on original command: %s", c.String())
+	}
+
+	for _, other := range []*DefaultCommand{
+		NewCommand("docker", "ps"),
+		NewCommand("docker"),
+		NewCommand("npm", "compose"),
+	} {
+		if IsCompose(other) {
+			t.Errorf("unexpected non compose command told as compose: %s", other.String())
+		}
+
+		if v1 := ComposeV1(other); v1 != Command(other) {
+			t.Errorf("unexpected change on non compose command: %s", v1.String())
+		}
+	}
+}
+
+func TestComposeV2(t *testing.T) {
+	if c := NewComposeCommand("ps", "-q"); c.String() != "docker compose ps -q" {
+		t.Errorf("unexpected compose command: %s", c.String())
+	}
+
+	c := NewCommand("docker-compose", "-p", "my-project", "up")
+
+	if v2 := ComposeV2(c); v2.String() != "docker compose -p my-project up" {
+		t.Errorf("unexpected compose V2 command: %s", v2.String())
+	}
+
+	for _, other := range []*DefaultCommand{
+		NewComposeCommand("up"),
+		NewCommand("docker", "ps"),
+	} {
+		if v2 := ComposeV2(other); v2 != Command(other) {
+			t.Errorf("unexpected change on command: %s", v2.String())
+		}
+	}
+}
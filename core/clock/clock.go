@@ -0,0 +1,35 @@
+// Package clock abstracts the passing of time, so that time dependent
+// behaviors (like timeouts and polling) can be tested without sleeping.
+package clock
+
+import "time"
+
+// Clock tells the current time and waits for time to pass
+type Clock interface {
+	Now() time.Time
+	After(time.Duration) <-chan time.Time
+	Sleep(time.Duration)
+}
+
+// DefaultClock is the Clock backed by the actual time
+type DefaultClock struct{}
+
+// NewClock creates a new Clock backed by the actual time
+func NewClock() Clock {
+	return &DefaultClock{}
+}
+
+// Now returns the current time
+func (c *DefaultClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for the duration to elapse and then sends the current time
+func (c *DefaultClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Sleep pauses for the given duration
+func (c *DefaultClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
@@ -0,0 +1,27 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDefaultClock(t *testing.T) {
+	c := NewClock()
+
+	if _, ok := c.(*DefaultClock); !ok {
+		t.Errorf("unexpected Clock on NewClock: %T", c)
+	}
+
+	start := c.Now()
+	c.Sleep(time.Millisecond)
+
+	select {
+	case <-c.After(time.Millisecond):
+	case <-time.After(time.Second):
+		t.Error("After did not fire")
+	}
+
+	if elapsed := c.Now().Sub(start); elapsed < 2*time.Millisecond {
+		t.Errorf("expected at least 2ms to have passed; got %v", elapsed)
+	}
+}
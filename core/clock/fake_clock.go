@@ -0,0 +1,54 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock is a Clock for testing whose time only passes when waiting
+// for it, which happens right away; so timeouts are reached without sleeping
+type FakeClock struct {
+	MockNow time.Time
+
+	CalledSleep, CalledAfter bool
+	Slept                    time.Duration
+
+	mutex sync.Mutex
+}
+
+// NewFakeClock creates a new FakeClock set at the given time
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{MockNow: now}
+}
+
+// Now returns the fake current time
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.MockNow
+}
+
+// After advances the fake time by the given duration and
+// returns a channel which already received the new fake time
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.CalledAfter = true
+	c.MockNow = c.MockNow.Add(d)
+
+	ch := make(chan time.Time, 1)
+	ch <- c.MockNow
+	return ch
+}
+
+// Sleep advances the fake time by the given duration right away
+func (c *FakeClock) Sleep(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.CalledSleep = true
+	c.Slept += d
+	c.MockNow = c.MockNow.Add(d)
+}
@@ -0,0 +1,40 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+
+	if !c.Now().Equal(start) {
+		t.Errorf("expected fake time %v; got %v", start, c.Now())
+	}
+
+	c.Sleep(time.Minute)
+	c.Sleep(time.Minute)
+
+	if !c.CalledSleep || c.Slept != 2*time.Minute || !c.Now().Equal(start.Add(2*time.Minute)) {
+		t.Errorf("expected Sleep to advance the fake time; got %v", c.Now())
+	}
+}
+
+func TestFakeClockAfter(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+
+	select {
+	case now := <-c.After(time.Second):
+		if !now.Equal(start.Add(time.Second)) || !c.Now().Equal(now) {
+			t.Errorf("expected After to advance the fake time; got %v", now)
+		}
+	default:
+		t.Error("After should fire right away")
+	}
+
+	if !c.CalledAfter {
+		t.Error("did not set CalledAfter")
+	}
+}
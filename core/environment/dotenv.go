@@ -0,0 +1,280 @@
+package environment
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var dotenvKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+var dotenvVariableRegex = regexp.MustCompile(`^(?:\{([A-Za-z0-9_]+)\}|([A-Za-z0-9_]+))`)
+
+// ReadDotenv reads and parses the given .env file
+func ReadDotenv(filename string) (envs map[string]string, err error) {
+	var file *os.File
+
+	if file, err = os.Open(filename); err != nil {
+		return
+	}
+
+	defer file.Close()
+
+	envs, err = ParseDotenv(file)
+	return
+}
+
+// LoadDotenv reads the given .env file into the process
+// environment, not overriding variables already set
+func LoadDotenv(filename string) (err error) {
+	var envs map[string]string
+
+	if envs, err = ReadDotenv(filename); err != nil {
+		return
+	}
+
+	for key, value := range envs {
+		if _, exists := os.LookupEnv(key); !exists {
+			os.Setenv(key, value)
+		}
+	}
+
+	return
+}
+
+// ParseDotenv parses the contents of a .env file. Values may be unquoted,
+// single quoted (taken literally) or double quoted (supporting escape
+// sequences); quoted values may span multiple lines. Unquoted and double
+// quoted values have $VAR and ${VAR} references expanded, looking them up
+// in the variables defined earlier in the file before the process environment.
+func ParseDotenv(r io.Reader) (envs map[string]string, err error) {
+	var raw []byte
+
+	if raw, err = io.ReadAll(r); err != nil {
+		return
+	}
+
+	p := &dotenvParser{
+		src:  []rune(strings.ReplaceAll(string(raw), "\r\n", "\n")),
+		line: 1,
+		envs: make(map[string]string),
+	}
+
+	err = p.parse()
+	envs = p.envs
+	return
+}
+
+type dotenvParser struct {
+	src  []rune
+	pos  int
+	line int
+	envs map[string]string
+}
+
+func (p *dotenvParser) parse() (err error) {
+	var key, value string
+
+	for {
+		p.skipBlank()
+
+		if p.eof() {
+			return
+		}
+
+		if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+
+		if key, err = p.parseKey(); err != nil {
+			return
+		}
+
+		if value, err = p.parseValue(key); err != nil {
+			return
+		}
+
+		p.envs[key] = value
+	}
+}
+
+func (p *dotenvParser) parseKey() (key string, err error) {
+	start, line := p.pos, p.line
+
+	for !p.eof() && p.peek() != '=' && p.peek() != '\n' {
+		p.pos++
+	}
+
+	if p.eof() || p.peek() != '=' {
+		err = fmt.Errorf("invalid .env line %d: expected KEY=VALUE", line)
+		return
+	}
+
+	key = strings.TrimSpace(string(p.src[start:p.pos]))
+	key = strings.TrimSpace(strings.TrimPrefix(key, "export "))
+
+	if !dotenvKeyRegex.MatchString(key) {
+		err = fmt.Errorf("invalid .env line %d: bad variable name '%s'", line, key)
+		return
+	}
+
+	// skip the equal sign
+	p.pos++
+	return
+}
+
+func (p *dotenvParser) parseValue(key string) (value string, err error) {
+	var sb strings.Builder
+
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+
+	if p.eof() {
+		return
+	}
+
+	switch quote := p.peek(); quote {
+	case '\'', '"':
+		line := p.line
+		p.pos++
+
+		for {
+			if p.eof() {
+				err = fmt.Errorf("invalid .env line %d: unterminated quoted value for %s", line, key)
+				return
+			}
+
+			c := p.next()
+
+			if c == quote {
+				break
+			}
+
+			if quote == '"' {
+				if c == '\\' && !p.eof() {
+					sb.WriteString(unescapeDotenv(p.next()))
+					continue
+				}
+
+				if c == '$' {
+					sb.WriteString(p.expand())
+					continue
+				}
+			}
+
+			sb.WriteRune(c)
+		}
+
+		value = sb.String()
+
+		for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+			p.pos++
+		}
+
+		if !p.eof() && p.peek() != '\n' && p.peek() != '#' {
+			err = fmt.Errorf("invalid .env line %d: unexpected text after the quoted value for %s", p.line, key)
+			return
+		}
+	default:
+		for !p.eof() && p.peek() != '\n' {
+			c := p.peek()
+
+			// an inline comment needs a whitespace before the hash
+			if c == '#' && (p.src[p.pos-1] == ' ' || p.src[p.pos-1] == '\t') {
+				break
+			}
+
+			p.pos++
+
+			if c == '\\' && !p.eof() && p.peek() == '$' {
+				sb.WriteRune(p.next())
+			} else if c == '$' {
+				sb.WriteString(p.expand())
+			} else {
+				sb.WriteRune(c)
+			}
+		}
+
+		value = strings.TrimSpace(sb.String())
+	}
+
+	// anything left on the line is a comment
+	p.skipLine()
+	return
+}
+
+// expand reads a $VAR or ${VAR} reference right after the dollar sign
+// and returns its value; a lone dollar sign is kept as it is
+func (p *dotenvParser) expand() string {
+	var (
+		rest  = string(p.src[p.pos:])
+		match = dotenvVariableRegex.FindStringSubmatch(rest)
+	)
+
+	if match == nil {
+		return "$"
+	}
+
+	p.pos += len([]rune(match[0]))
+
+	name := match[1]
+	if name == "" {
+		name = match[2]
+	}
+
+	// variables defined earlier in the file come first, like godotenv does
+	if value, defined := p.envs[name]; defined {
+		return value
+	}
+
+	return os.Getenv(name)
+}
+
+func unescapeDotenv(c rune) string {
+	switch c {
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case '"', '\\', '$':
+		return string(c)
+	}
+
+	return `\` + string(c)
+}
+
+func (p *dotenvParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *dotenvParser) peek() rune {
+	return p.src[p.pos]
+}
+
+func (p *dotenvParser) next() (c rune) {
+	c = p.src[p.pos]
+	p.pos++
+
+	if c == '\n' {
+		p.line++
+	}
+
+	return
+}
+
+func (p *dotenvParser) skipBlank() {
+	for !p.eof() && strings.ContainsRune(" \t\n", p.peek()) {
+		p.next()
+	}
+}
+
+func (p *dotenvParser) skipLine() {
+	for !p.eof() && p.next() != '\n' {
+	}
+}
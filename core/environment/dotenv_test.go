@@ -0,0 +1,121 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	os.Setenv("DOTENV_TESTING_HOST", "localhost")
+	defer os.Unsetenv("DOTENV_TESTING_HOST")
+	os.Setenv("DOTENV_TESTING_SHADOWED", "from-os")
+	defer os.Unsetenv("DOTENV_TESTING_SHADOWED")
+
+	content := `# full line comment
+PLAIN=value
+  SPACED =  spaced value   
+export EXPORTED=1
+EMPTY=
+INLINE=value # inline comment
+HASH=value#not-a-comment
+SINGLE='single $PLAIN \n quoted' # comment
+DOUBLE="double \"quoted\" \\ \t\$PLAIN"
+QUOTED_HASH="value # not a comment"
+EXPANDED=${DOUBLE_MISSING}$PLAIN-${DOUBLE_MISSING:-x}
+FROM_OS="http://${DOTENV_TESTING_HOST}:80"
+DOTENV_TESTING_SHADOWED=from-file
+FROM_FILE=$DOTENV_TESTING_SHADOWED
+ESCAPED=\$PLAIN
+LONE=costs 5$
+PRIVATE_KEY="-----BEGIN KEY-----
+abc
+-----END KEY-----"
+ESCAPED_NEWLINES="line1\nline2"
+MULTI_SINGLE='first
+second'
+WINDOWS=crlf` + "\r\n" + `LAST=last`
+
+	envs, err := ParseDotenv(strings.NewReader(content))
+
+	if err != nil {
+		t.Fatalf("unexpected error parsing dotenv: %v", err)
+	}
+
+	expected := map[string]string{
+		"PLAIN":                   "value",
+		"SPACED":                  "spaced value",
+		"EXPORTED":                "1",
+		"EMPTY":                   "",
+		"INLINE":                  "value",
+		"HASH":                    "value#not-a-comment",
+		"SINGLE":                  `single $PLAIN \n quoted`,
+		"DOUBLE":                  "double \"quoted\" \\ \t$PLAIN",
+		"QUOTED_HASH":             "value # not a comment",
+		"EXPANDED":                "value-${DOUBLE_MISSING:-x}",
+		"FROM_OS":                 "http://localhost:80",
+		"DOTENV_TESTING_SHADOWED": "from-file",
+		"FROM_FILE":               "from-file",
+		"ESCAPED":                 "$PLAIN",
+		"LONE":                    "costs 5$",
+		"PRIVATE_KEY":             "-----BEGIN KEY-----\nabc\n-----END KEY-----",
+		"ESCAPED_NEWLINES":        "line1\nline2",
+		"MULTI_SINGLE":            "first\nsecond",
+		"WINDOWS":                 "crlf",
+		"LAST":                    "last",
+	}
+
+	if len(envs) != len(expected) {
+		t.Errorf("expected %d variables; got %d: %v", len(expected), len(envs), envs)
+	}
+
+	for key, value := range expected {
+		if envs[key] != value {
+			t.Errorf("unexpected value for %s; expected %q got %q", key, value, envs[key])
+		}
+	}
+}
+
+func TestParseDotenvErrors(t *testing.T) {
+	for content, expected := range map[string]string{
+		"NOVALUE":                   "invalid .env line 1: expected KEY=VALUE",
+		"A=1\nBAD KEY=1":            "invalid .env line 2: bad variable name 'BAD KEY'",
+		"A=1\n\nKEY=\"unterminated": "invalid .env line 3: unterminated quoted value for KEY",
+		"KEY='unterminated\nvalue":  "invalid .env line 1: unterminated quoted value for KEY",
+		"A=1\nKEY=\"x\"y":           "invalid .env line 2: unexpected text after the quoted value for KEY",
+		"KEY='multi\nline' y":       "invalid .env line 2: unexpected text after the quoted value for KEY",
+	} {
+		if _, err := ParseDotenv(strings.NewReader(content)); err == nil || err.Error() != expected {
+			t.Errorf("expected error %q; got %v", expected, err)
+		}
+	}
+}
+
+func TestLoadDotenv(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+
+	if err := os.WriteFile(file, []byte("DOTENV_TESTING_NEW=\"new\nvalue\"\nDOTENV_TESTING_SET=new"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("DOTENV_TESTING_SET", "original")
+	defer os.Unsetenv("DOTENV_TESTING_SET")
+	defer os.Unsetenv("DOTENV_TESTING_NEW")
+
+	if err := LoadDotenv(file); err != nil {
+		t.Errorf("unexpected error loading dotenv: %v", err)
+	}
+
+	if value := os.Getenv("DOTENV_TESTING_NEW"); value != "new\nvalue" {
+		t.Errorf("failed loading multiline variable; got %q", value)
+	}
+
+	if value := os.Getenv("DOTENV_TESTING_SET"); value != "original" {
+		t.Errorf("should not override existing variable; got %q", value)
+	}
+
+	if err := LoadDotenv(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error loading missing file")
+	}
+}
@@ -1,6 +1,7 @@
 package environment
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"strings"
@@ -10,6 +11,11 @@ import (
 
 var envFiles = []string{".env.local", ".env"}
 
+// fileVariables keeps the names of the variables InitEnvironmentVariables
+// took from the environment files, telling them apart from the ones set on
+// the actual environment (which take precedence; see EnvWatcher)
+var fileVariables = make(map[string]bool)
+
 // InitEnvironmentVariables handles the reading of .env files and
 // setting up important environment variables necessary for kool
 // to operate as expected.
@@ -42,12 +48,22 @@ func InitEnvironmentVariables(envStorage EnvStorage) {
 			continue
 		}
 
+		markFileVariables(envStorage, envFile)
+
 		err = envStorage.Load(envFile)
 		if err != nil {
 			log.Fatal("Failure loading environment file ", envFile, " error: '", err, "'")
 		}
 	}
 
+	if _, err = os.Stat(EncryptedEnvFile); err == nil {
+		if err = LoadEncryptedEnv(envStorage, EncryptedEnvFile); err != nil {
+			// we don't stop here so commands that do not
+			// depend on those variables can still run
+			fmt.Fprintf(os.Stderr, "Warning: failed loading %s - %v\n", EncryptedEnvFile, err)
+		}
+	}
+
 	// Now that we loaded up the files, we will check for
 	// missing variables that we need to fix
 	if envStorage.Get("KOOL_NAME") == "" {
@@ -61,3 +77,15 @@ func InitEnvironmentVariables(envStorage EnvStorage) {
 
 	initAsuser(envStorage)
 }
+
+// markFileVariables keeps track of the variables the environment file
+// is about to set, which are the ones not set yet (see fileVariables)
+func markFileVariables(envStorage EnvStorage, envFile string) {
+	envs, _ := ReadDotenv(envFile)
+
+	for key := range envs {
+		if _, isSet := envStorage.Lookup(key); !isSet {
+			fileVariables[key] = true
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package environment
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// EncryptedEnvFile is the file holding encrypted environment variables
+const EncryptedEnvFile = ".env.enc"
+
+// EnvKeyVariable is the environment variable holding the key used
+// for encrypting/decrypting the EncryptedEnvFile
+const EnvKeyVariable = "KOOL_ENV_KEY"
+
+// ErrMissingEnvKey happens when handling encrypted environment
+// files without having the encryption key available
+var ErrMissingEnvKey = fmt.Errorf("missing %s for handling %s; set it on the environment or store it in the keychain (service '%s', account '%s')", EnvKeyVariable, EncryptedEnvFile, KeychainService, EnvKeyVariable)
+
+// ErrInvalidEnvKey happens when the encryption key is malformed
+var ErrInvalidEnvKey = fmt.Errorf("invalid %s; it must be a base64 encoded 32 bytes key (i.e 'openssl rand -base64 32')", EnvKeyVariable)
+
+// ErrBadEncryptedEnv happens when the encrypted contents cannot be decrypted
+var ErrBadEncryptedEnv = errors.New("failed decrypting environment variables; check your key")
+
+// EnvKey gets the decoded encryption key from the EnvStorage,
+// falling back to the one stored in the OS keychain
+func EnvKey(envStorage EnvStorage) (key []byte, err error) {
+	var encoded = strings.TrimSpace(envStorage.Get(EnvKeyVariable))
+
+	if encoded == "" {
+		encoded, _ = keychainLookup()
+	}
+
+	if encoded == "" {
+		err = ErrMissingEnvKey
+		return
+	}
+
+	if key, err = base64.StdEncoding.DecodeString(encoded); err != nil || len(key) != 32 {
+		key = nil
+		err = ErrInvalidEnvKey
+	}
+
+	return
+}
+
+// EncryptEnv encrypts the given contents with AES-GCM, returning
+// the base64 encoded nonce and cipher text
+func EncryptEnv(plain, key []byte) (encrypted []byte, err error) {
+	var (
+		gcm   cipher.AEAD
+		nonce []byte
+	)
+
+	if gcm, err = newGCM(key); err != nil {
+		return
+	}
+
+	nonce = make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return
+	}
+
+	sealed := gcm.Seal(nonce, nonce, plain, nil)
+
+	encrypted = make([]byte, base64.StdEncoding.EncodedLen(len(sealed)))
+	base64.StdEncoding.Encode(encrypted, sealed)
+	return
+}
+
+// DecryptEnv decrypts contents previously encrypted by EncryptEnv
+func DecryptEnv(encrypted, key []byte) (plain []byte, err error) {
+	var (
+		gcm    cipher.AEAD
+		sealed []byte
+	)
+
+	if gcm, err = newGCM(key); err != nil {
+		return
+	}
+
+	if sealed, err = base64.StdEncoding.DecodeString(string(bytes.TrimSpace(encrypted))); err != nil || len(sealed) < gcm.NonceSize() {
+		err = ErrBadEncryptedEnv
+		return
+	}
+
+	if plain, err = gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil); err != nil {
+		err = ErrBadEncryptedEnv
+	}
+
+	return
+}
+
+// LoadEncryptedEnv decrypts the given file in memory and loads its
+// variables into the EnvStorage, not overriding existing ones
+func LoadEncryptedEnv(envStorage EnvStorage, filename string) (err error) {
+	var (
+		key, encrypted, plain []byte
+		envs                  map[string]string
+	)
+
+	if key, err = EnvKey(envStorage); err != nil {
+		return
+	}
+
+	if encrypted, err = os.ReadFile(filename); err != nil {
+		return
+	}
+
+	if plain, err = DecryptEnv(encrypted, key); err != nil {
+		return
+	}
+
+	if envs, err = ParseDotenv(bytes.NewReader(plain)); err != nil {
+		return
+	}
+
+	for k, v := range envs {
+		if _, set := envStorage.Lookup(k); !set {
+			envStorage.Set(k, v)
+		}
+	}
+
+	return
+}
+
+func newGCM(key []byte) (gcm cipher.AEAD, err error) {
+	var block cipher.Block
+
+	if block, err = aes.NewCipher(key); err != nil {
+		return
+	}
+
+	gcm, err = cipher.NewGCM(block)
+	return
+}
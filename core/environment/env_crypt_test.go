@@ -0,0 +1,113 @@
+package environment
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testingEnvKey = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+
+func TestEnvKey(t *testing.T) {
+	f := NewFakeEnvStorage()
+
+	originalKeychainLookup := keychainLookup
+	defer func() { keychainLookup = originalKeychainLookup }()
+
+	keychainLookup = func() (string, error) { return "", errors.New("not found") }
+
+	if _, err := EnvKey(f); err != ErrMissingEnvKey {
+		t.Errorf("expected ErrMissingEnvKey; got %v", err)
+	}
+
+	f.Set(EnvKeyVariable, "not-a-key")
+
+	if _, err := EnvKey(f); err != ErrInvalidEnvKey {
+		t.Errorf("expected ErrInvalidEnvKey; got %v", err)
+	}
+
+	f.Set(EnvKeyVariable, testingEnvKey)
+
+	if key, err := EnvKey(f); err != nil || len(key) != 32 {
+		t.Errorf("unexpected key %v (err: %v)", key, err)
+	}
+
+	f = NewFakeEnvStorage()
+	keychainLookup = func() (string, error) { return testingEnvKey + "\n", nil }
+
+	if key, err := EnvKey(f); err != nil || len(key) != 32 {
+		t.Errorf("expected the key from the keychain; got %v (err: %v)", key, err)
+	}
+}
+
+func TestEncryptDecryptEnv(t *testing.T) {
+	key, _ := base64.StdEncoding.DecodeString(testingEnvKey)
+
+	encrypted, err := EncryptEnv([]byte("SECRET=value\n"), key)
+
+	if err != nil {
+		t.Fatalf("unexpected error encrypting: %v", err)
+	}
+
+	if strings.Contains(string(encrypted), "SECRET") {
+		t.Error("encrypted content should not contain plain text")
+	}
+
+	plain, err := DecryptEnv(encrypted, key)
+
+	if err != nil || string(plain) != "SECRET=value\n" {
+		t.Errorf("failed decrypting: %s (err: %v)", plain, err)
+	}
+
+	otherKey := []byte("abcdef0123456789abcdef0123456789")
+
+	if _, err = DecryptEnv(encrypted, otherKey); err != ErrBadEncryptedEnv {
+		t.Errorf("expected ErrBadEncryptedEnv decrypting with wrong key; got %v", err)
+	}
+}
+
+func TestLoadEncryptedEnv(t *testing.T) {
+	var (
+		f        = NewFakeEnvStorage()
+		filename = filepath.Join(t.TempDir(), EncryptedEnvFile)
+		key, _   = base64.StdEncoding.DecodeString(testingEnvKey)
+	)
+
+	originalKeychainLookup := keychainLookup
+	defer func() { keychainLookup = originalKeychainLookup }()
+
+	keychainLookup = func() (string, error) { return "", errors.New("not found") }
+
+	encrypted, _ := EncryptEnv([]byte("SECRET=\"value\"\nEXISTING=new\nEMPTY=new\n"), key)
+
+	if err := os.WriteFile(filename, encrypted, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadEncryptedEnv(f, filename); err != ErrMissingEnvKey {
+		t.Errorf("expected ErrMissingEnvKey; got %v", err)
+	}
+
+	f.Set(EnvKeyVariable, testingEnvKey)
+	f.Set("EXISTING", "old")
+	f.Set("EMPTY", "")
+
+	if err := LoadEncryptedEnv(f, filename); err != nil {
+		t.Fatalf("unexpected error loading encrypted env: %v", err)
+	}
+
+	if f.Get("SECRET") != "value" {
+		t.Errorf("expected SECRET to be loaded; got '%s'", f.Get("SECRET"))
+	}
+
+	if f.Get("EXISTING") != "old" {
+		t.Errorf("should not override existing variables; got '%s'", f.Get("EXISTING"))
+	}
+
+	if f.Get("EMPTY") != "" {
+		t.Errorf("should not override variables set empty; got '%s'", f.Get("EMPTY"))
+	}
+}
@@ -2,8 +2,9 @@ package environment
 
 import (
 	"os"
-
-	"github.com/fireworkweb/godotenv"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // DefaultEnvStorage holds data to store environment variables
@@ -12,10 +13,14 @@ type DefaultEnvStorage struct{}
 // EnvStorage contract that holds environment variables storage logic
 type EnvStorage interface {
 	Get(string) string
+	Lookup(string) (string, bool)
 	Set(string, string)
+	Unset(string)
 	Load(string) error
 	All() []string
 	IsTrue(string) bool
+	GetInt(string) (int, bool)
+	GetDuration(string) (time.Duration, bool)
 }
 
 // NewEnvStorage creates a new Environment Storage instance
@@ -28,14 +33,24 @@ func (es *DefaultEnvStorage) Get(key string) string {
 	return os.Getenv(key)
 }
 
+// Lookup get environment variable value, telling whether it is set at all
+func (es *DefaultEnvStorage) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
 // Set set environment variable value
 func (es *DefaultEnvStorage) Set(key string, value string) {
 	os.Setenv(key, value)
 }
 
+// Unset removes the environment variable
+func (es *DefaultEnvStorage) Unset(key string) {
+	os.Unsetenv(key)
+}
+
 // Load load environment file
 func (es *DefaultEnvStorage) Load(filename string) error {
-	return godotenv.Load(filename)
+	return LoadDotenv(filename)
 }
 
 // All get all environment variables
@@ -49,3 +64,44 @@ func (es *DefaultEnvStorage) IsTrue(key string) bool {
 	value := os.Getenv(key)
 	return value == "1" || value == "true"
 }
+
+// GetInt parses the given environment variable as an integer; found
+// is false when it is unset or not a valid integer
+func (es *DefaultEnvStorage) GetInt(key string) (int, bool) {
+	return parseInt(os.Getenv(key))
+}
+
+// GetDuration parses the given environment variable either as a duration
+// (i.e 90s, 2m) or a number of seconds; found is false when it is unset
+// or not a valid duration
+func (es *DefaultEnvStorage) GetDuration(key string) (time.Duration, bool) {
+	return parseDuration(os.Getenv(key))
+}
+
+func parseInt(value string) (number int, found bool) {
+	var err error
+
+	if number, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+		return 0, false
+	}
+
+	found = true
+	return
+}
+
+func parseDuration(value string) (duration time.Duration, found bool) {
+	var err error
+
+	value = strings.TrimSpace(value)
+
+	if duration, err = time.ParseDuration(value); err == nil {
+		found = true
+		return
+	}
+
+	if seconds, isInt := parseInt(value); isInt {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	return 0, false
+}
@@ -3,6 +3,7 @@ package environment
 import (
 	"os"
 	"testing"
+	"time"
 )
 
 func TestEnvStorage(t *testing.T) {
@@ -24,6 +25,22 @@ func TestEnvStorage(t *testing.T) {
 		t.Error("failed to get environment variable on EnvStorage")
 	}
 
+	os.Setenv("VAR_TESTING_ENV_STORAGE_EMPTY", "")
+
+	if value, found := e.Lookup("VAR_TESTING_ENV_STORAGE_EMPTY"); !found || value != "" {
+		t.Error("failed to look up empty environment variable on EnvStorage")
+	}
+
+	if _, found := e.Lookup("VAR_TESTING_ENV_STORAGE_UNSET"); found {
+		t.Error("unexpected unset environment variable found on EnvStorage")
+	}
+
+	e.Unset("VAR_TESTING_ENV_STORAGE_EMPTY")
+
+	if _, present := os.LookupEnv("VAR_TESTING_ENV_STORAGE_EMPTY"); present {
+		t.Error("failed to unset environment variable on EnvStorage")
+	}
+
 	err := e.Load(".env.testing")
 
 	if err != nil {
@@ -85,3 +102,46 @@ func TestIsTrueNonBooleanStringEnvStorage(t *testing.T) {
 		t.Error("Environment variable non-boolean value should not be true.")
 	}
 }
+
+func TestGetIntEnvStorage(t *testing.T) {
+	e := NewEnvStorage()
+
+	t.Setenv("env-int", "42")
+	t.Setenv("env-bad-int", "4x")
+
+	if value, found := e.GetInt("env-int"); !found || value != 42 {
+		t.Errorf("expected 42; got %d (found: %v)", value, found)
+	}
+
+	if _, found := e.GetInt("env-bad-int"); found {
+		t.Error("malformed integer should not be found")
+	}
+
+	if _, found := e.GetInt("undefined-env-variable"); found {
+		t.Error("undefined environment variable should not be found")
+	}
+}
+
+func TestGetDurationEnvStorage(t *testing.T) {
+	e := NewEnvStorage()
+
+	t.Setenv("env-duration", "2m")
+	t.Setenv("env-seconds", "30")
+	t.Setenv("env-bad-duration", "soon")
+
+	if value, found := e.GetDuration("env-duration"); !found || value != 2*time.Minute {
+		t.Errorf("expected 2m; got %v (found: %v)", value, found)
+	}
+
+	if value, found := e.GetDuration("env-seconds"); !found || value != 30*time.Second {
+		t.Errorf("expected 30s; got %v (found: %v)", value, found)
+	}
+
+	if _, found := e.GetDuration("env-bad-duration"); found {
+		t.Error("malformed duration should not be found")
+	}
+
+	if _, found := e.GetDuration("undefined-env-variable"); found {
+		t.Error("undefined environment variable should not be found")
+	}
+}
@@ -0,0 +1,175 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// defaultWatchDebounce is how long we wait after the latest write
+// to an environment file before reloading it
+const defaultWatchDebounce = 300 * time.Millisecond
+
+// EnvWatcher holds logic for reloading environment files
+// into an EnvStorage whenever they change on disk
+type EnvWatcher struct {
+	env      EnvStorage
+	dir      string
+	files    []string
+	debounce time.Duration
+
+	// loaded tells the variables taken from the files, which are
+	// the ones to be reloaded (see reload)
+	loaded map[string]bool
+
+	watcher *fsnotify.Watcher
+	mtx     sync.Mutex
+	timers  map[string]*time.Timer
+}
+
+// NewEnvWatcher creates a new watcher for the environment
+// files within the current working directory
+func NewEnvWatcher(env EnvStorage) *EnvWatcher {
+	loaded := make(map[string]bool)
+
+	for key := range fileVariables {
+		loaded[key] = true
+	}
+
+	return &EnvWatcher{
+		env:      env,
+		dir:      env.Get("PWD"),
+		files:    envFiles,
+		debounce: defaultWatchDebounce,
+		loaded:   loaded,
+		timers:   make(map[string]*time.Timer),
+	}
+}
+
+// Watch starts watching the environment files; onReload gets called
+// with the name of the file that changed and the names of the variables
+// whose values changed after loading the files again
+func (w *EnvWatcher) Watch(onReload func(string, []string)) (err error) {
+	if w.watcher, err = fsnotify.NewWatcher(); err != nil {
+		return
+	}
+
+	// editors usually replace files instead of writing to them,
+	// so we watch the whole directory and filter by file name
+	if err = w.watcher.Add(w.dir); err != nil {
+		w.watcher.Close()
+		return
+	}
+
+	go func() {
+		for event := range w.watcher.Events {
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+
+			if file := filepath.Base(event.Name); w.isEnvFile(file) {
+				w.schedule(file, onReload)
+			}
+		}
+	}()
+
+	go func() {
+		// errors from the underlying watcher are not fatal
+		// for the ongoing command, so we just drain them
+		for range w.watcher.Errors {
+		}
+	}()
+
+	return
+}
+
+// Close stops watching for changes
+func (w *EnvWatcher) Close() (err error) {
+	w.mtx.Lock()
+	for _, timer := range w.timers {
+		timer.Stop()
+	}
+	w.mtx.Unlock()
+
+	if w.watcher != nil {
+		err = w.watcher.Close()
+	}
+	return
+}
+
+func (w *EnvWatcher) isEnvFile(file string) bool {
+	for _, envFile := range w.files {
+		if file == envFile {
+			return true
+		}
+	}
+
+	return false
+}
+
+// schedule debounces rapid writes so the file gets reloaded only
+// once after it settles down
+func (w *EnvWatcher) schedule(file string, onReload func(string, []string)) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if timer, exists := w.timers[file]; exists {
+		timer.Stop()
+	}
+
+	w.timers[file] = time.AfterFunc(w.debounce, func() {
+		w.mtx.Lock()
+		reloaded, err := w.reload()
+		w.mtx.Unlock()
+
+		if err == nil && onReload != nil {
+			onReload(file, reloaded)
+		}
+	})
+}
+
+// reload reads the environment files again (interpolating their values)
+// by the same rules as InitEnvironmentVariables: the files coming first
+// take precedence (.env.local over .env), and variables set on the actual
+// environment are left alone; it returns the variables that changed.
+func (w *EnvWatcher) reload() (reloaded []string, err error) {
+	var (
+		envs map[string]string
+		seen = make(map[string]bool)
+	)
+
+	for _, file := range w.files {
+		if envs, err = ReadDotenv(filepath.Join(w.dir, file)); os.IsNotExist(err) {
+			err = nil
+			continue
+		} else if err != nil {
+			return
+		}
+
+		for key, value := range envs {
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+
+			if _, isSet := w.env.Lookup(key); isSet && !w.loaded[key] {
+				continue
+			}
+
+			w.loaded[key] = true
+
+			if current, _ := w.env.Lookup(key); current != value {
+				w.env.Set(key, value)
+				reloaded = append(reloaded, key)
+			}
+		}
+	}
+
+	sort.Strings(reloaded)
+	return
+}
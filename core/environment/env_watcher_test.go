@@ -0,0 +1,102 @@
+package environment
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEnvWatcherReloadsOnChange(t *testing.T) {
+	var (
+		dir      = t.TempDir()
+		f        = NewFakeEnvStorage()
+		reloaded = make(chan []string, 10)
+	)
+
+	f.Set("PWD", dir)
+
+	w := NewEnvWatcher(f)
+	w.debounce = 10 * time.Millisecond
+
+	if err := w.Watch(func(file string, keys []string) { reloaded <- append([]string{file}, keys...) }); err != nil {
+		t.Fatalf("unexpected error watching: %v", err)
+	}
+	defer w.Close()
+
+	if err := os.WriteFile(filepath.Join(dir, "other.txt"), []byte("FOO=ignored\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("FOO=bar\nBAZ=${FOO}-baz\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case got := <-reloaded:
+		if len(got) != 3 || got[0] != ".env" || got[1] != "BAZ" || got[2] != "FOO" {
+			t.Errorf("expected BAZ and FOO reloaded from .env; got %v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for environment file reload")
+	}
+
+	if f.Get("FOO") != "bar" || f.Get("BAZ") != "bar-baz" {
+		t.Errorf("failed reloading variables: FOO=%s BAZ=%s", f.Get("FOO"), f.Get("BAZ"))
+	}
+}
+
+func TestEnvWatcherReloadRules(t *testing.T) {
+	var (
+		dir = t.TempDir()
+		f   = NewFakeEnvStorage()
+	)
+
+	_ = os.WriteFile(filepath.Join(dir, ".env"), []byte("APP=old\nREAL=file\nSHARED=env\n"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, ".env.local"), []byte("SHARED=local\n"), 0644)
+
+	f.Set("PWD", dir)
+	f.Set("REAL", "environment")
+
+	markFileVariables(f, filepath.Join(dir, ".env.local"))
+	_ = f.Load(filepath.Join(dir, ".env.local"))
+	markFileVariables(f, filepath.Join(dir, ".env"))
+	_ = f.Load(filepath.Join(dir, ".env"))
+
+	w := NewEnvWatcher(f)
+
+	if !w.loaded["APP"] || !w.loaded["SHARED"] || w.loaded["REAL"] {
+		t.Fatalf("expected the variables taken from the files to be told apart; got %v", w.loaded)
+	}
+
+	_ = os.WriteFile(filepath.Join(dir, ".env"), []byte("APP=new\nREAL=changed\nSHARED=changed\nNEW=env\n"), 0644)
+
+	reloaded, err := w.reload()
+
+	if err != nil || fmt.Sprint(reloaded) != "[APP NEW]" {
+		t.Errorf("expected APP and NEW reloaded; got %v (err: %v)", reloaded, err)
+	}
+
+	if f.Get("APP") != "new" || f.Get("NEW") != "env" {
+		t.Errorf("failed reloading variables: APP=%s NEW=%s", f.Get("APP"), f.Get("NEW"))
+	}
+
+	if f.Get("REAL") != "environment" {
+		t.Errorf("expected the variable set on the environment to be kept; got %s", f.Get("REAL"))
+	}
+
+	if f.Get("SHARED") != "local" {
+		t.Errorf("expected .env.local to take precedence over .env; got %s", f.Get("SHARED"))
+	}
+
+	_ = os.Remove(filepath.Join(dir, ".env.local"))
+
+	if reloaded, err = w.reload(); err != nil || fmt.Sprint(reloaded) != "[SHARED]" || f.Get("SHARED") != "changed" {
+		t.Errorf("expected SHARED reloaded from .env; got %v (err: %v)", reloaded, err)
+	}
+
+	if reloaded, _ = w.reload(); len(reloaded) != 0 {
+		t.Errorf("expected nothing reloaded when nothing changed; got %v", reloaded)
+	}
+}
@@ -2,8 +2,7 @@ package environment
 
 import (
 	"fmt"
-
-	"github.com/fireworkweb/godotenv"
+	"time"
 )
 
 // FakeEnvStorage holds fake environment variables
@@ -26,16 +25,27 @@ func (f *FakeEnvStorage) Get(key string) string {
 	return f.Envs[key]
 }
 
+// Lookup get environment variable value, telling whether it is set (fake behavior)
+func (f *FakeEnvStorage) Lookup(key string) (value string, found bool) {
+	value, found = f.Envs[key]
+	return
+}
+
 // Set set environment variable value (fake behavior)
 func (f *FakeEnvStorage) Set(key string, value string) {
 	f.Envs[key] = value
 	f.EnvsHistory[key] = append(f.EnvsHistory[key], value)
 }
 
+// Unset removes the environment variable (fake behavior)
+func (f *FakeEnvStorage) Unset(key string) {
+	delete(f.Envs, key)
+}
+
 // Load load environment file (fake behavior)
 func (f *FakeEnvStorage) Load(filename string) error {
 	f.CalledLoad = true
-	envs, _ := godotenv.Read(filename)
+	envs, _ := ReadDotenv(filename)
 	for k, v := range envs {
 		if _, exists := f.Envs[k]; !exists {
 			f.Envs[k] = v
@@ -59,3 +69,13 @@ func (f *FakeEnvStorage) IsTrue(key string) bool {
 	value := f.Envs[key]
 	return value == "1" || value == "true"
 }
+
+// GetInt parses the given environment variable as an integer (fake behavior)
+func (f *FakeEnvStorage) GetInt(key string) (int, bool) {
+	return parseInt(f.Envs[key])
+}
+
+// GetDuration parses the given environment variable as a duration (fake behavior)
+func (f *FakeEnvStorage) GetDuration(key string) (time.Duration, bool) {
+	return parseDuration(f.Envs[key])
+}
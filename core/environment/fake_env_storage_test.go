@@ -3,6 +3,7 @@ package environment
 import (
 	"sort"
 	"testing"
+	"time"
 )
 
 func TestFakeEnvStorage(t *testing.T) {
@@ -20,6 +21,20 @@ func TestFakeEnvStorage(t *testing.T) {
 		t.Errorf("expecting value 'testing_value' on FakeEnvStorage Get, got '%s'", got)
 	}
 
+	if value, found := f.Lookup("testing_key"); !found || value != "testing_value" {
+		t.Error("failed to look up environment variable on FakeEnvStorage")
+	}
+
+	if _, found := f.Lookup("unset_key"); found {
+		t.Error("unexpected unset environment variable found on FakeEnvStorage")
+	}
+
+	f.Unset("testing_key")
+
+	if _, found := f.Lookup("testing_key"); found {
+		t.Error("failed to unset environment variable on FakeEnvStorage")
+	}
+
 	_ = f.Load("")
 
 	if !f.CalledLoad {
@@ -98,3 +113,31 @@ func TestEnvsHistoryFakeEnvStorage(t *testing.T) {
 		t.Errorf("expecting to get 'first-value' in history, got %s", history[0])
 	}
 }
+
+func TestGetIntFakeEnvStorage(t *testing.T) {
+	f := NewFakeEnvStorage()
+	f.Envs["env-int"] = " 7 "
+	f.Envs["env-bad-int"] = "seven"
+
+	if value, found := f.GetInt("env-int"); !found || value != 7 {
+		t.Errorf("expected 7; got %d (found: %v)", value, found)
+	}
+
+	if _, found := f.GetInt("env-bad-int"); found {
+		t.Error("malformed integer should not be found")
+	}
+}
+
+func TestGetDurationFakeEnvStorage(t *testing.T) {
+	f := NewFakeEnvStorage()
+	f.Envs["env-duration"] = "90s"
+	f.Envs["env-bad-duration"] = "-"
+
+	if value, found := f.GetDuration("env-duration"); !found || value != 90*time.Second {
+		t.Errorf("expected 90s; got %v (found: %v)", value, found)
+	}
+
+	if _, found := f.GetDuration("env-bad-duration"); found {
+		t.Error("malformed duration should not be found")
+	}
+}
@@ -0,0 +1,41 @@
+package environment
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// KeychainService is the service name the encryption key is stored
+// under in the OS keychain, with EnvKeyVariable as the account
+const KeychainService = "kool"
+
+// keychainLookup reads the encryption key from the OS keychain
+var keychainLookup = lookupKeychain
+
+// lookupKeychain reads the encryption key through the macOS keychain
+// (security) or the freedesktop secret service on Linux (secret-tool)
+func lookupKeychain() (key string, err error) {
+	var (
+		cmd *exec.Cmd
+		out []byte
+	)
+
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", KeychainService, "-a", EnvKeyVariable, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", KeychainService, "account", EnvKeyVariable)
+	default:
+		err = fmt.Errorf("no keychain support on %s", runtime.GOOS)
+		return
+	}
+
+	if out, err = cmd.Output(); err != nil {
+		return
+	}
+
+	key = strings.TrimSpace(string(out))
+	return
+}
@@ -0,0 +1,64 @@
+// Package errs holds the kinds of common failures, so callers can tell
+// the cause of an error apart with errors.Is instead of matching its text.
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDockerNotRunning happens when the Docker daemon cannot be reached
+var ErrDockerNotRunning = errors.New("docker daemon doesn't seem to be running, run it first and retry")
+
+// ErrComposeNotFound happens when neither the Docker Compose V2 plugin
+// nor the standalone docker-compose (V1) binary can be found
+var ErrComposeNotFound = errors.New("docker compose doesn't seem to be installed (neither the V2 plugin nor docker-compose), see https://docs.docker.com/compose/install/ and retry")
+
+// ErrComposeFileMissing happens when a required docker compose file is not found
+var ErrComposeFileMissing = errors.New("docker compose file not found")
+
+// ErrServiceNotFound happens when the service is not defined by docker compose
+var ErrServiceNotFound = errors.New("no such service")
+
+// Error is an error of one of the known kinds; its message is
+// either the kind's own or a more specific one, followed by
+// the underlying error that caused it, if any
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+}
+
+// Wrap tags the underlying error as being of the given kind
+func Wrap(kind error, err error) error {
+	return &Error{Kind: kind, Err: err}
+}
+
+// Newf creates an error of the given kind with a specific message
+func Newf(kind error, format string, a ...interface{}) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, a...)}
+}
+
+// Error returns the string representation for the error
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Kind.Error()
+	}
+
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s (%v)", msg, e.Err)
+	}
+
+	return msg
+}
+
+// Is tells whether the error is of the target kind
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,50 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	var (
+		cause = errors.New("exit status 1")
+		err   = Wrap(ErrDockerNotRunning, cause)
+	)
+
+	if !errors.Is(err, ErrDockerNotRunning) {
+		t.Error("expected wrapped error to be of the ErrDockerNotRunning kind")
+	}
+
+	if errors.Is(err, ErrServiceNotFound) {
+		t.Error("wrapped error should not be of the ErrServiceNotFound kind")
+	}
+
+	if !errors.Is(err, cause) {
+		t.Error("expected wrapped error to unwrap to its cause")
+	}
+
+	if expected := ErrDockerNotRunning.Error() + " (exit status 1)"; err.Error() != expected {
+		t.Errorf("expected message '%s'; got '%s'", expected, err.Error())
+	}
+
+	if err = fmt.Errorf("starting: %w", err); !errors.Is(err, ErrDockerNotRunning) {
+		t.Error("expected the kind to be found through further wrapping")
+	}
+}
+
+func TestNewf(t *testing.T) {
+	err := Newf(ErrComposeFileMissing, "could not find required file '%s'", "docker-compose.yml")
+
+	if !errors.Is(err, ErrComposeFileMissing) {
+		t.Error("expected error to be of the ErrComposeFileMissing kind")
+	}
+
+	if err.Error() != "could not find required file 'docker-compose.yml'" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+
+	if (&Error{Kind: ErrServiceNotFound}).Error() != ErrServiceNotFound.Error() {
+		t.Error("expected the kind message when there is no specific one")
+	}
+}
@@ -0,0 +1,27 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"kool-dev/kool/core/environment"
+)
+
+// ErrOffline is returned by the operations that need network
+// access when kool is running in offline mode
+var ErrOffline = errors.New("kool is in offline mode (--offline or KOOL_OFFLINE)")
+
+// Offline tells whether kool is running in offline mode,
+// where no network operations should be attempted
+func Offline(env environment.EnvStorage) bool {
+	return env.IsTrue("KOOL_OFFLINE")
+}
+
+// CheckOnline fails with ErrOffline when kool is running in
+// offline mode, telling what could not be done without network
+func CheckOnline(env environment.EnvStorage, what string) (err error) {
+	if Offline(env) {
+		err = fmt.Errorf("%w; %s needs network access", ErrOffline, what)
+	}
+
+	return
+}
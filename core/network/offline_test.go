@@ -0,0 +1,36 @@
+package network
+
+import (
+	"errors"
+	"kool-dev/kool/core/environment"
+	"strings"
+	"testing"
+)
+
+func TestOffline(t *testing.T) {
+	env := environment.NewFakeEnvStorage()
+
+	if Offline(env) {
+		t.Error("should not be offline without KOOL_OFFLINE")
+	}
+
+	if err := CheckOnline(env, "testing"); err != nil {
+		t.Errorf("unexpected error when online: %v", err)
+	}
+
+	env.Set("KOOL_OFFLINE", "1")
+
+	if !Offline(env) {
+		t.Error("should be offline with KOOL_OFFLINE")
+	}
+
+	err := CheckOnline(env, "testing")
+
+	if !errors.Is(err, ErrOffline) {
+		t.Errorf("expected ErrOffline; got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "testing needs network access") {
+		t.Errorf("unexpected offline error message: %v", err)
+	}
+}
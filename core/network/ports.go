@@ -0,0 +1,39 @@
+package network
+
+import (
+	"net"
+	"time"
+)
+
+// PortInUse tells whether the given TCP port is already taken on the
+// host address; an empty address stands for all the host interfaces
+func PortInUse(host, port string) bool {
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, port))
+
+	if err != nil {
+		// other failures (like lacking permission for privileged
+		// ports) do not tell the port is taken
+		return isAddrInUse(err)
+	}
+
+	listener.Close()
+	return false
+}
+
+// Reachable tells whether a TCP connection can be made to the given
+// port on the host address within the timeout; unspecified addresses
+// (like 0.0.0.0 or an empty one) stand for the local host
+func Reachable(host, port string, timeout time.Duration) bool {
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), timeout)
+
+	if err != nil {
+		return false
+	}
+
+	conn.Close()
+	return true
+}
@@ -0,0 +1,49 @@
+package network
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPortInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	if !PortInUse("127.0.0.1", port) {
+		t.Errorf("expected port %s to be in use", port)
+	}
+
+	listener.Close()
+
+	if PortInUse("127.0.0.1", port) {
+		t.Errorf("expected port %s to be free", port)
+	}
+}
+
+func TestReachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	for _, host := range []string{"127.0.0.1", "0.0.0.0", ""} {
+		if !Reachable(host, port, time.Second) {
+			t.Errorf("expected port %s to be reachable on '%s'", port, host)
+		}
+	}
+
+	listener.Close()
+
+	if Reachable("127.0.0.1", port, time.Second) {
+		t.Errorf("expected port %s not to be reachable", port)
+	}
+}
@@ -0,0 +1,13 @@
+package network
+
+import (
+	"errors"
+	"syscall"
+)
+
+// wsaeaddrinuse is the Windows Sockets error for an address already in use
+const wsaeaddrinuse = syscall.Errno(10048)
+
+func isAddrInUse(err error) bool {
+	return errors.Is(err, wsaeaddrinuse)
+}
@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"fmt"
+	"kool-dev/kool/core/builder"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// scriptArgumentRef matches the positional argument references on kool.yml
+// script lines ($1 to $9, ${1} to ${9} and $@); they get marked as argument
+// placeholders before the line is expanded, so the environment expansion
+// does not take them for (empty) variables
+var scriptArgumentRef = regexp.MustCompile(`\$(?:([1-9@])|\{([1-9@])\})`)
+
+// scriptPlaceholder matches the argument placeholders on parsed script
+// commands: the marked positional ones ({{arg:1}} to {{arg:9}} and
+// {{arg:@}}) and named ones like {{arg:branch}}; the arg: prefix keeps
+// them apart from Go templates some commands take, like the
+// docker ps --format '{{.Names}}' one
+var scriptPlaceholder = regexp.MustCompile(`\{\{arg:(?:([1-9@])|([A-Za-z_][A-Za-z0-9_]*))\}\}`)
+
+// allArgumentsPlaceholder is the marked form of $@
+const allArgumentsPlaceholder = "{{arg:@}}"
+
+// markScriptArguments turns the positional argument references on the
+// script line into placeholders, to be applied by ApplyScriptArguments
+func markScriptArguments(line string) string {
+	return scriptArgumentRef.ReplaceAllString(line, "{{arg:${1}${2}}}")
+}
+
+// scriptParams holds the argument placeholders found on a script
+type scriptParams struct {
+	names    []string
+	count    int
+	variadic bool
+}
+
+// position returns the argument position taken by the given placeholder
+// match: the number of positional ones, or the order in which named
+// ones first show up on the script
+func (p *scriptParams) position(match []string) int {
+	if match[1] != "" {
+		n, _ := strconv.Atoi(match[1])
+		return n
+	}
+
+	for i, name := range p.names {
+		if name == match[2] {
+			return i + 1
+		}
+	}
+
+	return 0
+}
+
+// usage returns the names of the script arguments, as shown to the user
+func (p *scriptParams) usage() (params []string) {
+	for i := 0; i < p.count; i++ {
+		if i < len(p.names) {
+			params = append(params, fmt.Sprintf("<%s>", p.names[i]))
+		} else {
+			params = append(params, fmt.Sprintf("<arg%d>", i+1))
+		}
+	}
+	return
+}
+
+// findScriptParams looks up the argument placeholders on the commands
+func findScriptParams(commands []builder.Command) (params *scriptParams, found bool) {
+	params = &scriptParams{}
+
+	for _, command := range commands {
+		for _, token := range append([]string{command.Cmd()}, command.Args()...) {
+			for _, match := range scriptPlaceholder.FindAllStringSubmatch(token, -1) {
+				found = true
+
+				if match[1] == "@" {
+					params.variadic = true
+					continue
+				}
+
+				if match[2] != "" && params.position(match) == 0 {
+					params.names = append(params.names, match[2])
+				}
+
+				if position := params.position(match); position > params.count {
+					params.count = position
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// ApplyScriptArguments puts the given arguments in place of the argument
+// placeholders on the script commands: $1 to $9 take the argument at that
+// position, $@ takes all of them and named placeholders like {{arg:branch}}
+// take the arguments in the order they first show up on the script. It
+// tells whether the script has placeholders at all; the commands of
+// scripts without them are returned as they are, for the arguments
+// to be appended instead.
+func ApplyScriptArguments(script string, commands []builder.Command, args []string) (applied []builder.Command, hasPlaceholders bool, err error) {
+	var params *scriptParams
+
+	if params, hasPlaceholders = findScriptParams(commands); !hasPlaceholders {
+		applied = commands
+		return
+	}
+
+	if len(args) < params.count || (len(args) > params.count && !params.variadic) {
+		err = &ErrScriptArguments{script, params.usage(), params.variadic, len(args)}
+		return
+	}
+
+	replace := func(match string) string {
+		submatch := scriptPlaceholder.FindStringSubmatch(match)
+
+		if submatch[1] == "@" {
+			return strings.Join(args, " ")
+		}
+
+		return args[params.position(submatch)-1]
+	}
+
+	for _, command := range commands {
+		var (
+			tokens   []string
+			replaced bool
+		)
+
+		for _, token := range append([]string{command.Cmd()}, command.Args()...) {
+			if !scriptPlaceholder.MatchString(token) {
+				tokens = append(tokens, token)
+				continue
+			}
+
+			replaced = true
+
+			// $@ on its own keeps each argument as a separate one
+			if token == allArgumentsPlaceholder {
+				tokens = append(tokens, args...)
+				continue
+			}
+
+			tokens = append(tokens, scriptPlaceholder.ReplaceAllStringFunc(token, replace))
+		}
+
+		if !replaced {
+			applied = append(applied, command)
+			continue
+		}
+
+		if len(tokens) == 0 {
+			err = fmt.Errorf("script '%s' has a command made of just $@, which got no arguments", script)
+			return
+		}
+
+		var replacedCommand builder.Command = builder.NewCommand(tokens[0], tokens[1:]...)
+
+		if step, isConditional := command.(*ConditionalCommand); isConditional {
+			// the step keeps its if condition
+			replacedCommand = step.with(replacedCommand)
+		}
+
+		applied = append(applied, replacedCommand)
+	}
+
+	return
+}
@@ -0,0 +1,150 @@
+package parser
+
+import (
+	"errors"
+	"kool-dev/kool/core/builder"
+	"strings"
+	"testing"
+)
+
+func TestMarkScriptArguments(t *testing.T) {
+	var testCases = []struct {
+		line     string
+		expected string
+	}{
+		{"git push $1 ${2}", "git push {{arg:1}} {{arg:2}}"},
+		{"phpunit $@", "phpunit {{arg:@}}"},
+		{"echo --name=$1$2", "echo --name={{arg:1}}{{arg:2}}"},
+		{"echo $0 $HOME ${HOME} $10", "echo $0 $HOME ${HOME} {{arg:1}}0"},
+		{"git checkout {{arg:branch}}", "git checkout {{arg:branch}}"},
+	}
+
+	for _, tc := range testCases {
+		if marked := markScriptArguments(tc.line); marked != tc.expected {
+			t.Errorf("expected '%s' to be marked as '%s'; got '%s'", tc.line, tc.expected, marked)
+		}
+	}
+}
+
+func parseTestCommands(t *testing.T, lines ...string) (commands []builder.Command) {
+	for _, line := range lines {
+		command, err := builder.ParseCommand(markScriptArguments(line))
+
+		if err != nil {
+			t.Fatalf("failed parsing test command '%s': %v", line, err)
+		}
+
+		commands = append(commands, command)
+	}
+	return
+}
+
+func commandsString(commands []builder.Command) string {
+	var lines []string
+
+	for _, command := range commands {
+		lines = append(lines, command.String())
+	}
+
+	return strings.Join(lines, "; ")
+}
+
+func TestApplyScriptArguments(t *testing.T) {
+	var testCases = []struct {
+		lines    []string
+		args     []string
+		expected string
+	}{
+		{[]string{"git push $1 $2"}, []string{"origin", "main"}, "git push origin main"},
+		{[]string{"git fetch {{arg:remote}}", "git push {{arg:remote}} {{arg:branch}}"}, []string{"origin", "main"}, "git fetch origin; git push origin main"},
+		{[]string{"deploy --env=$1", "notify {{arg:env}} $@"}, []string{"staging", "--quiet"}, "deploy --env=staging; notify staging staging --quiet"},
+		{[]string{"phpunit $@"}, []string{}, "phpunit"},
+		{[]string{"echo \"$@\""}, []string{"a", "b"}, "echo a b"},
+		{[]string{"composer install", "echo $1"}, []string{"done"}, "composer install; echo done"},
+		{[]string{"$1 --version"}, []string{"php"}, "php --version"},
+		{[]string{"docker ps --format '{{.Names}}' --filter name=$1"}, []string{"app"}, "docker ps --format {{.Names}} --filter name=app"},
+	}
+
+	for _, tc := range testCases {
+		commands := parseTestCommands(t, tc.lines...)
+		applied, hasPlaceholders, err := ApplyScriptArguments("script", commands, tc.args)
+
+		if err != nil || !hasPlaceholders {
+			t.Errorf("unexpected result applying %v to %v; placeholders: %v, err: %v", tc.args, tc.lines, hasPlaceholders, err)
+			continue
+		}
+
+		if got := commandsString(applied); got != tc.expected {
+			t.Errorf("expected %v applied to %v to be '%s'; got '%s'", tc.args, tc.lines, tc.expected, got)
+		}
+	}
+
+	// separate arguments are kept apart for $@ on its own
+	applied, _, _ := ApplyScriptArguments("script", parseTestCommands(t, "ls $@"), []string{"my dir", "-l"})
+
+	if args := applied[0].Args(); len(args) != 2 || args[0] != "my dir" {
+		t.Errorf("expected $@ to keep the arguments apart; got %v", args)
+	}
+
+	commands := parseTestCommands(t, "composer install")
+
+	if applied, hasPlaceholders, err := ApplyScriptArguments("script", commands, []string{"arg"}); err != nil || hasPlaceholders || applied[0] != commands[0] {
+		t.Errorf("expected commands without placeholders to be left as they are (err: %v)", err)
+	}
+
+	// Go templates taken by the commands are no argument placeholders
+	commands = parseTestCommands(t, "docker ps --format '{{.Names}} {{.ID}} {{.Status}}'")
+
+	if applied, hasPlaceholders, err := ApplyScriptArguments("script", commands, []string{"-a"}); err != nil || hasPlaceholders || applied[0] != commands[0] {
+		t.Errorf("expected Go templates not to be taken for placeholders; placeholders: %v, err: %v", hasPlaceholders, err)
+	}
+}
+
+func TestApplyScriptArgumentsConditionalSteps(t *testing.T) {
+	parsed := &KoolYaml{Scripts: map[string]interface{}{
+		"deploy": []interface{}{
+			map[interface{}]interface{}{"command": "deploy $1", "if": "true"},
+		},
+	}}
+
+	commands, err := parsed.ParseCommands("deploy")
+
+	if err != nil {
+		t.Fatalf("unexpected error parsing the script: %v", err)
+	}
+
+	applied, _, err := ApplyScriptArguments("deploy", commands, []string{"staging"})
+
+	if step, isConditional := applied[0].(*ConditionalCommand); err != nil || !isConditional || step.Condition != "true" || step.String() != "deploy staging" {
+		t.Errorf("expected the step to keep its condition; got %v (err: %v)", applied, err)
+	}
+}
+
+func TestApplyScriptArgumentsErrors(t *testing.T) {
+	var testCases = []struct {
+		lines    []string
+		args     []string
+		expected string
+	}{
+		{[]string{"git push {{arg:remote}} {{arg:branch}}"}, []string{"origin"}, "script 'script' is missing the <branch> argument(s); run it as 'kool run script <remote> <branch>'"},
+		{[]string{"echo $2 $@"}, []string{}, "script 'script' is missing the <arg1>, <arg2> argument(s); run it as 'kool run script <arg1> <arg2> [args...]'"},
+		{[]string{"echo $1"}, []string{"a", "b"}, "script 'script' takes 1 argument(s) but got 2; run it as 'kool run script <arg1>'"},
+		{[]string{"$@"}, []string{}, "script 'script' has a command made of just $@, which got no arguments"},
+	}
+
+	for _, tc := range testCases {
+		_, _, err := ApplyScriptArguments("script", parseTestCommands(t, tc.lines...), tc.args)
+
+		if err == nil || err.Error() != tc.expected {
+			t.Errorf("expected error '%s' applying %v to %v; got '%v'", tc.expected, tc.args, tc.lines, err)
+		}
+	}
+
+	_, _, err := ApplyScriptArguments("deploy", parseTestCommands(t, "deploy {{arg:env}}"), nil)
+
+	var argsErr *ErrScriptArguments
+
+	if !errors.As(err, &argsErr) || argsErr.Script != "deploy" || argsErr.Got != 0 || argsErr.Usage() != "kool run deploy <env>" {
+		t.Errorf("expected ErrScriptArguments for deploy; got %v", err)
+	}
+}
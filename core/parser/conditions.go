@@ -0,0 +1,305 @@
+package parser
+
+import (
+	"fmt"
+	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/environment"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// condition is a compiled if expression of a script step; it evaluates to
+// either a string (like env.APP_ENV or "local") or a bool (like the result
+// of a comparison)
+type condition func(env environment.EnvStorage) interface{}
+
+// ConditionalCommand is a script step with an if condition; the condition
+// is not evaluated when parsing the script, but right before the step runs
+// (see Holds), for it to see what the steps before it did.
+type ConditionalCommand struct {
+	builder.Command
+
+	Condition string
+	cond      condition
+}
+
+// Holds evaluates the if condition of the step on the given environment
+// (and the current working directory, for file.exists)
+func (c *ConditionalCommand) Holds(env environment.EnvStorage) bool {
+	return truthy(c.cond(env))
+}
+
+// Copy returns a copy of the step, keeping its condition
+func (c *ConditionalCommand) Copy() builder.Command {
+	return c.with(c.Command.Copy())
+}
+
+// with returns the step with its command swapped by the given one
+func (c *ConditionalCommand) with(command builder.Command) *ConditionalCommand {
+	return &ConditionalCommand{command, c.Condition, c.cond}
+}
+
+// conditionParser compiles the if expressions of script steps, which
+// support env.NAME, file.exists("path"), "strings", true and false,
+// along with ==, !=, !, &&, || and parentheses
+type conditionParser struct {
+	tokens []string
+	pos    int
+}
+
+// compileCondition compiles the given if expression
+func compileCondition(expression string) (cond condition, err error) {
+	var p = &conditionParser{}
+
+	if p.tokens, err = tokenizeCondition(expression); err != nil {
+		err = fmt.Errorf("invalid condition '%s': %v", expression, err)
+		return
+	}
+
+	if len(p.tokens) == 0 {
+		err = fmt.Errorf("invalid condition '%s': it is empty", expression)
+		return
+	}
+
+	if cond, err = p.parseOr(); err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected '%s'", p.tokens[p.pos])
+	}
+
+	if err != nil {
+		cond = nil
+		err = fmt.Errorf("invalid condition '%s': %v", expression, err)
+	}
+	return
+}
+
+// evaluateCondition compiles and evaluates the given if expression
+func evaluateCondition(expression string, env environment.EnvStorage) (met bool, err error) {
+	var cond condition
+
+	if cond, err = compileCondition(expression); err != nil {
+		return
+	}
+
+	met = truthy(cond(env))
+	return
+}
+
+// tokenizeCondition splits the expression into identifiers (like
+// env.APP_ENV), quoted strings (with their quotes) and operators
+func tokenizeCondition(expression string) (tokens []string, err error) {
+	for i := 0; i < len(expression); {
+		c := rune(expression[i])
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexRune(expression[i+1:], c)
+
+			if end < 0 {
+				err = fmt.Errorf("unterminated string")
+				return
+			}
+
+			tokens = append(tokens, expression[i:i+end+2])
+			i += end + 2
+		case strings.HasPrefix(expression[i:], "==") || strings.HasPrefix(expression[i:], "!=") ||
+			strings.HasPrefix(expression[i:], "&&") || strings.HasPrefix(expression[i:], "||"):
+			tokens = append(tokens, expression[i:i+2])
+			i += 2
+		case c == '!' || c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '_' || c == '.' || unicode.IsLetter(c) || unicode.IsDigit(c):
+			start := i
+
+			for i < len(expression) && (expression[i] == '_' || expression[i] == '.' || unicode.IsLetter(rune(expression[i])) || unicode.IsDigit(rune(expression[i]))) {
+				i++
+			}
+
+			tokens = append(tokens, expression[start:i])
+		default:
+			err = fmt.Errorf("unexpected '%c'", c)
+			return
+		}
+	}
+	return
+}
+
+func (p *conditionParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *conditionParser) next() (token string, err error) {
+	if p.pos >= len(p.tokens) {
+		err = fmt.Errorf("unexpected end of the expression")
+		return
+	}
+
+	token = p.tokens[p.pos]
+	p.pos++
+	return
+}
+
+func (p *conditionParser) expect(expected string) (err error) {
+	var token string
+
+	if token, err = p.next(); err == nil && token != expected {
+		err = fmt.Errorf("expected '%s' but got '%s'", expected, token)
+	}
+	return
+}
+
+func (p *conditionParser) parseOr() (cond condition, err error) {
+	var right condition
+
+	if cond, err = p.parseAnd(); err != nil {
+		return
+	}
+
+	for p.peek() == "||" {
+		p.pos++
+
+		if right, err = p.parseAnd(); err != nil {
+			return
+		}
+
+		left := cond
+		cond = func(env environment.EnvStorage) interface{} {
+			return truthy(left(env)) || truthy(right(env))
+		}
+	}
+	return
+}
+
+func (p *conditionParser) parseAnd() (cond condition, err error) {
+	var right condition
+
+	if cond, err = p.parseUnary(); err != nil {
+		return
+	}
+
+	for p.peek() == "&&" {
+		p.pos++
+
+		if right, err = p.parseUnary(); err != nil {
+			return
+		}
+
+		left := cond
+		cond = func(env environment.EnvStorage) interface{} {
+			return truthy(left(env)) && truthy(right(env))
+		}
+	}
+	return
+}
+
+func (p *conditionParser) parseUnary() (cond condition, err error) {
+	if p.peek() != "!" {
+		cond, err = p.parseComparison()
+		return
+	}
+
+	p.pos++
+
+	var operand condition
+
+	if operand, err = p.parseUnary(); err != nil {
+		return
+	}
+
+	cond = func(env environment.EnvStorage) interface{} {
+		return !truthy(operand(env))
+	}
+	return
+}
+
+func (p *conditionParser) parseComparison() (cond condition, err error) {
+	var right condition
+
+	if cond, err = p.parseOperand(); err != nil {
+		return
+	}
+
+	if operator := p.peek(); operator == "==" || operator == "!=" {
+		p.pos++
+
+		if right, err = p.parseOperand(); err != nil {
+			return
+		}
+
+		left := cond
+		cond = func(env environment.EnvStorage) interface{} {
+			return (fmt.Sprint(left(env)) == fmt.Sprint(right(env))) == (operator == "==")
+		}
+	}
+	return
+}
+
+func (p *conditionParser) parseOperand() (cond condition, err error) {
+	var token string
+
+	if token, err = p.next(); err != nil {
+		return
+	}
+
+	switch {
+	case token == "(":
+		if cond, err = p.parseOr(); err != nil {
+			return
+		}
+
+		err = p.expect(")")
+	case token[0] == '"' || token[0] == '\'':
+		value := token[1 : len(token)-1]
+		cond = func(environment.EnvStorage) interface{} { return value }
+	case token == "true" || token == "false":
+		value := token == "true"
+		cond = func(environment.EnvStorage) interface{} { return value }
+	case strings.HasPrefix(token, "env.") && len(token) > len("env."):
+		name := strings.TrimPrefix(token, "env.")
+		cond = func(env environment.EnvStorage) interface{} { return env.Get(name) }
+	case token == "file.exists":
+		var path string
+
+		if err = p.expect("("); err != nil {
+			return
+		}
+
+		if path, err = p.next(); err != nil {
+			return
+		}
+
+		if path[0] != '"' && path[0] != '\'' {
+			err = fmt.Errorf("expected a quoted path for file.exists but got '%s'", path)
+			return
+		}
+
+		if err = p.expect(")"); err != nil {
+			return
+		}
+
+		path = path[1 : len(path)-1]
+		cond = func(environment.EnvStorage) interface{} {
+			_, statErr := os.Stat(path)
+			return statErr == nil
+		}
+	default:
+		err = fmt.Errorf("unknown '%s' (expected env.NAME, file.exists(\"path\"), a quoted string, true or false)", token)
+	}
+	return
+}
+
+// truthy tells whether the value of a condition holds; strings
+// (like env.NAME) hold when they are not empty
+func truthy(value interface{}) bool {
+	if b, isBool := value.(bool); isBool {
+		return b
+	}
+
+	return value.(string) != ""
+}
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"kool-dev/kool/core/environment"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEvaluateCondition(t *testing.T) {
+	var (
+		env  = environment.NewFakeEnvStorage()
+		file = filepath.Join(t.TempDir(), "composer.json")
+	)
+
+	env.Set("APP_ENV", "local")
+	env.Set("CI", "")
+	_ = os.WriteFile(file, []byte("{}"), os.ModePerm)
+
+	var testCases = []struct {
+		expression string
+		expected   bool
+	}{
+		{`env.APP_ENV == "local"`, true},
+		{`env.APP_ENV != 'local'`, false},
+		{`env.APP_ENV`, true},
+		{`env.CI`, false},
+		{`!env.CI`, true},
+		{`env.MISSING == ""`, true},
+		{`file.exists("` + file + `")`, true},
+		{`file.exists('missing.json')`, false},
+		{`!file.exists("missing.json") && env.APP_ENV == "local"`, true},
+		{`env.CI || env.APP_ENV == "production"`, false},
+		{`(env.CI || env.APP_ENV == "local") && !false`, true},
+		{`true == "true"`, true},
+		{`!!true`, true},
+	}
+
+	for _, tc := range testCases {
+		if met, err := evaluateCondition(tc.expression, env); err != nil || met != tc.expected {
+			t.Errorf("expected condition '%s' to be %v; got %v (err: %v)", tc.expression, tc.expected, met, err)
+		}
+	}
+}
+
+func TestEvaluateConditionErrors(t *testing.T) {
+	env := environment.NewFakeEnvStorage()
+
+	var testCases = []struct {
+		expression string
+		expected   string
+	}{
+		{``, "invalid condition '': it is empty"},
+		{`env.APP_ENV ==`, "invalid condition 'env.APP_ENV ==': unexpected end of the expression"},
+		{`env.APP_ENV = "local"`, "invalid condition 'env.APP_ENV = \"local\"': unexpected '='"},
+		{`"local`, "invalid condition '\"local': unterminated string"},
+		{`(env.CI`, "invalid condition '(env.CI': unexpected end of the expression"},
+		{`env.CI)`, "invalid condition 'env.CI)': unexpected ')'"},
+		{`app_env == "local"`, "invalid condition 'app_env == \"local\"': unknown 'app_env' (expected env.NAME, file.exists(\"path\"), a quoted string, true or false)"},
+		{`file.exists(composer.json)`, "invalid condition 'file.exists(composer.json)': expected a quoted path for file.exists but got 'composer.json'"},
+		{`file.exists "composer.json"`, "invalid condition 'file.exists \"composer.json\"': expected '(' but got '\"composer.json\"'"},
+	}
+
+	for _, tc := range testCases {
+		if _, err := evaluateCondition(tc.expression, env); err == nil || err.Error() != tc.expected {
+			t.Errorf("expected error '%s' for condition '%s'; got '%v'", tc.expected, tc.expression, err)
+		}
+	}
+}
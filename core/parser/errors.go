@@ -18,6 +18,77 @@ func IsMultipleDefinedScriptError(err error) bool {
 // ErrKoolYmlNotFound means there was no kool.yml file in the targeted folders
 var ErrKoolYmlNotFound = errors.New("could not find any kool.yml file")
 
+// ErrNoKoolYml happens when a command requiring a kool.yml file is run
+// outside of a project; it is of the ErrKoolYmlNotFound kind, telling
+// the folder it was looked up in and how to get one
+type ErrNoKoolYml struct {
+	Dir string
+}
+
+// Error tells where the kool.yml file was missing from
+func (e *ErrNoKoolYml) Error() string {
+	return fmt.Sprintf("No kool.yml found in %s; run 'kool preset' to create one.", e.Dir)
+}
+
+// Is makes errors.Is(err, ErrKoolYmlNotFound) hold for it
+func (e *ErrNoKoolYml) Is(target error) bool {
+	return target == ErrKoolYmlNotFound
+}
+
+// ErrDependencyCycle happens when scripts end up needing themselves
+// through their needs, telling the chain of scripts that does it
+type ErrDependencyCycle struct {
+	Chain []string
+}
+
+// Error tells the chain of scripts needing each other
+func (e *ErrDependencyCycle) Error() string {
+	return fmt.Sprintf("scripts needs form a cycle: %s", strings.Join(e.Chain, " -> "))
+}
+
+// ErrDuplicateScript happens when a script is defined on more than
+// one of the files brought together by include on kool.yml
+type ErrDuplicateScript struct {
+	Script string
+	Files  []string
+}
+
+// Error tells the files defining the script
+func (e *ErrDuplicateScript) Error() string {
+	return fmt.Sprintf("script '%s' is defined in both %s", e.Script, strings.Join(e.Files, " and "))
+}
+
+// ErrScriptArguments happens when a script with argument placeholders
+// is given fewer arguments than it has placeholders for, or more of them
+// when it does not take all of them with $@
+type ErrScriptArguments struct {
+	Script   string
+	Params   []string
+	Variadic bool
+	Got      int
+}
+
+// Error tells which arguments are missing (or how many are expected)
+// along with how the script is meant to be run
+func (e *ErrScriptArguments) Error() string {
+	if e.Got < len(e.Params) {
+		return fmt.Sprintf("script '%s' is missing the %s argument(s); run it as '%s'", e.Script, strings.Join(e.Params[e.Got:], ", "), e.Usage())
+	}
+
+	return fmt.Sprintf("script '%s' takes %d argument(s) but got %d; run it as '%s'", e.Script, len(e.Params), e.Got, e.Usage())
+}
+
+// Usage tells how the script is meant to be run, with its arguments
+func (e *ErrScriptArguments) Usage() string {
+	usage := append([]string{"kool", "run", e.Script}, e.Params...)
+
+	if e.Variadic {
+		usage = append(usage, "[args...]")
+	}
+
+	return strings.Join(usage, " ")
+}
+
 // ErrPossibleTypo implements error interface and can be used
 // to determine specific situations of not-found scripts but
 // where similar names exist, indicating a possible typo
@@ -50,3 +50,31 @@ func TestIsPossibleTypoError(t *testing.T) {
 		t.Error("failed to assert that error was not a typo one")
 	}
 }
+
+func TestErrNoKoolYml(t *testing.T) {
+	var err error = &ErrNoKoolYml{Dir: "/some/dir"}
+
+	if expected := "No kool.yml found in /some/dir; run 'kool preset' to create one."; err.Error() != expected {
+		t.Errorf("expected message '%s'; got '%s'", expected, err.Error())
+	}
+
+	if !errors.Is(err, ErrKoolYmlNotFound) {
+		t.Error("expected ErrNoKoolYml to be of the ErrKoolYmlNotFound kind")
+	}
+}
+
+func TestErrDependencyCycle(t *testing.T) {
+	err := &ErrDependencyCycle{[]string{"build", "test", "build"}}
+
+	if err.Error() != "scripts needs form a cycle: build -> test -> build" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
+
+func TestErrDuplicateScript(t *testing.T) {
+	err := &ErrDuplicateScript{"deploy", []string{"kool.yml", "kool.deploy.yml"}}
+
+	if err.Error() != "script 'deploy' is defined in both kool.yml and kool.deploy.yml" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+}
@@ -3,23 +3,51 @@ package parser
 import (
 	"kool-dev/kool/core/builder"
 	"strings"
+	"time"
 )
 
 // FakeParser implements all fake behaviors for using parser in tests.
 type FakeParser struct {
 	CalledAddLookupPath            bool
 	TargetFiles                    []string
+	MockAddLookupPathError         error
 	CalledParse                    bool
 	CalledParseAvailableScripts    bool
 	MockParsedCommands             map[string][]builder.Command
 	MockParseError                 map[string]error
 	MockScripts                    []string
 	MockParseAvailableScriptsError error
+	CalledParseBootstrapScripts    bool
+	MockBootstrapScripts           []string
+	MockParseBootstrapScriptsError error
+	CalledParseProjectName         bool
+	MockProjectName                string
+	MockParseProjectNameError      error
+	CalledParseBinPaths            bool
+	MockBinPaths                   []string
+	MockParseBinPathsError         error
+	CalledParseScriptTimeout       bool
+	MockScriptTimeout              map[string]time.Duration
+	MockParseScriptTimeoutError    error
+	CalledParseScriptParallel      bool
+	MockScriptParallel             map[string]bool
+	MockParseScriptParallelError   error
+	CalledParseEnvironments        bool
+	MockEnvironments               []string
+	MockParseEnvironmentsError     error
+	CalledParseDependencies        bool
+	MockDependencies               map[string][]string
+	MockParseDependenciesError     error
 }
 
 // AddLookupPath implements fake AddLookupPath behavior
 func (f *FakeParser) AddLookupPath(rootPath string) (err error) {
 	f.CalledAddLookupPath = true
+
+	if err = f.MockAddLookupPathError; err != nil {
+		return
+	}
+
 	f.TargetFiles = append(f.TargetFiles, "kool.yml")
 	return
 }
@@ -49,3 +77,59 @@ func (f *FakeParser) ParseAvailableScripts(filter string) (scripts []string, err
 	err = f.MockParseAvailableScriptsError
 	return
 }
+
+// ParseBootstrapScripts implements fake ParseBootstrapScripts behavior
+func (f *FakeParser) ParseBootstrapScripts() (scripts []string, err error) {
+	f.CalledParseBootstrapScripts = true
+	scripts = f.MockBootstrapScripts
+	err = f.MockParseBootstrapScriptsError
+	return
+}
+
+// ParseProjectName implements fake ParseProjectName behavior
+func (f *FakeParser) ParseProjectName() (project string, err error) {
+	f.CalledParseProjectName = true
+	project = f.MockProjectName
+	err = f.MockParseProjectNameError
+	return
+}
+
+// ParseBinPaths implements fake ParseBinPaths behavior
+func (f *FakeParser) ParseBinPaths() (paths []string, err error) {
+	f.CalledParseBinPaths = true
+	paths = f.MockBinPaths
+	err = f.MockParseBinPathsError
+	return
+}
+
+// ParseScriptTimeout implements fake ParseScriptTimeout behavior
+func (f *FakeParser) ParseScriptTimeout(script string) (timeout time.Duration, err error) {
+	f.CalledParseScriptTimeout = true
+	timeout = f.MockScriptTimeout[script]
+	err = f.MockParseScriptTimeoutError
+	return
+}
+
+// ParseScriptParallel implements fake ParseScriptParallel behavior
+func (f *FakeParser) ParseScriptParallel(script string) (parallel bool, err error) {
+	f.CalledParseScriptParallel = true
+	parallel = f.MockScriptParallel[script]
+	err = f.MockParseScriptParallelError
+	return
+}
+
+// ParseEnvironments implements fake ParseEnvironments behavior
+func (f *FakeParser) ParseEnvironments() (environments []string, err error) {
+	f.CalledParseEnvironments = true
+	environments = f.MockEnvironments
+	err = f.MockParseEnvironmentsError
+	return
+}
+
+// ParseDependencies implements fake ParseDependencies behavior
+func (f *FakeParser) ParseDependencies(script string) (dependencies []string, err error) {
+	f.CalledParseDependencies = true
+	dependencies = f.MockDependencies[script]
+	err = f.MockParseDependenciesError
+	return
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"kool-dev/kool/core/builder"
 	"testing"
+	"time"
 )
 
 func TestFakeParser(t *testing.T) {
@@ -52,6 +53,48 @@ func TestFakeParser(t *testing.T) {
 	if len(scripts) != 0 {
 		t.Error("failed to use mocked ParseAvailableScripts function on FakeParser")
 	}
+
+	f.MockBootstrapScripts = []string{"setup"}
+
+	if scripts, _ = f.ParseBootstrapScripts(); !f.CalledParseBootstrapScripts || len(scripts) != 1 || scripts[0] != "setup" {
+		t.Error("failed to use mocked ParseBootstrapScripts function on FakeParser")
+	}
+
+	f.MockProjectName = "project"
+
+	if project, _ := f.ParseProjectName(); !f.CalledParseProjectName || project != "project" {
+		t.Error("failed to use mocked ParseProjectName function on FakeParser")
+	}
+
+	f.MockBinPaths = []string{"bin"}
+
+	if paths, _ := f.ParseBinPaths(); !f.CalledParseBinPaths || len(paths) != 1 {
+		t.Error("failed to use mocked ParseBinPaths function on FakeParser")
+	}
+
+	f.MockScriptTimeout = map[string]time.Duration{"script": time.Minute}
+
+	if timeout, _ := f.ParseScriptTimeout("script"); !f.CalledParseScriptTimeout || timeout != time.Minute {
+		t.Error("failed to use mocked ParseScriptTimeout function on FakeParser")
+	}
+
+	f.MockScriptParallel = map[string]bool{"script": true}
+
+	if parallel, _ := f.ParseScriptParallel("script"); !f.CalledParseScriptParallel || !parallel {
+		t.Error("failed to use mocked ParseScriptParallel function on FakeParser")
+	}
+
+	f.MockEnvironments = []string{"ci"}
+
+	if environments, _ := f.ParseEnvironments(); !f.CalledParseEnvironments || len(environments) != 1 {
+		t.Error("failed to use mocked ParseEnvironments function on FakeParser")
+	}
+
+	f.MockDependencies = map[string][]string{"test": {"setup"}}
+
+	if dependencies, _ := f.ParseDependencies("test"); !f.CalledParseDependencies || len(dependencies) != 1 {
+		t.Error("failed to use mocked ParseDependencies function on FakeParser")
+	}
 }
 
 func TestFakeFailedParser(t *testing.T) {
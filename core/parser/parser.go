@@ -2,10 +2,13 @@ package parser
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"kool-dev/kool/core/builder"
 )
@@ -15,6 +18,13 @@ type Parser interface {
 	AddLookupPath(string) error
 	Parse(string) ([]builder.Command, error)
 	ParseAvailableScripts(string) ([]string, error)
+	ParseBootstrapScripts() ([]string, error)
+	ParseProjectName() (string, error)
+	ParseBinPaths() ([]string, error)
+	ParseScriptTimeout(string) (time.Duration, error)
+	ParseScriptParallel(string) (bool, error)
+	ParseEnvironments() ([]string, error)
+	ParseDependencies(string) ([]string, error)
 }
 
 // DefaultParser implements all default behavior for using kool.yml files.
@@ -142,3 +152,240 @@ func (p *DefaultParser) ParseAvailableScripts(filter string) (scripts []string,
 
 	return
 }
+
+// ParseBootstrapScripts returns the list of scripts to be run by kool bootstrap,
+// as defined by the first kool.yml file that has a bootstrap entry.
+func (p *DefaultParser) ParseBootstrapScripts() (scripts []string, err error) {
+	var parsedFile *KoolYaml
+
+	if len(p.targetFiles) == 0 {
+		err = errors.New("kool.yml not found")
+		return
+	}
+
+	for _, koolFile := range p.targetFiles {
+		if parsedFile, err = ParseKoolYaml(koolFile); err != nil {
+			return
+		}
+
+		if len(parsedFile.Bootstrap) > 0 {
+			scripts = parsedFile.Bootstrap
+			return
+		}
+	}
+
+	return
+}
+
+// ParseProjectName returns the compose project name as defined
+// by the first kool.yml file that has a project entry.
+func (p *DefaultParser) ParseProjectName() (project string, err error) {
+	var parsedFile *KoolYaml
+
+	if len(p.targetFiles) == 0 {
+		err = errors.New("kool.yml not found")
+		return
+	}
+
+	for _, koolFile := range p.targetFiles {
+		if parsedFile, err = ParseKoolYaml(koolFile); err != nil {
+			return
+		}
+
+		if parsedFile.Project != "" {
+			project = parsedFile.Project
+			return
+		}
+	}
+
+	return
+}
+
+// ParseDependencies returns the scripts the given script needs, directly
+// or through the scripts it needs, in the order they are to be run: each
+// one after the ones it needs, and each just once. Scripts are looked up
+// the same way Parse does, so needs can refer to any kool.yml file.
+func (p *DefaultParser) ParseDependencies(script string) (dependencies []string, err error) {
+	var (
+		parsedFiles []*KoolYaml
+		parsedFile  *KoolYaml
+		visited     = make(map[string]bool)
+		chain       []string
+		visit       func(string) error
+	)
+
+	if len(p.targetFiles) == 0 {
+		err = errors.New("kool.yml not found")
+		return
+	}
+
+	for _, koolFile := range p.targetFiles {
+		if parsedFile, err = ParseKoolYaml(koolFile); err != nil {
+			return
+		}
+
+		parsedFiles = append(parsedFiles, parsedFile)
+	}
+
+	// definedIn returns the first kool.yml file defining the script
+	definedIn := func(name string) *KoolYaml {
+		for _, parsedFile := range parsedFiles {
+			if parsedFile.HasScript(name) {
+				return parsedFile
+			}
+		}
+
+		return nil
+	}
+
+	visit = func(name string) (err error) {
+		var needs []string
+
+		for i, previous := range chain {
+			if previous == name {
+				return &ErrDependencyCycle{append(append([]string{}, chain[i:]...), name)}
+			}
+		}
+
+		if visited[name] {
+			return
+		}
+
+		if needs, err = definedIn(name).ScriptNeeds(name); err != nil {
+			return
+		}
+
+		chain = append(chain, name)
+
+		for _, need := range needs {
+			if definedIn(need) == nil {
+				return fmt.Errorf("script '%s' needs '%s', which is not defined", name, need)
+			}
+
+			if err = visit(need); err != nil {
+				return
+			}
+		}
+
+		chain = chain[:len(chain)-1]
+		visited[name] = true
+
+		if name != script {
+			dependencies = append(dependencies, name)
+		}
+
+		return
+	}
+
+	if definedIn(script) != nil {
+		err = visit(script)
+	}
+
+	return
+}
+
+// ParseEnvironments returns the sorted names of the environments
+// declared under the environments key of all kool.yml files.
+func (p *DefaultParser) ParseEnvironments() (environments []string, err error) {
+	var (
+		parsedFile *KoolYaml
+		found      = make(map[string]bool)
+	)
+
+	if len(p.targetFiles) == 0 {
+		err = errors.New("kool.yml not found")
+		return
+	}
+
+	for _, koolFile := range p.targetFiles {
+		if parsedFile, err = ParseKoolYaml(koolFile); err != nil {
+			return
+		}
+
+		for name := range parsedFile.Environments {
+			if !found[name] {
+				found[name] = true
+				environments = append(environments, name)
+			}
+		}
+	}
+
+	sort.Strings(environments)
+	return
+}
+
+// ParseBinPaths returns the directories listed under the path key of all
+// kool.yml files, in lookup order; relative directories are resolved
+// against the folder of the kool.yml file that lists them.
+func (p *DefaultParser) ParseBinPaths() (paths []string, err error) {
+	var parsedFile *KoolYaml
+
+	if len(p.targetFiles) == 0 {
+		err = errors.New("kool.yml not found")
+		return
+	}
+
+	for _, koolFile := range p.targetFiles {
+		if parsedFile, err = ParseKoolYaml(koolFile); err != nil {
+			return
+		}
+
+		for _, binPath := range parsedFile.Path {
+			if !filepath.IsAbs(binPath) {
+				binPath = filepath.Join(filepath.Dir(koolFile), binPath)
+			}
+
+			paths = append(paths, binPath)
+		}
+	}
+
+	return
+}
+
+// ParseScriptParallel tells whether the given script runs its commands
+// in parallel, on the first kool.yml file that defines it.
+func (p *DefaultParser) ParseScriptParallel(script string) (parallel bool, err error) {
+	var parsedFile *KoolYaml
+
+	if len(p.targetFiles) == 0 {
+		err = errors.New("kool.yml not found")
+		return
+	}
+
+	for _, koolFile := range p.targetFiles {
+		if parsedFile, err = ParseKoolYaml(koolFile); err != nil {
+			return
+		}
+
+		if parsedFile.HasScript(script) {
+			parallel = parsedFile.ScriptParallel(script)
+			return
+		}
+	}
+
+	return
+}
+
+// ParseScriptTimeout returns the timeout declared for the given script
+// on the first kool.yml file that defines it; zero means no timeout.
+func (p *DefaultParser) ParseScriptTimeout(script string) (timeout time.Duration, err error) {
+	var parsedFile *KoolYaml
+
+	if len(p.targetFiles) == 0 {
+		err = errors.New("kool.yml not found")
+		return
+	}
+
+	for _, koolFile := range p.targetFiles {
+		if parsedFile, err = ParseKoolYaml(koolFile); err != nil {
+			return
+		}
+
+		if parsedFile.HasScript(script) {
+			timeout, err = parsedFile.ScriptTimeout(script)
+			return
+		}
+	}
+
+	return
+}
@@ -4,8 +4,10 @@ import (
 	"kool-dev/kool/core/builder"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestErrPossibleTypo(t *testing.T) {
@@ -150,3 +152,206 @@ func TestParserParseAvailableScriptsFilter(t *testing.T) {
 		t.Error("failed to get filtered scripts from kool.yml")
 	}
 }
+
+func TestParserParseBootstrapScripts(t *testing.T) {
+	var (
+		p       Parser = NewParser()
+		scripts []string
+		err     error
+	)
+
+	if _, err = p.ParseBootstrapScripts(); err == nil || err.Error() != "kool.yml not found" {
+		t.Errorf("expecting error 'kool.yml not found', got '%v'", err)
+	}
+
+	workDir, _ := os.Getwd()
+	_ = p.AddLookupPath(path.Join(workDir, "testing_files"))
+
+	if scripts, err = p.ParseBootstrapScripts(); err != nil {
+		t.Errorf("unexpected error; error: %s", err)
+	}
+
+	if len(scripts) != 1 || scripts[0] != "testing" {
+		t.Errorf("failed to get bootstrap scripts from kool.yml; got %v", scripts)
+	}
+}
+
+func TestParserParseProjectName(t *testing.T) {
+	var (
+		p       Parser = NewParser()
+		project string
+		err     error
+	)
+
+	if _, err = p.ParseProjectName(); err == nil || err.Error() != "kool.yml not found" {
+		t.Errorf("expecting error 'kool.yml not found', got '%v'", err)
+	}
+
+	workDir, _ := os.Getwd()
+	_ = p.AddLookupPath(path.Join(workDir, "testing_files"))
+
+	if project, err = p.ParseProjectName(); err != nil {
+		t.Errorf("unexpected error; error: %s", err)
+	}
+
+	if project != "testing" {
+		t.Errorf("failed to get project name from kool.yml; got %s", project)
+	}
+}
+
+func TestParserParseScriptTimeout(t *testing.T) {
+	var (
+		p       Parser = NewParser()
+		dir            = t.TempDir()
+		timeout time.Duration
+		err     error
+	)
+
+	if _, err = p.ParseScriptTimeout("test"); err == nil || err.Error() != "kool.yml not found" {
+		t.Errorf("expecting error 'kool.yml not found', got '%v'", err)
+	}
+
+	_ = os.WriteFile(path.Join(dir, "kool.yml"), []byte("scripts:\n  test:\n    commands: go test ./...\n    timeout: 5m\n"), os.ModePerm)
+	_ = p.AddLookupPath(dir)
+
+	if timeout, err = p.ParseScriptTimeout("test"); err != nil || timeout != 5*time.Minute {
+		t.Errorf("expected timeout of 5m; got %s (err: %v)", timeout, err)
+	}
+
+	if timeout, err = p.ParseScriptTimeout("missing"); err != nil || timeout != 0 {
+		t.Errorf("expected no timeout for missing script; got %s (err: %v)", timeout, err)
+	}
+}
+
+func TestParserParseScriptParallel(t *testing.T) {
+	var (
+		p        Parser = NewParser()
+		dir             = t.TempDir()
+		parallel bool
+		err      error
+	)
+
+	if _, err = p.ParseScriptParallel("build"); err == nil || err.Error() != "kool.yml not found" {
+		t.Errorf("expecting error 'kool.yml not found', got '%v'", err)
+	}
+
+	_ = os.WriteFile(path.Join(dir, "kool.yml"), []byte("scripts:\n  build:\n    parallel: [npm run build, composer install]\n  test: go test ./...\n"), os.ModePerm)
+	_ = p.AddLookupPath(dir)
+
+	if parallel, err = p.ParseScriptParallel("build"); err != nil || !parallel {
+		t.Errorf("expected build script to be parallel (err: %v)", err)
+	}
+
+	for _, script := range []string{"test", "missing"} {
+		if parallel, err = p.ParseScriptParallel(script); err != nil || parallel {
+			t.Errorf("expected %s script not to be parallel (err: %v)", script, err)
+		}
+	}
+}
+
+func TestParserParseEnvironments(t *testing.T) {
+	var (
+		p            Parser = NewParser()
+		dir                 = t.TempDir()
+		environments []string
+		err          error
+	)
+
+	if _, err = p.ParseEnvironments(); err == nil || err.Error() != "kool.yml not found" {
+		t.Errorf("expecting error 'kool.yml not found', got '%v'", err)
+	}
+
+	_ = os.WriteFile(path.Join(dir, "kool.yml"), []byte("scripts:\n  test: go test\nenvironments:\n  staging: {}\n  ci:\n    project: ci\n"), os.ModePerm)
+	_ = p.AddLookupPath(dir)
+
+	if environments, err = p.ParseEnvironments(); err != nil || strings.Join(environments, ",") != "ci,staging" {
+		t.Errorf("expected environments ci and staging; got %v (err: %v)", environments, err)
+	}
+}
+
+func TestParserParseDependencies(t *testing.T) {
+	var (
+		p            Parser = NewParser()
+		dir                 = t.TempDir()
+		home                = t.TempDir()
+		dependencies []string
+		err          error
+	)
+
+	if _, err = p.ParseDependencies("test"); err == nil || err.Error() != "kool.yml not found" {
+		t.Errorf("expecting error 'kool.yml not found', got '%v'", err)
+	}
+
+	_ = os.WriteFile(path.Join(dir, "kool.yml"), []byte(`scripts:
+  install: composer install
+  migrate:
+    needs: [install]
+    commands: php artisan migrate
+  test:
+    needs: [install, migrate, tools]
+    commands: phpunit
+  all:
+    needs: [test]
+  ping:
+    needs: [pong]
+  pong:
+    needs: [ping]
+  broken:
+    needs: [missing]
+  loop:
+    needs: [loop]
+`), os.ModePerm)
+	_ = os.WriteFile(path.Join(home, "kool.yml"), []byte("scripts:\n  tools:\n    needs: [install]\n    commands: echo tools\n"), os.ModePerm)
+
+	_ = p.AddLookupPath(dir)
+	_ = p.AddLookupPath(home)
+
+	if dependencies, err = p.ParseDependencies("test"); err != nil || strings.Join(dependencies, ",") != "install,migrate,tools" {
+		t.Errorf("expected dependencies install, migrate and tools; got %v (err: %v)", dependencies, err)
+	}
+
+	if dependencies, err = p.ParseDependencies("all"); err != nil || strings.Join(dependencies, ",") != "install,migrate,tools,test" {
+		t.Errorf("expected dependencies install, migrate, tools and test; got %v (err: %v)", dependencies, err)
+	}
+
+	for _, script := range []string{"install", "undefined"} {
+		if dependencies, err = p.ParseDependencies(script); err != nil || len(dependencies) != 0 {
+			t.Errorf("expected no dependencies for %s; got %v (err: %v)", script, dependencies, err)
+		}
+	}
+
+	if _, err = p.ParseDependencies("ping"); err == nil || err.Error() != "scripts needs form a cycle: ping -> pong -> ping" {
+		t.Errorf("expected dependency cycle error; got %v", err)
+	}
+
+	if _, err = p.ParseDependencies("loop"); err == nil || err.Error() != "scripts needs form a cycle: loop -> loop" {
+		t.Errorf("expected dependency cycle error; got %v", err)
+	}
+
+	if _, err = p.ParseDependencies("broken"); err == nil || err.Error() != "script 'broken' needs 'missing', which is not defined" {
+		t.Errorf("expected missing dependency error; got %v", err)
+	}
+}
+
+func TestParserParseBinPaths(t *testing.T) {
+	var (
+		p     Parser = NewParser()
+		paths []string
+		err   error
+	)
+
+	if _, err = p.ParseBinPaths(); err == nil || err.Error() != "kool.yml not found" {
+		t.Errorf("expecting error 'kool.yml not found', got '%v'", err)
+	}
+
+	workDir, _ := os.Getwd()
+	_ = p.AddLookupPath(path.Join(workDir, "testing_files"))
+
+	if paths, err = p.ParseBinPaths(); err != nil {
+		t.Errorf("unexpected error; error: %s", err)
+	}
+
+	if len(paths) != 2 || paths[0] != filepath.Join(workDir, "testing_files", "bin") || paths[1] != filepath.Join(workDir, "testing_files", "vendor", "bin") {
+		t.Errorf("failed to get bin paths from kool.yml; got %v", paths)
+	}
+}
@@ -0,0 +1,357 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/agnivade/levenshtein"
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// koolYamlKeys are the top level keys known on kool.yml files
+var koolYamlKeys = []string{"scripts", "bootstrap", "project", "path", "include", "environments"}
+
+// scriptKeys are the keys known on scripts written as a mapping
+var scriptKeys = []string{"commands", "parallel", "timeout", "needs"}
+
+// stepKeys are the keys known on script steps written as a mapping
+var stepKeys = []string{"command", "if"}
+
+// ValidationProblem is a structural problem found on a kool.yml file
+type ValidationProblem struct {
+	Path    string
+	Line    int
+	Problem string
+}
+
+// String returns the string representation for the problem
+func (p ValidationProblem) String() string {
+	if p.Path == "" {
+		return fmt.Sprintf("line %d: %s", p.Line, p.Problem)
+	}
+
+	return fmt.Sprintf("line %d: %s: %s", p.Line, p.Path, p.Problem)
+}
+
+type koolYamlValidator struct {
+	filePath string
+	problems []ValidationProblem
+}
+
+// ValidateKoolYaml checks the structure of the given kool.yml file, returning
+// the problems found; err is only set when the file cannot be read or parsed
+func ValidateKoolYaml(filePath string) (problems []ValidationProblem, err error) {
+	var (
+		raw      []byte
+		document yaml3.Node
+		v        = &koolYamlValidator{filePath: filePath}
+	)
+
+	if raw, err = os.ReadFile(filePath); err != nil {
+		return
+	}
+
+	if err = yaml3.Unmarshal(raw, &document); err != nil {
+		return
+	}
+
+	if len(document.Content) > 0 {
+		v.validateRoot(document.Content[0])
+	}
+
+	problems = v.problems
+	return
+}
+
+func (v *koolYamlValidator) report(node *yaml3.Node, path, format string, a ...interface{}) {
+	v.problems = append(v.problems, ValidationProblem{path, node.Line, fmt.Sprintf(format, a...)})
+}
+
+func (v *koolYamlValidator) validateRoot(root *yaml3.Node) {
+	if root.Kind != yaml3.MappingNode {
+		v.report(root, "", "expected a mapping of keys (scripts, bootstrap, project, path, include, environments), got %s", nodeKind(root))
+		return
+	}
+
+	var (
+		scripts      = make(map[string]bool)
+		environments = v.validateConfig(root, "", scripts)
+	)
+
+	if environments == nil {
+		return
+	}
+
+	if environments.Kind != yaml3.MappingNode {
+		if !isNull(environments) {
+			v.report(environments, "environments", "expected a mapping of environment names to overrides, got %s", nodeKind(environments))
+		}
+		return
+	}
+
+	for i := 0; i+1 < len(environments.Content); i += 2 {
+		key, value := environments.Content[i], environments.Content[i+1]
+		path := "environments." + key.Value
+
+		if value.Kind != yaml3.MappingNode {
+			if !isNull(value) {
+				v.report(value, path, "expected a mapping of keys (scripts, bootstrap, project, path), got %s", nodeKind(value))
+			}
+			continue
+		}
+
+		// environment bootstrap entries may refer to base scripts as well
+		envScripts := make(map[string]bool)
+		for script := range scripts {
+			envScripts[script] = true
+		}
+
+		if nested := v.validateConfig(value, path+".", envScripts); nested != nil {
+			v.report(nested, path+".environments", "environments cannot be nested")
+		}
+	}
+}
+
+// validateConfig validates the keys of the root config or an environment
+// overrides section, returning the environments node, if any
+func (v *koolYamlValidator) validateConfig(node *yaml3.Node, prefix string, scripts map[string]bool) (environments *yaml3.Node) {
+	var bootstrap, include *yaml3.Node
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		switch key.Value {
+		case "scripts":
+			v.validateScripts(value, prefix, scripts)
+		case "bootstrap":
+			v.validateStrings(value, prefix+"bootstrap")
+			bootstrap = value
+		case "project":
+			v.validateString(value, prefix+"project")
+		case "path":
+			v.validateStrings(value, prefix+"path")
+		case "include":
+			if prefix != "" {
+				v.report(key, prefix+"include", "include can only be set at the top level")
+			} else {
+				include = value
+			}
+		case "environments":
+			environments = value
+		default:
+			v.report(key, prefix+key.Value, "unknown key%s", suggestKey(key.Value, koolYamlKeys))
+		}
+	}
+
+	// included after the whole config, so its own scripts are known
+	if include != nil {
+		v.validateIncludes(include, scripts)
+	}
+
+	if bootstrap != nil && bootstrap.Kind == yaml3.SequenceNode {
+		for i, item := range bootstrap.Content {
+			if item.Kind == yaml3.ScalarNode && !scripts[item.Value] {
+				v.report(item, fmt.Sprintf("%sbootstrap[%d]", prefix, i), "script '%s' is not defined under scripts", item.Value)
+			}
+		}
+	}
+
+	return
+}
+
+// validateIncludes validates the include list and the files it brings in,
+// adding their scripts to the ones defined (for bootstrap to refer to them)
+func (v *koolYamlValidator) validateIncludes(node *yaml3.Node, scripts map[string]bool) {
+	var parsed = &KoolYaml{Scripts: make(map[string]interface{})}
+
+	if v.validateStrings(node, "include"); node.Kind != yaml3.SequenceNode {
+		return
+	}
+
+	for _, item := range node.Content {
+		parsed.Include = append(parsed.Include, item.Value)
+	}
+
+	for script := range scripts {
+		parsed.Scripts[script] = nil
+	}
+
+	if err := parsed.mergeIncludes(v.filePath); err != nil {
+		v.report(node, "include", "%v", err)
+		return
+	}
+
+	for script := range parsed.Scripts {
+		scripts[script] = true
+	}
+}
+
+func (v *koolYamlValidator) validateScripts(node *yaml3.Node, prefix string, scripts map[string]bool) {
+	var defined = make(map[string]bool)
+
+	if node.Kind != yaml3.MappingNode {
+		if !isNull(node) {
+			v.report(node, prefix+"scripts", "expected a mapping of script names to commands, got %s", nodeKind(node))
+		}
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		path := prefix + "scripts." + key.Value
+
+		if defined[key.Value] {
+			v.report(key, path, "script is defined more than once")
+		}
+		defined[key.Value] = true
+		scripts[key.Value] = true
+
+		if value.Kind == yaml3.MappingNode {
+			v.validateScriptMapping(value, path)
+			continue
+		}
+
+		v.validateCommands(value, path)
+	}
+}
+
+// validateScriptMapping validates a script written as a mapping,
+// which holds its commands along with its options (like timeout)
+func (v *koolYamlValidator) validateScriptMapping(node *yaml3.Node, path string) {
+	var hasCommands, hasParallel, hasNeeds bool
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		switch key.Value {
+		case "commands":
+			hasCommands = true
+			v.validateCommands(value, path+".commands")
+		case "parallel":
+			hasParallel = true
+			v.validateCommands(value, path+".parallel")
+		case "needs":
+			hasNeeds = true
+			v.validateStrings(value, path+".needs")
+		case "timeout":
+			if duration, err := time.ParseDuration(value.Value); value.Kind != yaml3.ScalarNode || err != nil || duration <= 0 {
+				v.report(value, path+".timeout", "expected a duration like 90s or 5m")
+			}
+		default:
+			v.report(key, path+"."+key.Value, "unknown key%s", suggestKey(key.Value, scriptKeys))
+		}
+	}
+
+	if hasCommands && hasParallel {
+		v.report(node, path, "expected either the commands or the parallel key, not both")
+	} else if !hasCommands && !hasParallel && !hasNeeds {
+		v.report(node, path, "expected the commands, parallel or needs key")
+	}
+}
+
+func (v *koolYamlValidator) validateCommands(node *yaml3.Node, path string) {
+	switch node.Kind {
+	case yaml3.ScalarNode:
+		v.validateString(node, path)
+	case yaml3.SequenceNode:
+		if len(node.Content) == 0 {
+			v.report(node, path, "expected at least one command")
+		}
+
+		for i, item := range node.Content {
+			if item.Kind == yaml3.MappingNode {
+				v.validateStep(item, fmt.Sprintf("%s[%d]", path, i))
+			} else {
+				v.validateString(item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	default:
+		v.report(node, path, "expected a command string or a list of command strings, got %s", nodeKind(node))
+	}
+}
+
+// validateStep validates a script step written as a mapping,
+// which holds its command along with an if condition
+func (v *koolYamlValidator) validateStep(node *yaml3.Node, path string) {
+	var hasCommand bool
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		switch key.Value {
+		case "command":
+			hasCommand = true
+			v.validateString(value, path+".command")
+		case "if":
+			if value.Kind != yaml3.ScalarNode || (value.ShortTag() != "!!str" && value.ShortTag() != "!!bool") {
+				v.report(value, path+".if", "expected a condition like env.APP_ENV == \"local\", got %s", nodeKind(value))
+			} else if _, err := compileCondition(value.Value); value.ShortTag() == "!!str" && err != nil {
+				v.report(value, path+".if", "%v", err)
+			}
+		default:
+			v.report(key, path+"."+key.Value, "unknown key%s", suggestKey(key.Value, stepKeys))
+		}
+	}
+
+	if !hasCommand {
+		v.report(node, path, "expected the command key")
+	}
+}
+
+func (v *koolYamlValidator) validateStrings(node *yaml3.Node, path string) {
+	if node.Kind != yaml3.SequenceNode {
+		v.report(node, path, "expected a list of strings, got %s", nodeKind(node))
+		return
+	}
+
+	for i, item := range node.Content {
+		v.validateString(item, fmt.Sprintf("%s[%d]", path, i))
+	}
+}
+
+func (v *koolYamlValidator) validateString(node *yaml3.Node, path string) {
+	if node.Kind != yaml3.ScalarNode || node.ShortTag() != "!!str" {
+		v.report(node, path, "expected a string, got %s", nodeKind(node))
+	} else if node.Value == "" {
+		v.report(node, path, "expected a non-empty string")
+	}
+}
+
+func isNull(node *yaml3.Node) bool {
+	return node.Kind == yaml3.ScalarNode && node.ShortTag() == "!!null"
+}
+
+// nodeKind describes the kind of the YAML node for error messages
+func nodeKind(node *yaml3.Node) string {
+	switch node.Kind {
+	case yaml3.MappingNode:
+		return "a mapping"
+	case yaml3.SequenceNode:
+		return "a list"
+	case yaml3.AliasNode:
+		return "an alias"
+	}
+
+	switch node.ShortTag() {
+	case "!!null":
+		return "an empty value"
+	case "!!int", "!!float":
+		return "a number"
+	case "!!bool":
+		return "a boolean"
+	}
+
+	return "a string"
+}
+
+// suggestKey returns a "did you mean" hint for a misspelled key
+func suggestKey(key string, known []string) string {
+	for _, k := range known {
+		if levenshtein.ComputeDistance(key, k) <= SimilarThreshold {
+			return fmt.Sprintf(" (did you mean '%s'?)", k)
+		}
+	}
+
+	return ""
+}
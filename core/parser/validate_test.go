@@ -0,0 +1,186 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateKoolYaml(t *testing.T) {
+	workDir, _ := os.Getwd()
+
+	if problems, err := ValidateKoolYaml(filepath.Join(workDir, "testing_files", "kool.yml")); err != nil || len(problems) != 0 {
+		t.Errorf("expected testing kool.yml to be valid; got %v (err: %v)", problems, err)
+	}
+
+	if _, err := ValidateKoolYaml(filepath.Join(workDir, "testing_files", "missing.yml")); err == nil {
+		t.Error("expected error validating a missing file")
+	}
+
+	file := filepath.Join(t.TempDir(), "kool.yml")
+	_ = os.WriteFile(file, []byte(`scrips:
+  foo: bar
+scripts:
+  ok: echo ok
+  list:
+    - echo one
+    - nested: value
+  number: 10
+  empty: []
+  timed:
+    commands: echo timed
+    timeout: 5m
+  mapping:
+    timeot: soon
+  build:
+    parallel:
+      - npm run build
+      - composer install
+  both:
+    commands: echo one
+    parallel: [echo two]
+  steps:
+    - command: composer install
+      if: file.exists("composer.json")
+    - command: echo off
+      if: false
+    - command: echo broken
+      if: env.APP_ENV ==
+    - comand: echo typo
+bootstrap:
+  - ok
+  - missing
+project:
+  - not a string
+`), os.ModePerm)
+
+	problems, err := ValidateKoolYaml(file)
+
+	if err != nil {
+		t.Fatalf("unexpected error validating: %v", err)
+	}
+
+	var got []string
+	for _, problem := range problems {
+		got = append(got, problem.String())
+	}
+
+	expected := []string{
+		"line 1: scrips: unknown key (did you mean 'scripts'?)",
+		"line 7: scripts.list[1].nested: unknown key",
+		"line 7: scripts.list[1]: expected the command key",
+		"line 8: scripts.number: expected a string, got a number",
+		"line 9: scripts.empty: expected at least one command",
+		"line 14: scripts.mapping.timeot: unknown key (did you mean 'timeout'?)",
+		"line 14: scripts.mapping: expected the commands, parallel or needs key",
+		"line 20: scripts.both: expected either the commands or the parallel key, not both",
+		"line 28: scripts.steps[2].if: invalid condition 'env.APP_ENV ==': unexpected end of the expression",
+		"line 29: scripts.steps[3].comand: unknown key (did you mean 'command'?)",
+		"line 29: scripts.steps[3]: expected the command key",
+		"line 34: project: expected a string, got a list",
+		"line 32: bootstrap[1]: script 'missing' is not defined under scripts",
+	}
+
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected problems:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	_ = os.WriteFile(file, []byte("- just a list\n"), os.ModePerm)
+
+	if problems, _ = ValidateKoolYaml(file); len(problems) != 1 || !strings.Contains(problems[0].Problem, "expected a mapping") {
+		t.Errorf("expected problem for non-mapping kool.yml; got %v", problems)
+	}
+
+	_ = os.WriteFile(file, []byte("scripts: [\n"), os.ModePerm)
+
+	if _, err = ValidateKoolYaml(file); err == nil {
+		t.Error("expected error for invalid YAML syntax")
+	}
+}
+
+func TestValidateKoolYamlEnvironments(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "kool.yml")
+	_ = os.WriteFile(file, []byte(`scripts:
+  test: phpunit
+environments:
+  ci:
+    scripts:
+      test: phpunit --coverage
+      lint: phpcs
+    bootstrap:
+      - test
+      - lint
+      - missing
+  staging:
+    scrips: {}
+    environments: {}
+  broken: [not, a, mapping]
+`), os.ModePerm)
+
+	problems, err := ValidateKoolYaml(file)
+
+	if err != nil {
+		t.Fatalf("unexpected error validating: %v", err)
+	}
+
+	var got []string
+	for _, problem := range problems {
+		got = append(got, problem.String())
+	}
+
+	expected := []string{
+		"line 11: environments.ci.bootstrap[2]: script 'missing' is not defined under scripts",
+		"line 13: environments.staging.scrips: unknown key (did you mean 'scripts'?)",
+		"line 14: environments.staging.environments: environments cannot be nested",
+		"line 15: environments.broken: expected a mapping of keys (scripts, bootstrap, project, path), got a list",
+	}
+
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected problems:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestValidateKoolYamlIncludes(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "kool.yml")
+
+	_ = os.WriteFile(filepath.Join(dir, "kool.db.yml"), []byte("scripts:\n  migrate: php artisan migrate\n"), os.ModePerm)
+	_ = os.WriteFile(file, []byte(`include:
+  - kool.db.yml
+scripts:
+  test: phpunit
+bootstrap:
+  - migrate
+environments:
+  ci:
+    include: [kool.ci.yml]
+`), os.ModePerm)
+
+	problems, err := ValidateKoolYaml(file)
+
+	if err != nil || len(problems) != 1 || problems[0].String() != "line 9: environments.ci.include: include can only be set at the top level" {
+		t.Errorf("unexpected problems validating includes: %v (err: %v)", problems, err)
+	}
+
+	_ = os.WriteFile(file, []byte(`scripts:
+  migrate: echo migrate
+include: [kool.db.yml, missing.yml]
+`), os.ModePerm)
+
+	if problems, _ = ValidateKoolYaml(file); len(problems) != 1 || problems[0].String() != "line 3: include: script 'migrate' is defined in both kool.yml and kool.db.yml" {
+		t.Errorf("expected duplicate script problem; got %v", problems)
+	}
+
+	_ = os.WriteFile(file, []byte("include: [missing.yml]\n"), os.ModePerm)
+
+	if problems, _ = ValidateKoolYaml(file); len(problems) != 1 || !strings.Contains(problems[0].String(), "line 1: include: failed including missing.yml") {
+		t.Errorf("expected missing include problem; got %v", problems)
+	}
+
+	_ = os.WriteFile(file, []byte("include: kool.db.yml\n"), os.ModePerm)
+
+	if problems, _ = ValidateKoolYaml(file); len(problems) != 1 || problems[0].String() != "line 1: include: expected a list of strings, got a string" {
+		t.Errorf("expected include list problem; got %v", problems)
+	}
+}
@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"fmt"
+	"kool-dev/kool/core/environment"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// scriptVariable matches the ${VAR} and ${VAR:-default} references on kool.yml scripts
+var scriptVariable = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandScriptVariables resolves the environment variable references on the
+// script line in a single pass, so the values put in place are not expanded
+// again: ${VAR} references a variable that must be set (even if empty), to
+// catch typos, unless a default is given as in ${VAR:-default}, which is
+// taken when the variable is unset or empty; plain $VAR references resolve
+// to an empty value when unset, as in the shell.
+func expandScriptVariables(script, line string, env environment.EnvStorage) (expanded string, err error) {
+	var (
+		sb   strings.Builder
+		last int
+	)
+
+	for _, match := range scriptVariable.FindAllStringSubmatchIndex(line, -1) {
+		name := line[match[2]:match[3]]
+		value, found := env.Lookup(name)
+
+		if !found && match[4] < 0 {
+			err = fmt.Errorf("script '%s' references undefined variable %s; set it or give a default with ${%s:-default}", script, name, name)
+			return
+		}
+
+		if value == "" && match[4] >= 0 {
+			value = os.Expand(line[match[6]:match[7]], env.Get)
+		}
+
+		sb.WriteString(os.Expand(line[last:match[0]], env.Get))
+		sb.WriteString(value)
+		last = match[1]
+	}
+
+	sb.WriteString(os.Expand(line[last:], env.Get))
+	expanded = sb.String()
+	return
+}
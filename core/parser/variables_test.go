@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"kool-dev/kool/core/environment"
+	"strings"
+	"testing"
+)
+
+func TestExpandScriptVariables(t *testing.T) {
+	env := environment.NewFakeEnvStorage()
+	env.Set("APP_DIR", "/app/src")
+	env.Set("USER_NAME", "kool")
+	env.Set("EMPTY", "")
+	env.Set("DOLLAR", "a$HOME")
+
+	var testCases = []struct {
+		line     string
+		expected string
+	}{
+		{"ls ${APP_DIR}", "ls /app/src"},
+		{"cp ${APP_DIR}/a ${APP_DIR}/b --owner=${USER_NAME}", "cp /app/src/a /app/src/b --owner=kool"},
+		{"echo ${MISSING:-fallback} ${MISSING:-}", "echo fallback "},
+		{"echo ${USER_NAME:-fallback}", "echo kool"},
+		{"echo $APP_DIR $UNSET_DIR", "echo /app/src "},
+		{"echo ${EMPTY}-${EMPTY:-fallback}", "echo -fallback"},
+		{"echo ${MISSING:-$APP_DIR}", "echo /app/src"},
+		{"echo ${DOLLAR} $DOLLAR", "echo a$HOME a$HOME"},
+		{"no references", "no references"},
+	}
+
+	for _, tc := range testCases {
+		expanded, err := expandScriptVariables("script", tc.line, env)
+
+		if err != nil {
+			t.Errorf("unexpected error expanding '%s': %v", tc.line, err)
+		} else if expanded != tc.expected {
+			t.Errorf("expected '%s' expanded to '%s'; got '%s'", tc.line, tc.expected, expanded)
+		}
+	}
+}
+
+func TestExpandScriptVariablesUndefined(t *testing.T) {
+	env := environment.NewFakeEnvStorage()
+	env.Set("APP_DIR", "/app")
+
+	_, err := expandScriptVariables("build", "ls ${APP_DIR} ${APP_DRI}", env)
+
+	if err == nil || !strings.Contains(err.Error(), "script 'build' references undefined variable APP_DRI") {
+		t.Errorf("expected undefined variable error; got %v", err)
+	}
+}
@@ -4,7 +4,12 @@ import (
 	"fmt"
 	"io"
 	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/environment"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/agnivade/levenshtein"
 	"gopkg.in/yaml.v2"
@@ -14,11 +19,20 @@ import (
 // script names for them to be considered similarss
 const SimilarThreshold int = 2
 
+// DefaultEnvironment is the kool.yml environment used when KOOL_ENV is not set
+const DefaultEnvironment = "local"
+
 type yamlMarshalFnType func(interface{}) ([]byte, error)
 
 // KoolYaml holds the structure for parsing the custom commands file
 type KoolYaml struct {
-	Scripts map[string]interface{} `yaml:"scripts"`
+	Scripts   map[string]interface{} `yaml:"scripts"`
+	Bootstrap []string               `yaml:"bootstrap,omitempty"`
+	Project   string                 `yaml:"project,omitempty"`
+	Path      []string               `yaml:"path,omitempty"`
+	Include   []string               `yaml:"include,omitempty"`
+
+	Environments map[string]*KoolYaml `yaml:"environments,omitempty"`
 }
 
 // KoolYamlParser holds logic for handling kool yaml
@@ -32,9 +46,44 @@ type KoolYamlParser interface {
 
 var yamlMarshalFn yamlMarshalFnType = yaml.Marshal
 
+// scriptEnv is the environment the kool.yml scripts are resolved from
+var scriptEnv environment.EnvStorage = environment.NewEnvStorage()
+
 // ParseKoolYaml decodes the target kool.yml into its
-// the expected KoolYaml representation.
+// the expected KoolYaml representation, with the scripts
+// of the files it includes, the local overrides file
+// (kool.local.yml) and then the overrides of the active
+// environment (KOOL_ENV) merged in.
 func ParseKoolYaml(filePath string) (parsed *KoolYaml, err error) {
+	var local *KoolYaml
+
+	if parsed, err = decodeKoolYaml(filePath); err != nil {
+		return
+	}
+
+	if err = parsed.mergeIncludes(filePath); err != nil {
+		return
+	}
+
+	if localPath := localKoolYamlPath(filePath); localPath != "" {
+		if local, err = decodeKoolYaml(localPath); err == nil {
+			err = local.mergeIncludes(localPath)
+		}
+
+		if err != nil {
+			err = fmt.Errorf("failed parsing %s: %v", filepath.Base(localPath), err)
+			return
+		}
+
+		parsed.mergeLocal(local)
+	}
+
+	parsed.mergeEnvironment(ActiveEnvironment())
+	return
+}
+
+// decodeKoolYaml decodes the given file as it is, with no overrides
+func decodeKoolYaml(filePath string) (parsed *KoolYaml, err error) {
 	var (
 		file *os.File
 		raw  []byte
@@ -52,10 +101,209 @@ func ParseKoolYaml(filePath string) (parsed *KoolYaml, err error) {
 
 	parsed = new(KoolYaml)
 	err = yaml.Unmarshal(raw, parsed)
+	return
+}
 
+// includeResolver merges the scripts of included files into a config,
+// keeping track of which file defines each script
+type includeResolver struct {
+	baseDir  string
+	origins  map[string]string
+	included map[string]bool
+	chain    []string
+}
+
+// mergeIncludes merges the scripts of the files listed under include into
+// this config, along with the ones they include in turn. Included files are
+// merged in the order they are listed, each just once, with their paths
+// relative to the file including them; they can only hold scripts (and
+// include others), and a script defined in more than one of the files is
+// an error, as well as files including each other.
+func (y *KoolYaml) mergeIncludes(filePath string) (err error) {
+	if len(y.Include) == 0 {
+		return
+	}
+
+	filePath = filepath.Clean(filePath)
+
+	r := &includeResolver{
+		filepath.Dir(filePath),
+		make(map[string]string),
+		map[string]bool{filePath: true},
+		[]string{filePath},
+	}
+
+	for name := range y.Scripts {
+		r.origins[name] = filePath
+	}
+
+	if y.Scripts == nil {
+		y.Scripts = make(map[string]interface{})
+	}
+
+	err = r.resolve(y, y.Include, filePath)
 	return
 }
 
+func (r *includeResolver) resolve(root *KoolYaml, includes []string, filePath string) (err error) {
+	for _, include := range includes {
+		var (
+			included    *KoolYaml
+			includePath = include
+			names       []string
+		)
+
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(filePath), include)
+		}
+
+		for _, including := range r.chain {
+			if including == includePath {
+				err = fmt.Errorf("kool.yml includes form a cycle: %s", strings.Join(r.display(append(r.chain, includePath)...), " -> "))
+				return
+			}
+		}
+
+		if r.included[includePath] {
+			continue
+		}
+
+		r.included[includePath] = true
+
+		if included, err = decodeKoolYaml(includePath); err != nil {
+			err = fmt.Errorf("failed including %s: %v", r.display(includePath)[0], err)
+			return
+		}
+
+		if len(included.Bootstrap) > 0 || included.Project != "" || len(included.Path) > 0 || len(included.Environments) > 0 {
+			err = fmt.Errorf("failed including %s: included files can only have scripts and include", r.display(includePath)[0])
+			return
+		}
+
+		for name := range included.Scripts {
+			names = append(names, name)
+		}
+
+		// sorted so that the same duplicate gets reported every time
+		sort.Strings(names)
+
+		for _, name := range names {
+			if origin, defined := r.origins[name]; defined {
+				err = &ErrDuplicateScript{name, r.display(origin, includePath)}
+				return
+			}
+
+			r.origins[name] = includePath
+			root.Scripts[name] = included.Scripts[name]
+		}
+
+		r.chain = append(r.chain, includePath)
+
+		if err = r.resolve(root, included.Include, includePath); err != nil {
+			return
+		}
+
+		r.chain = r.chain[:len(r.chain)-1]
+	}
+	return
+}
+
+// display returns the given paths relative to the including kool.yml
+func (r *includeResolver) display(paths ...string) (displayed []string) {
+	for _, path := range paths {
+		if rel, err := filepath.Rel(r.baseDir, path); err == nil {
+			path = rel
+		}
+
+		displayed = append(displayed, path)
+	}
+	return
+}
+
+// localKoolYamlPath returns the path of the local overrides file sitting
+// next to the given kool.yml (kool.local.yml or kool.local.yaml), if any
+func localKoolYamlPath(filePath string) string {
+	var (
+		ext  = filepath.Ext(filePath)
+		base = strings.TrimSuffix(filePath, ext)
+	)
+
+	for _, localPath := range []string{base + ".local.yml", base + ".local.yaml"} {
+		if _, err := os.Stat(localPath); err == nil {
+			return localPath
+		}
+	}
+
+	return ""
+}
+
+// mergeLocal merges the local overrides over the base config, the same
+// way environment overrides are merged; the local environments get
+// merged over the base environments with the same name
+func (y *KoolYaml) mergeLocal(local *KoolYaml) {
+	y.merge(local)
+
+	for name, override := range local.Environments {
+		if override == nil {
+			continue
+		}
+
+		if y.Environments == nil {
+			y.Environments = make(map[string]*KoolYaml)
+		}
+
+		if y.Environments[name] == nil {
+			y.Environments[name] = new(KoolYaml)
+		}
+
+		y.Environments[name].merge(override)
+	}
+}
+
+// ActiveEnvironment returns the kool.yml environment in use, as set by KOOL_ENV
+func ActiveEnvironment() (env string) {
+	if env = environment.NewEnvStorage().Get("KOOL_ENV"); env == "" {
+		env = DefaultEnvironment
+	}
+	return
+}
+
+// mergeEnvironment merges the given environment overrides over the base config
+func (y *KoolYaml) mergeEnvironment(env string) {
+	var override, found = y.Environments[env]
+
+	if !found || override == nil {
+		return
+	}
+
+	y.merge(override)
+}
+
+// merge merges the given overrides over this config: its scripts replace
+// the ones with the same name (or are added), while bootstrap, project
+// and path replace the base ones when set
+func (y *KoolYaml) merge(override *KoolYaml) {
+	for name, script := range override.Scripts {
+		if y.Scripts == nil {
+			y.Scripts = make(map[string]interface{})
+		}
+
+		y.Scripts[name] = script
+	}
+
+	if len(override.Bootstrap) > 0 {
+		y.Bootstrap = override.Bootstrap
+	}
+
+	if override.Project != "" {
+		y.Project = override.Project
+	}
+
+	if len(override.Path) > 0 {
+		y.Path = override.Path
+	}
+}
+
 // Parse decodes the target kool.yml
 func (y *KoolYaml) Parse(filePath string) (err error) {
 	var parsed *KoolYaml
@@ -64,6 +312,11 @@ func (y *KoolYaml) Parse(filePath string) (err error) {
 	}
 
 	y.Scripts = parsed.Scripts
+	y.Bootstrap = parsed.Bootstrap
+	y.Project = parsed.Project
+	y.Path = parsed.Path
+	y.Include = parsed.Include
+	y.Environments = parsed.Environments
 	return
 }
 
@@ -88,7 +341,9 @@ func (y *KoolYaml) GetSimilars(script string) (has bool, similars []string) {
 }
 
 // ParseCommands parsed the given script from kool.yml file into a list
-// of commands parsed.
+// of commands parsed, resolving the variable references from the environment
+// and leaving the argument placeholders for ApplyScriptArguments; steps with
+// an if condition come as ConditionalCommand, to be evaluated as they run.
 func (y *KoolYaml) ParseCommands(script string) (commands []builder.Command, err error) {
 	var (
 		isSingle bool
@@ -96,20 +351,45 @@ func (y *KoolYaml) ParseCommands(script string) (commands []builder.Command, err
 		line     string
 		lines    []interface{}
 		command  *builder.DefaultCommand
+		env      = scriptEnv
 	)
 
-	if line, isSingle = y.Scripts[script].(string); isSingle {
-		if command, err = builder.ParseCommand(line); err != nil {
+	if definition, isMapping := y.Scripts[script].(map[interface{}]interface{}); isMapping && definition["commands"] == nil && definition["parallel"] == nil && definition["needs"] != nil {
+		// a script may just run the ones it needs
+		return
+	}
+
+	if line, isSingle = y.scriptCommands(script).(string); isSingle {
+		if line, err = expandScriptVariables(script, markScriptArguments(line), env); err != nil {
+			return
+		}
+
+		if command, err = builder.SplitCommand(line); err != nil {
 			return
 		}
 
 		commands = append(commands, command)
-	} else if lines, isList = y.Scripts[script].([]interface{}); isList {
+	} else if lines, isList = y.scriptCommands(script).([]interface{}); isList {
 		for _, i := range lines {
-			if command, err = builder.ParseCommand(i.(string)); err != nil {
+			var step *ConditionalCommand
+
+			if line, step, err = scriptStep(script, i); err != nil {
+				return
+			}
+
+			if line, err = expandScriptVariables(script, markScriptArguments(line), env); err != nil {
+				return
+			}
+
+			if command, err = builder.SplitCommand(line); err != nil {
 				return
 			}
 
+			if step != nil {
+				commands = append(commands, step.with(command))
+				continue
+			}
+
 			commands = append(commands, command)
 		}
 	} else {
@@ -118,6 +398,136 @@ func (y *KoolYaml) ParseCommands(script string) (commands []builder.Command, err
 	return
 }
 
+// scriptStep returns the command line of a step of a script list, which is
+// either the line itself or a mapping of its command and an if condition;
+// for the latter, step holds the compiled condition (its command is set
+// once the line gets parsed).
+func scriptStep(script string, item interface{}) (line string, step *ConditionalCommand, err error) {
+	var (
+		definition map[interface{}]interface{}
+		expression string
+		isString   bool
+		isMapping  bool
+	)
+
+	if line, isString = item.(string); isString {
+		return
+	}
+
+	if definition, isMapping = item.(map[interface{}]interface{}); !isMapping {
+		err = fmt.Errorf("failed parsing script '%s': expected string or array of strings", script)
+		return
+	}
+
+	line, _ = definition["command"].(string)
+
+	if expression, isString = definition["if"].(string); !isString {
+		if value, isBool := definition["if"].(bool); isBool {
+			// a plain true (or false) turns the step on and off
+			expression, isString = fmt.Sprint(value), true
+		}
+	}
+
+	if line == "" || len(definition) > 2 || (len(definition) == 2 && !isString) {
+		err = fmt.Errorf("failed parsing script '%s': expected steps to be a command string or a mapping of command and if", script)
+		return
+	}
+
+	if !isString {
+		return
+	}
+
+	step = &ConditionalCommand{Condition: expression}
+
+	if step.cond, err = compileCondition(expression); err != nil {
+		step = nil
+		err = fmt.Errorf("failed parsing script '%s': %v", script, err)
+	}
+	return
+}
+
+// scriptCommands returns the commands of the given script; that is the
+// script itself, or the commands (or parallel) key when it is written
+// as a mapping
+func (y *KoolYaml) scriptCommands(script string) interface{} {
+	if definition, isMapping := y.Scripts[script].(map[interface{}]interface{}); isMapping {
+		if definition["parallel"] != nil {
+			return definition["parallel"]
+		}
+
+		return definition["commands"]
+	}
+
+	return y.Scripts[script]
+}
+
+// ScriptTimeout returns the timeout declared for the given script
+// (when written as a mapping), or zero when it declares none.
+func (y *KoolYaml) ScriptTimeout(script string) (timeout time.Duration, err error) {
+	var (
+		definition map[interface{}]interface{}
+		value      string
+		isMapping  bool
+		isString   bool
+	)
+
+	if definition, isMapping = y.Scripts[script].(map[interface{}]interface{}); !isMapping || definition["timeout"] == nil {
+		return
+	}
+
+	if value, isString = definition["timeout"].(string); isString {
+		timeout, err = time.ParseDuration(value)
+	}
+
+	if !isString || err != nil || timeout <= 0 {
+		timeout = 0
+		err = fmt.Errorf("failed parsing script '%s': expected timeout to be a duration like 90s or 5m", script)
+	}
+	return
+}
+
+// ScriptParallel tells whether the given script has its commands under
+// the parallel key (when written as a mapping), so they run concurrently.
+func (y *KoolYaml) ScriptParallel(script string) (parallel bool) {
+	if definition, isMapping := y.Scripts[script].(map[interface{}]interface{}); isMapping {
+		parallel = definition["parallel"] != nil
+	}
+	return
+}
+
+// ScriptNeeds returns the scripts the given script needs to be run
+// before itself (when written as a mapping), if any.
+func (y *KoolYaml) ScriptNeeds(script string) (needs []string, err error) {
+	var (
+		definition map[interface{}]interface{}
+		list       []interface{}
+		isMapping  bool
+		isList     bool
+	)
+
+	if definition, isMapping = y.Scripts[script].(map[interface{}]interface{}); !isMapping || definition["needs"] == nil {
+		return
+	}
+
+	if list, isList = definition["needs"].([]interface{}); !isList {
+		err = fmt.Errorf("failed parsing script '%s': expected needs to be a list of script names", script)
+		return
+	}
+
+	for _, item := range list {
+		name, isString := item.(string)
+
+		if !isString || name == "" {
+			err = fmt.Errorf("failed parsing script '%s': expected needs to be a list of script names", script)
+			return
+		}
+
+		needs = append(needs, name)
+	}
+
+	return
+}
+
 // SetScript set script into kool yaml
 func (y *KoolYaml) SetScript(key string, commands []string) {
 	if len(commands) == 0 {
@@ -3,10 +3,12 @@ package parser
 import (
 	"errors"
 	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/environment"
 	"os"
 	"path"
 	"strings"
 	"testing"
+	"time"
 )
 
 const KoolYmlOK = `scripts:
@@ -192,6 +194,183 @@ func TestErrorParseKoolYamlStruct(t *testing.T) {
 	}
 }
 
+func TestParseCommandsInvalidListKoolYaml(t *testing.T) {
+	parsed := &KoolYaml{Scripts: map[string]interface{}{
+		"nested": []interface{}{"echo first", []interface{}{"echo nested"}},
+		"number": 123,
+	}}
+
+	for _, script := range []string{"nested", "number"} {
+		if _, err := parsed.ParseCommands(script); err == nil || !strings.Contains(err.Error(), "expected string or array of strings") {
+			t.Errorf("expected parse error for script '%s'; got %v", script, err)
+		}
+	}
+}
+
+func TestScriptMappingKoolYaml(t *testing.T) {
+	parsed := &KoolYaml{Scripts: map[string]interface{}{
+		"plain": "echo plain",
+		"test": map[interface{}]interface{}{
+			"commands": []interface{}{"echo one", "echo two"},
+			"timeout":  "10m",
+		},
+		"untimed":  map[interface{}]interface{}{"commands": "echo untimed"},
+		"invalid":  map[interface{}]interface{}{"commands": "echo invalid", "timeout": "soon"},
+		"number":   map[interface{}]interface{}{"commands": "echo number", "timeout": 10},
+		"negative": map[interface{}]interface{}{"commands": "echo negative", "timeout": "-1m"},
+	}}
+
+	if commands, err := parsed.ParseCommands("test"); err != nil || len(commands) != 2 || commands[1].String() != "echo two" {
+		t.Errorf("failed parsing commands of script mapping; got %v (err: %v)", commands, err)
+	}
+
+	if timeout, err := parsed.ScriptTimeout("test"); err != nil || timeout != 10*time.Minute {
+		t.Errorf("expected timeout of 10m; got %s (err: %v)", timeout, err)
+	}
+
+	for _, script := range []string{"plain", "untimed", "missing"} {
+		if timeout, err := parsed.ScriptTimeout(script); err != nil || timeout != 0 {
+			t.Errorf("expected no timeout for script '%s'; got %s (err: %v)", script, timeout, err)
+		}
+	}
+
+	for _, script := range []string{"invalid", "number", "negative"} {
+		if _, err := parsed.ScriptTimeout(script); err == nil || !strings.Contains(err.Error(), "expected timeout to be a duration") {
+			t.Errorf("expected timeout error for script '%s'; got %v", script, err)
+		}
+	}
+}
+
+func TestScriptParallelKoolYaml(t *testing.T) {
+	parsed := &KoolYaml{Scripts: map[string]interface{}{
+		"plain": "echo plain",
+		"build": map[interface{}]interface{}{
+			"parallel": []interface{}{"npm run build", "composer install"},
+		},
+		"all": map[interface{}]interface{}{
+			"needs":    []interface{}{"plain"},
+			"parallel": []interface{}{"echo one", "echo two"},
+		},
+		"sequential": map[interface{}]interface{}{"commands": []interface{}{"echo one", "echo two"}},
+	}}
+
+	for _, script := range []string{"build", "all"} {
+		if !parsed.ScriptParallel(script) {
+			t.Errorf("expected script '%s' to be parallel", script)
+		}
+
+		if commands, err := parsed.ParseCommands(script); err != nil || len(commands) != 2 {
+			t.Errorf("failed parsing parallel commands of script '%s'; got %v (err: %v)", script, commands, err)
+		}
+	}
+
+	for _, script := range []string{"plain", "sequential", "missing"} {
+		if parsed.ScriptParallel(script) {
+			t.Errorf("expected script '%s' not to be parallel", script)
+		}
+	}
+}
+
+func TestScriptStepsKoolYaml(t *testing.T) {
+	env := environment.NewFakeEnvStorage()
+	env.Set("KOOL_TEST_STEP_ENV", "ci")
+
+	parsed := &KoolYaml{Scripts: map[string]interface{}{
+		"setup": []interface{}{
+			"echo start",
+			map[interface{}]interface{}{"command": "echo ci", "if": `env.KOOL_TEST_STEP_ENV == "ci"`},
+			map[interface{}]interface{}{"command": "echo local", "if": `env.KOOL_TEST_STEP_ENV == "local"`},
+			map[interface{}]interface{}{"command": "echo off", "if": false},
+			map[interface{}]interface{}{"command": "echo always"},
+		},
+		"broken":    []interface{}{map[interface{}]interface{}{"command": "echo broken", "if": "env.CI =="}},
+		"no-cmd":    []interface{}{map[interface{}]interface{}{"if": "true"}},
+		"extra-key": []interface{}{map[interface{}]interface{}{"command": "echo", "unless": "true"}},
+		"bad-if":    []interface{}{map[interface{}]interface{}{"command": "echo", "if": 10}},
+	}}
+
+	commands, err := parsed.ParseCommands("setup")
+
+	if err != nil || len(commands) != 5 || commands[1].String() != "echo ci" || commands[4].String() != "echo always" {
+		t.Fatalf("expected all the steps parsed, conditions left to run time; got %v (err: %v)", commands, err)
+	}
+
+	expected := []struct {
+		condition string
+		holds     bool
+	}{
+		{`env.KOOL_TEST_STEP_ENV == "ci"`, true},
+		{`env.KOOL_TEST_STEP_ENV == "local"`, false},
+		{"false", false},
+	}
+
+	for i, step := range expected {
+		conditional, isConditional := commands[i+1].(*ConditionalCommand)
+
+		if !isConditional || conditional.Condition != step.condition || conditional.Holds(env) != step.holds {
+			t.Errorf("unexpected step %d: %v", i+1, commands[i+1])
+		}
+	}
+
+	for _, i := range []int{0, 4} {
+		if _, isConditional := commands[i].(*ConditionalCommand); isConditional {
+			t.Errorf("expected step %d to have no condition", i)
+		}
+	}
+
+	// conditions are evaluated as they run, on the environment by then
+	env.Set("KOOL_TEST_STEP_ENV", "local")
+
+	if commands[1].(*ConditionalCommand).Holds(env) || !commands[2].(*ConditionalCommand).Holds(env) {
+		t.Error("expected the conditions evaluated on the current environment")
+	}
+
+	if copied, isConditional := commands[2].Copy().(*ConditionalCommand); !isConditional || copied.Condition != `env.KOOL_TEST_STEP_ENV == "local"` || copied.String() != "echo local" {
+		t.Errorf("expected the copy to keep the condition; got %v", copied)
+	}
+
+	if _, err = parsed.ParseCommands("broken"); err == nil || !strings.Contains(err.Error(), "invalid condition 'env.CI =='") {
+		t.Errorf("expected invalid condition error; got %v", err)
+	}
+
+	for _, script := range []string{"no-cmd", "extra-key", "bad-if"} {
+		if _, err = parsed.ParseCommands(script); err == nil || !strings.Contains(err.Error(), "expected steps to be a command string or a mapping of command and if") {
+			t.Errorf("expected invalid step error for script '%s'; got %v", script, err)
+		}
+	}
+}
+
+func TestScriptNeedsKoolYaml(t *testing.T) {
+	parsed := &KoolYaml{Scripts: map[string]interface{}{
+		"plain": "echo plain",
+		"test": map[interface{}]interface{}{
+			"needs":    []interface{}{"setup", "lint"},
+			"commands": "go test ./...",
+		},
+		"all":     map[interface{}]interface{}{"needs": []interface{}{"test"}},
+		"single":  map[interface{}]interface{}{"needs": "setup"},
+		"numbers": map[interface{}]interface{}{"needs": []interface{}{1}},
+	}}
+
+	if needs, err := parsed.ScriptNeeds("test"); err != nil || strings.Join(needs, ",") != "setup,lint" {
+		t.Errorf("expected needs setup and lint; got %v (err: %v)", needs, err)
+	}
+
+	if needs, err := parsed.ScriptNeeds("plain"); err != nil || len(needs) != 0 {
+		t.Errorf("expected no needs for plain script; got %v (err: %v)", needs, err)
+	}
+
+	for _, script := range []string{"single", "numbers"} {
+		if _, err := parsed.ScriptNeeds(script); err == nil || !strings.Contains(err.Error(), "expected needs to be a list of script names") {
+			t.Errorf("expected needs error for script '%s'; got %v", script, err)
+		}
+	}
+
+	if commands, err := parsed.ParseCommands("all"); err != nil || len(commands) != 0 {
+		t.Errorf("expected no commands for script with just needs; got %v (err: %v)", commands, err)
+	}
+}
+
 func TestSetScriptEmptyCommandsKoolYmlParser(t *testing.T) {
 	parsed := new(KoolYaml)
 	var emptyCommands []string
@@ -226,3 +405,260 @@ func TestErrorStringKoolYmlParser(t *testing.T) {
 		t.Errorf("expecting error 'marshal error' on String, got '%v'", err)
 	}
 }
+
+func TestParseKoolYamlEnvironments(t *testing.T) {
+	tmpPath := path.Join(t.TempDir(), "kool.yml")
+
+	err := os.WriteFile(tmpPath, []byte(`scripts:
+  test: phpunit
+  lint: phpcs
+bootstrap:
+  - test
+project: base
+environments:
+  ci:
+    scripts:
+      test: phpunit --coverage
+      report:
+        - echo one
+        - echo two
+    bootstrap:
+      - report
+  local:
+    project: local-project
+`), os.ModePerm)
+
+	if err != nil {
+		t.Fatal("failed creating temporary file for test", err)
+	}
+
+	var testCases = []struct {
+		env       string
+		test      string
+		scripts   int
+		bootstrap string
+		project   string
+	}{
+		{"", "phpunit", 2, "test", "local-project"},
+		{"ci", "phpunit --coverage", 3, "report", "base"},
+		{"staging", "phpunit", 2, "test", "base"},
+	}
+
+	for _, tc := range testCases {
+		t.Setenv("KOOL_ENV", tc.env)
+
+		parsed, err := ParseKoolYaml(tmpPath)
+
+		if err != nil {
+			t.Fatalf("unexpected error parsing kool.yml for environment '%s': %v", tc.env, err)
+		}
+
+		cmds, _ := parsed.ParseCommands("test")
+
+		if len(cmds) != 1 || cmds[0].String() != tc.test {
+			t.Errorf("environment '%s': expected test script '%s'; got %v", tc.env, tc.test, cmds)
+		}
+
+		if len(parsed.Scripts) != tc.scripts || !parsed.HasScript("lint") {
+			t.Errorf("environment '%s': expected %d scripts, keeping the base ones; got %v", tc.env, tc.scripts, parsed.Scripts)
+		}
+
+		if strings.Join(parsed.Bootstrap, ",") != tc.bootstrap {
+			t.Errorf("environment '%s': expected bootstrap '%s'; got %v", tc.env, tc.bootstrap, parsed.Bootstrap)
+		}
+
+		if parsed.Project != tc.project {
+			t.Errorf("environment '%s': expected project '%s'; got '%s'", tc.env, tc.project, parsed.Project)
+		}
+	}
+}
+
+func TestParseKoolYamlLocalOverrides(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("KOOL_ENV", "ci")
+
+	_ = os.WriteFile(path.Join(dir, "kool.yml"), []byte(`scripts:
+  test: phpunit
+  lint: phpcs
+bootstrap:
+  - test
+project: base
+environments:
+  ci:
+    scripts:
+      lint: phpcs --report=checkstyle
+`), os.ModePerm)
+
+	parsed, err := ParseKoolYaml(path.Join(dir, "kool.yml"))
+
+	if err != nil || parsed.Project != "base" || len(parsed.Scripts) != 2 {
+		t.Fatalf("unexpected parsing without kool.local.yml: %v (err: %v)", parsed, err)
+	}
+
+	_ = os.WriteFile(path.Join(dir, "kool.local.yml"), []byte(`scripts:
+  test: phpunit --stop-on-failure
+  tinker: php artisan tinker
+project: mine
+environments:
+  ci:
+    scripts:
+      test: phpunit --coverage
+    bootstrap:
+      - lint
+`), os.ModePerm)
+
+	if parsed, err = ParseKoolYaml(path.Join(dir, "kool.yml")); err != nil {
+		t.Fatalf("unexpected error parsing with kool.local.yml: %v", err)
+	}
+
+	var expected = map[string]string{
+		// the local environment override wins over the local base script
+		"test": "phpunit --coverage",
+		// the base environment override is kept
+		"lint":   "phpcs --report=checkstyle",
+		"tinker": "php artisan tinker",
+	}
+
+	for script, command := range expected {
+		if cmds, _ := parsed.ParseCommands(script); len(cmds) != 1 || cmds[0].String() != command {
+			t.Errorf("expected %s script '%s'; got %v", script, command, cmds)
+		}
+	}
+
+	if len(parsed.Scripts) != 3 || parsed.Project != "mine" || strings.Join(parsed.Bootstrap, ",") != "lint" {
+		t.Errorf("unexpected merged config: %d scripts, project %s, bootstrap %v", len(parsed.Scripts), parsed.Project, parsed.Bootstrap)
+	}
+
+	t.Setenv("KOOL_ENV", "")
+
+	if parsed, _ = ParseKoolYaml(path.Join(dir, "kool.yml")); strings.Join(parsed.Bootstrap, ",") != "test" {
+		t.Errorf("expected base bootstrap to be kept out of the ci environment; got %v", parsed.Bootstrap)
+	}
+
+	if cmds, _ := parsed.ParseCommands("test"); len(cmds) != 1 || cmds[0].String() != "phpunit --stop-on-failure" {
+		t.Errorf("expected the local test script; got %v", cmds)
+	}
+
+	_ = os.WriteFile(path.Join(dir, "kool.local.yml"), []byte("scripts: [bad"), os.ModePerm)
+
+	if _, err = ParseKoolYaml(path.Join(dir, "kool.yml")); err == nil || !strings.Contains(err.Error(), "kool.local.yml") {
+		t.Errorf("expected error telling kool.local.yml is broken; got %v", err)
+	}
+}
+
+func TestParseCommandsVariablesKoolYaml(t *testing.T) {
+	originalEnv := scriptEnv
+	defer func() { scriptEnv = originalEnv }()
+
+	fakeEnv := environment.NewFakeEnvStorage()
+	fakeEnv.Set("KOOL_TEST_SCRIPT_DIR", "/some/dir")
+	fakeEnv.Set("KOOL_TEST_SCRIPT_DOLLAR", "a$HOME")
+	scriptEnv = fakeEnv
+
+	parsed := &KoolYaml{Scripts: map[string]interface{}{
+		"single":    "ls ${KOOL_TEST_SCRIPT_DIR}",
+		"multi":     []interface{}{"echo start", "ls ${KOOL_TEST_SCRIPT_DIR}/sub"},
+		"undefined": []interface{}{"echo start", "ls ${KOOL_TEST_SCRIPT_UNDEFINED}"},
+		"arguments": "ls ${KOOL_TEST_SCRIPT_DIR}/$1 $@ {{arg:name}}",
+		"template":  "docker ps --format '{{.Names}}'",
+		"dollar":    "echo ${KOOL_TEST_SCRIPT_DOLLAR}",
+	}}
+
+	if cmds, err := parsed.ParseCommands("single"); err != nil || cmds[0].String() != "ls /some/dir" {
+		t.Errorf("expected variable resolved on single line script; got %v (err: %v)", cmds, err)
+	}
+
+	if cmds, err := parsed.ParseCommands("multi"); err != nil || cmds[1].String() != "ls /some/dir/sub" {
+		t.Errorf("expected variable resolved on multi line script; got %v (err: %v)", cmds, err)
+	}
+
+	if _, err := parsed.ParseCommands("undefined"); err == nil || !strings.Contains(err.Error(), "undefined variable KOOL_TEST_SCRIPT_UNDEFINED") {
+		t.Errorf("expected undefined variable error; got %v", err)
+	}
+
+	if cmds, err := parsed.ParseCommands("arguments"); err != nil || cmds[0].String() != "ls /some/dir/{{arg:1}} {{arg:@}} {{arg:name}}" {
+		t.Errorf("expected argument placeholders kept on the script; got %v (err: %v)", cmds, err)
+	}
+
+	if cmds, err := parsed.ParseCommands("template"); err != nil || cmds[0].Args()[2] != "{{.Names}}" {
+		t.Errorf("expected the Go template kept on the script; got %v (err: %v)", cmds, err)
+	}
+
+	if cmds, err := parsed.ParseCommands("dollar"); err != nil || cmds[0].String() != "echo a$HOME" {
+		t.Errorf("expected the variable value not to be expanded again; got %v (err: %v)", cmds, err)
+	}
+}
+
+func TestParseKoolYamlIncludes(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("KOOL_ENV", "")
+
+	_ = os.MkdirAll(path.Join(dir, "tools"), os.ModePerm)
+	_ = os.WriteFile(path.Join(dir, "kool.yml"), []byte(`include:
+  - kool.deploy.yml
+  - tools/kool.db.yml
+scripts:
+  test: phpunit
+bootstrap:
+  - migrate
+`), os.ModePerm)
+	_ = os.WriteFile(path.Join(dir, "kool.deploy.yml"), []byte("include: [kool.shared.yml]\nscripts:\n  deploy: kool cloud deploy\n"), os.ModePerm)
+	_ = os.WriteFile(path.Join(dir, "tools", "kool.db.yml"), []byte("include: [../kool.shared.yml]\nscripts:\n  migrate: php artisan migrate\n"), os.ModePerm)
+	_ = os.WriteFile(path.Join(dir, "kool.shared.yml"), []byte("scripts:\n  shared: echo shared\n"), os.ModePerm)
+
+	parsed, err := ParseKoolYaml(path.Join(dir, "kool.yml"))
+
+	if err != nil {
+		t.Fatalf("unexpected error parsing kool.yml with includes: %v", err)
+	}
+
+	for _, script := range []string{"test", "deploy", "migrate", "shared"} {
+		if !parsed.HasScript(script) {
+			t.Errorf("expected script '%s' to be merged in", script)
+		}
+	}
+
+	if len(parsed.Scripts) != 4 || strings.Join(parsed.Bootstrap, ",") != "migrate" {
+		t.Errorf("unexpected merged config: %v", parsed)
+	}
+
+	var testCases = []struct {
+		file     string
+		content  string
+		expected string
+	}{
+		{"kool.deploy.yml", "scripts:\n  test: echo deploy test\n", "script 'test' is defined in both kool.yml and kool.deploy.yml"},
+		{"kool.deploy.yml", "scripts:\n  migrate: echo migrate\n  shared: echo shared\n", "script 'migrate' is defined in both kool.deploy.yml and tools/kool.db.yml"},
+		{"kool.deploy.yml", "include: [tools/kool.db.yml]\nscripts:\n  deploy: kool cloud deploy\n", ""},
+		{"kool.shared.yml", "include: [kool.yml]\n", "kool.yml includes form a cycle: kool.yml -> kool.deploy.yml -> kool.shared.yml -> kool.yml"},
+		{"kool.deploy.yml", "project: other\n", "failed including kool.deploy.yml: included files can only have scripts and include"},
+		{"kool.deploy.yml", "include: [missing.yml]\n", "failed including missing.yml: open"},
+		{"kool.deploy.yml", "scripts: [invalid\n", "failed including kool.deploy.yml: yaml:"},
+	}
+
+	for _, tc := range testCases {
+		original, _ := os.ReadFile(path.Join(dir, tc.file))
+		_ = os.WriteFile(path.Join(dir, tc.file), []byte(tc.content), os.ModePerm)
+
+		_, err = ParseKoolYaml(path.Join(dir, "kool.yml"))
+
+		if tc.expected == "" && err != nil {
+			t.Errorf("unexpected error for %s with content %q: %v", tc.file, tc.content, err)
+		} else if tc.expected != "" && (err == nil || !strings.HasPrefix(err.Error(), tc.expected)) {
+			t.Errorf("expected error '%s' for %s with content %q; got '%v'", tc.expected, tc.file, tc.content, err)
+		}
+
+		_ = os.WriteFile(path.Join(dir, tc.file), original, os.ModePerm)
+	}
+
+	_ = os.WriteFile(path.Join(dir, "kool.local.yml"), []byte("include: [kool.mine.yml]\n"), os.ModePerm)
+	_ = os.WriteFile(path.Join(dir, "kool.mine.yml"), []byte("scripts:\n  test: phpunit --stop-on-failure\n  mine: echo mine\n"), os.ModePerm)
+
+	if parsed, err = ParseKoolYaml(path.Join(dir, "kool.yml")); err != nil || !parsed.HasScript("mine") {
+		t.Fatalf("expected local overrides includes to be merged in; got %v (err: %v)", parsed, err)
+	}
+
+	if cmds, _ := parsed.ParseCommands("test"); len(cmds) != 1 || cmds[0].String() != "phpunit --stop-on-failure" {
+		t.Errorf("expected local include to override the test script; got %v", cmds)
+	}
+}
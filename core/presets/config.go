@@ -1,15 +1,20 @@
 package presets
 
 import (
+	"fmt"
 	"kool-dev/kool/core/automate"
+	"kool-dev/kool/core/shell"
+
+	"github.com/blang/semver"
 )
 
 // PresetConfig preset config
 type PresetConfig struct {
-	Name   string                `yaml:"name"`
-	Tags   []string              `yaml:"tags"`
-	Create []*automate.ActionSet `yaml:"create"`
-	Preset []*automate.ActionSet `yaml:"preset"`
+	Name       string                `yaml:"name"`
+	Tags       []string              `yaml:"tags"`
+	MinVersion string                `yaml:"minVersion"`
+	Create     []*automate.ActionSet `yaml:"create"`
+	Preset     []*automate.ActionSet `yaml:"preset"`
 
 	presetID string
 }
@@ -22,3 +27,59 @@ func (c *PresetConfig) HasTag(tag string) bool {
 	}
 	return false
 }
+
+// ID is the preset identifier (its folder name)
+func (c *PresetConfig) ID() string {
+	return c.presetID
+}
+
+// Title is the preset display name, falling back to its ID
+func (c *PresetConfig) Title() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.presetID
+}
+
+// Matches tells whether the search term fuzzy matches
+// the preset name, identifier or any of its tags
+func (c *PresetConfig) Matches(term string) bool {
+	if c.HasTag(term) || shell.FuzzyMatch(term, c.Title()) || shell.FuzzyMatch(term, c.presetID) {
+		return true
+	}
+
+	for _, t := range c.Tags {
+		if shell.FuzzyMatch(term, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckVersion tells whether the given kool version satisfies the
+// preset minimum required version; development builds are always accepted
+func (c *PresetConfig) CheckVersion(koolVersion string) (err error) {
+	var minVersion, current semver.Version
+
+	if c.MinVersion == "" {
+		return
+	}
+
+	if minVersion, err = semver.ParseTolerant(c.MinVersion); err != nil {
+		err = fmt.Errorf("preset %s has an invalid minVersion '%s' (%v)", c.presetID, c.MinVersion, err)
+		return
+	}
+
+	if current, err = semver.ParseTolerant(koolVersion); err != nil || (current.Major == 0 && current.Minor == 0 && current.Patch == 0) {
+		// unknown or development version; nothing to compare against
+		err = nil
+		return
+	}
+
+	if current.LT(minVersion) {
+		err = fmt.Errorf("preset %s requires kool %s or newer, but you are running %s; please update kool (kool self-update)", c.presetID, minVersion, current)
+	}
+
+	return
+}
@@ -1,6 +1,7 @@
 package presets
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -13,3 +14,60 @@ func TestPresetConfigHasTags(t *testing.T) {
 		t.Errorf("should NOT have tag 'bar'")
 	}
 }
+
+func TestPresetConfigMatches(t *testing.T) {
+	c := &PresetConfig{Name: "Laravel", Tags: []string{"php", "framework"}, presetID: "laravel"}
+
+	if c.ID() != "laravel" || c.Title() != "Laravel" {
+		t.Errorf("unexpected ID/Title: %s/%s", c.ID(), c.Title())
+	}
+
+	for term, expected := range map[string]bool{
+		"lrv":   true,
+		"php":   true,
+		"frmwk": true,
+		"node":  false,
+	} {
+		if got := c.Matches(term); got != expected {
+			t.Errorf("Matches(%q) expected %v; got %v", term, expected, got)
+		}
+	}
+
+	if c.Name = ""; c.Title() != "laravel" {
+		t.Errorf("Title should fall back to ID; got %s", c.Title())
+	}
+}
+
+func TestPresetConfigCheckVersion(t *testing.T) {
+	c := &PresetConfig{presetID: "laravel"}
+
+	if err := c.CheckVersion("1.0.0"); err != nil {
+		t.Errorf("unexpected error without minVersion: %v", err)
+	}
+
+	c.MinVersion = "2.1.0"
+
+	for version, shouldFail := range map[string]bool{
+		"2.0.9":     true,
+		"1.15.0":    true,
+		"2.1.0":     false,
+		"v2.3.1":    false,
+		"3.0.0":     false,
+		"0.0.0-dev": false,
+		"":          false,
+	} {
+		err := c.CheckVersion(version)
+
+		if shouldFail && (err == nil || !strings.Contains(err.Error(), "please update kool")) {
+			t.Errorf("expected 'please update kool' error for version %q; got %v", version, err)
+		} else if !shouldFail && err != nil {
+			t.Errorf("unexpected error for version %q: %v", version, err)
+		}
+	}
+
+	c.MinVersion = "not-a-version"
+
+	if err := c.CheckVersion("2.0.0"); err == nil || !strings.Contains(err.Error(), "invalid minVersion") {
+		t.Errorf("expected invalid minVersion error; got %v", err)
+	}
+}
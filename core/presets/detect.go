@@ -0,0 +1,129 @@
+package presets
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// detectFiles maps well-known project files to the presets they point to
+var detectFiles = []struct {
+	file    string
+	presets []string
+}{
+	{"artisan", []string{"laravel", "laravel+octane"}},
+	{"symfony.lock", []string{"symfony"}},
+	{"spark", []string{"codeigniter"}},
+	{"wp-config.php", []string{"wordpress"}},
+	{"wp-config-sample.php", []string{"wordpress"}},
+	{"hugo.toml", []string{"hugo"}},
+	{"go.mod", []string{"golang-cli"}},
+}
+
+// detectPackages maps package.json dependencies to the presets they point to
+var detectPackages = []struct {
+	pkg     string
+	presets []string
+}{
+	{"@adonisjs/core", []string{"adonis"}},
+	{"@nestjs/core", []string{"nestjs", "nest+next"}},
+	{"next", []string{"nextjs"}},
+	{"nuxt", []string{"nuxtjs"}},
+	{"express", []string{"expressjs"}},
+}
+
+// detectTags maps well-known project files to the preset tags they point to
+var detectTags = []struct {
+	file string
+	tags []string
+}{
+	{"composer.json", []string{"PHP"}},
+	{"package.json", []string{"JS", "Typescript"}},
+	{"go.mod", []string{"Golang"}},
+}
+
+// DetectProject looks for well-known files in the given directory, returning
+// the IDs of the presets which likely match the project there, from the most
+// to the least specific, along with the tags of the matching presets family
+func DetectProject(dir string) (ids []string, tags []string) {
+	var (
+		seenIDs  = make(map[string]bool)
+		seenTags = make(map[string]bool)
+	)
+
+	addIDs := func(presets []string) {
+		for _, id := range presets {
+			if !seenIDs[id] {
+				seenIDs[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	for _, detect := range detectFiles {
+		if fileExists(filepath.Join(dir, detect.file)) {
+			addIDs(detect.presets)
+		}
+	}
+
+	dependencies := packageDependencies(filepath.Join(dir, "package.json"))
+
+	for _, detect := range detectPackages {
+		if dependencies[detect.pkg] {
+			addIDs(detect.presets)
+		}
+	}
+
+	for _, detect := range detectTags {
+		if !fileExists(filepath.Join(dir, detect.file)) {
+			continue
+		}
+
+		for _, tag := range detect.tags {
+			if !seenTags[tag] {
+				seenTags[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	return
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// packageDependencies reads the dependencies names from a package.json
+// file; a missing or malformed file just means no dependencies
+func packageDependencies(path string) (dependencies map[string]bool) {
+	var (
+		raw []byte
+		err error
+		pkg struct {
+			Dependencies    map[string]interface{} `json:"dependencies"`
+			DevDependencies map[string]interface{} `json:"devDependencies"`
+		}
+	)
+
+	dependencies = make(map[string]bool)
+
+	if raw, err = os.ReadFile(path); err != nil {
+		return
+	}
+
+	if err = json.Unmarshal(raw, &pkg); err != nil {
+		return
+	}
+
+	for name := range pkg.Dependencies {
+		dependencies[name] = true
+	}
+
+	for name := range pkg.DevDependencies {
+		dependencies[name] = true
+	}
+
+	return
+}
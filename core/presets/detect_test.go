@@ -0,0 +1,47 @@
+package presets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectProject(t *testing.T) {
+	var testCases = []struct {
+		files map[string]string
+		ids   string
+		tags  string
+	}{
+		{map[string]string{}, "", ""},
+		{map[string]string{"artisan": "", "composer.json": "{}"}, "laravel,laravel+octane", "PHP"},
+		{map[string]string{"composer.json": "{}"}, "", "PHP"},
+		{
+			map[string]string{"package.json": `{"dependencies":{"next":"14"},"devDependencies":{"@nestjs/core":"10"}}`},
+			"nestjs,nest+next,nextjs",
+			"JS,Typescript",
+		},
+		{map[string]string{"package.json": "not json"}, "", "JS,Typescript"},
+		{map[string]string{"go.mod": "module app"}, "golang-cli", "Golang"},
+	}
+
+	for _, tc := range testCases {
+		dir := t.TempDir()
+
+		for file, content := range tc.files {
+			if err := os.WriteFile(filepath.Join(dir, file), []byte(content), os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		ids, tags := DetectProject(dir)
+
+		if strings.Join(ids, ",") != tc.ids {
+			t.Errorf("expected detected presets '%s' for files %v; got %v", tc.ids, tc.files, ids)
+		}
+
+		if strings.Join(tags, ",") != tc.tags {
+			t.Errorf("expected detected tags '%s' for files %v; got %v", tc.tags, tc.files, tags)
+		}
+	}
+}
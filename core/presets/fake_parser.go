@@ -7,16 +7,27 @@ type FakeParser struct {
 	CalledExists     bool
 	CalledGetTags    bool
 	CalledGetPresets bool
+	CalledGetConfigs bool
 	CalledInstall    bool
 	CalledCreate     bool
 	CalledAdd        bool
+	CalledTemplate   bool
+	CalledSetForce   bool
+	CalledSetDiff    bool
 
 	MockExists     bool
 	MockGetTags    []string
 	MockGetPresets map[string]string
+	MockGetConfigs []*PresetConfig
 	MockInstall    error
 	MockCreate     error
 	MockAdd        error
+	MockTemplate   error
+	MockForce      bool
+	MockDiff       bool
+	MockWritten    []string
+	MockSkipped    []string
+	MockBackups    map[string]string
 }
 
 // Exists check if preset exists
@@ -30,6 +41,30 @@ func (f *FakeParser) PrepareExecutor(sh shell.Shell) {
 	// noop
 }
 
+// SetForce
+func (f *FakeParser) SetForce(force bool) {
+	f.CalledSetForce = true
+	f.MockForce = force
+}
+
+// SetDiffMode
+func (f *FakeParser) SetDiffMode(diff bool) {
+	f.CalledSetDiff = true
+	f.MockDiff = diff
+}
+
+// WriteSummary
+func (f *FakeParser) WriteSummary() (written []string, skipped []string) {
+	written, skipped = f.MockWritten, f.MockSkipped
+	return
+}
+
+// Backups
+func (f *FakeParser) Backups() (backups map[string]string) {
+	backups = f.MockBackups
+	return
+}
+
 // GetTags get all presets tags
 func (f *FakeParser) GetTags() (languages []string) {
 	f.CalledGetTags = true
@@ -44,6 +79,13 @@ func (f *FakeParser) GetPresets(tag string) (presets map[string]string) {
 	return
 }
 
+// GetConfigs get all presets configs
+func (f *FakeParser) GetConfigs() (configs []*PresetConfig) {
+	f.CalledGetConfigs = true
+	configs = f.MockGetConfigs
+	return
+}
+
 // Install
 func (f *FakeParser) Install(tag string) (err error) {
 	f.CalledInstall = true
@@ -64,3 +106,10 @@ func (f *FakeParser) Add(tag string, sh shell.Shell) (err error) {
 	err = f.MockAdd
 	return
 }
+
+// InstallTemplate
+func (f *FakeParser) InstallTemplate(dir string) (err error) {
+	f.CalledTemplate = true
+	err = f.MockTemplate
+	return
+}
@@ -29,6 +29,12 @@ func TestFakeParser(t *testing.T) {
 		t.Error("failed to use mocked GetTags function on FakeParser")
 	}
 
+	f.MockGetConfigs = []*PresetConfig{{Name: "preset"}}
+
+	if configs := f.GetConfigs(); !f.CalledGetConfigs || len(configs) != 1 {
+		t.Error("failed to use mocked GetConfigs function on FakeParser")
+	}
+
 	f.MockInstall = errors.New("Install")
 	errInstall := f.Install("")
 
@@ -49,4 +55,37 @@ func TestFakeParser(t *testing.T) {
 	if !f.CalledAdd || errAdd == nil || errAdd.Error() != "Add" {
 		t.Error("failed to use mocked Add function on FakeParser")
 	}
+
+	f.MockTemplate = errors.New("InstallTemplate")
+	errTemplate := f.InstallTemplate("")
+
+	if !f.CalledTemplate || errTemplate == nil || errTemplate.Error() != "InstallTemplate" {
+		t.Error("failed to use mocked InstallTemplate function on FakeParser")
+	}
+
+	f.SetForce(true)
+
+	if !f.CalledSetForce || !f.MockForce {
+		t.Error("failed to use mocked SetForce function on FakeParser")
+	}
+
+	f.SetDiffMode(true)
+
+	if !f.CalledSetDiff || !f.MockDiff {
+		t.Error("failed to use mocked SetDiffMode function on FakeParser")
+	}
+
+	f.MockWritten = []string{"written"}
+	f.MockSkipped = []string{"skipped"}
+	f.MockBackups = map[string]string{"file": "file.bak"}
+
+	if backups := f.Backups(); backups["file"] != "file.bak" {
+		t.Error("failed to use mocked Backups function on FakeParser")
+	}
+
+	written, skipped := f.WriteSummary()
+
+	if len(written) != 1 || len(skipped) != 1 {
+		t.Error("failed to use mocked WriteSummary function on FakeParser")
+	}
 }
@@ -6,6 +6,8 @@ import (
 	"io/fs"
 	"kool-dev/kool/core/automate"
 	"kool-dev/kool/core/shell"
+	"os"
+	"path/filepath"
 	"sort"
 
 	"gopkg.in/yaml.v2"
@@ -13,6 +15,10 @@ import (
 
 const presetConfigFile = "presets/%s/config.yml"
 
+// TemplateConfigFile is the preset config a template repository may
+// carry for setting up the project created out of it
+const TemplateConfigFile = "kool.preset.yml"
+
 // SourceFS componds all required interfaces for managing
 // the sourcing of presets and templates on a filesystem
 type SourceFS interface {
@@ -23,15 +29,27 @@ type SourceFS interface {
 
 var source SourceFS
 
+// koolVersion is the running kool version, used
+// for checking presets minimum required version
+var koolVersion string
+
 // SetSource informs the package about the
 // source of template files and configs
 func SetSource(src SourceFS) {
 	source = src
 }
 
+// SetKoolVersion informs the package about the running kool version
+func SetKoolVersion(version string) {
+	koolVersion = version
+}
+
 // DefaultParser holds presets parsing data
 type DefaultParser struct {
-	presetID string
+	presetID    string
+	templateDir string
+	force       bool
+	diff        bool
 
 	execRunner *automate.Executor
 }
@@ -41,11 +59,17 @@ type Parser interface {
 	Exists(string) bool
 	GetTags() []string
 	GetPresets(string) map[string]string
+	GetConfigs() []*PresetConfig
 	Install(string) error
 	Create(string) error
 	Add(string, shell.Shell) error
+	InstallTemplate(string) error
 
 	PrepareExecutor(shell.Shell)
+	SetForce(bool)
+	SetDiffMode(bool)
+	WriteSummary() ([]string, []string)
+	Backups() map[string]string
 }
 
 // NewParser creates a new preset default parser
@@ -129,6 +153,31 @@ func (p *DefaultParser) GetPresets(tag string) (presets map[string]string) {
 	return
 }
 
+// GetConfigs returns all the presets configs sorted by their title
+func (p *DefaultParser) GetConfigs() (configs []*PresetConfig) {
+	var (
+		entries []fs.DirEntry
+		folder  fs.DirEntry
+		config  *PresetConfig
+		err     error
+	)
+
+	entries, _ = source.ReadDir("presets")
+
+	for _, folder = range entries {
+		if config, err = p.getConfig(folder.Name()); err != nil {
+			continue
+		}
+
+		configs = append(configs, config)
+	}
+
+	sort.Slice(configs, func(i, j int) bool {
+		return configs[i].Title() < configs[j].Title()
+	})
+	return
+}
+
 // ErrPresetWriteAllBytes error throwed when did not write all preset file bytes
 var ErrPresetWriteAllBytes = errors.New("failed to write all bytes")
 
@@ -145,6 +194,10 @@ func (p *DefaultParser) Install(preset string) (err error) {
 		return
 	}
 
+	if err = config.CheckVersion(koolVersion); err != nil {
+		return
+	}
+
 	p.presetID = preset
 
 	if p.execRunner == nil {
@@ -169,6 +222,10 @@ func (p *DefaultParser) Create(preset string) (err error) {
 		return
 	}
 
+	if err = config.CheckVersion(koolVersion); err != nil {
+		return
+	}
+
 	p.presetID = preset
 
 	if p.execRunner == nil {
@@ -183,8 +240,85 @@ func (p *DefaultParser) Create(preset string) (err error) {
 	return
 }
 
+// InstallTemplate executes the create and preset actions of the template
+// config found in the given directory; it does nothing if there is none
+func (p *DefaultParser) InstallTemplate(dir string) (err error) {
+	var (
+		data   []byte
+		config *PresetConfig
+	)
+
+	if data, err = os.ReadFile(filepath.Join(dir, TemplateConfigFile)); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+
+	config = new(PresetConfig)
+	if err = yaml.Unmarshal(data, config); err != nil {
+		err = fmt.Errorf("failed parsing template config %s: %v", TemplateConfigFile, err)
+		return
+	}
+	config.presetID = filepath.Base(dir)
+
+	if err = config.CheckVersion(koolVersion); err != nil {
+		return
+	}
+
+	p.templateDir = dir
+
+	if p.execRunner == nil {
+		err = ErrExecutorNotPrepared
+		return
+	}
+
+	if err = p.execRunner.Do(config.Create); err != nil {
+		return
+	}
+
+	err = p.execRunner.Do(config.Preset)
+	return
+}
+
 func (p *DefaultParser) PrepareExecutor(sh shell.Shell) {
-	p.execRunner = automate.NewExecutor(sh, p.getSourceFile)
+	p.execRunner = p.newExecutor(sh)
+}
+
+func (p *DefaultParser) newExecutor(sh shell.Shell) (execRunner *automate.Executor) {
+	execRunner = automate.NewExecutor(sh, p.getSourceFile)
+	execRunner.SetForce(p.force)
+	execRunner.SetDiffMode(p.diff)
+	return
+}
+
+// SetForce tells whether existing files should be overwritten
+// without asking; it must be called before PrepareExecutor
+func (p *DefaultParser) SetForce(force bool) {
+	p.force = force
+}
+
+// SetDiffMode tells whether only a diff of the changes should be shown,
+// without changing anything; it must be called before PrepareExecutor
+func (p *DefaultParser) SetDiffMode(diff bool) {
+	p.diff = diff
+}
+
+// WriteSummary returns the files written and skipped by the executor
+func (p *DefaultParser) WriteSummary() (written []string, skipped []string) {
+	if p.execRunner != nil {
+		written, skipped = p.execRunner.Summary()
+	}
+	return
+}
+
+// Backups returns the files overwritten by the executor, mapped
+// to the backups of their original content
+func (p *DefaultParser) Backups() (backups map[string]string) {
+	if p.execRunner != nil {
+		backups = p.execRunner.Backups()
+	}
+	return
 }
 
 func (p *DefaultParser) Add(recipe string, sh shell.Shell) (err error) {
@@ -199,7 +333,7 @@ func (p *DefaultParser) Add(recipe string, sh shell.Shell) (err error) {
 		},
 	}
 
-	if err = automate.NewExecutor(sh, p.getSourceFile).Do(steps); err != nil {
+	if err = p.newExecutor(sh).Do(steps); err != nil {
 		return
 	}
 
@@ -207,6 +341,13 @@ func (p *DefaultParser) Add(recipe string, sh shell.Shell) (err error) {
 }
 
 func (p *DefaultParser) getSourceFile(path string) (data []byte, err error) {
+	if p.templateDir != "" {
+		// look up in the template being installed
+		if data, err = os.ReadFile(filepath.Join(p.templateDir, path)); err == nil {
+			return
+		}
+	}
+
 	if p.presetID != "" {
 		// look up in the preset folder
 		if data, err = source.ReadFile(fmt.Sprintf("presets/%s/%s", p.presetID, path)); err == nil {
@@ -2,6 +2,9 @@ package presets
 
 import (
 	"embed"
+	"kool-dev/kool/core/shell"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/leaanthony/debme"
@@ -39,4 +42,43 @@ func TestPresetParser(t *testing.T) {
 	if len(p.GetPresets("bar")) != 0 {
 		t.Error("should NOT have found any preset with tag bar")
 	}
+
+	if configs := p.GetConfigs(); len(configs) != 1 || configs[0].ID() != "foo" {
+		t.Errorf("should have found the foo preset config; got %v", configs)
+	}
+}
+
+func TestInstallTemplateParser(t *testing.T) {
+	var (
+		dir = t.TempDir()
+		p   = NewParser()
+		wd  string
+		err error
+	)
+
+	if err = p.InstallTemplate(dir); err != nil {
+		t.Errorf("unexpected error installing a template without config: %v", err)
+	}
+
+	_ = os.MkdirAll(filepath.Join(dir, "stubs"), os.ModePerm)
+	_ = os.WriteFile(filepath.Join(dir, "stubs", "app.env"), []byte("APP=template\n"), os.ModePerm)
+	_ = os.WriteFile(filepath.Join(dir, TemplateConfigFile), []byte("preset:\n  - name: env\n    actions:\n      - copy: stubs/app.env\n        dst: .env\n"), os.ModePerm)
+
+	if err = p.InstallTemplate(dir); err != ErrExecutorNotPrepared {
+		t.Errorf("expected ErrExecutorNotPrepared; got %v", err)
+	}
+
+	wd, _ = os.Getwd()
+	defer func() { _ = os.Chdir(wd) }()
+	_ = os.Chdir(dir)
+
+	p.PrepareExecutor(&shell.FakeShell{})
+
+	if err = p.InstallTemplate(dir); err != nil {
+		t.Fatalf("unexpected error installing template: %v", err)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(dir, ".env")); string(data) != "APP=template\n" {
+		t.Errorf("expected template stub to be copied to .env; got %q", string(data))
+	}
 }
@@ -0,0 +1,86 @@
+package shell
+
+import (
+	"fmt"
+	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/errs"
+	"sync"
+)
+
+// Docker Compose flavours kool knows how to run
+const (
+	composeUnresolved = iota
+	composeV2
+	composeV1
+	composeMissing
+)
+
+var (
+	composeMutex sync.Mutex
+
+	// composeResolved caches which Docker Compose was found,
+	// so it is looked for only once
+	composeResolved = composeUnresolved
+)
+
+// composeCommand resolves the given docker compose command to the
+// Docker Compose available, preferring the V2 plugin over the
+// standalone docker-compose (V1); commands are expected in the V2
+// plugin form (see builder.ComposeV2) and other commands are
+// returned as they are
+func (s *DefaultShell) composeCommand(command builder.Command) (builder.Command, error) {
+	if !builder.IsCompose(command) {
+		return command, nil
+	}
+
+	switch s.resolveCompose() {
+	case composeV1:
+		return builder.ComposeV1(command), nil
+	case composeMissing:
+		return nil, errs.ErrComposeNotFound
+	}
+
+	return command, nil
+}
+
+// ComposeInUse tells which Docker Compose kool runs: the V2 plugin
+// (docker compose) or the standalone docker-compose binary (V1)
+func ComposeInUse() (using string, err error) {
+	switch (&DefaultShell{}).resolveCompose() {
+	case composeV2:
+		using = "docker compose"
+	case composeV1:
+		using = "docker-compose"
+	default:
+		err = errs.ErrComposeNotFound
+	}
+
+	return
+}
+
+// resolveCompose looks for the Docker Compose V2 plugin and then for
+// the standalone docker-compose binary, caching the result
+func (s *DefaultShell) resolveCompose() int {
+	composeMutex.Lock()
+	defer composeMutex.Unlock()
+
+	if composeResolved != composeUnresolved {
+		return composeResolved
+	}
+
+	var using string
+
+	if _, err := execLookPathFn("docker"); err == nil && execCmdFn("docker", "compose", "version").Run() == nil {
+		composeResolved, using = composeV2, "docker compose (V2 plugin)"
+	} else if _, err := execLookPathFn("docker-compose"); err == nil {
+		composeResolved, using = composeV1, "docker-compose (V1)"
+	} else {
+		composeResolved = composeMissing
+	}
+
+	if using != "" && s.env != nil && s.env.IsTrue("KOOL_VERBOSE") {
+		fmt.Fprintf(s.ErrStream(), "[using %s]\n", using)
+	}
+
+	return composeResolved
+}
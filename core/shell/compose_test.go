@@ -0,0 +1,164 @@
+package shell
+
+import (
+	"bytes"
+	"errors"
+	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/errs"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// shell tests must not depend on the Docker Compose installed
+	composeResolved = composeV2
+	os.Exit(m.Run())
+}
+
+func mockComposeLookup(t *testing.T, found map[string]bool, pluginWorks bool) {
+	originalLookPath, originalExecCmd, originalResolved := execLookPathFn, execCmdFn, composeResolved
+
+	execLookPathFn = func(exe string) (string, error) {
+		if found[exe] {
+			return "/usr/bin/" + exe, nil
+		}
+		return "", errors.New("not found")
+	}
+
+	execCmdFn = func(exe string, args ...string) *exec.Cmd {
+		if pluginWorks {
+			return exec.Command("true")
+		}
+		return exec.Command("false")
+	}
+
+	composeResolved = composeUnresolved
+
+	t.Cleanup(func() {
+		execLookPathFn, execCmdFn, composeResolved = originalLookPath, originalExecCmd, originalResolved
+	})
+}
+
+func TestResolveCompose(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		found       map[string]bool
+		pluginWorks bool
+		expected    string
+		err         error
+	}{
+		{"prefers V2 plugin", map[string]bool{"docker": true, "docker-compose": true}, true, "docker compose -p p up", nil},
+		{"falls back to V1", map[string]bool{"docker": true, "docker-compose": true}, false, "docker-compose -p p up", nil},
+		{"V1 only", map[string]bool{"docker-compose": true}, true, "docker-compose -p p up", nil},
+		{"missing", map[string]bool{"docker": true}, false, "", errs.ErrComposeNotFound},
+	}
+
+	for _, tc := range testCases {
+		mockComposeLookup(t, tc.found, tc.pluginWorks)
+
+		errOutput := new(bytes.Buffer)
+		s := &DefaultShell{errStream: errOutput, env: environment.NewFakeEnvStorage()}
+		s.env.Set("KOOL_VERBOSE", "true")
+
+		command, err := s.composeCommand(builder.NewCommand("docker", "compose", "-p", "p", "up"))
+
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: expected error %v; got %v", tc.name, tc.err, err)
+		}
+
+		if err == nil && command.String() != tc.expected {
+			t.Errorf("%s: expected command '%s'; got '%s'", tc.name, tc.expected, command.String())
+		}
+
+		if err == nil && !strings.HasPrefix(errOutput.String(), "[using docker") {
+			t.Errorf("%s: expected the compose in use on verbose output; got '%s'", tc.name, errOutput.String())
+		}
+	}
+}
+
+func TestComposeInUse(t *testing.T) {
+	var testCases = []struct {
+		found       map[string]bool
+		pluginWorks bool
+		expected    string
+		err         error
+	}{
+		{map[string]bool{"docker": true, "docker-compose": true}, true, "docker compose", nil},
+		{map[string]bool{"docker-compose": true}, true, "docker-compose", nil},
+		{map[string]bool{}, false, "", errs.ErrComposeNotFound},
+	}
+
+	for _, tc := range testCases {
+		mockComposeLookup(t, tc.found, tc.pluginWorks)
+
+		if using, err := ComposeInUse(); using != tc.expected || !errors.Is(err, tc.err) {
+			t.Errorf("expected compose in use '%s' (err: %v); got '%s' (err: %v)", tc.expected, tc.err, using, err)
+		}
+	}
+}
+
+func TestResolveComposeCached(t *testing.T) {
+	mockComposeLookup(t, map[string]bool{"docker": true}, true)
+
+	s := &DefaultShell{errStream: new(bytes.Buffer), env: environment.NewFakeEnvStorage()}
+	s.resolveCompose()
+
+	execLookPathFn = func(exe string) (string, error) {
+		t.Errorf("should not look for %s again", exe)
+		return "", errors.New("not found")
+	}
+
+	if resolved := s.resolveCompose(); resolved != composeV2 {
+		t.Errorf("expected cached V2 plugin; got %d", resolved)
+	}
+
+	if command, err := s.composeCommand(builder.NewCommand("docker", "ps")); err != nil || command.String() != "docker ps" {
+		t.Errorf("unexpected change on non compose command: %v (err: %v)", command, err)
+	}
+}
+
+func TestExecResolvedComposeForms(t *testing.T) {
+	var testCases = []struct {
+		resolved     int
+		command      *builder.DefaultCommand
+		expectedExe  string
+		expectedArgs string
+	}{
+		{composeV2, builder.NewComposeCommand("ps"), "docker", "compose -p my-project ps app"},
+		{composeV2, builder.NewCommand("docker-compose", "ps"), "docker", "compose -p my-project ps app"},
+		{composeV1, builder.NewComposeCommand("ps"), "docker-compose", "-p my-project ps app"},
+		{composeV1, builder.NewCommand("docker-compose", "ps"), "docker-compose", "-p my-project ps app"},
+	}
+
+	originalExecCmdFn, originalResolved := execCmdFn, composeResolved
+	defer func() {
+		execCmdFn, composeResolved = originalExecCmdFn, originalResolved
+	}()
+
+	for _, tc := range testCases {
+		var (
+			exeTest  string
+			argsTest []string
+		)
+
+		execCmdFn = func(exe string, args ...string) *exec.Cmd {
+			exeTest, argsTest = exe, args
+			return exec.Command("echo", "x1")
+		}
+		composeResolved = tc.resolved
+
+		s := &DefaultShell{errStream: new(bytes.Buffer), env: environment.NewFakeEnvStorage()}
+		s.env.Set("COMPOSE_PROJECT_NAME", "my-project")
+
+		if _, err := s.Exec(tc.command, "app"); err != nil {
+			t.Errorf("unexpected error running %s; error: %v", tc.command.String(), err)
+		}
+
+		if exeTest != tc.expectedExe || strings.Join(argsTest, " ") != tc.expectedArgs {
+			t.Errorf("expected %s to run as '%s %s'; got '%s %s'", tc.command.String(), tc.expectedExe, tc.expectedArgs, exeTest, strings.Join(argsTest, " "))
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package shell
+
+import (
+	"strings"
+
+	"github.com/gookit/color"
+)
+
+// ColorizeDiff renders the given unified diff with colors: additions in
+// green, deletions in red and hunk headers in cyan. When colors are
+// disabled (like with NO_COLOR) the diff is returned as it is.
+func ColorizeDiff(diff string) string {
+	if !color.Enable || !color.SupportColor() {
+		return diff
+	}
+
+	var (
+		sb   strings.Builder
+		line string
+	)
+
+	// a little room for the color codes, so large diffs grow just once
+	sb.Grow(len(diff) + len(diff)/4)
+
+	for rest := diff; rest != ""; {
+		if i := strings.IndexByte(rest, '\n'); i >= 0 {
+			line, rest = rest[:i], rest[i+1:]
+		} else {
+			line, rest = rest, ""
+		}
+
+		sb.WriteString(colorizeDiffLine(line))
+
+		if rest != "" || strings.HasSuffix(diff, "\n") {
+			sb.WriteByte('\n')
+		}
+	}
+
+	return sb.String()
+}
+
+// colorizeDiffLine colors a single line of a unified diff by its prefix
+func colorizeDiffLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		return color.Bold.Sprint(line)
+	case strings.HasPrefix(line, "+"):
+		return color.Green.Sprint(line)
+	case strings.HasPrefix(line, "-"):
+		return color.Red.Sprint(line)
+	case strings.HasPrefix(line, "@@"):
+		return color.Cyan.Sprint(line)
+	}
+
+	return line
+}
@@ -0,0 +1,67 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gookit/color"
+)
+
+const testingDiff = `--- file.txt
++++ file.txt
+@@ -1,2 +1,2 @@
+ a
+-b
++c
+`
+
+func TestColorizeDiff(t *testing.T) {
+	enabled := color.Enable
+	level := color.ForceSetColorLevel(color.Level16)
+	color.Enable = true
+	defer func() {
+		color.Enable = enabled
+		color.ForceSetColorLevel(level)
+	}()
+
+	output := ColorizeDiff(testingDiff)
+
+	for _, expected := range []string{
+		color.Bold.Sprint("--- file.txt"),
+		color.Bold.Sprint("+++ file.txt"),
+		color.Cyan.Sprint("@@ -1,2 +1,2 @@"),
+		"\n a\n",
+		color.Red.Sprint("-b"),
+		color.Green.Sprint("+c") + "\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q on colorized diff; got %q", expected, output)
+		}
+	}
+
+	if lines := strings.Count(output, "\n"); lines != strings.Count(testingDiff, "\n") {
+		t.Errorf("expected colorized diff to keep %d lines; got %d", strings.Count(testingDiff, "\n"), lines)
+	}
+
+	if output = ColorizeDiff("-b\n+c"); strings.HasSuffix(output, "\n") {
+		t.Errorf("unexpected trailing line break added; got %q", output)
+	}
+
+	large := strings.Repeat("+added line\n-removed line\n", 50000)
+
+	if output = ColorizeDiff(large); strings.Count(output, "\n") != 100000 {
+		t.Errorf("expected all lines of a large diff; got %d", strings.Count(output, "\n"))
+	}
+}
+
+func TestColorizeDiffWithoutColors(t *testing.T) {
+	enabled := color.Enable
+	color.Enable = false
+	defer func() {
+		color.Enable = enabled
+	}()
+
+	if output := ColorizeDiff(testingDiff); output != testingDiff {
+		t.Errorf("expected diff untouched without colors; got %q", output)
+	}
+}
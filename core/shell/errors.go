@@ -5,6 +5,10 @@ import (
 	"fmt"
 )
 
+// UserCancelledExitCode is the exit code used when the user
+// cancels an operation (same as a SIGINT'ed process on shells)
+const UserCancelledExitCode int = 130
+
 var ErrUserCancelled = fmt.Errorf("user cancelled the operation")
 
 func IsUserCancelledError(err error) bool {
@@ -19,3 +23,8 @@ type ErrExitable struct {
 func (e ErrExitable) Error() string {
 	return e.Err.Error()
 }
+
+// Unwrap returns the underlying error
+func (e ErrExitable) Unwrap() error {
+	return e.Err
+}
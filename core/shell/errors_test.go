@@ -29,3 +29,11 @@ func TestExitable(t *testing.T) {
 		t.Error("error should be the same")
 	}
 }
+
+func TestExitableUnwrap(t *testing.T) {
+	exitable := ErrExitable{Err: ErrUserCancelled, Code: UserCancelledExitCode}
+
+	if !IsUserCancelledError(exitable) {
+		t.Error("exitable error should unwrap to the underlying error")
+	}
+}
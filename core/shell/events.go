@@ -0,0 +1,78 @@
+package shell
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+)
+
+// Lifecycle event types
+const (
+	EventCommandStarted  = "command.started"
+	EventCommandFinished = "command.finished"
+	EventServiceUp       = "service.up"
+	EventError           = "error"
+)
+
+// Event is a machine-readable lifecycle event, written as a single
+// line of JSON to the events socket for integrations to follow
+type Event struct {
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	Command  string    `json:"command,omitempty"`
+	Services []string  `json:"services,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+type eventsEmitter struct {
+	mtx  sync.Mutex
+	conn net.Conn
+}
+
+var emitter = &eventsEmitter{}
+
+// OpenEventsSocket connects to the Unix socket events are emitted to;
+// it does nothing if a socket is already open
+func OpenEventsSocket(path string) (err error) {
+	emitter.mtx.Lock()
+	defer emitter.mtx.Unlock()
+
+	if emitter.conn != nil {
+		return
+	}
+
+	emitter.conn, err = net.Dial("unix", path)
+	return
+}
+
+// CloseEventsSocket closes the events socket, if open
+func CloseEventsSocket() {
+	emitter.mtx.Lock()
+	defer emitter.mtx.Unlock()
+
+	if emitter.conn != nil {
+		emitter.conn.Close()
+		emitter.conn = nil
+	}
+}
+
+// EmitEvent writes the event to the events socket; it is a no-op when
+// there is no socket open, and failures are ignored since events are
+// just informative
+func EmitEvent(event Event) {
+	emitter.mtx.Lock()
+	defer emitter.mtx.Unlock()
+
+	if emitter.conn == nil {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	if data, err := json.Marshal(event); err == nil {
+		_, _ = emitter.conn.Write(append(data, '\n'))
+	}
+}
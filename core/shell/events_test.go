@@ -0,0 +1,60 @@
+package shell
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func TestEmitEvent(t *testing.T) {
+	// no-op without a socket
+	EmitEvent(Event{Type: EventCommandStarted})
+
+	socket := filepath.Join(t.TempDir(), "events.sock")
+
+	if err := OpenEventsSocket(socket); err == nil {
+		CloseEventsSocket()
+		t.Fatal("expected error connecting to a socket nobody listens to")
+	}
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets not available: %v", err)
+	}
+	defer listener.Close()
+
+	if err = OpenEventsSocket(socket); err != nil {
+		t.Fatalf("unexpected error opening events socket: %v", err)
+	}
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	EmitEvent(Event{Type: EventServiceUp, Services: []string{"app"}})
+	CloseEventsSocket()
+
+	var (
+		event   Event
+		scanner = bufio.NewScanner(conn)
+	)
+
+	if !scanner.Scan() {
+		t.Fatal("expected an event line on the socket")
+	}
+
+	if err = json.Unmarshal(scanner.Bytes(), &event); err != nil {
+		t.Fatalf("failed parsing event JSON: %v", err)
+	}
+
+	if event.Type != EventServiceUp || len(event.Services) != 1 || event.Services[0] != "app" || event.Time.IsZero() {
+		t.Errorf("unexpected event: %+v", event)
+	}
+
+	// closed socket means no-op again
+	EmitEvent(Event{Type: EventError})
+}
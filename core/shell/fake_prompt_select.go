@@ -6,6 +6,10 @@ type FakePromptSelect struct {
 	MockAnswer map[string]string
 	MockError  map[string]error
 
+	CalledSearch  bool
+	SearchOptions []string
+	SearchFilter  SearchFilter
+
 	CalledConfirm []*struct {
 		question string
 		args     []any
@@ -22,6 +26,16 @@ func (f *FakePromptSelect) Ask(question string, options []string) (answer string
 	return
 }
 
+// Search mocked behavior for testing prompting a searchable select question
+func (f *FakePromptSelect) Search(question string, options []string, filter SearchFilter) (answer string, err error) {
+	f.CalledSearch = true
+	f.SearchOptions = options
+	f.SearchFilter = filter
+	answer = f.MockAnswer[question]
+	err = f.MockError[question]
+	return
+}
+
 // Confirm mocked behavior for testing prompting a confirm question
 func (f *FakePromptSelect) Confirm(question string, args ...any) (confirmed bool, err error) {
 	f.CalledConfirm = append(f.CalledConfirm, &struct {
@@ -29,6 +29,12 @@ func TestFakePromptSelect(t *testing.T) {
 		t.Errorf("should throw an error on Ask")
 	}
 
+	f.MockAnswer["search"] = "found"
+
+	if answer, _ := f.Search("search", []string{"found"}, func(string, int) bool { return true }); !f.CalledSearch || answer != "found" || len(f.SearchOptions) != 1 || f.SearchFilter == nil {
+		t.Errorf("bad return from mocked Search")
+	}
+
 	f.MockConfirm = make(map[string]bool)
 	f.MockConfirm["question"] = true
 	f.MockConfirmError = make(map[string]error)
@@ -1,6 +1,7 @@
 package shell
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"kool-dev/kool/core/builder"
@@ -20,6 +21,12 @@ type FakeShell struct {
 	CalledInteractive  map[string]bool
 	CalledLookPath     map[string]bool
 	ArgsInteractive    map[string][]string
+	CalledSetBinPaths  bool
+	BinPaths           []string
+	CalledSetContext   bool
+	Contexts           []context.Context
+	CalledFork         bool
+	Forks              []*FakeShell
 
 	Err           error
 	OutLines      []string
@@ -54,6 +61,29 @@ func (f *FakeShell) SetInStream(inStream io.Reader) {
 	f.CalledSetInStream = true
 }
 
+// SetBinPaths is a mocked testing function
+func (f *FakeShell) SetBinPaths(paths []string) {
+	f.CalledSetBinPaths = true
+	f.BinPaths = paths
+}
+
+// SetContext is a mocked testing function
+func (f *FakeShell) SetContext(ctx context.Context) {
+	f.CalledSetContext = true
+	f.Contexts = append(f.Contexts, ctx)
+}
+
+// Fork is a mocked testing function; the forked fake
+// shells are kept for the tests to check on them
+func (f *FakeShell) Fork(outStream, errStream io.Writer) Shell {
+	f.CalledFork = true
+
+	forked := &FakeShell{MockOutStream: outStream, MockErrStream: errStream}
+	f.Forks = append(f.Forks, forked)
+
+	return forked
+}
+
 // OutStream is a mocked testing function
 func (f *FakeShell) OutStream() (outStream io.Writer) {
 	f.CalledOutStream = true
@@ -25,6 +25,16 @@ func TestFakeShell(t *testing.T) {
 		t.Error("failed to use mocked SetInStream function on FakeShell")
 	}
 
+	f.SetBinPaths([]string{"bin"})
+
+	if !f.CalledSetBinPaths || len(f.BinPaths) != 1 {
+		t.Error("failed to use mocked SetBinPaths function on FakeShell")
+	}
+
+	if forked := f.Fork(io.Discard, io.Discard); !f.CalledFork || len(f.Forks) != 1 || forked != f.Forks[0] {
+		t.Error("failed to use mocked Fork function on FakeShell")
+	}
+
 	f.MockOutStream = io.Discard
 
 	out := f.OutStream()
@@ -0,0 +1,52 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gookit/color"
+)
+
+// Log levels for the shell messages, from the most verbose
+// to the least; the level in use is taken from KOOL_LOG_LEVEL
+const (
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+var logLevels = map[string]int{
+	LogLevelInfo:  0,
+	LogLevelWarn:  1,
+	LogLevelError: 2,
+}
+
+// ValidateLogLevel checks the given log level is a known one
+func ValidateLogLevel(level string) (err error) {
+	if _, ok := logLevels[strings.ToLower(level)]; !ok {
+		err = fmt.Errorf("invalid log level '%s'; expected info, warn or error", level)
+	}
+	return
+}
+
+// logEnabled tells whether messages of the given level should be
+// printed out; unknown or unset levels print out everything
+func (s *DefaultShell) logEnabled(level string) bool {
+	if s.env == nil {
+		return true
+	}
+
+	current, ok := logLevels[strings.ToLower(s.env.Get("KOOL_LOG_LEVEL"))]
+
+	return !ok || logLevels[level] >= current
+}
+
+// logMessage renders the message with the level color; when colors
+// are disabled (like with NO_COLOR) the level is told by a prefix instead
+func logMessage(level string, style color.Style, out ...interface{}) string {
+	if !color.Enable || !color.SupportColor() {
+		return fmt.Sprintf("%-5s %s", strings.ToUpper(level), fmt.Sprint(out...))
+	}
+
+	return style.Sprint(out...)
+}
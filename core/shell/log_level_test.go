@@ -0,0 +1,75 @@
+package shell
+
+import (
+	"bytes"
+	"kool-dev/kool/core/environment"
+	"strings"
+	"testing"
+
+	"github.com/gookit/color"
+)
+
+func TestValidateLogLevel(t *testing.T) {
+	for _, level := range []string{"info", "warn", "error", "WARN"} {
+		if err := ValidateLogLevel(level); err != nil {
+			t.Errorf("unexpected error validating log level %s: %v", level, err)
+		}
+	}
+
+	if err := ValidateLogLevel("debug"); err == nil || !strings.Contains(err.Error(), "invalid log level 'debug'") {
+		t.Errorf("expected invalid log level error; got %v", err)
+	}
+}
+
+func TestLogLevelFiltering(t *testing.T) {
+	var testCases = []struct {
+		level    string
+		expected []string
+	}{
+		{"", []string{"info", "success", "warning"}},
+		{"info", []string{"info", "success", "warning"}},
+		{"warn", []string{"warning"}},
+		{"error", []string{}},
+	}
+
+	for _, tc := range testCases {
+		env := environment.NewFakeEnvStorage()
+		env.Set("KOOL_LOG_LEVEL", tc.level)
+
+		buf := new(bytes.Buffer)
+		s := &DefaultShell{outStream: buf, env: env}
+
+		s.Info("info")
+		s.Success("success")
+		s.Warning("warning")
+
+		output := strings.TrimSpace(buf.String())
+
+		for _, message := range []string{"info", "success", "warning"} {
+			expected := false
+			for _, e := range tc.expected {
+				expected = expected || e == message
+			}
+
+			if strings.Contains(output, message) != expected {
+				t.Errorf("log level '%s': expected message '%s' printed: %v; output: %q", tc.level, message, expected, output)
+			}
+		}
+	}
+}
+
+func TestLogMessagePrefixWithoutColors(t *testing.T) {
+	enabled := color.Enable
+	color.Enable = false
+	defer func() {
+		color.Enable = enabled
+	}()
+
+	if message := logMessage(LogLevelWarn, color.New(color.Yellow), "testing", " warning"); message != "WARN  testing warning" {
+		t.Errorf("unexpected message without colors: %q", message)
+	}
+
+	if message := logMessage(LogLevelInfo, color.New(color.Cyan), "testing info"); message != "INFO  testing info" {
+		t.Errorf("unexpected message without colors: %q", message)
+	}
+}
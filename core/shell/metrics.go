@@ -0,0 +1,63 @@
+package shell
+
+import (
+	"sync"
+	"time"
+)
+
+// CommandMetric holds the time taken by a single executed command
+type CommandMetric struct {
+	Command  string
+	Duration time.Duration
+}
+
+type metricsRecorder struct {
+	mtx     sync.Mutex
+	enabled bool
+	metrics []CommandMetric
+}
+
+var recorder = &metricsRecorder{}
+
+// EnableCommandMetrics sets whether to record the time taken by the executed
+// commands; it is kept in this process only, so kool processes spawned
+// along the way do not print metrics of their own
+func EnableCommandMetrics(enabled bool) {
+	recorder.mtx.Lock()
+	defer recorder.mtx.Unlock()
+
+	recorder.enabled = enabled
+}
+
+// CommandMetricsEnabled tells whether the executed commands are being timed
+func CommandMetricsEnabled() bool {
+	recorder.mtx.Lock()
+	defer recorder.mtx.Unlock()
+
+	return recorder.enabled
+}
+
+// RecordCommandMetric stores how long the given command took to run
+func RecordCommandMetric(command string, duration time.Duration) {
+	recorder.mtx.Lock()
+	defer recorder.mtx.Unlock()
+
+	recorder.metrics = append(recorder.metrics, CommandMetric{command, duration})
+}
+
+// CommandMetrics returns the recorded metrics in execution order
+func CommandMetrics() (metrics []CommandMetric) {
+	recorder.mtx.Lock()
+	defer recorder.mtx.Unlock()
+
+	metrics = append(metrics, recorder.metrics...)
+	return
+}
+
+// ResetCommandMetrics discards all recorded metrics
+func ResetCommandMetrics() {
+	recorder.mtx.Lock()
+	defer recorder.mtx.Unlock()
+
+	recorder.metrics = nil
+}
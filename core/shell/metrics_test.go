@@ -0,0 +1,62 @@
+package shell
+
+import (
+	"io"
+	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/environment"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCommandMetrics(t *testing.T) {
+	ResetCommandMetrics()
+	defer ResetCommandMetrics()
+
+	RecordCommandMetric("cmd1", time.Second)
+	RecordCommandMetric("cmd2", 2*time.Second)
+
+	metrics := CommandMetrics()
+
+	if len(metrics) != 2 || metrics[0].Command != "cmd1" || metrics[1].Duration != 2*time.Second {
+		t.Errorf("unexpected recorded metrics: %v", metrics)
+	}
+
+	ResetCommandMetrics()
+
+	if len(CommandMetrics()) != 0 {
+		t.Error("failed resetting metrics")
+	}
+}
+
+func TestExecRecordsMetrics(t *testing.T) {
+	ResetCommandMetrics()
+	defer ResetCommandMetrics()
+	defer EnableCommandMetrics(false)
+
+	s := &DefaultShell{
+		inStream:  os.Stdin,
+		outStream: io.Discard,
+		errStream: io.Discard,
+		env:       environment.NewFakeEnvStorage(),
+		lookedUp:  newLookupCache(),
+	}
+
+	if _, err := s.Exec(builder.NewCommand("echo", "x")); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(CommandMetrics()) != 0 {
+		t.Error("should not record metrics unless enabled")
+	}
+
+	EnableCommandMetrics(true)
+
+	if _, err := s.Exec(builder.NewCommand("echo", "x")); err != nil {
+		t.Fatal(err)
+	}
+
+	if metrics := CommandMetrics(); len(metrics) != 1 || metrics[0].Command != "echo x" {
+		t.Errorf("failed recording metrics for Exec: %v", metrics)
+	}
+}
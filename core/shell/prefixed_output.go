@@ -0,0 +1,86 @@
+package shell
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// PrefixedOutput shares one output stream between writers used
+// concurrently (like commands running in parallel), writing each
+// complete line along with the prefix of the writer it came from,
+// so lines from different writers interleave but never mix up.
+type PrefixedOutput struct {
+	mtx *sync.Mutex
+	out io.Writer
+}
+
+// PrefixedWriter is a writer of a PrefixedOutput, holding
+// the last incomplete line written until it is completed
+type PrefixedWriter struct {
+	output  *PrefixedOutput
+	prefix  []byte
+	pending []byte
+}
+
+// NewPrefixedOutput creates a PrefixedOutput writing to the given stream
+func NewPrefixedOutput(out io.Writer) *PrefixedOutput {
+	var mtx sync.Mutex
+	return &PrefixedOutput{&mtx, out}
+}
+
+// Writer creates a new writer for the output, prefixing its lines with the given prefix
+func (o *PrefixedOutput) Writer(prefix string) *PrefixedWriter {
+	return &PrefixedWriter{o, []byte(prefix), nil}
+}
+
+// Write writes the complete lines of the given data to the
+// shared output, keeping the last incomplete one for later
+func (w *PrefixedWriter) Write(data []byte) (n int, err error) {
+	w.output.mtx.Lock()
+	defer w.output.mtx.Unlock()
+
+	w.pending = append(w.pending, data...)
+
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+
+		if i < 0 {
+			break
+		}
+
+		if err = w.writeLine(w.pending[:i+1]); err != nil {
+			// the failed lines are dropped, not written again later
+			w.pending = nil
+			return
+		}
+
+		w.pending = w.pending[i+1:]
+	}
+
+	n = len(data)
+	return
+}
+
+// Flush writes out the last incomplete line, if any
+func (w *PrefixedWriter) Flush() (err error) {
+	w.output.mtx.Lock()
+	defer w.output.mtx.Unlock()
+
+	if len(w.pending) == 0 {
+		return
+	}
+
+	err = w.writeLine(append(w.pending, '\n'))
+	w.pending = nil
+	return
+}
+
+func (w *PrefixedWriter) writeLine(line []byte) (err error) {
+	if _, err = w.output.out.Write(w.prefix); err != nil {
+		return
+	}
+
+	_, err = w.output.out.Write(line)
+	return
+}
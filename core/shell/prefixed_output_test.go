@@ -0,0 +1,88 @@
+package shell
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write error")
+}
+
+func TestPrefixedOutput(t *testing.T) {
+	var (
+		buf    bytes.Buffer
+		output = NewPrefixedOutput(&buf)
+		first  = output.Writer("[1] ")
+		second = output.Writer("[2] ")
+	)
+
+	_, _ = first.Write([]byte("first "))
+	_, _ = second.Write([]byte("second line\nsecond "))
+	_, _ = first.Write([]byte("line\n"))
+
+	if buf.String() != "[2] second line\n[1] first line\n" {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+
+	buf.Reset()
+
+	if err := second.Flush(); err != nil || buf.String() != "[2] second \n" {
+		t.Errorf("unexpected flushed output: %q (err: %v)", buf.String(), err)
+	}
+
+	buf.Reset()
+
+	if err := first.Flush(); err != nil || buf.String() != "" {
+		t.Errorf("expected nothing to flush; got %q (err: %v)", buf.String(), err)
+	}
+}
+
+func TestPrefixedOutputConcurrentWriters(t *testing.T) {
+	var (
+		buf    bytes.Buffer
+		output = NewPrefixedOutput(&buf)
+		wg     sync.WaitGroup
+	)
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func(w *PrefixedWriter) {
+			defer wg.Done()
+
+			for j := 0; j < 50; j++ {
+				_, _ = w.Write([]byte("a line\n"))
+			}
+		}(output.Writer(fmt.Sprintf("[%d] ", i+1)))
+	}
+
+	wg.Wait()
+
+	for _, line := range bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n")) {
+		if len(line) != len("[1] a line") || !bytes.HasSuffix(line, []byte("] a line")) {
+			t.Fatalf("lines got mixed up: %q", line)
+		}
+	}
+}
+
+func TestPrefixedOutputWriteError(t *testing.T) {
+	w := NewPrefixedOutput(failingWriter{}).Writer("[1] ")
+
+	if _, err := w.Write([]byte("line\n")); err == nil {
+		t.Error("expected error writing to a failing stream")
+	}
+
+	if _, err := w.Write([]byte("incomplete")); err != nil {
+		t.Errorf("unexpected error for an incomplete line: %v", err)
+	}
+
+	if err := w.Flush(); err == nil {
+		t.Error("expected error flushing to a failing stream")
+	}
+}
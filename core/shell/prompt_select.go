@@ -2,15 +2,22 @@ package shell
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/AlecAivazis/survey/v2/terminal"
 )
 
+// SearchFilter tells whether the option at the given
+// index matches the search input typed by the user
+type SearchFilter func(input string, index int) bool
+
 // PromptSelect contract that holds logic for prompt a select question
 type PromptSelect interface {
 	Ask(string, []string) (string, error)
 
+	Search(string, []string, SearchFilter) (string, error)
+
 	Confirm(string, ...any) (bool, error)
 }
 
@@ -34,6 +41,41 @@ func (p *DefaultPromptSelect) Ask(question string, options []string) (answer str
 	return
 }
 
+// Search prompt to the user a select question which options
+// can be filtered by typing; matching is done by the given filter
+func (p *DefaultPromptSelect) Search(question string, options []string, filter SearchFilter) (answer string, err error) {
+	prompt := &survey.Select{
+		Message:  question,
+		Options:  options,
+		PageSize: 10,
+		Filter: func(input string, _ string, index int) bool {
+			return input == "" || filter(input, index)
+		},
+	}
+	if err = survey.AskOne(prompt, &answer); err != nil && err == terminal.InterruptErr {
+		err = ErrUserCancelled
+	}
+	return
+}
+
+// FuzzyMatch tells whether all the characters of input appear
+// in the target, in the same order (case insensitive)
+func FuzzyMatch(input, target string) bool {
+	target = strings.ToLower(target)
+
+	for _, r := range strings.ToLower(input) {
+		var idx int
+
+		if idx = strings.IndexRune(target, r); idx < 0 {
+			return false
+		}
+
+		target = target[idx+len(string(r)):]
+	}
+
+	return true
+}
+
 // Confirm prompts to the user a Yes/No confirm question
 func (p *DefaultPromptSelect) Confirm(question string, args ...any) (confirmed bool, err error) {
 	if args != nil {
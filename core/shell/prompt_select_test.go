@@ -62,3 +62,18 @@ func TestConfirmPromptSelect(t *testing.T) {
 		t.Error("failed to render the Confirm prompt and its options")
 	}
 }
+
+func TestFuzzyMatch(t *testing.T) {
+	for input, expected := range map[string]bool{
+		"":        true,
+		"lar":     true,
+		"LRVL":    true,
+		"laravel": true,
+		"lvr":     false,
+		"symfony": false,
+	} {
+		if got := FuzzyMatch(input, "Laravel"); got != expected {
+			t.Errorf("FuzzyMatch(%q, Laravel) expected %v; got %v", input, expected, got)
+		}
+	}
+}
@@ -1,15 +1,21 @@
 package shell
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"kool-dev/kool/core/builder"
 	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/errs"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/gookit/color"
 )
@@ -23,6 +29,14 @@ var execLookPathFn execLookPathFnType = exec.LookPath
 // ErrLookPath error when we fail to find in PATH
 var ErrLookPath = errors.New("command not found")
 
+// dockerDaemonRetries is how many times commands failing to connect to the
+// Docker daemon get retried; this is usually transient right after the
+// daemon (re)starts, like when waking up the laptop
+var dockerDaemonRetries = 3
+
+// dockerDaemonRetryDelay is how long we wait before retrying
+var dockerDaemonRetryDelay = time.Second
+
 // RecursiveCall is used to proxy self-executaion commands internally
 // instead of creating a whole new OS process
 var RecursiveCall func([]string, io.Reader, io.Writer, io.Writer) error
@@ -34,6 +48,9 @@ type DefaultShell struct {
 	errStream io.Writer
 	lookedUp  *lookupCache
 	env       environment.EnvStorage
+	binPaths  []string
+	ctx       context.Context
+	forked    bool
 }
 
 // OutputWritter implements basic output for CLIss
@@ -71,6 +88,9 @@ type Shell interface {
 	Error(error)
 
 	IsTerminal() bool
+	SetBinPaths([]string)
+	SetContext(context.Context)
+	Fork(io.Writer, io.Writer) Shell
 }
 
 // NewShell creates a new shell
@@ -121,8 +141,15 @@ func (s *DefaultShell) SetErrStream(errStream io.Writer) {
 }
 
 // Exec will execute the given command silently and return the combined
-// error/standard output, and an error if any.
+// error/standard output, and an error if any. Failures to connect to the
+// Docker daemon are retried a few times before giving up.
 func (s *DefaultShell) Exec(command builder.Command, extraArgs ...string) (outStr string, err error) {
+	command = builder.ComposeProject(builder.ComposeV2(command), s.env.Get("COMPOSE_PROJECT_NAME"))
+
+	if command, err = s.composeCommand(command); err != nil {
+		return
+	}
+
 	var (
 		cmd     *exec.Cmd
 		out     []byte
@@ -142,15 +169,38 @@ func (s *DefaultShell) Exec(command builder.Command, extraArgs ...string) (outSt
 		)
 	}
 
-	cmd = execCmdFn(exe, args...)
-	cmd.Env = os.Environ()
-	cmd.Stdin = s.InStream()
-	out, err = cmd.CombinedOutput()
+	if CommandMetricsEnabled() {
+		defer s.recordMetric(time.Now(), exe, args)
+	}
+
+	for attempt := 1; ; attempt++ {
+		cmd = execCmdFn(exe, args...)
+		cmd.Env = os.Environ()
+		cmd.Stdin = s.InStream()
+		out, err = cmd.CombinedOutput()
+
+		if err == nil || attempt > dockerDaemonRetries || !isDockerDaemonConnectionError(string(out)) {
+			break
+		}
+
+		if verbose {
+			fmt.Fprintf(s.ErrStream(), "[could not connect to the Docker daemon; retrying (%d/%d)]\n", attempt, dockerDaemonRetries)
+		}
+
+		time.Sleep(dockerDaemonRetryDelay)
+	}
+
 	outStr = strings.TrimSpace(string(out))
 	if err != nil && len(out) != 0 {
 		// let's use the actual output for error, appending practical exec error
 		// (most probably the later will be an non-zero exit status error)
 		err = fmt.Errorf("%s (%s)", outStr, err.Error())
+
+		if isDockerDaemonConnectionError(outStr) {
+			err = errs.Wrap(errs.ErrDockerNotRunning, err)
+		} else if isNoSuchServiceError(outStr) {
+			err = errs.Wrap(errs.ErrServiceNotFound, err)
+		}
 	}
 	return
 }
@@ -166,6 +216,16 @@ func (s *DefaultShell) Interactive(originalCmd builder.Command, extraArgs ...str
 
 	command.AppendArgs(extraArgs...)
 
+	command = builder.ComposeProject(builder.ComposeV2(command), s.env.Get("COMPOSE_PROJECT_NAME"))
+
+	if command, err = s.composeCommand(command); err != nil {
+		return
+	}
+
+	if verbose {
+		command = builder.VerboseCompose(command)
+	}
+
 	// soon should refactor this into a struct with methods
 	// so we can remove this too long list of returned values.
 	if cmdptr, err = parseRedirects(command, s); err != nil {
@@ -182,24 +242,127 @@ func (s *DefaultShell) Interactive(originalCmd builder.Command, extraArgs ...str
 		)
 	}
 
-	if cmdptr.Command.Cmd() == "kool" && RecursiveCall != nil {
+	// forked shells run concurrently, so they cannot share this process
+	// (environment and all) with recursive calls; kool runs on its own
+	if cmdptr.Command.Cmd() == "kool" && RecursiveCall != nil && !s.forked {
 		if verbose {
 			fmt.Fprintln(s.ErrStream(), "[recursive call]")
 		}
 		err = RecursiveCall(cmdptr.Command.Args(), cmdptr.in, cmdptr.out, cmdptr.err)
 	} else {
-		if err = s.LookPath(cmdptr.Command); err != nil {
+		if cmdptr.Command.Cmd() == "kool" && s.forked {
+			if exe, exeErr := os.Executable(); exeErr == nil {
+				cmdptr.Command = builder.NewCommand(exe, cmdptr.Command.Args()...)
+			}
+		}
+
+		if binPath, found := s.lookupBinPaths(cmdptr.Command.Cmd()); found {
+			cmdptr.Command = builder.NewCommand(binPath, cmdptr.Command.Args()...)
+		} else if err = s.LookPath(cmdptr.Command); err != nil {
 			err = ErrLookPath
 			return
 		}
 
-		err = s.execute(cmdptr.Cmd())
+		if CommandMetricsEnabled() {
+			defer s.recordMetric(time.Now(), cmdptr.Command.Cmd(), cmdptr.Command.Args())
+		}
+
+		cmd := cmdptr.Cmd()
+		cmd.Env = s.prependBinPaths(cmd.Env)
+
+		err = s.execute(cmd)
 
 		defer cmdptr.Close()
 	}
 	return
 }
 
+// isDockerDaemonConnectionError tells whether the output
+// is from failing to reach the Docker daemon
+func isDockerDaemonConnectionError(out string) bool {
+	return strings.Contains(out, "Cannot connect to the Docker daemon")
+}
+
+// isNoSuchServiceError tells whether the output is from
+// docker compose not knowing about the given service
+func isNoSuchServiceError(out string) bool {
+	return strings.Contains(out, "no such service")
+}
+
+// SetBinPaths sets the directories that take precedence over
+// the PATH for commands run interactively by this shell
+func (s *DefaultShell) SetBinPaths(paths []string) {
+	s.binPaths = paths
+}
+
+// SetContext sets the context bounding the commands run interactively
+// by this shell; they get killed once the context is done
+func (s *DefaultShell) SetContext(ctx context.Context) {
+	s.ctx = ctx
+}
+
+// Fork returns a copy of this shell (with its bin paths and context)
+// writing to the given output and error streams, for running commands
+// concurrently; the copy reads no input, and runs kool commands as a
+// new process rather than a recursive call.
+func (s *DefaultShell) Fork(outStream, errStream io.Writer) Shell {
+	forked := *s
+
+	forked.forked = true
+	forked.inStream = bytes.NewReader(nil)
+	forked.outStream = outStream
+	forked.errStream = errStream
+
+	return &forked
+}
+
+// lookupBinPaths looks for the executable within the shell bin paths
+func (s *DefaultShell) lookupBinPaths(exe string) (binPath string, found bool) {
+	if strings.ContainsRune(exe, '/') || strings.ContainsRune(exe, filepath.Separator) {
+		return
+	}
+
+	for _, dir := range s.binPaths {
+		candidate := filepath.Join(dir, exe)
+
+		if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() && (runtime.GOOS == "windows" || info.Mode()&0111 != 0) {
+			binPath, found = candidate, true
+			return
+		}
+	}
+
+	return
+}
+
+// prependBinPaths returns the given environment with
+// the shell bin paths prepended to its PATH
+func (s *DefaultShell) prependBinPaths(env []string) []string {
+	if len(s.binPaths) == 0 {
+		return env
+	}
+
+	var (
+		prefix = strings.Join(s.binPaths, string(os.PathListSeparator))
+		newEnv = make([]string, 0, len(env)+1)
+		found  bool
+	)
+
+	for _, entry := range env {
+		if pair := strings.SplitN(entry, "=", 2); strings.EqualFold(pair[0], "PATH") && len(pair) == 2 {
+			entry = pair[0] + "=" + prefix + string(os.PathListSeparator) + pair[1]
+			found = true
+		}
+
+		newEnv = append(newEnv, entry)
+	}
+
+	if !found {
+		newEnv = append(newEnv, "PATH="+prefix)
+	}
+
+	return newEnv
+}
+
 // LookPath returns if the command exists
 func (s *DefaultShell) LookPath(command builder.Command) (err error) {
 	var (
@@ -225,6 +388,11 @@ func (s *DefaultShell) LookPath(command builder.Command) (err error) {
 	return
 }
 
+// recordMetric stores the time elapsed since start for the given command
+func (s *DefaultShell) recordMetric(start time.Time, exe string, args []string) {
+	RecordCommandMetric(strings.TrimSpace(exe+" "+strings.Join(args, " ")), time.Since(start))
+}
+
 // Println execs Println on writer
 func (s *DefaultShell) Println(out ...interface{}) {
 	fmt.Fprintln(s.OutStream(), out...)
@@ -242,17 +410,23 @@ func (s *DefaultShell) Error(err error) {
 
 // Warning warning message
 func (s *DefaultShell) Warning(out ...interface{}) {
-	fmt.Fprintln(s.OutStream(), color.New(color.Yellow).Sprint(out...))
+	if s.logEnabled(LogLevelWarn) {
+		fmt.Fprintln(s.OutStream(), logMessage(LogLevelWarn, color.New(color.Yellow), out...))
+	}
 }
 
 // Success success message
 func (s *DefaultShell) Success(out ...interface{}) {
-	fmt.Fprintln(s.OutStream(), color.New(color.Green).Sprint(out...))
+	if s.logEnabled(LogLevelInfo) {
+		fmt.Fprintln(s.OutStream(), logMessage(LogLevelInfo, color.New(color.Green), out...))
+	}
 }
 
 // Info info message
 func (s *DefaultShell) Info(out ...interface{}) {
-	fmt.Fprintln(s.OutStream(), color.New(color.Cyan).Sprint(out...))
+	if s.logEnabled(LogLevelInfo) {
+		fmt.Fprintln(s.OutStream(), logMessage(LogLevelInfo, color.New(color.Cyan), out...))
+	}
 }
 
 // Exec will execute the given command silently and return the combined
@@ -264,6 +438,45 @@ func Exec(exe string, args ...string) (outStr string, err error) {
 	return
 }
 
+// Capture runs the given command non-interactively and returns its combined
+// error/standard output as it is, for parsing the output of docker commands.
+// Unlike Exec the output is neither trimmed nor folded into the error.
+func Capture(exe string, args ...string) (output string, err error) {
+	s := NewShell().(*DefaultShell)
+	output, err = s.capture(builder.NewCommand(exe, args...))
+	return
+}
+
+// capture runs the command with no input, collecting all of its output;
+// the verbose trace still goes to the error stream
+func (s *DefaultShell) capture(command builder.Command) (output string, err error) {
+	command = builder.ComposeProject(builder.ComposeV2(command), s.env.Get("COMPOSE_PROJECT_NAME"))
+
+	if command, err = s.composeCommand(command); err != nil {
+		return
+	}
+
+	var (
+		out  []byte
+		args = command.Args()
+		exe  = command.Cmd()
+	)
+
+	if s.env.IsTrue("KOOL_VERBOSE") {
+		fmt.Fprintf(s.ErrStream(), "$ (capture) %s %s\n", exe, strings.Join(args, " "))
+	}
+
+	cmd := execCmdFn(exe, args...)
+	cmd.Env = os.Environ()
+	out, err = cmd.CombinedOutput()
+	output = string(out)
+
+	if err != nil && isDockerDaemonConnectionError(output) {
+		err = errs.Wrap(errs.ErrDockerNotRunning, err)
+	}
+	return
+}
+
 // Interactive runs the given command proxying current Stdin/Stdout/Stderr
 // which makes it interactive for running even something like `bash`.
 func Interactive(exe string, args ...string) (err error) {
@@ -312,11 +525,22 @@ func (s *DefaultShell) execute(cmd *exec.Cmd) (err error) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan)
 
+	// a nil channel never fires, so without a context we just wait
+	var done <-chan struct{}
+	if s.ctx != nil {
+		done = s.ctx.Done()
+	}
+
 	// You need a for loop to handle multiple signals
 	for {
 		select {
 		case err = <-waitCh:
 			return
+		case <-done:
+			_ = cmd.Process.Kill()
+			<-waitCh
+			err = s.ctx.Err()
+			return
 		case sig := <-sigChan:
 			if err := cmd.Process.Signal(sig); err != nil {
 				// check if it is something we should care about
@@ -2,16 +2,20 @@ package shell
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"kool-dev/kool/core/builder"
 	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/errs"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"reflect"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gookit/color"
 )
@@ -146,6 +150,30 @@ func TestExecDockerComposeDefaultShell(t *testing.T) {
 	}
 }
 
+func TestExecComposeProjectName(t *testing.T) {
+	s := NewShell()
+	s.(*DefaultShell).env = environment.NewFakeEnvStorage()
+	s.(*DefaultShell).env.Set("COMPOSE_PROJECT_NAME", "my-project")
+
+	var argsTest []string
+
+	originalExecCmdFn := execCmdFn
+	execCmdFn = func(exe string, args ...string) *exec.Cmd {
+		argsTest = args
+		return exec.Command("echo", "x1")
+	}
+	defer func() {
+		execCmdFn = originalExecCmdFn
+	}()
+
+	_, _ = s.Exec(builder.NewCommand("docker", "compose", "ps"), "app")
+
+	expectedArgs := []string{"compose", "-p", "my-project", "ps", "app"}
+	if !reflect.DeepEqual(argsTest, expectedArgs) {
+		t.Errorf("expecting args '%v', got '%v'", expectedArgs, argsTest)
+	}
+}
+
 func TestExec(t *testing.T) {
 	output, err := Exec("echo", "x")
 
@@ -372,7 +400,7 @@ func TestWarningShell(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	expected := color.New(color.Yellow).Sprint("testing warning")
+	expected := logMessage(LogLevelWarn, color.New(color.Yellow), "testing warning")
 
 	if output != expected {
 		t.Errorf("expecting output '%s', got '%s'", expected, output)
@@ -390,7 +418,7 @@ func TestSuccessShell(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	expected := color.New(color.Green).Sprint("testing success")
+	expected := logMessage(LogLevelInfo, color.New(color.Green), "testing success")
 
 	if output != expected {
 		t.Errorf("expecting output '%s', got '%s'", expected, output)
@@ -447,6 +475,24 @@ func TestVerbose(t *testing.T) {
 		t.Errorf("unexpected StdErr verbose output: %v", verboseOutput)
 	}
 }
+
+func TestVerboseCompose(t *testing.T) {
+	s := NewShell()
+
+	s.(*DefaultShell).env = environment.NewFakeEnvStorage()
+
+	s.(*DefaultShell).env.Set("KOOL_VERBOSE", "true")
+
+	buff := bytes.NewBuffer([]byte(""))
+	s.SetErrStream(buff)
+
+	_ = s.Interactive(builder.NewCommand("docker", "compose", "ps"))
+
+	if verboseOutput := buff.String(); !strings.Contains(verboseOutput, "docker --debug compose ps") {
+		t.Errorf("expected docker compose to run in debug mode; got: %v", verboseOutput)
+	}
+}
+
 func TestVerboseRecursive(t *testing.T) {
 	s := NewShell()
 
@@ -472,3 +518,261 @@ func TestVerboseRecursive(t *testing.T) {
 		t.Errorf("unexpected StdErr verbose output: %v", verboseOutput)
 	}
 }
+
+func TestExecRetriesDockerDaemonConnection(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on sh")
+	}
+
+	s := &DefaultShell{
+		inStream:  os.Stdin,
+		outStream: io.Discard,
+		errStream: io.Discard,
+		env:       environment.NewFakeEnvStorage(),
+		lookedUp:  newLookupCache(),
+	}
+
+	var calls int
+
+	originalExecCmdFn, originalDelay := execCmdFn, dockerDaemonRetryDelay
+	dockerDaemonRetryDelay = 0
+	execCmdFn = func(exe string, args ...string) *exec.Cmd {
+		calls++
+		if calls < 3 {
+			return exec.Command("sh", "-c", "echo 'Cannot connect to the Docker daemon at unix:///var/run/docker.sock.' >&2; exit 1")
+		}
+		return exec.Command("echo", "ok")
+	}
+	defer func() {
+		execCmdFn, dockerDaemonRetryDelay = originalExecCmdFn, originalDelay
+	}()
+
+	if out, err := s.Exec(builder.NewCommand("docker", "info")); err != nil || out != "ok" {
+		t.Errorf("expected retried command to succeed; got '%s' (err: %v)", out, err)
+	}
+
+	if calls != 3 {
+		t.Errorf("expected 3 attempts; got %d", calls)
+	}
+
+	calls = -10
+	if _, err := s.Exec(builder.NewCommand("docker", "info")); !errors.Is(err, errs.ErrDockerNotRunning) {
+		t.Errorf("expected daemon error after exhausting retries; got %v", err)
+	}
+
+	if calls != -10+dockerDaemonRetries+1 {
+		t.Errorf("expected %d attempts; got %d", dockerDaemonRetries+1, calls+10)
+	}
+
+	calls = 0
+	execCmdFn = func(exe string, args ...string) *exec.Cmd {
+		calls++
+		return exec.Command("sh", "-c", "echo 'other failure' >&2; exit 1")
+	}
+
+	if _, err := s.Exec(builder.NewCommand("docker", "info")); err == nil || calls != 1 {
+		t.Errorf("non-daemon errors should not be retried; got %d attempts (err: %v)", calls, err)
+	} else if errors.Is(err, errs.ErrDockerNotRunning) {
+		t.Errorf("unexpected ErrDockerNotRunning kind for other failures")
+	}
+}
+
+func TestExecServiceNotFound(t *testing.T) {
+	s := &DefaultShell{
+		outStream: io.Discard,
+		errStream: io.Discard,
+		env:       environment.NewFakeEnvStorage(),
+		lookedUp:  newLookupCache(),
+	}
+
+	originalExecCmdFn := execCmdFn
+	execCmdFn = func(exe string, args ...string) *exec.Cmd {
+		return exec.Command("sh", "-c", "echo 'no such service: db' >&2; exit 1")
+	}
+	defer func() {
+		execCmdFn = originalExecCmdFn
+	}()
+
+	_, err := s.Exec(builder.NewCommand("docker", "compose", "exec", "-T", "db", "ls"))
+
+	if !errors.Is(err, errs.ErrServiceNotFound) {
+		t.Errorf("expected ErrServiceNotFound; got %v", err)
+	} else if !strings.Contains(err.Error(), "no such service: db") {
+		t.Errorf("expected the original output to be kept on the message; got %v", err)
+	}
+}
+
+func TestInteractiveBinPaths(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script executables are not supported on Windows")
+	}
+
+	var (
+		dir = t.TempDir()
+		out bytes.Buffer
+		s   = NewShell()
+	)
+
+	_ = os.WriteFile(filepath.Join(dir, "kool-bin-test"), []byte("#!/bin/sh\necho \"$PATH\"\n"), 0755)
+
+	s.SetOutStream(&out)
+
+	if err := s.Interactive(builder.NewCommand("kool-bin-test")); err == nil {
+		t.Error("expected an error running a command not in PATH without bin paths")
+	}
+
+	s.SetBinPaths([]string{dir})
+
+	if err := s.Interactive(builder.NewCommand("kool-bin-test")); err != nil {
+		t.Fatalf("unexpected error running command from bin paths: %v", err)
+	}
+
+	if output := strings.TrimSpace(out.String()); !strings.HasPrefix(output, dir+string(os.PathListSeparator)) {
+		t.Errorf("expected bin path to be prepended to PATH; got '%s'", output)
+	}
+
+	if env := s.(*DefaultShell).prependBinPaths([]string{"FOO=bar"}); len(env) != 2 || env[1] != "PATH="+dir {
+		t.Errorf("expected PATH to be added when missing; got %v", env)
+	}
+}
+
+func TestFork(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on sh")
+	}
+
+	var (
+		out, errOut bytes.Buffer
+		s           = NewShell()
+	)
+
+	s.SetBinPaths([]string{"bin"})
+	s.SetContext(context.Background())
+
+	forked := s.Fork(&out, &errOut)
+
+	if forked == s || forked.OutStream() != &out || forked.ErrStream() != &errOut {
+		t.Fatal("expected a copy of the shell writing to the given streams")
+	}
+
+	if forked.(*DefaultShell).ctx != s.(*DefaultShell).ctx || len(forked.(*DefaultShell).binPaths) != 1 {
+		t.Error("expected the forked shell to keep the context and bin paths")
+	}
+
+	if !forked.(*DefaultShell).forked || s.(*DefaultShell).forked {
+		t.Error("expected just the copy to be marked as forked")
+	}
+
+	if err := forked.Interactive(builder.NewCommand("sh", "-c", "cat; echo out; echo err >&2")); err != nil {
+		t.Fatalf("unexpected error running on forked shell: %v", err)
+	}
+
+	if out.String() != "out\n" || errOut.String() != "err\n" {
+		t.Errorf("unexpected forked shell output: %q / %q", out.String(), errOut.String())
+	}
+
+	if s.OutStream() != os.Stdout {
+		t.Error("forking should not change the original shell")
+	}
+}
+
+func TestInteractiveContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on sleep")
+	}
+
+	s := NewShell()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	s.SetContext(ctx)
+
+	start := time.Now()
+	err := s.Interactive(builder.NewCommand("sleep", "5"))
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded error; got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("expected command to be killed on the deadline; it took %s", elapsed)
+	}
+
+	s.SetContext(context.Background())
+
+	if err = s.Interactive(builder.NewCommand("true")); err != nil {
+		t.Errorf("unexpected error running command without deadline: %v", err)
+	}
+}
+
+func TestCapture(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on sh")
+	}
+
+	var calledExe string
+
+	originalExecCmdFn := execCmdFn
+	execCmdFn = func(exe string, args ...string) *exec.Cmd {
+		calledExe = exe
+		return exec.Command("sh", "-c", "echo 'out'; echo 'err' >&2; echo '  last  '")
+	}
+	defer func() {
+		execCmdFn = originalExecCmdFn
+	}()
+
+	output, err := Capture("docker", "ps")
+
+	if err != nil {
+		t.Errorf("unexpected error capturing output: %v", err)
+	}
+
+	if calledExe != "docker" {
+		t.Errorf("expected to run docker; got %s", calledExe)
+	}
+
+	if expected := "out\nerr\n  last  \n"; output != expected {
+		t.Errorf("expected combined untrimmed output %q; got %q", expected, output)
+	}
+
+	execCmdFn = func(exe string, args ...string) *exec.Cmd {
+		return exec.Command("sh", "-c", "echo 'Cannot connect to the Docker daemon at unix:///var/run/docker.sock.' >&2; exit 1")
+	}
+
+	output, err = Capture("docker", "ps")
+
+	if !errors.Is(err, errs.ErrDockerNotRunning) {
+		t.Errorf("expected ErrDockerNotRunning; got %v", err)
+	}
+
+	if !strings.HasPrefix(output, "Cannot connect to the Docker daemon") {
+		t.Errorf("expected the output to be captured on failure too; got %q", output)
+	}
+}
+
+func TestCaptureVerbose(t *testing.T) {
+	s := &DefaultShell{
+		outStream: io.Discard,
+		errStream: bytes.NewBufferString(""),
+		env:       environment.NewFakeEnvStorage(),
+		lookedUp:  newLookupCache(),
+	}
+	s.env.Set("KOOL_VERBOSE", "1")
+
+	originalExecCmdFn := execCmdFn
+	execCmdFn = func(exe string, args ...string) *exec.Cmd {
+		return exec.Command("echo", "x")
+	}
+	defer func() {
+		execCmdFn = originalExecCmdFn
+	}()
+
+	if output, err := s.capture(builder.NewCommand("docker", "ps")); err != nil || output != "x\n" {
+		t.Errorf("unexpected capture result %q (error: %v)", output, err)
+	}
+
+	if trace := s.errStream.(*bytes.Buffer).String(); trace != "$ (capture) docker ps\n" {
+		t.Errorf("expected the verbose trace on the error stream; got %q", trace)
+	}
+}
@@ -1,9 +1,16 @@
 package shell
 
-import "github.com/moby/term"
+import (
+	"kool-dev/kool/core/environment"
+	"strconv"
+
+	"github.com/moby/term"
+)
 
 const standardTermWidth int = 80
 
+var termWidthEnv environment.EnvStorage = environment.NewEnvStorage()
+
 // TerminalChecker holds logic to check if environment is a terminal
 type TerminalChecker interface {
 	IsTerminal(...interface{}) bool
@@ -27,3 +34,15 @@ func (t *DefaultTerminalChecker) IsTerminal(fds ...interface{}) bool {
 func NewTerminalChecker() TerminalChecker {
 	return &DefaultTerminalChecker{}
 }
+
+// fallbackTermWidth returns the width to be used when there is no
+// TTY to query, which can be overridden by KOOL_TERM_WIDTH
+func fallbackTermWidth() (width int) {
+	width = standardTermWidth
+
+	if parsed, err := strconv.Atoi(termWidthEnv.Get("KOOL_TERM_WIDTH")); err == nil && parsed > 0 {
+		width = parsed
+	}
+
+	return
+}
@@ -18,12 +18,15 @@ func GetTerminalWidth(tty interface{}) (width int, err error) {
 	)
 
 	if fh, assert = tty.(*os.File); !assert {
-		width = standardTermWidth
+		width = fallbackTermWidth()
 		err = errors.New("TTY is not a files")
 		return
 	}
 
-	width, _, err = term.GetSize(int(fh.Fd()))
+	// queried on every call, so the width keeps up with window resizes
+	if width, _, err = term.GetSize(int(fh.Fd())); err != nil {
+		width = fallbackTermWidth()
+	}
 
 	return
 }
@@ -0,0 +1,65 @@
+//go:build !windows
+// +build !windows
+
+package shell
+
+import (
+	"bytes"
+	"kool-dev/kool/core/environment"
+	"testing"
+
+	"github.com/creack/pty"
+)
+
+func TestGetTerminalWidthFallback(t *testing.T) {
+	originalEnv := termWidthEnv
+	defer func() { termWidthEnv = originalEnv }()
+
+	fakeEnv := environment.NewFakeEnvStorage()
+	termWidthEnv = fakeEnv
+
+	if width, err := GetTerminalWidth(new(bytes.Buffer)); err == nil {
+		t.Error("expected error getting width from non-file output")
+	} else if width != standardTermWidth {
+		t.Errorf("expected fallback width %d; got %d", standardTermWidth, width)
+	}
+
+	fakeEnv.Set("KOOL_TERM_WIDTH", "120")
+
+	if width, _ := GetTerminalWidth(new(bytes.Buffer)); width != 120 {
+		t.Errorf("expected KOOL_TERM_WIDTH width 120; got %d", width)
+	}
+
+	fakeEnv.Set("KOOL_TERM_WIDTH", "wide")
+
+	if width, _ := GetTerminalWidth(new(bytes.Buffer)); width != standardTermWidth {
+		t.Errorf("expected fallback width %d on invalid KOOL_TERM_WIDTH; got %d", standardTermWidth, width)
+	}
+}
+
+func TestGetTerminalWidthResize(t *testing.T) {
+	ptmx, tty, err := pty.Open()
+
+	if err != nil {
+		t.Fatalf("failed creating PTY for testing: %v", err)
+	}
+
+	defer ptmx.Close()
+	defer tty.Close()
+
+	if err = pty.Setsize(ptmx, &pty.Winsize{Rows: 24, Cols: 100}); err != nil {
+		t.Fatal(err)
+	}
+
+	if width, err := GetTerminalWidth(tty); err != nil || width != 100 {
+		t.Fatalf("expected width 100; got %d (err: %v)", width, err)
+	}
+
+	if err = pty.Setsize(ptmx, &pty.Winsize{Rows: 24, Cols: 132}); err != nil {
+		t.Fatal(err)
+	}
+
+	if width, err := GetTerminalWidth(tty); err != nil || width != 132 {
+		t.Errorf("expected width 132 after resize; got %d (err: %v)", width, err)
+	}
+}
@@ -2,5 +2,5 @@ package shell
 
 // GetTerminalWidth checks if input is a terminal
 func GetTerminalWidth(tty interface{}) (width int, err error) {
-	return standardTermWidth, nil
+	return fallbackTermWidth(), nil
 }
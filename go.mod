@@ -8,7 +8,6 @@ require (
 	github.com/blang/semver v3.5.1+incompatible
 	github.com/briandowns/spinner v1.23.0
 	github.com/creack/pty v1.1.18
-	github.com/fireworkweb/godotenv v1.3.1-0.20200525231918-bdecbe8dfc58
 	github.com/golang/protobuf v1.5.3 // indirect
 	github.com/google/shlex v0.0.0-20191202100458-e7afc7fbc510
 	github.com/gookit/color v1.5.2
@@ -32,7 +31,11 @@ require (
 	gopkg.in/yaml.v3 v3.0.1
 )
 
-require github.com/compose-spec/compose-go v1.13.0
+require (
+	github.com/compose-spec/compose-go v1.13.0
+	github.com/fsnotify/fsnotify v1.7.0
+	github.com/pmezard/go-difflib v1.0.0
+)
 
 require (
 	github.com/Azure/go-ansiterm v0.0.0-20230124172434-306776ec8161 // indirect
@@ -0,0 +1,22 @@
+// Package kool exposes an entrypoint for embedding kool into other Go
+// programs, running its commands without shelling out to the binary.
+//
+// Presets and recipes are embedded only into the kool binary itself, so
+// programs relying on them must provide their own sources through
+// presets.SetSource and automate.SetRecipesSource.
+package kool
+
+import (
+	"io"
+
+	"kool-dev/kool/commands"
+	"kool-dev/kool/core/environment"
+)
+
+// Run executes kool with the given arguments (not including the program
+// name) bound to the given streams and returns the process exit code.
+func Run(args []string, in io.Reader, out, err io.Writer) int {
+	environment.InitEnvironmentVariables(environment.NewEnvStorage())
+
+	return commands.Run(args, in, out, err)
+}
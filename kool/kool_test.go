@@ -0,0 +1,19 @@
+package kool
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var out, errOut bytes.Buffer
+
+	if code := Run([]string{"--help"}, strings.NewReader(""), &out, &errOut); code != 0 {
+		t.Errorf("expected exit code 0; got %d", code)
+	}
+
+	if !strings.Contains(out.String(), "Usage:") {
+		t.Errorf("expected help on the given output stream; got %q", out.String())
+	}
+}
@@ -1,7 +1,11 @@
 package checker
 
 import (
+	"errors"
+	"fmt"
 	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/errs"
 	"kool-dev/kool/core/shell"
 	"strings"
 )
@@ -16,14 +20,16 @@ type DefaultChecker struct {
 	dockerCmd        builder.Command
 	dockerComposeCmd builder.Command
 	shell            shell.Shell
+	env              environment.EnvStorage
 }
 
 // NewChecker initializes checker
 func NewChecker(s shell.Shell) *DefaultChecker {
 	return &DefaultChecker{
 		builder.NewCommand("docker", "info"),
-		builder.NewCommand("docker", "compose", "ps"),
+		builder.NewComposeCommand("ps"),
 		s,
+		environment.NewEnvStorage(),
 	}
 }
 
@@ -37,13 +43,40 @@ func (c *DefaultChecker) Check() error {
 		if strings.Contains(strings.ToLower(err.Error()), "is not a docker command") {
 			return ErrDockerComposeNotFound
 		}
+		if errors.Is(err, ErrDockerNotRunning) {
+			return c.notRunning(err)
+		}
 		// anything else, raise the original error
 		return err
 	}
 
 	if _, err := c.shell.Exec(c.dockerCmd); err != nil {
-		return ErrDockerNotRunning
+		return c.notRunning(err)
 	}
 
 	return nil
 }
+
+// notRunning tags the error as the docker daemon not being reachable,
+// telling which host it is when using a remote one (DOCKER_HOST)
+func (c *DefaultChecker) notRunning(err error) error {
+	if host := c.env.Get("DOCKER_HOST"); host != "" {
+		var cause = err
+
+		if wrapped, ok := err.(*errs.Error); ok && wrapped.Err != nil {
+			cause = wrapped.Err
+		}
+
+		return &errs.Error{
+			Kind:    ErrDockerNotRunning,
+			Message: fmt.Sprintf("could not reach the docker host %s; check it is up and the connection to it works", host),
+			Err:     cause,
+		}
+	}
+
+	if errors.Is(err, ErrDockerNotRunning) {
+		return err
+	}
+
+	return errs.Wrap(ErrDockerNotRunning, err)
+}
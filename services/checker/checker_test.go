@@ -3,7 +3,10 @@ package checker
 import (
 	"errors"
 	"kool-dev/kool/core/builder"
+	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/errs"
 	"kool-dev/kool/core/shell"
+	"strings"
 	"testing"
 )
 
@@ -23,7 +26,7 @@ func TestDockerNotInstalled(t *testing.T) {
 
 	s := &shell.FakeShell{}
 
-	c = &DefaultChecker{dockerCmd, dockerComposeCmd, s}
+	c = &DefaultChecker{dockerCmd, dockerComposeCmd, s, environment.NewFakeEnvStorage()}
 
 	err := c.Check()
 
@@ -45,7 +48,7 @@ func TestDockerComposeNotInstalled(t *testing.T) {
 
 	s := &shell.FakeShell{}
 
-	c = &DefaultChecker{dockerCmd, dockerComposeCmd, s}
+	c = &DefaultChecker{dockerCmd, dockerComposeCmd, s, environment.NewFakeEnvStorage()}
 
 	err := c.Check()
 
@@ -73,7 +76,7 @@ func TestDockerNotRunning(t *testing.T) {
 
 	s := &shell.FakeShell{}
 
-	c = &DefaultChecker{dockerCmd, dockerComposeCmd, s}
+	c = &DefaultChecker{dockerCmd, dockerComposeCmd, s, environment.NewFakeEnvStorage()}
 
 	err := c.Check()
 
@@ -85,6 +88,35 @@ func TestDockerNotRunning(t *testing.T) {
 	if !IsDockerNotRunningError(err) {
 		t.Errorf("Expected the message '%s', got '%s'", ErrDockerNotRunning.Error(), err.Error())
 	}
+
+	if !errors.Is(err, dockerCmd.MockExecError) {
+		t.Errorf("Expected the underlying error to be wrapped, got '%v'", err)
+	}
+}
+
+func TestRemoteDockerHostNotReachable(t *testing.T) {
+	dockerCmd := &builder.FakeCommand{MockExecError: errors.New("ssh: connect to host devbox port 22: Connection refused")}
+	env := environment.NewFakeEnvStorage()
+	env.Set("DOCKER_HOST", "ssh://me@devbox")
+
+	c := &DefaultChecker{dockerCmd, &builder.FakeCommand{}, &shell.FakeShell{}, env}
+
+	err := c.Check()
+
+	if !IsDockerNotRunningError(err) {
+		t.Errorf("expected ErrDockerNotRunning; got %v", err)
+	}
+
+	if !strings.HasPrefix(err.Error(), "could not reach the docker host ssh://me@devbox") || !strings.Contains(err.Error(), "Connection refused") {
+		t.Errorf("unexpected error message for remote docker host: %v", err)
+	}
+
+	dockerComposeCmd := &builder.FakeCommand{MockExecError: errs.Wrap(ErrDockerNotRunning, errors.New("Connection refused"))}
+	c = &DefaultChecker{&builder.FakeCommand{}, dockerComposeCmd, &shell.FakeShell{}, env}
+
+	if err = c.Check(); err == nil || err.Error() != "could not reach the docker host ssh://me@devbox; check it is up and the connection to it works (Connection refused)" {
+		t.Errorf("unexpected error message for remote docker host on compose: %v", err)
+	}
 }
 
 func TestCheckKoolDependencies(t *testing.T) {
@@ -98,7 +130,7 @@ func TestCheckKoolDependencies(t *testing.T) {
 
 	s := &shell.FakeShell{}
 
-	c = &DefaultChecker{dockerCmd, dockerComposeCmd, s}
+	c = &DefaultChecker{dockerCmd, dockerComposeCmd, s, environment.NewFakeEnvStorage()}
 
 	if err := c.Check(); err != nil {
 		t.Errorf("Expected no errors, got %v.", err)
@@ -1,27 +1,30 @@
 package checker
 
-import "errors"
+import (
+	"errors"
+	"kool-dev/kool/core/errs"
+)
 
 // ErrDockerNotFound happens when docker is not installed
 var ErrDockerNotFound = errors.New("docker doesn't seem to be installed, install it first and retry")
 
 // IsDockerNotFoundError tells whether the given error is checker.ErrDockerNotFound
 func IsDockerNotFoundError(err error) bool {
-	return err.Error() == ErrDockerNotFound.Error()
+	return errors.Is(err, ErrDockerNotFound)
 }
 
-// ErrDockerComposeNotFound happens when docker compose V2 is not installed
-var ErrDockerComposeNotFound = errors.New("docker compose V2 doesn't seem to be installed, upgrade your docker installation and retry")
+// ErrDockerComposeNotFound happens when docker compose is not installed
+var ErrDockerComposeNotFound = errs.ErrComposeNotFound
 
 // IsDockerComposeNotFoundError tells whether the given error is checker.ErrDockerComposeNotFound
 func IsDockerComposeNotFoundError(err error) bool {
-	return err.Error() == ErrDockerComposeNotFound.Error()
+	return errors.Is(err, ErrDockerComposeNotFound)
 }
 
 // ErrDockerNotRunning happens when docker daemon is not running
-var ErrDockerNotRunning = errors.New("docker daemon doesn't seem to be running, run it first and retry")
+var ErrDockerNotRunning = errs.ErrDockerNotRunning
 
 // IsDockerNotRunningError tells whether the given error is checker.ErrDockerNotRunning
 func IsDockerNotRunningError(err error) bool {
-	return err.Error() == ErrDockerNotRunning.Error()
+	return errors.Is(err, ErrDockerNotRunning)
 }
@@ -41,6 +41,14 @@ func NewDeploy(tarballPath string) *Deploy {
 	}
 }
 
+// ResumeDeploy creates a handler for following up
+// a deployment already requested, by its ID
+func ResumeDeploy(id string) *Deploy {
+	d := NewDeploy("")
+	d.id = id
+	return d
+}
+
 // GetID returns the ID for the deployment
 func (d *Deploy) GetID() string {
 	return d.id
@@ -76,7 +84,7 @@ func (d *Deploy) SendFile() (err error) {
 				}
 				err = ErrPayloadValidation
 			} else if errAPI.Status != http.StatusOK && errAPI.Status != http.StatusCreated {
-				err = ErrBadResponseStatus
+				err = fmt.Errorf("%w: %w", ErrBadResponseStatus, errAPI)
 			}
 		}
 		return
@@ -51,6 +51,14 @@ func TestNewDeploy(t *testing.T) {
 	}
 }
 
+func TestResumeDeploy(t *testing.T) {
+	d := ResumeDeploy("100")
+
+	if d.GetID() != "100" {
+		t.Errorf("unexpected id on resumed deploy: %s", d.GetID())
+	}
+}
+
 func TestSendFile(t *testing.T) {
 	tarball := filepath.Join(t.TempDir(), "test.tgz")
 	_ = os.WriteFile(tarball, []byte("test"), os.ModePerm)
@@ -96,6 +104,9 @@ func TestSendFile(t *testing.T) {
 	if err := d.SendFile(); err == nil || !errors.Is(err, ErrBadResponseStatus) {
 		t.Errorf("unexpected error from SendFile (ErrBadResponseStatus): %v", err)
 	}
+	if err := d.SendFile(); !IsTransient(err) {
+		t.Errorf("expected server error from SendFile to be transient: %v", err)
+	}
 }
 
 func TestFetchLatestStatus(t *testing.T) {
@@ -122,4 +133,7 @@ func TestFetchLatestStatus(t *testing.T) {
 	if err := d.FetchLatestStatus(); !strings.Contains(err.Error(), "bad API response") {
 		t.Errorf("unexpected error from FetchLatestStatus (bad API response): %v", err)
 	}
+	if err := d.FetchLatestStatus(); !IsTransient(err) {
+		t.Errorf("expected server error from FetchLatestStatus to be transient: %v", err)
+	}
 }
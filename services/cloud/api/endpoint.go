@@ -1,14 +1,18 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/network"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
+	"time"
 )
 
 // HTTPRequester interface holds the methods to execute HTTP requests
@@ -18,6 +22,10 @@ type HTTPRequester interface {
 
 var httpRequester HTTPRequester = http.DefaultClient
 
+// DefaultRequestTimeout bounds each call to the API, unless
+// a different timeout is set by KOOL_API_REQUEST_TIMEOUT
+const DefaultRequestTimeout = 5 * time.Minute
+
 // Endpoint interface encapsulates the behaviour necessary for consuming
 // an API endpoint
 type Endpoint interface {
@@ -100,6 +108,10 @@ func (e *DefaultEndpoint) DoCall() (err error) {
 		verbose = e.env.IsTrue("KOOL_VERBOSE")
 	)
 
+	if err = network.CheckOnline(e.env, "Kool Cloud"); err != nil {
+		return
+	}
+
 	if e.method == "POST" {
 		if e.rawBody != nil {
 			body = e.rawBody
@@ -115,7 +127,17 @@ func (e *DefaultEndpoint) DoCall() (err error) {
 		fmt.Fprintf(os.Stderr, "[Kool Cloud] Going to call: %s\n", reqURL)
 	}
 
-	if request, err = http.NewRequest(e.method, reqURL, body); err != nil {
+	timeout := e.requestTimeout()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	defer func() {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("%w after %s", ErrRequestTimeout, timeout)
+		}
+	}()
+
+	if request, err = http.NewRequestWithContext(ctx, e.method, reqURL, body); err != nil {
 		return
 	}
 
@@ -145,6 +167,12 @@ func (e *DefaultEndpoint) DoCall() (err error) {
 		apiErr := new(ErrAPI)
 		if err = json.Unmarshal(raw, apiErr); err != nil {
 			err = fmt.Errorf("%v (parse error: %v)", ErrUnexpectedResponse, err)
+
+			if e.statusCode >= http.StatusInternalServerError {
+				// like a gateway error page; keep the status so
+				// callers can tell the request may be retried
+				err = &ErrAPI{Status: e.statusCode, Message: err.Error()}
+			}
 			return
 		}
 		apiErr.Status = e.statusCode
@@ -159,6 +187,16 @@ func (e *DefaultEndpoint) DoCall() (err error) {
 	return
 }
 
+// requestTimeout parses KOOL_API_REQUEST_TIMEOUT either as a
+// duration (i.e 90s, 2m) or a number of seconds
+func (e *DefaultEndpoint) requestTimeout() time.Duration {
+	if timeout, found := e.env.GetDuration("KOOL_API_REQUEST_TIMEOUT"); found && timeout > 0 {
+		return timeout
+	}
+
+	return DefaultRequestTimeout
+}
+
 func (e *DefaultEndpoint) doRequest(request *http.Request) (resp *http.Response, err error) {
 	var apiToken string = e.env.Get("KOOL_API_TOKEN")
 
@@ -4,10 +4,12 @@ import (
 	"errors"
 	"io"
 	"kool-dev/kool/core/environment"
+	"kool-dev/kool/core/network"
 	"net/http"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 )
 
 func newFakeDefaultEndpoint(method string) *DefaultEndpoint {
@@ -288,3 +290,65 @@ func TestDoCallPost(t *testing.T) {
 		t.Errorf("bad contentType: %s", e.contentType)
 	}
 }
+
+type slowHTTP struct{}
+
+func (s *slowHTTP) Do(r *http.Request) (*http.Response, error) {
+	<-r.Context().Done()
+	return nil, r.Context().Err()
+}
+
+func TestDoCallTimeout(t *testing.T) {
+	e := newFakeDefaultEndpoint("GET")
+	e.env.Set("KOOL_API_TOKEN", "fake token")
+	e.env.Set("KOOL_API_REQUEST_TIMEOUT", "10ms")
+
+	oldHTTPRequester := httpRequester
+	defer func() {
+		httpRequester = oldHTTPRequester
+	}()
+
+	httpRequester = &slowHTTP{}
+
+	if err := e.DoCall(); !errors.Is(err, ErrRequestTimeout) {
+		t.Errorf("expected ErrRequestTimeout; got %v", err)
+	}
+}
+
+func TestDoCallOffline(t *testing.T) {
+	e := newFakeDefaultEndpoint("GET")
+	e.env.Set("KOOL_API_TOKEN", "fake token")
+	e.env.Set("KOOL_OFFLINE", "true")
+
+	oldHTTPRequester := httpRequester
+	defer func() {
+		httpRequester = oldHTTPRequester
+	}()
+
+	httpRequester = &slowHTTP{}
+
+	if err := e.DoCall(); !errors.Is(err, network.ErrOffline) {
+		t.Errorf("expected ErrOffline; got %v", err)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	e := newFakeDefaultEndpoint("GET")
+
+	for value, expected := range map[string]time.Duration{
+		"":      DefaultRequestTimeout,
+		"bad":   DefaultRequestTimeout,
+		"-1s":   DefaultRequestTimeout,
+		"90s":   90 * time.Second,
+		"2m":    2 * time.Minute,
+		"30":    30 * time.Second,
+		"0":     DefaultRequestTimeout,
+		"1m30s": 90 * time.Second,
+	} {
+		e.env.Set("KOOL_API_REQUEST_TIMEOUT", value)
+
+		if got := e.requestTimeout(); got != expected {
+			t.Errorf("expected timeout %s for '%s'; got %s", expected, value, got)
+		}
+	}
+}
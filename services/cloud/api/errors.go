@@ -3,6 +3,8 @@ package api
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"strings"
 )
 
@@ -27,6 +29,9 @@ var ErrBadResponseStatus error
 // ErrUnexpectedResponse bad API response; please ask for support
 var ErrUnexpectedResponse error
 
+// ErrRequestTimeout the API took too long to respond
+var ErrRequestTimeout error
+
 // ErrAPI reprents a default error returned from the API
 type ErrAPI struct {
 	Status int
@@ -47,6 +52,43 @@ func (e *ErrAPI) Error() string {
 	return fmt.Sprintf("\n%d - %s\n", e.Status, e.Message)
 }
 
+// IsTransient tells whether the error may go away by trying again, like
+// a request timeout, a network failure or the API being unavailable
+func IsTransient(err error) bool {
+	var (
+		errAPI *ErrAPI
+		errNet net.Error
+	)
+
+	if errors.Is(err, ErrRequestTimeout) || errors.As(err, &errNet) {
+		return true
+	}
+
+	return errors.As(err, &errAPI) && errAPI.Status >= http.StatusInternalServerError
+}
+
+// IsSafeToResend tells whether a request that is not idempotent may be
+// sent again after failing: only when the connection could not be made,
+// so nothing reached the API, or when the API answered with a server error.
+// A timed out request may have been taken in, so it is not sent again.
+func IsSafeToResend(err error) bool {
+	var (
+		errAPI *ErrAPI
+		errOp  *net.OpError
+		errDNS *net.DNSError
+	)
+
+	if errors.Is(err, ErrRequestTimeout) {
+		return false
+	}
+
+	if errors.As(err, &errDNS) || (errors.As(err, &errOp) && errOp.Op == "dial") {
+		return true
+	}
+
+	return errors.As(err, &errAPI) && errAPI.Status >= http.StatusInternalServerError
+}
+
 func init() {
 	ErrBadAPIServer = errors.New("bad API server response")
 	ErrDeployFailed = errors.New("deploy process has failed")
@@ -55,4 +97,5 @@ func init() {
 	ErrBadResponseStatus = errors.New("unexpected return status")
 	ErrUnexpectedResponse = errors.New("bad API response; please ask for support")
 	ErrMissingToken = errors.New("missing KOOL_API_TOKEN")
+	ErrRequestTimeout = errors.New("request to Kool Cloud API timed out")
 }
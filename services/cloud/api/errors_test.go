@@ -1,6 +1,10 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
 	"testing"
 )
 
@@ -37,3 +41,58 @@ func TestApiErr(t *testing.T) {
 		t.Errorf("unexpected error message: %s", err.Error())
 	}
 }
+
+func TestIsTransient(t *testing.T) {
+	transient := []error{
+		fmt.Errorf("%w after 5m0s", ErrRequestTimeout),
+		&url.Error{Op: "Post", URL: "https://kool.dev", Err: errors.New("connection reset by peer")},
+		&ErrAPI{Status: 502, Message: "bad gateway"},
+		fmt.Errorf("%w: %w", ErrBadResponseStatus, &ErrAPI{Status: 503}),
+	}
+
+	for _, err := range transient {
+		if !IsTransient(err) {
+			t.Errorf("expected error to be transient: %v", err)
+		}
+	}
+
+	permanent := []error{
+		nil,
+		ErrDeployFailed,
+		ErrUnauthorized,
+		&ErrAPI{Status: 422, Message: "invalid"},
+	}
+
+	for _, err := range permanent {
+		if IsTransient(err) {
+			t.Errorf("expected error not to be transient: %v", err)
+		}
+	}
+}
+
+func TestIsSafeToResend(t *testing.T) {
+	safe := []error{
+		&url.Error{Op: "Post", URL: "https://kool.dev", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}},
+		&url.Error{Op: "Post", URL: "https://kool.dev", Err: &net.DNSError{Err: "no such host", Name: "kool.dev"}},
+		fmt.Errorf("%w: %w", ErrBadResponseStatus, &ErrAPI{Status: 503}),
+	}
+
+	for _, err := range safe {
+		if !IsSafeToResend(err) {
+			t.Errorf("expected request to be safe to send again: %v", err)
+		}
+	}
+
+	unsafe := []error{
+		nil,
+		fmt.Errorf("%w after 5m0s", ErrRequestTimeout),
+		&url.Error{Op: "Post", URL: "https://kool.dev", Err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}},
+		&ErrAPI{Status: 422, Message: "invalid"},
+	}
+
+	for _, err := range unsafe {
+		if IsSafeToResend(err) {
+			t.Errorf("expected request not to be sent again: %v", err)
+		}
+	}
+}
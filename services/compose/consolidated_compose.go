@@ -1,7 +1,7 @@
 package compose
 
 import (
-	"fmt"
+	"kool-dev/kool/core/errs"
 	"kool-dev/kool/services/yamler"
 	"os"
 	"path/filepath"
@@ -66,17 +66,34 @@ func ParseConsolidatedDockerComposeConfig(workingDir string) (dockerComposeConfi
 	return
 }
 
+// FileSeparator is the separator of the files listed on COMPOSE_FILE,
+// the same as docker compose uses: COMPOSE_PATH_SEPARATOR, if set, or
+// else the OS path list separator
+func FileSeparator() string {
+	if separator := os.Getenv("COMPOSE_PATH_SEPARATOR"); separator != "" {
+		return separator
+	}
+
+	return string(os.PathListSeparator)
+}
+
 // getDockerComposeFiles returns a list of docker-compose files (absolute paths)
 func getDockerComposeFiles(workingDir string) (files []string, err error) {
 	composerFile := os.Getenv("COMPOSE_FILE")
 
 	if composerFile != "" {
-		files = strings.Split(composerFile, ":")
+		files = strings.Split(composerFile, FileSeparator())
 
 		for i := range files {
-			file := filepath.Join(workingDir, files[i])
+			file := files[i]
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(workingDir, file)
+			}
+
+			files[i] = file
+
 			if _, err = os.Stat(file); os.IsNotExist(err) {
-				err = fmt.Errorf("could not find required file (%s) on current working directory (referenced by COMPOSE_FILE)", file)
+				err = errs.Newf(errs.ErrComposeFileMissing, "could not find required file (%s) on current working directory (referenced by COMPOSE_FILE)", file)
 				return
 			} else if err != nil {
 				return
@@ -90,7 +107,7 @@ func getDockerComposeFiles(workingDir string) (files []string, err error) {
 	file := filepath.Join(workingDir, "docker-compose.yml")
 
 	if _, err = os.Stat(file); os.IsNotExist(err) {
-		err = fmt.Errorf("could not find required file 'docker-compose.yml' on current working directory")
+		err = errs.Newf(errs.ErrComposeFileMissing, "could not find required file 'docker-compose.yml' on current working directory")
 		return
 	} else if err == nil {
 		files = append(files, file)
@@ -0,0 +1,67 @@
+package compose
+
+import (
+	"errors"
+	"kool-dev/kool/core/errs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseConsolidatedMissingComposeFile(t *testing.T) {
+	t.Setenv("COMPOSE_FILE", "")
+
+	if _, err := ParseConsolidatedDockerComposeConfig(t.TempDir()); !errors.Is(err, errs.ErrComposeFileMissing) {
+		t.Errorf("expected ErrComposeFileMissing for missing docker-compose.yml; got %v", err)
+	}
+
+	t.Setenv("COMPOSE_FILE", "docker-compose.yml:docker-compose.override.yml")
+
+	if _, err := ParseConsolidatedDockerComposeConfig(t.TempDir()); !errors.Is(err, errs.ErrComposeFileMissing) {
+		t.Errorf("expected ErrComposeFileMissing for missing COMPOSE_FILE files; got %v", err)
+	}
+}
+
+func TestParseConsolidatedComposeFiles(t *testing.T) {
+	var (
+		workingDir = t.TempDir()
+		otherDir   = t.TempDir()
+		override   = filepath.Join(otherDir, "compose.override.yaml")
+	)
+
+	if err := os.WriteFile(filepath.Join(workingDir, "compose.yaml"), []byte("services:\n  app:\n    image: php\n"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(override, []byte("services:\n  app:\n    ports: [\"80:80\"]\n"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("COMPOSE_PATH_SEPARATOR", "")
+	t.Setenv("COMPOSE_FILE", strings.Join([]string{"compose.yaml", override}, FileSeparator()))
+
+	config, err := ParseConsolidatedDockerComposeConfig(workingDir)
+
+	if err != nil {
+		t.Fatalf("unexpected error parsing compose files: %v", err)
+	}
+
+	if app := config.Services["app"]; app == nil || app.Image == nil || len(app.Ports) != 1 {
+		t.Errorf("expected the app service merged out of both files; got %+v", app)
+	}
+}
+
+func TestFileSeparator(t *testing.T) {
+	t.Setenv("COMPOSE_PATH_SEPARATOR", "")
+
+	if separator := FileSeparator(); separator != string(os.PathListSeparator) {
+		t.Errorf("expected the path list separator; got %s", separator)
+	}
+
+	t.Setenv("COMPOSE_PATH_SEPARATOR", ",")
+
+	if separator := FileSeparator(); separator != "," {
+		t.Errorf("expected COMPOSE_PATH_SEPARATOR; got %s", separator)
+	}
+}
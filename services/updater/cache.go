@@ -0,0 +1,102 @@
+package updater
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/blang/semver"
+)
+
+// latestReleaseTTL is for how long the latest release found in GitHub
+// is reused before looking it up again
+const latestReleaseTTL = time.Hour
+
+// latestReleaseCachePath tells where the latest release lookup is cached
+var latestReleaseCachePath = func() (path string, err error) {
+	var cacheDir string
+
+	if cacheDir, err = os.UserCacheDir(); err != nil {
+		return
+	}
+
+	path = filepath.Join(cacheDir, "kool", "latest-release.json")
+	return
+}
+
+// latestReleaseCache is the cached result of the latest release lookup
+type latestReleaseCache struct {
+	Version   string    `json:"version"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// cachedLatestVersion returns the cached latest release version
+// as long as it was looked up within the TTL
+func cachedLatestVersion() (version semver.Version, ok bool) {
+	var (
+		path    string
+		content []byte
+		cache   latestReleaseCache
+		err     error
+	)
+
+	if path, err = latestReleaseCachePath(); err != nil {
+		return
+	}
+
+	if content, err = os.ReadFile(path); err != nil {
+		return
+	}
+
+	if err = json.Unmarshal(content, &cache); err != nil || time.Since(cache.CheckedAt) > latestReleaseTTL {
+		return
+	}
+
+	if version, err = semver.Parse(cache.Version); err != nil {
+		return
+	}
+
+	ok = true
+	return
+}
+
+// cacheLatestVersion stores the latest release version found; caching
+// is best effort, so failing to write it does not fail the lookup
+func cacheLatestVersion(version semver.Version) {
+	var (
+		path    string
+		content []byte
+		err     error
+	)
+
+	if path, err = latestReleaseCachePath(); err != nil {
+		return
+	}
+
+	if content, err = json.Marshal(latestReleaseCache{version.String(), time.Now()}); err != nil {
+		return
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return
+	}
+
+	_ = os.WriteFile(path, content, 0644)
+}
+
+// ClearCache drops the cached latest release lookup,
+// so the next check goes to GitHub Releases
+func (u *DefaultUpdater) ClearCache() (err error) {
+	var path string
+
+	if path, err = latestReleaseCachePath(); err != nil {
+		return
+	}
+
+	if err = os.Remove(path); os.IsNotExist(err) {
+		err = nil
+	}
+
+	return
+}
@@ -0,0 +1,71 @@
+package updater
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+)
+
+func fakeLatestReleaseCachePath(t *testing.T) (path string) {
+	path = filepath.Join(t.TempDir(), "kool", "latest-release.json")
+
+	original := latestReleaseCachePath
+	latestReleaseCachePath = func() (string, error) {
+		return path, nil
+	}
+
+	t.Cleanup(func() {
+		latestReleaseCachePath = original
+	})
+	return
+}
+
+func TestLatestVersionCache(t *testing.T) {
+	path := fakeLatestReleaseCachePath(t)
+
+	if _, ok := cachedLatestVersion(); ok {
+		t.Error("should not find a cached version before caching one")
+	}
+
+	cacheLatestVersion(semver.MustParse("2.1.0"))
+
+	if version, ok := cachedLatestVersion(); !ok || version.String() != "2.1.0" {
+		t.Errorf("expected cached version 2.1.0; got %s (found: %v)", version, ok)
+	}
+
+	stale, _ := json.Marshal(latestReleaseCache{"2.0.0", time.Now().Add(-latestReleaseTTL - time.Minute)})
+
+	if err := os.WriteFile(path, stale, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := cachedLatestVersion(); ok {
+		t.Error("should not use a cached version older than the TTL")
+	}
+
+	if err := (&DefaultUpdater{}).ClearCache(); err != nil {
+		t.Errorf("unexpected error clearing the cache: %v", err)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected cache file to be removed")
+	}
+
+	if err := (&DefaultUpdater{}).ClearCache(); err != nil {
+		t.Errorf("unexpected error clearing a missing cache: %v", err)
+	}
+}
+
+func TestGetLatestVersionUsesCache(t *testing.T) {
+	fakeLatestReleaseCachePath(t)
+
+	cacheLatestVersion(semver.MustParse("3.0.0"))
+
+	if version, err := (&DefaultUpdater{}).GetLatestVersion(); err != nil || version.String() != "3.0.0" {
+		t.Errorf("expected the cached version 3.0.0; got %s (err: %v)", version, err)
+	}
+}
@@ -8,12 +8,18 @@ import (
 
 // FakeUpdater implements all fake behaviors for self-update
 type FakeUpdater struct {
-	CalledGetCurrentVersion, CalledUpdate,
-	CalledCheckForUpdates, CalledCheckPermission bool
+	CalledGetCurrentVersion, CalledGetLatestVersion, CalledUpdate,
+	CalledCheckForUpdates, CalledCheckPermission, CalledHasVersion,
+	CalledClearCache bool
 
-	MockCurrentVersion, MockLatestVersion string
-	MockErrorUpdate, MockErrorPermission  error
-	MockTimeoutDelay                      bool
+	UpdatedToVersion string
+
+	MockCurrentVersion, MockLatestVersion                 string
+	MockErrorUpdate, MockErrorPermission, MockErrorLatest error
+	MockTimeoutDelay                                      bool
+	MockVersionNotFound                                   bool
+	MockErrorVersion                                      error
+	MockErrorClearCache                                   error
 }
 
 // GetCurrentVersion get mocked current version
@@ -22,6 +28,14 @@ func (u *FakeUpdater) GetCurrentVersion() semver.Version {
 	return semver.MustParse(u.MockCurrentVersion)
 }
 
+// GetLatestVersion get mocked latest version
+func (u *FakeUpdater) GetLatestVersion() (latestVersion semver.Version, err error) {
+	u.CalledGetLatestVersion = true
+	latestVersion = semver.MustParse(u.MockLatestVersion)
+	err = u.MockErrorLatest
+	return
+}
+
 // Update implements fake update
 func (u *FakeUpdater) Update(currentVersion semver.Version) (updatedVersion semver.Version, err error) {
 	updatedVersion = semver.MustParse(u.MockLatestVersion)
@@ -30,6 +44,21 @@ func (u *FakeUpdater) Update(currentVersion semver.Version) (updatedVersion semv
 	return
 }
 
+// HasVersion tells whether the mocked version exists
+func (u *FakeUpdater) HasVersion(version semver.Version) (found bool, err error) {
+	u.CalledHasVersion = true
+	found = !u.MockVersionNotFound
+	err = u.MockErrorVersion
+	return
+}
+
+// UpdateToVersion implements fake update to a specific version
+func (u *FakeUpdater) UpdateToVersion(version semver.Version) (err error) {
+	u.UpdatedToVersion = version.String()
+	err = u.MockErrorUpdate
+	return
+}
+
 // CheckForUpdates implements fake available update check
 func (u *FakeUpdater) CheckForUpdates(currentVersion semver.Version, ch chan bool) {
 	u.CalledCheckForUpdates = true
@@ -48,3 +77,10 @@ func (u *FakeUpdater) CheckPermission() (err error) {
 	err = u.MockErrorPermission
 	return
 }
+
+// ClearCache implements fake release cache clearing
+func (u *FakeUpdater) ClearCache() (err error) {
+	u.CalledClearCache = true
+	err = u.MockErrorClearCache
+	return
+}
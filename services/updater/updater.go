@@ -20,9 +20,13 @@ type DefaultUpdater struct {
 // Updater holds logic for updating kool
 type Updater interface {
 	GetCurrentVersion() semver.Version
+	GetLatestVersion() (semver.Version, error)
 	Update(semver.Version) (semver.Version, error)
+	HasVersion(semver.Version) (bool, error)
+	UpdateToVersion(semver.Version) error
 	CheckForUpdates(semver.Version, chan bool)
 	CheckPermission() error
+	ClearCache() error
 }
 
 // GetCurrentVersion get current version
@@ -37,9 +41,7 @@ func (u *DefaultUpdater) Update(currentVersion semver.Version) (updatedVersion s
 		latest  *selfupdate.Release
 	)
 
-	if updater, err = selfupdate.NewUpdater(selfupdate.Config{
-		Validator: &selfupdate.SHA2Validator{},
-	}); err != nil {
+	if updater, err = newValidatingUpdater(); err != nil {
 		return
 	}
 
@@ -47,23 +49,115 @@ func (u *DefaultUpdater) Update(currentVersion semver.Version) (updatedVersion s
 		return
 	}
 
+	cacheLatestVersion(latest.Version)
 	updatedVersion = latest.Version
 	return
 }
 
+// HasVersion tells whether there is a kool release of the given version
+// for the current platform
+func (u *DefaultUpdater) HasVersion(version semver.Version) (found bool, err error) {
+	var updater *selfupdate.Updater
+
+	if updater, err = newValidatingUpdater(); err != nil {
+		return
+	}
+
+	_, found, err = findRelease(updater, version)
+	return
+}
+
+// UpdateToVersion replaces the kool binary with the release of the
+// given version, whether it is newer or older than the current one
+func (u *DefaultUpdater) UpdateToVersion(version semver.Version) (err error) {
+	var (
+		updater *selfupdate.Updater
+		release *selfupdate.Release
+		found   bool
+		binPath string
+	)
+
+	if updater, err = newValidatingUpdater(); err != nil {
+		return
+	}
+
+	if release, found, err = findRelease(updater, version); err != nil {
+		return
+	}
+
+	if !found {
+		err = fmt.Errorf("could not find kool release %s", version)
+		return
+	}
+
+	if binPath, err = os.Executable(); err != nil {
+		return
+	}
+
+	err = updater.UpdateTo(release, binPath)
+	return
+}
+
+// newValidatingUpdater creates an updater checking the downloaded
+// release against its published SHA256 checksum
+func newValidatingUpdater() (*selfupdate.Updater, error) {
+	return selfupdate.NewUpdater(selfupdate.Config{
+		Validator: &selfupdate.SHA2Validator{},
+	})
+}
+
+// findRelease looks for the release of the given version, whose tag
+// may or may not be prefixed by a 'v'
+func findRelease(updater *selfupdate.Updater, version semver.Version) (release *selfupdate.Release, found bool, err error) {
+	for _, tag := range []string{version.String(), "v" + version.String()} {
+		if release, found, err = updater.DetectVersion("kool-dev/kool", tag); err != nil || found {
+			return
+		}
+	}
+
+	return
+}
+
+// GetLatestVersion queries the latest kool release version; the
+// result is cached for a while to spare repeated GitHub lookups
+func (u *DefaultUpdater) GetLatestVersion() (latestVersion semver.Version, err error) {
+	var (
+		latest *selfupdate.Release
+		found  bool
+		cached bool
+	)
+
+	if latestVersion, cached = cachedLatestVersion(); cached {
+		return
+	}
+
+	if latest, found, err = selfupdate.DetectLatest("kool-dev/kool"); err != nil {
+		return
+	}
+
+	if !found {
+		err = fmt.Errorf("could not find any kool release")
+		return
+	}
+
+	latestVersion = latest.Version
+	cacheLatestVersion(latestVersion)
+	return
+}
+
 // CheckForUpdates checks if there is a new version
 func (u *DefaultUpdater) CheckForUpdates(current semver.Version, chHasNewVersion chan bool) {
 	var (
-		latest *selfupdate.Release
+		latest semver.Version
 		err    error
 	)
 
-	if latest, _, err = selfupdate.DetectLatest("kool-dev/kool"); err != nil {
+	if latest, err = u.GetLatestVersion(); err != nil {
 		chHasNewVersion <- false
 		return
 	}
 
-	if !latest.Version.Equals(current) {
+	if !latest.Equals(current) {
 		chHasNewVersion <- true
 	}
 
@@ -17,42 +17,54 @@ type DefaultOutputWritter struct{}
 
 func (o *DefaultOutputWritter) WriteYAML(filePath string, document *yaml.Node) (err error) {
 	var (
-		buff    = new(bytes.Buffer)
-		encoder *yaml.Encoder
-		file    *os.File
+		data []byte
+		file *os.File
 	)
 
-	if document.Kind != yaml.DocumentNode {
-		err = fmt.Errorf("unexpected yaml.Node; expected document (1), but got %d", document.Kind)
+	if data, err = EncodeYAML(document); err != nil {
 		return
 	}
 
-	encoder = yaml.NewEncoder(buff)
-	encoder.SetIndent(2)
-
-	if err = encoder.Encode(document); err != nil {
+	if file, err = os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.ModePerm); err != nil {
 		return
 	}
 
-	if err = encoder.Close(); err != nil {
+	if _, err = io.Copy(file, bytes.NewReader(data)); err != nil {
 		return
 	}
 
-	if file, err = os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.ModePerm); err != nil {
+	if err = file.Sync(); err != nil {
 		return
 	}
 
-	if _, err = io.Copy(file, buff); err != nil {
+	err = file.Close()
+	return
+}
+
+// EncodeYAML encodes the given YAML document the same way
+// it gets written to files by DefaultOutputWritter
+func EncodeYAML(document *yaml.Node) (data []byte, err error) {
+	var (
+		buff    = new(bytes.Buffer)
+		encoder *yaml.Encoder
+	)
+
+	if document.Kind != yaml.DocumentNode {
+		err = fmt.Errorf("unexpected yaml.Node; expected document (1), but got %d", document.Kind)
 		return
 	}
 
-	buff.Reset()
-	buff = nil
+	encoder = yaml.NewEncoder(buff)
+	encoder.SetIndent(2)
+
+	if err = encoder.Encode(document); err != nil {
+		return
+	}
 
-	if err = file.Sync(); err != nil {
+	if err = encoder.Close(); err != nil {
 		return
 	}
 
-	err = file.Close()
+	data = buff.Bytes()
 	return
 }
@@ -57,3 +57,19 @@ func TestWriteYAMLIndentation(t *testing.T) {
 		t.Errorf("bad YML; expected '%s' but got '%s'", expect, got)
 	}
 }
+
+func TestEncodeYAML(t *testing.T) {
+	y := new(yaml.Node)
+
+	_ = yaml.Unmarshal([]byte("foo:\n    bar: xxx"), y)
+
+	if data, err := EncodeYAML(y); err != nil {
+		t.Errorf("unexpected error encoding YAML: %v", err)
+	} else if got := strings.Trim(string(data), " \t\n"); got != "foo:\n  bar: xxx" {
+		t.Errorf("bad YML; got '%s'", got)
+	}
+
+	if _, err := EncodeYAML(y.Content[0]); err == nil {
+		t.Error("expected error encoding non-document node")
+	}
+}